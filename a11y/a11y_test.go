@@ -0,0 +1,207 @@
+package a11y_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/a11y"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+func mustParse(t *testing.T, html string) *dom.Document {
+	t.Helper()
+	doc, err := JustGoHTML.Parse("<!DOCTYPE html>" + html)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", html, err)
+	}
+	return doc
+}
+
+// target returns the element with id "t".
+func target(t *testing.T, html string) *dom.Element {
+	t.Helper()
+	e := mustParse(t, html).GetElementByID("t")
+	if e == nil {
+		t.Fatalf("no element with id t in %q", html)
+	}
+	return e
+}
+
+func TestRole(t *testing.T) {
+	tests := []struct {
+		html, want string
+	}{
+		{`<a id=t href=/>x</a>`, "link"},
+		{`<a id=t>x</a>`, "generic"},
+		{`<header id=t></header>`, "banner"},
+		{`<article><header id=t></header></article>`, "generic"},
+		{`<footer id=t></footer>`, "contentinfo"},
+		{`<section id=t></section>`, "generic"},
+		{`<section id=t aria-label=News></section>`, "region"},
+		{`<form id=t></form>`, "generic"},
+		{`<form id=t title=Search></form>`, "form"},
+		{`<h3 id=t>x</h3>`, "heading"},
+		{`<img id=t src=a.png alt="">`, "presentation"},
+		{`<img id=t src=a.png alt=Logo>`, "img"},
+		{`<input id=t>`, "textbox"},
+		{`<input id=t type=submit>`, "button"},
+		{`<input id=t type=range>`, "slider"},
+		{`<input id=t type=search>`, "searchbox"},
+		{`<input id=t list=l>`, "combobox"},
+		{`<p><input id=t type=hidden>`, ""},
+		{`<select id=t></select>`, "combobox"},
+		{`<select id=t multiple></select>`, "listbox"},
+		{`<ul><li id=t>x</ul>`, "listitem"},
+		{`<table><tr><th id=t>a<td>b</table>`, "rowheader"},
+		{`<table><tr><th id=t>a<th>b</table>`, "columnheader"},
+		{`<table role=grid><tr><td id=t>a</table>`, "gridcell"},
+		{`<div id=t role="bogus tab">x</div>`, "tab"},
+		{`<button id=t role=none>x</button>`, "button"},
+		{`<h1 id=t role=presentation>x</h1>`, "presentation"},
+		{`<svg id=t></svg>`, "graphics-document"},
+		{`<label id=t>x</label>`, ""},
+	}
+	for _, tt := range tests {
+		if got := a11y.Role(target(t, tt.html)); got != tt.want {
+			t.Errorf("Role(%s) = %q, want %q", tt.html, got, tt.want)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		name, html, want string
+	}{
+		{"content", `<button id=t> Save <b>all</b> </button>`, "Save all"},
+		{"aria-label", `<button id=t aria-label="Close dialog">X</button>`, "Close dialog"},
+		{"aria-labelledby wins", `<span id=a>First</span><span id=b>Last</span><input id=t aria-labelledby="a b" aria-label=ignored>`, "First Last"},
+		{"labelledby hidden element", `<span id=a hidden>Secret name</span><input id=t aria-labelledby=a>`, "Secret name"},
+		{"labelledby self", `<span id=a>Delete</span><button id=t aria-labelledby="t a">File.txt</button>`, "File.txt Delete"},
+		{"label for", `<label for=t>User <i>name</i></label><input id=t>`, "User name"},
+		{"wrapping label", `<label>Email <input id=t type=email></label>`, "Email"},
+		{"labels in tree order", `<label>Name <input id=t></label><label for=t>Other</label>`, "Name Other"},
+		{"label of another control", `<label>Name <input> <input id=t></label>`, ""},
+		{"label for a duplicate ID", `<input id=t><label for=t>First</label><label>Second <input id=t></label>`, "First"},
+		{"embedded control", `<label for=t>Flash every <input type=number value=3> seconds</label><input id=t type=checkbox>`, "Flash every 3 seconds"},
+		{"embedded select", `<span id=a>Size</span><select id=s aria-labelledby="a s"><option>S<option selected>M</select><button id=t aria-labelledby="a s">x</button>`, "Size M"},
+		{"title and placeholder", `<input id=t title=Query placeholder=Search>`, "Query"},
+		{"placeholder", `<input id=t placeholder=Search>`, "Search"},
+		{"submit default", `<input id=t type=submit>`, "Submit"},
+		{"button value", `<input id=t type=button value=Go>`, "Go"},
+		{"image input", `<input id=t type=image alt=Send src=s.png>`, "Send"},
+		{"img alt", `<img id=t src=a.png alt="Company logo">`, "Company logo"},
+		{"fieldset legend", `<fieldset id=t><legend>Shipping <em>address</em></legend><input></fieldset>`, "Shipping address"},
+		{"figure", `<figure id=t><img src=a.png alt=""><figcaption>A cat</figcaption></figure>`, "A cat"},
+		{"table caption", `<table id=t><caption>Prices</caption><tr><td>1</table>`, "Prices"},
+		{"hidden content", `<a id=t href=/>Read <span aria-hidden=true>→</span><span style="display: none">more</span></a>`, "Read"},
+		{"overridden style", `<a id=t href=/>Read <span style="display: none !important; display: inline">more</span><span style="visibility: hidden; visibility: visible">now</span></a>`, "Read now"},
+		{"disabled selected option", `<span id=a>Size</span><select id=s aria-labelledby="a s"><option>S<option selected disabled>M</select><button id=t aria-labelledby="a s">x</button>`, "Size M"},
+		{"blocks are separated", `<a id=t href=/><div>Title</div><div>Subtitle</div></a>`, "Title Subtitle"},
+		{"img in link", `<a id=t href=/><img src=h.png alt=Home></a>`, "Home"},
+		{"title fallback", `<div id=t title=Tip>text</div>`, "Tip"},
+		{"no name from content", `<div id=t>text</div>`, ""},
+		{"svg title", `<svg id=t><title>Chart</title></svg>`, "Chart"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a11y.Name(target(t, tt.html)); got != tt.want {
+				t.Errorf("Name = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameCycle(t *testing.T) {
+	e := target(t, `<div id=a aria-labelledby=t>A</div><button id=t aria-labelledby=a>B</button>`)
+	if got := a11y.Name(e); got != "A" {
+		t.Errorf("Name = %q, want %q", got, "A")
+	}
+}
+
+func TestDescription(t *testing.T) {
+	tests := []struct {
+		name, html, want string
+	}{
+		{"describedby", `<input id=t aria-describedby="h1 h2"><p id=h1>At least</p><p id=h2 hidden>8 characters</p>`, "At least 8 characters"},
+		{"aria-description", `<button id=t aria-description="Opens a menu">Menu</button>`, "Opens a menu"},
+		{"title", `<button id=t title="Save the file">Save</button>`, "Save the file"},
+		{"title used as name", `<input id=t title=Query>`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a11y.Description(target(t, tt.html)); got != tt.want {
+				t.Errorf("Description = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTree(t *testing.T) {
+	doc := mustParse(t, `<title>Shop</title>
+<header><nav aria-label=Main><ul><li><a href="/">Home</a><li><a href="/cart">Cart</a></ul></nav></header>
+<main>
+<h1>Checkout</h1>
+<p>Fill in <span>all</span> fields.</p>
+<script>track()</script>
+<form aria-label=Order>
+<fieldset><legend>Delivery</legend>
+<label><input type=radio name=d checked> Standard</label>
+<label><input type=radio name=d> Express</label>
+</fieldset>
+<label for=c>Country</label><select id=c><option>DE<option selected>FR</select>
+<input id=e aria-label=Email value="ann@example.com" aria-describedby=h><small id=h>Never shared.</small>
+<details><summary>Terms</summary>Long text</details>
+<button disabled>Pay</button>
+<div hidden><button>Ghost</button></div>
+</form>
+</main>
+<footer>© 2026</footer>`)
+	want := `- document "Shop":
+  - banner:
+    - navigation "Main":
+      - list:
+        - listitem:
+          - link "Home"
+        - listitem:
+          - link "Cart"
+  - main:
+    - heading "Checkout" [level=1]
+    - paragraph: Fill in all fields.
+    - form "Order":
+      - group "Delivery":
+        - text: Delivery
+        - radio "Standard" [checked]
+        - text: Standard
+        - radio "Express"
+        - text: Express
+      - text: Country
+      - combobox "Country":
+        - option "DE"
+        - option "FR" [selected]
+      - textbox "Email" [description="Never shared."]: ann@example.com
+      - text: Never shared.
+      - group: Terms
+      - button "Pay" [disabled]
+  - contentinfo: © 2026
+`
+	if got := a11y.Tree(doc).String(); got != want {
+		t.Errorf("Tree =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTreeElement(t *testing.T) {
+	e := target(t, `<div id=t role=tablist><button role=tab aria-selected=true aria-expanded=false>One</button><button role=tab>Two</button></div>`)
+	got := a11y.Tree(e).String()
+	want := `- tablist:
+  - tab "One" [selected]
+  - tab "Two"
+`
+	if got != want {
+		t.Errorf("Tree =\n%s\nwant\n%s", got, want)
+	}
+	tree := a11y.Tree(e)
+	if tab := tree.Children[0]; tab.Expanded != "false" || tab.Element == nil || !strings.HasPrefix(tab.Element.Text(), "One") {
+		t.Errorf("first tab = %+v", tab)
+	}
+}
@@ -0,0 +1,388 @@
+package a11y
+
+import (
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// Name returns the accessible name of e, computed with the text alternative
+// algorithm of Accessible Name and Description Computation 1.2 and the
+// HTML-AAM rules for native labels: aria-labelledby, aria-label, associated
+// label elements, alt, value and placeholder attributes, legends, captions
+// and figcaptions, the content of elements whose role allows naming from
+// content, and finally the title attribute. Whitespace in the result is
+// collapsed.
+func Name(e *dom.Element) string {
+	c := &computation{visited: map[*dom.Element]bool{}}
+	return flatten(c.textAlternative(e, step{root: true}))
+}
+
+// Description returns the accessible description of e: the text of the
+// elements its aria-describedby attribute references, its aria-description
+// attribute, or its title when the title is not already its name.
+func Description(e *dom.Element) string {
+	c := &computation{visited: map[*dom.Element]bool{}}
+	if refs := c.references(e, "aria-describedby"); len(refs) > 0 {
+		parts := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			parts = append(parts, c.textAlternative(ref, step{referenced: true, hiddenReferenced: isHidden(ref)}))
+		}
+		if desc := flatten(strings.Join(parts, " ")); desc != "" {
+			return desc
+		}
+	}
+	if desc := flatten(e.Attr("aria-description")); desc != "" {
+		return desc
+	}
+	if title := flatten(e.Attr("title")); title != "" && title != Name(e) {
+		return title
+	}
+	return ""
+}
+
+// computation holds the state of one run of the text alternative algorithm.
+// Each element is visited at most once, which breaks reference cycles.
+type computation struct {
+	visited map[*dom.Element]bool
+}
+
+// step describes how the algorithm reached the current element.
+type step struct {
+	// root marks the element whose name is computed.
+	root bool
+
+	// referenced marks an aria-labelledby or aria-describedby traversal,
+	// and hiddenReferenced one whose directly referenced element is
+	// hidden, which makes hidden content count.
+	referenced, hiddenReferenced bool
+
+	// recursive marks the traversal of an element's content.
+	recursive bool
+}
+
+func (c *computation) textAlternative(e *dom.Element, s step) string {
+	if c.visited[e] {
+		return ""
+	}
+	c.visited[e] = true
+
+	// 2A: hidden elements contribute nothing, unless the referenced
+	// element they belong to is hidden itself.
+	if !s.root && !s.hiddenReferenced && isHidden(e) {
+		return ""
+	}
+
+	// 2B: aria-labelledby, unless this is already such a traversal.
+	if !s.referenced {
+		if refs := c.references(e, "aria-labelledby"); len(refs) > 0 {
+			parts := make([]string, 0, len(refs))
+			for _, ref := range refs {
+				if ref == e {
+					// An element naming itself contributes its other name
+					// sources.
+					delete(c.visited, e)
+				}
+				parts = append(parts, c.textAlternative(ref, step{referenced: true, hiddenReferenced: isHidden(ref)}))
+			}
+			if name := strings.TrimSpace(strings.Join(parts, " ")); name != "" {
+				return name
+			}
+		}
+	}
+
+	r := role(e, false)
+
+	// 2C: a control embedded in the label of another element contributes
+	// its value.
+	if !s.root && (s.recursive || s.referenced) && isEmbeddedControl(r) {
+		return controlValue(e, r)
+	}
+
+	// 2D: aria-label.
+	if label := strings.TrimSpace(e.Attr("aria-label")); label != "" {
+		return label
+	}
+
+	// 2E: the text alternative the host language provides.
+	if r != "none" && r != "presentation" {
+		if name := c.nativeName(e, r); strings.TrimSpace(name) != "" {
+			return name
+		}
+	}
+
+	// 2F: the content of elements whose role allows it, and of everything
+	// inside a label or referenced element.
+	if s.recursive || s.referenced || nameFromContentRoles[r] {
+		if name := c.content(e, s); strings.TrimSpace(name) != "" {
+			return name
+		}
+	}
+
+	// 2I: the tooltip attribute.
+	return e.Attr("title")
+}
+
+// nativeName returns the text alternative HTML provides for e, or "".
+func (c *computation) nativeName(e *dom.Element, r string) string {
+	if e.Namespace == dom.NamespaceSVG {
+		if e.TagName == "svg" {
+			for _, child := range dom.ChildElements(e) {
+				if child.TagName == "title" && child.Namespace == dom.NamespaceSVG {
+					return child.Text()
+				}
+			}
+		}
+		return ""
+	}
+	if e.Namespace != dom.NamespaceHTML {
+		return ""
+	}
+	switch e.TagName {
+	case "input":
+		typ := strings.ToLower(strings.TrimSpace(e.Attr("type")))
+		switch typ {
+		case "button", "submit", "reset":
+			if value, ok := e.Attributes.Get("value"); ok {
+				return value
+			}
+			switch typ {
+			case "submit":
+				return "Submit"
+			case "reset":
+				return "Reset"
+			}
+			return ""
+		case "image":
+			for _, attr := range []string{"alt", "value", "title"} {
+				if value := strings.TrimSpace(e.Attr(attr)); value != "" {
+					return value
+				}
+			}
+			return "Submit"
+		case "hidden":
+			return ""
+		}
+		if name := c.labels(e); strings.TrimSpace(name) != "" {
+			return name
+		}
+		if r == "textbox" || r == "searchbox" || r == "combobox" || r == "spinbutton" {
+			if title := strings.TrimSpace(e.Attr("title")); title != "" {
+				return title
+			}
+			return e.Attr("placeholder")
+		}
+	case "textarea":
+		if name := c.labels(e); strings.TrimSpace(name) != "" {
+			return name
+		}
+		if title := strings.TrimSpace(e.Attr("title")); title != "" {
+			return title
+		}
+		return e.Attr("placeholder")
+	case "button", "select", "meter", "output", "progress":
+		return c.labels(e)
+	case "img", "area":
+		return e.Attr("alt")
+	case "fieldset":
+		return c.firstChildContent(e, "legend")
+	case "figure":
+		return c.firstChildContent(e, "figcaption")
+	case "table":
+		return c.firstChildContent(e, "caption")
+	case "optgroup", "option":
+		return e.Attr("label")
+	}
+	return ""
+}
+
+// labels returns the text of the label elements of a labelable element, in
+// tree order: those whose for attribute names it, and a label without a for
+// attribute that it is the first labelable descendant of.
+func (c *computation) labels(e *dom.Element) string {
+	id := e.ID()
+	var labels []*dom.Element
+	var first *dom.Element // the first element with the ID of e
+	dom.WalkElements(dom.Root(e), func(el *dom.Element) bool {
+		if id != "" && first == nil && el.ID() == id {
+			first = el
+		}
+		if el.TagName != "label" || el.Namespace != dom.NamespaceHTML {
+			return true
+		}
+		if f, ok := el.Attributes.Get("for"); ok {
+			if id != "" && f == id {
+				labels = append(labels, el)
+			}
+		} else if isInclusiveAncestor(el, e) && firstLabelable(el) == e {
+			labels = append(labels, el)
+		}
+		return true
+	})
+	parts := make([]string, 0, len(labels))
+	for _, label := range labels {
+		if label.HasAttr("for") && first != e {
+			continue
+		}
+		parts = append(parts, c.textAlternative(label, step{recursive: true}))
+	}
+	return strings.Join(parts, " ")
+}
+
+// firstLabelable returns the first labelable element below label, which is
+// the control a label without a for attribute labels.
+func firstLabelable(label *dom.Element) *dom.Element {
+	var found *dom.Element
+	dom.WalkElements(label, func(el *dom.Element) bool {
+		if isLabelable(el) {
+			found = el
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// isLabelable reports whether e is an element a label can be associated
+// with.
+func isLabelable(e *dom.Element) bool {
+	if e.Namespace != dom.NamespaceHTML {
+		return false
+	}
+	switch e.TagName {
+	case "button", "meter", "output", "progress", "select", "textarea":
+		return true
+	case "input":
+		return !strings.EqualFold(strings.TrimSpace(e.Attr("type")), "hidden")
+	}
+	return false
+}
+
+func isInclusiveAncestor(anc, e *dom.Element) bool {
+	for ; e != nil; e = e.ParentElement() {
+		if e == anc {
+			return true
+		}
+	}
+	return false
+}
+
+// firstChildContent returns the text alternative of the first child of e
+// with the given tag.
+func (c *computation) firstChildContent(e *dom.Element, tag string) string {
+	for _, child := range dom.ChildElements(e) {
+		if child.TagName == tag && child.Namespace == dom.NamespaceHTML {
+			return c.textAlternative(child, step{recursive: true})
+		}
+	}
+	return ""
+}
+
+// content returns the text alternatives of the children of e, concatenated.
+// Block-level children are separated from their neighbors by spaces.
+func (c *computation) content(e *dom.Element, s step) string {
+	var sb strings.Builder
+	s.root = false
+	s.recursive = true
+	closed := isClosedDetails(e)
+	for _, child := range e.Children() {
+		switch n := child.(type) {
+		case *dom.Text:
+			if !closed {
+				sb.WriteString(n.Data)
+			}
+		case *dom.Element:
+			if isBlock(n) {
+				sb.WriteByte(' ')
+				sb.WriteString(c.textAlternative(n, s))
+				sb.WriteByte(' ')
+			} else {
+				sb.WriteString(c.textAlternative(n, s))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// references returns the elements the ID references in the attribute name
+// of e point to, skipping IDs that match no element.
+func (c *computation) references(e *dom.Element, name string) []*dom.Element {
+	ids := strings.Fields(e.Attr(name))
+	if len(ids) == 0 {
+		return nil
+	}
+	byID := map[string]*dom.Element{}
+	dom.WalkElements(dom.Root(e), func(el *dom.Element) bool {
+		if id := el.ID(); id != "" {
+			if _, ok := byID[id]; !ok {
+				byID[id] = el
+			}
+		}
+		return true
+	})
+	var refs []*dom.Element
+	for _, id := range ids {
+		if ref := byID[id]; ref != nil {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// isEmbeddedControl reports whether an element with role r contributes its
+// value when it is part of another element's label.
+func isEmbeddedControl(r string) bool {
+	switch r {
+	case "textbox", "searchbox", "combobox", "listbox", "slider", "spinbutton", "scrollbar":
+		return true
+	}
+	return false
+}
+
+// controlValue returns the value of a control with role r: the text of a
+// text box, the selected options of a combo box or list box, and the value
+// of a range.
+func controlValue(e *dom.Element, r string) string {
+	switch r {
+	case "textbox", "searchbox":
+		if e.TagName == "textarea" {
+			return e.Text()
+		}
+		if e.TagName == "input" {
+			return e.Attr("value")
+		}
+		return e.Text()
+	case "combobox", "listbox":
+		if e.TagName == "select" {
+			var parts []string
+			for _, option := range e.SelectedOptions() {
+				parts = append(parts, flatten(option.Text()))
+			}
+			return strings.Join(parts, " ")
+		}
+		if e.TagName == "input" {
+			return e.Attr("value")
+		}
+		var parts []string
+		dom.WalkElements(e, func(el *dom.Element) bool {
+			if role(el, false) == "option" && el.Attr("aria-selected") == "true" {
+				parts = append(parts, flatten(el.Text()))
+			}
+			return true
+		})
+		return strings.Join(parts, " ")
+	case "slider", "spinbutton", "scrollbar", "progressbar", "meter":
+		for _, attr := range []string{"aria-valuetext", "aria-valuenow", "value"} {
+			if value := strings.TrimSpace(e.Attr(attr)); value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// flatten collapses runs of whitespace to single spaces and trims the
+// result.
+func flatten(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
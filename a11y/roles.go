@@ -0,0 +1,299 @@
+// Package a11y computes accessibility information for parsed documents: the
+// ARIA role of each element (explicit, or implicit per HTML-AAM), accessible
+// names and descriptions (per Accessible Name and Description Computation
+// 1.2), and an accessibility tree with a stable text form for snapshot
+// tests.
+//
+// The computations work on the DOM alone. The rules of the document's
+// stylesheets are not applied; only the display and visibility declarations
+// of style attributes are, when deciding whether an element is hidden.
+package a11y
+
+import (
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// ariaRoles are the concrete roles of WAI-ARIA 1.2 and the graphics module
+// that a role attribute can select.
+var ariaRoles = map[string]bool{
+	"alert": true, "alertdialog": true, "application": true, "article": true,
+	"banner": true, "blockquote": true, "button": true, "caption": true,
+	"cell": true, "checkbox": true, "code": true, "columnheader": true,
+	"combobox": true, "complementary": true, "contentinfo": true,
+	"definition": true, "deletion": true, "dialog": true, "directory": true,
+	"document": true, "emphasis": true, "feed": true, "figure": true,
+	"form": true, "generic": true, "grid": true, "gridcell": true,
+	"group": true, "heading": true, "img": true, "insertion": true,
+	"link": true, "list": true, "listbox": true, "listitem": true,
+	"log": true, "main": true, "mark": true, "marquee": true, "math": true,
+	"menu": true, "menubar": true, "menuitem": true,
+	"menuitemcheckbox": true, "menuitemradio": true, "meter": true,
+	"navigation": true, "none": true, "note": true, "option": true,
+	"paragraph": true, "presentation": true, "progressbar": true,
+	"radio": true, "radiogroup": true, "region": true, "row": true,
+	"rowgroup": true, "rowheader": true, "scrollbar": true, "search": true,
+	"searchbox": true, "separator": true, "slider": true,
+	"spinbutton": true, "status": true, "strong": true, "subscript": true,
+	"superscript": true, "switch": true, "tab": true, "table": true,
+	"tablist": true, "tabpanel": true, "term": true, "textbox": true,
+	"time": true, "timer": true, "toolbar": true, "tooltip": true,
+	"tree": true, "treegrid": true, "treeitem": true,
+	"graphics-document": true, "graphics-object": true, "graphics-symbol": true,
+}
+
+// nameFromContentRoles are the roles whose accessible name can come from
+// their content.
+var nameFromContentRoles = map[string]bool{
+	"button": true, "cell": true, "checkbox": true, "columnheader": true,
+	"gridcell": true, "heading": true, "link": true, "menuitem": true,
+	"menuitemcheckbox": true, "menuitemradio": true, "option": true,
+	"radio": true, "row": true, "rowheader": true, "switch": true,
+	"tab": true, "tooltip": true, "treeitem": true,
+}
+
+// implicitRoles maps HTML elements to their implicit role when it does not
+// depend on attributes or context.
+var implicitRoles = map[string]string{
+	"address": "group", "article": "article", "aside": "complementary",
+	"b": "generic", "bdi": "generic", "bdo": "generic",
+	"blockquote": "blockquote", "body": "generic", "button": "button",
+	"caption": "caption", "code": "code", "data": "generic",
+	"datalist": "listbox", "dd": "definition", "del": "deletion",
+	"details": "group", "dfn": "term", "dialog": "dialog", "div": "generic",
+	"dt": "term", "em": "emphasis", "fieldset": "group", "figure": "figure",
+	"hgroup": "group", "hr": "separator", "html": "document", "i": "generic",
+	"ins": "insertion", "main": "main", "mark": "mark", "math": "math",
+	"menu": "list", "meter": "meter", "nav": "navigation", "ol": "list",
+	"optgroup": "group", "option": "option", "output": "status",
+	"p": "paragraph", "pre": "generic", "progress": "progressbar",
+	"q": "generic", "s": "deletion", "samp": "generic", "search": "search",
+	"small": "generic", "span": "generic", "strong": "strong",
+	"sub": "subscript", "sup": "superscript", "table": "table",
+	"tbody": "rowgroup", "textarea": "textbox", "tfoot": "rowgroup",
+	"thead": "rowgroup", "time": "time", "tr": "row", "u": "generic",
+	"ul": "list",
+}
+
+// Role returns the role of e: the first role in its role attribute that
+// is a known ARIA role, or its implicit role. A none or presentation role
+// is ignored on focusable elements and elements with global ARIA
+// attributes, as the conflict resolution rules require. It returns "" for
+// elements without a role.
+func Role(e *dom.Element) string {
+	return role(e, true)
+}
+
+// role returns the role of e. Without named, form and section elements get
+// the form and region roles whether they have a name or not, which lets the
+// name computation look up roles without recursing into itself.
+func role(e *dom.Element, named bool) string {
+	for _, token := range strings.Fields(strings.ToLower(e.Attr("role"))) {
+		if !ariaRoles[token] {
+			continue
+		}
+		if (token == "none" || token == "presentation") && (isFocusable(e) || hasGlobalARIA(e)) {
+			break
+		}
+		return token
+	}
+	return implicitRole(e, named)
+}
+
+// ImplicitRole returns the role HTML-AAM maps e to without a role attribute,
+// or "" if it has none.
+func ImplicitRole(e *dom.Element) string {
+	return implicitRole(e, true)
+}
+
+func implicitRole(e *dom.Element, named bool) string {
+	switch e.Namespace {
+	case dom.NamespaceSVG:
+		if e.TagName == "svg" {
+			return "graphics-document"
+		}
+		return ""
+	case dom.NamespaceMathML:
+		if e.TagName == "math" {
+			return "math"
+		}
+		return ""
+	}
+	switch e.TagName {
+	case "a", "area":
+		if e.HasAttr("href") {
+			return "link"
+		}
+		if e.TagName == "a" {
+			return "generic"
+		}
+		return ""
+	case "footer", "header":
+		if hasSectioningAncestor(e) {
+			return "generic"
+		}
+		if e.TagName == "footer" {
+			return "contentinfo"
+		}
+		return "banner"
+	case "form":
+		if !named || Name(e) != "" {
+			return "form"
+		}
+		return "generic"
+	case "section":
+		if !named || Name(e) != "" {
+			return "region"
+		}
+		return "generic"
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		return "heading"
+	case "img":
+		if alt, ok := e.Attributes.Get("alt"); ok && alt == "" {
+			return "presentation"
+		}
+		return "img"
+	case "input":
+		return inputRole(e)
+	case "li":
+		if parent, ok := e.Parent().(*dom.Element); ok {
+			switch parent.TagName {
+			case "ol", "ul", "menu":
+				return "listitem"
+			}
+		}
+		return "generic"
+	case "select":
+		if e.HasAttr("multiple") || sizeAttr(e) > 1 {
+			return "listbox"
+		}
+		return "combobox"
+	case "td":
+		if table := e.Ancestor("table"); table != nil {
+			switch role(table, false) {
+			case "grid", "treegrid":
+				return "gridcell"
+			}
+		}
+		return "cell"
+	case "th":
+		return headerCellRole(e)
+	}
+	return implicitRoles[e.TagName]
+}
+
+func inputRole(e *dom.Element) string {
+	typ := strings.ToLower(strings.TrimSpace(e.Attr("type")))
+	switch typ {
+	case "button", "image", "reset", "submit":
+		return "button"
+	case "checkbox":
+		return "checkbox"
+	case "radio":
+		return "radio"
+	case "range":
+		return "slider"
+	case "number":
+		return "spinbutton"
+	case "password":
+		// No role in HTML-AAM, but browsers expose password fields as
+		// text boxes.
+		return "textbox"
+	case "hidden", "color", "date", "datetime-local", "file",
+		"month", "time", "week":
+		return ""
+	case "search":
+		if e.HasAttr("list") {
+			return "combobox"
+		}
+		return "searchbox"
+	}
+	if e.HasAttr("list") {
+		return "combobox"
+	}
+	return "textbox"
+}
+
+// headerCellRole returns rowheader or columnheader for a th element, from
+// its scope, or from whether its row holds data cells.
+func headerCellRole(th *dom.Element) string {
+	switch strings.ToLower(strings.TrimSpace(th.Attr("scope"))) {
+	case "row", "rowgroup":
+		return "rowheader"
+	case "col", "colgroup":
+		return "columnheader"
+	}
+	if tr, ok := th.Parent().(*dom.Element); ok {
+		for _, cell := range dom.ChildElements(tr) {
+			if cell.TagName == "td" {
+				return "rowheader"
+			}
+		}
+	}
+	return "columnheader"
+}
+
+func hasSectioningAncestor(e *dom.Element) bool {
+	for anc := e.ParentElement(); anc != nil; anc = anc.ParentElement() {
+		switch anc.TagName {
+		case "article", "aside", "main", "nav", "section":
+			return true
+		}
+		switch anc.Attr("role") {
+		case "article", "complementary", "main", "navigation", "region":
+			return true
+		}
+	}
+	return false
+}
+
+// isFocusable reports whether e can receive focus: interactive controls
+// that are not disabled, links, and elements with a tabindex.
+func isFocusable(e *dom.Element) bool {
+	if e.HasAttr("tabindex") {
+		return true
+	}
+	switch e.TagName {
+	case "a", "area":
+		return e.HasAttr("href")
+	case "button", "select", "textarea":
+		return !e.HasAttr("disabled")
+	case "input":
+		return !e.HasAttr("disabled") && !strings.EqualFold(e.Attr("type"), "hidden")
+	}
+	return false
+}
+
+// globalARIA are the global states and properties that make a none or
+// presentation role fall back to the implicit role.
+var globalARIA = []string{
+	"aria-atomic", "aria-busy", "aria-controls", "aria-current",
+	"aria-describedby", "aria-details", "aria-dropeffect", "aria-flowto",
+	"aria-grabbed", "aria-haspopup", "aria-hidden", "aria-invalid",
+	"aria-keyshortcuts", "aria-live", "aria-owns", "aria-relevant",
+	"aria-roledescription",
+}
+
+func hasGlobalARIA(e *dom.Element) bool {
+	for _, name := range globalARIA {
+		if e.HasAttr(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func sizeAttr(e *dom.Element) int {
+	n := 0
+	for _, c := range strings.TrimSpace(e.Attr("size")) {
+		if c < '0' || c > '9' {
+			break
+		}
+		n = n*10 + int(c-'0')
+		if n > 1<<20 {
+			break
+		}
+	}
+	return n
+}
@@ -0,0 +1,389 @@
+package a11y
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/css"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// Node is a node of the accessibility tree.
+type Node struct {
+	// Role is the role of the node. Text nodes have the role "text".
+	Role string
+
+	// Name is the accessible name. For text nodes it is the text, with
+	// whitespace collapsed.
+	Name string
+
+	// Description is the accessible description.
+	Description string
+
+	// Value is the value of a text box, combo box, list box or range.
+	Value string
+
+	// Level is the heading level, or the aria-level of other elements; 0 if
+	// none applies.
+	Level int
+
+	// Checked, Pressed and Expanded hold the aria-checked, aria-pressed and
+	// aria-expanded states ("true", "false" or "mixed"), or "" where the
+	// state does not apply. Checked also reflects the checked attribute of
+	// checkboxes and radio buttons.
+	Checked, Pressed, Expanded string
+
+	// Selected reports a selected option, tab or cell.
+	Selected bool
+
+	// Disabled reports a disabled control.
+	Disabled bool
+
+	// Element is the element of the node, or nil for text nodes.
+	Element *dom.Element
+
+	// Children are the child nodes.
+	Children []*Node
+}
+
+// Tree returns the accessibility tree of root. For a document the root
+// node has the role "document", the document's title as its name, and the
+// content of the body as its children; for an element it is the node of
+// the element, whatever its role.
+//
+// Hidden elements (see the package documentation) are left out, as are
+// elements without a role or with the generic, none or presentation roles,
+// whose children take their place. Adjacent text is merged into one text
+// node, and the text of an element whose name equals it is left out.
+func Tree(root dom.Node) *Node {
+	switch n := root.(type) {
+	case *dom.Document:
+		doc := &Node{Role: "document", Name: flatten(n.Title())}
+		if body := n.Body(); body != nil {
+			doc.Children = children(body)
+		} else if html := n.DocumentElement(); html != nil {
+			doc.Children = children(html)
+		}
+		return doc
+	case *dom.Element:
+		return newNode(n, Role(n))
+	}
+	return &Node{Children: children(root)}
+}
+
+// newNode returns the node of e with the given role.
+func newNode(e *dom.Element, r string) *Node {
+	n := &Node{
+		Role:        r,
+		Name:        Name(e),
+		Description: Description(e),
+		Element:     e,
+		Level:       level(e, r),
+		Disabled:    isDisabled(e),
+	}
+	if isEmbeddedControl(r) || r == "progressbar" || r == "meter" {
+		n.Value = flatten(controlValue(e, r))
+	}
+	switch r {
+	case "checkbox", "radio", "switch", "menuitemcheckbox", "menuitemradio":
+		n.Checked = checked(e)
+	case "button":
+		n.Pressed = tristate(e.Attr("aria-pressed"))
+	case "option", "tab", "gridcell", "row", "treeitem", "columnheader", "rowheader":
+		n.Selected = strings.EqualFold(e.Attr("aria-selected"), "true") ||
+			(e.TagName == "option" && e.HasAttr("selected"))
+	}
+	if expanded := tristate(e.Attr("aria-expanded")); expanded != "mixed" {
+		n.Expanded = expanded
+	}
+	if isLeaf(e, r) {
+		return n
+	}
+	n.Children = children(e)
+	if n.Name != "" && allText(n.Children) {
+		var parts []string
+		for _, child := range n.Children {
+			parts = append(parts, child.Name)
+		}
+		if strings.Join(parts, " ") == n.Name {
+			n.Children = nil
+		}
+	}
+	return n
+}
+
+// children returns the nodes of the children of parent.
+func children(parent dom.Node) []*Node {
+	var out []*Node
+	var text strings.Builder
+	flush := func() {
+		if s := flatten(text.String()); s != "" {
+			out = append(out, &Node{Role: "text", Name: s})
+		}
+		text.Reset()
+	}
+	var walk func(dom.Node)
+	walk = func(n dom.Node) {
+		elem, _ := n.(*dom.Element)
+		closed := elem != nil && isClosedDetails(elem)
+		for _, child := range n.Children() {
+			switch c := child.(type) {
+			case *dom.Text:
+				if !closed {
+					text.WriteString(c.Data)
+				}
+			case *dom.Element:
+				if ownHidden(c) {
+					continue
+				}
+				switch r := Role(c); r {
+				case "", "generic", "none", "presentation":
+					block := isBlock(c)
+					if block {
+						text.WriteByte(' ')
+					}
+					walk(c)
+					if block {
+						text.WriteByte(' ')
+					}
+				default:
+					flush()
+					out = append(out, newNode(c, r))
+				}
+			}
+		}
+	}
+	walk(parent)
+	flush()
+	return out
+}
+
+// isLeaf reports whether the content of e is left out of the tree: the
+// content of controls is their value, and images, SVG and MathML are
+// exposed as a whole.
+func isLeaf(e *dom.Element, r string) bool {
+	switch r {
+	case "textbox", "searchbox", "img", "math", "graphics-document", "meter", "progressbar":
+		return true
+	}
+	return e.TagName == "input"
+}
+
+func allText(nodes []*Node) bool {
+	for _, n := range nodes {
+		if n.Role != "text" {
+			return false
+		}
+	}
+	return len(nodes) > 0
+}
+
+// level returns the heading level of a heading, or the aria-level of e.
+func level(e *dom.Element, r string) int {
+	if n, err := strconv.Atoi(strings.TrimSpace(e.Attr("aria-level"))); err == nil && n > 0 {
+		return n
+	}
+	if r != "heading" {
+		return 0
+	}
+	if len(e.TagName) == 2 && e.TagName[0] == 'h' && '1' <= e.TagName[1] && e.TagName[1] <= '6' {
+		return int(e.TagName[1] - '0')
+	}
+	return 2
+}
+
+// checked returns the checked state of a checkbox or radio button.
+func checked(e *dom.Element) string {
+	if e.TagName == "input" && e.Namespace == dom.NamespaceHTML {
+		switch strings.ToLower(strings.TrimSpace(e.Attr("type"))) {
+		case "checkbox", "radio":
+			if e.HasAttr("checked") {
+				return "true"
+			}
+			return "false"
+		}
+	}
+	if state := tristate(e.Attr("aria-checked")); state != "" {
+		return state
+	}
+	return "false"
+}
+
+// tristate normalizes the value of a true/false/mixed state attribute, and
+// returns "" for missing and invalid values.
+func tristate(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "true", "false", "mixed":
+		return value
+	}
+	return ""
+}
+
+// isDisabled reports whether e is disabled: it has aria-disabled="true", or
+// it is a disabled form control.
+func isDisabled(e *dom.Element) bool {
+	return strings.EqualFold(strings.TrimSpace(e.Attr("aria-disabled")), "true") || e.Disabled()
+}
+
+func firstChild(e *dom.Element, tag string) *dom.Element {
+	for _, child := range dom.ChildElements(e) {
+		if child.TagName == tag {
+			return child
+		}
+	}
+	return nil
+}
+
+// isHidden reports whether e or one of its ancestors is hidden.
+func isHidden(e *dom.Element) bool {
+	for anc := e; anc != nil; anc = anc.ParentElement() {
+		if ownHidden(anc) {
+			return true
+		}
+	}
+	return false
+}
+
+// ownHidden reports whether e hides itself and its content: elements that
+// are not rendered, elements with a hidden attribute, aria-hidden="true" or
+// a display:none or visibility:hidden style, hidden inputs, closed dialogs
+// and the content of closed details elements.
+func ownHidden(e *dom.Element) bool {
+	if e.HasAttr("hidden") || strings.EqualFold(strings.TrimSpace(e.Attr("aria-hidden")), "true") {
+		return true
+	}
+	if style := e.Attr("style"); style != "" && hiddenByStyle(style) {
+		return true
+	}
+	if e.Namespace != dom.NamespaceHTML {
+		return false
+	}
+	switch e.TagName {
+	case "head", "script", "style", "template", "noscript", "title", "meta",
+		"link", "base", "datalist", "param", "source", "track":
+		return true
+	case "input":
+		return strings.EqualFold(strings.TrimSpace(e.Attr("type")), "hidden")
+	case "dialog":
+		return !e.HasAttr("open")
+	}
+	if parent := e.ParentElement(); parent != nil && isClosedDetails(parent) {
+		return e != firstChild(parent, "summary")
+	}
+	return false
+}
+
+// isClosedDetails reports whether e is a details element without an open
+// attribute, which shows only its summary.
+func isClosedDetails(e *dom.Element) bool {
+	return e.TagName == "details" && e.Namespace == dom.NamespaceHTML && !e.HasAttr("open")
+}
+
+// hiddenByStyle reports whether a style attribute declares display:none or
+// visibility:hidden (or collapse). Only style attributes are consulted; the
+// rules of the document's stylesheets are not applied.
+func hiddenByStyle(style string) bool {
+	decls := map[string]css.Declaration{}
+	for _, d := range css.ParseDeclarations(style) {
+		if prev, ok := decls[d.Property]; !ok || d.Important || !prev.Important {
+			decls[d.Property] = d
+		}
+	}
+	switch strings.ToLower(decls["visibility"].Value) {
+	case "hidden", "collapse":
+		return true
+	}
+	return strings.EqualFold(decls["display"].Value, "none")
+}
+
+// blockTags are the elements rendered as blocks (or table parts) by
+// default, whose text is separated from the text around them.
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"br": true, "caption": true, "dd": true, "details": true, "dialog": true,
+	"div": true, "dl": true, "dt": true, "fieldset": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hgroup": true, "hr": true, "legend": true, "li": true,
+	"main": true, "nav": true, "ol": true, "p": true, "pre": true,
+	"section": true, "summary": true, "table": true, "tbody": true,
+	"td": true, "tfoot": true, "th": true, "thead": true, "tr": true,
+	"ul": true,
+}
+
+func isBlock(e *dom.Element) bool {
+	return e.Namespace == dom.NamespaceHTML && blockTags[e.TagName]
+}
+
+// String returns the tree in a YAML-like form suited to snapshot tests: one
+// line per node, such as `- heading "Welcome" [level=1]`, indented by two
+// spaces per level. Names are quoted and followed by the states and
+// properties that are set. A node with children ends in ":" and lists them
+// on the following lines, except that a single text child, or the value of
+// a node without children, follows the colon on the same line, as in
+// `- paragraph: Some text`.
+func (n *Node) String() string {
+	var sb strings.Builder
+	n.write(&sb, 0)
+	return sb.String()
+}
+
+func (n *Node) write(sb *strings.Builder, depth int) {
+	sb.WriteString(strings.Repeat("  ", depth))
+	sb.WriteString("- ")
+	sb.WriteString(n.Role)
+	if n.Role == "text" {
+		sb.WriteString(": " + n.Name + "\n")
+		return
+	}
+	if n.Name != "" {
+		sb.WriteString(" " + strconv.Quote(n.Name))
+	}
+	for _, attr := range n.attrs() {
+		sb.WriteString(" [" + attr + "]")
+	}
+	switch {
+	case len(n.Children) == 1 && n.Children[0].Role == "text":
+		sb.WriteString(": " + n.Children[0].Name + "\n")
+	case len(n.Children) > 0:
+		sb.WriteString(":\n")
+		for _, child := range n.Children {
+			child.write(sb, depth+1)
+		}
+	case n.Value != "":
+		sb.WriteString(": " + n.Value + "\n")
+	default:
+		sb.WriteString("\n")
+	}
+}
+
+// attrs returns the states and properties of the node as written by
+// String. False states are left out.
+func (n *Node) attrs() []string {
+	var attrs []string
+	if n.Level > 0 {
+		attrs = append(attrs, "level="+strconv.Itoa(n.Level))
+	}
+	state := func(name, value string) {
+		switch value {
+		case "true":
+			attrs = append(attrs, name)
+		case "mixed":
+			attrs = append(attrs, name+"=mixed")
+		}
+	}
+	state("checked", n.Checked)
+	state("pressed", n.Pressed)
+	state("expanded", n.Expanded)
+	if n.Selected {
+		attrs = append(attrs, "selected")
+	}
+	if n.Disabled {
+		attrs = append(attrs, "disabled")
+	}
+	if n.Description != "" {
+		attrs = append(attrs, "description="+strconv.Quote(n.Description))
+	}
+	return attrs
+}
@@ -219,6 +219,83 @@ func TestParseWithOptions(t *testing.T) {
 			t.Fatal("WithXMLCoercion returned nil document")
 		}
 	})
+
+	t.Run("with element index", func(t *testing.T) {
+		// Adoption agency and foster parenting move nodes around during
+		// construction; the index must still match a tree walk.
+		input := `<p id=x class="a b"><b class=a>1<p id=y class=b>2</b>3</p><table><div class=a id=z>foster</div></table>`
+		doc, err := Parse(input, WithElementIndex())
+		if err != nil {
+			t.Fatalf("WithElementIndex error = %v", err)
+		}
+		if !doc.IndexEnabled() {
+			t.Fatal("WithElementIndex should enable the document index")
+		}
+		plain, _ := Parse(input)
+
+		for _, id := range []string{"x", "y", "z"} {
+			got, want := doc.GetElementByID(id), plain.GetElementByID(id)
+			if (got == nil) != (want == nil) || (got != nil && got.TagName != want.TagName) {
+				t.Errorf("GetElementByID(%q) = %v, want %v", id, got, want)
+			}
+		}
+		for _, class := range []string{"a", "b", "a b"} {
+			if got, want := len(doc.GetElementsByClassName(class)), len(plain.GetElementsByClassName(class)); got != want {
+				t.Errorf("GetElementsByClassName(%q) returned %d elements, want %d", class, got, want)
+			}
+		}
+		if got, want := len(doc.GetElementsByTagName("b")), len(plain.GetElementsByTagName("b")); got != want {
+			t.Errorf("GetElementsByTagName(b) returned %d elements, want %d", got, want)
+		}
+	})
+
+	t.Run("with base URL", func(t *testing.T) {
+		doc, err := Parse(`<base href="sub/"><a href="x">x</a>`, WithBaseURL("https://example.com/dir/page"))
+		if err != nil {
+			t.Fatalf("WithBaseURL error = %v", err)
+		}
+		if doc.URL != "https://example.com/dir/page" {
+			t.Errorf("doc.URL = %q", doc.URL)
+		}
+		a, _ := doc.QueryFirst("a")
+		if got, ok := a.ResolvedAttr("href"); !ok || got != "https://example.com/dir/sub/x" {
+			t.Errorf("ResolvedAttr(href) = %q, %v", got, ok)
+		}
+	})
+
+	t.Run("with scripting", func(t *testing.T) {
+		input := `<head><noscript><link rel=x></noscript></head><body><noscript><p title="</noscript><b>x</b>">`
+		doc, err := Parse(input, WithScripting())
+		if err != nil {
+			t.Fatalf("WithScripting error = %v", err)
+		}
+		noscripts := doc.GetElementsByTagName("noscript")
+		if len(noscripts) != 2 {
+			t.Fatalf("got %d noscript elements, want 2", len(noscripts))
+		}
+		if got, want := noscripts[0].Text(), "<link rel=x>"; got != want {
+			t.Errorf("head noscript text = %q, want %q", got, want)
+		}
+		if got, want := noscripts[1].Text(), `<p title="`; got != want {
+			t.Errorf("body noscript text = %q, want %q", got, want)
+		}
+		if len(doc.GetElementsByTagName("b")) != 1 {
+			t.Error("markup after </noscript> should be parsed as elements")
+		}
+
+		plain, _ := Parse(input)
+		if len(plain.GetElementsByTagName("p")) != 1 || len(plain.GetElementsByTagName("b")) != 0 {
+			t.Error("without scripting, noscript content should be parsed as markup")
+		}
+
+		nodes, err := ParseFragment("<b>x</b>", "noscript", WithScripting())
+		if err != nil {
+			t.Fatalf("ParseFragment error = %v", err)
+		}
+		if len(nodes) != 0 {
+			t.Errorf("noscript fragment with scripting produced %d elements, want 0", len(nodes))
+		}
+	})
 }
 
 // TestParseComplexHTML tests parsing more complex HTML structures.
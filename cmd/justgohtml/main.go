@@ -2,6 +2,7 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -11,7 +12,10 @@ import (
 
 	"github.com/MeKo-Christian/JustGoHTML"
 	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/encoding"
 	"github.com/MeKo-Christian/JustGoHTML/serialize"
+	"github.com/MeKo-Christian/JustGoHTML/tables"
+	"github.com/MeKo-Christian/JustGoHTML/validate"
 
 	// Import selector package to register selector functions via init()
 	_ "github.com/MeKo-Christian/JustGoHTML/selector"
@@ -22,6 +26,8 @@ const (
 	outputFormatHTML     = "html"
 	outputFormatText     = "text"
 	outputFormatMarkdown = "markdown"
+	outputFormatCSV      = "csv"
+	outputFormatJSON     = "json"
 )
 
 var version = "dev"
@@ -35,6 +41,7 @@ type config struct {
 	strip     bool
 	pretty    bool
 	indent    int
+	validate  bool
 }
 
 func main() {
@@ -61,6 +68,16 @@ func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 		return fmt.Errorf("reading input: %w", err)
 	}
 
+	if cfg.validate {
+		// Decode as ParseBytes does, so that the byte order mark and the
+		// meta charset are honored.
+		html, _, err := encoding.Decode(input, "")
+		if err != nil {
+			return fmt.Errorf("decoding input: %w", err)
+		}
+		return writeValidation(stdout, html)
+	}
+
 	// Parse HTML
 	doc, err := JustGoHTML.ParseBytes(input)
 	if err != nil {
@@ -84,6 +101,11 @@ func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 		nodes = []dom.Node{doc}
 	}
 
+	// Tables are exported as a whole rather than node by node
+	if cfg.format == outputFormatCSV || cfg.format == outputFormatJSON {
+		return writeTables(stdout, nodes, cfg.format)
+	}
+
 	// Format and output
 	output := formatNodes(nodes, cfg)
 	_, err = fmt.Fprint(stdout, output)
@@ -102,13 +124,14 @@ func parseFlags(args []string, stderr io.Writer) (*config, string, error) {
 
 	fs.StringVar(&cfg.selector, "selector", "", "CSS selector to filter output")
 	fs.StringVar(&selectorShort, "s", "", "CSS selector to filter output (shorthand)")
-	fs.StringVar(&cfg.format, "format", "html", "Output format: html, text, markdown")
+	fs.StringVar(&cfg.format, "format", "html", "Output format: html, text, markdown, csv, json")
 	fs.StringVar(&formatShort, "f", "", "Output format (shorthand)")
 	fs.BoolVar(&cfg.first, "first", false, "Output only first match")
 	fs.StringVar(&cfg.separator, "separator", " ", "Separator for text output")
-	fs.BoolVar(&cfg.strip, "strip", true, "Strip whitespace from text")
+	fs.BoolVar(&cfg.strip, "strip", true, "Lay out text like a browser instead of printing raw text data")
 	fs.BoolVar(&cfg.pretty, "pretty", true, "Pretty-print HTML output")
 	fs.IntVar(&cfg.indent, "indent", 2, "Indentation size for pretty-print")
+	fs.BoolVar(&cfg.validate, "validate", false, "Check the document for conformance errors instead of printing it")
 	fs.BoolVar(&showVersion, "version", false, "Show version")
 	fs.BoolVar(&versionShort, "v", false, "Show version (shorthand)")
 
@@ -124,6 +147,8 @@ func parseFlags(args []string, stderr io.Writer) (*config, string, error) {
 		fmt.Fprintf(stderr, "  justgohtml -s 'p' index.html             Extract all <p> elements\n")
 		fmt.Fprintf(stderr, "  justgohtml -s 'h1' -f text index.html    Extract h1 text content\n")
 		fmt.Fprintf(stderr, "  curl -s URL | justgohtml -s 'title' -    Extract title from piped HTML\n")
+		fmt.Fprintf(stderr, "  justgohtml -s '#prices' -f csv page.html Export a table as CSV\n")
+		fmt.Fprintf(stderr, "  justgohtml --validate index.html         Report conformance errors\n")
 	}
 
 	if err := fs.Parse(args); err != nil {
@@ -143,10 +168,10 @@ func parseFlags(args []string, stderr io.Writer) (*config, string, error) {
 
 	// Validate format
 	switch cfg.format {
-	case outputFormatHTML, outputFormatText, outputFormatMarkdown:
+	case outputFormatHTML, outputFormatText, outputFormatMarkdown, outputFormatCSV, outputFormatJSON:
 		// valid
 	default:
-		return nil, "", fmt.Errorf("invalid format %q: must be html, text, or markdown", cfg.format)
+		return nil, "", fmt.Errorf("invalid format %q: must be html, text, markdown, csv, or json", cfg.format)
 	}
 
 	// Show version
@@ -201,6 +226,53 @@ func formatNodes(nodes []dom.Node, cfg *config) string {
 	return output
 }
 
+// writeValidation writes the parse and conformance errors of html, one per
+// line, and fails if there are errors other than warnings.
+func writeValidation(w io.Writer, html string) error {
+	failed := 0
+	for _, e := range validate.HTML(html) {
+		if _, err := fmt.Fprintln(w, e); err != nil {
+			return err
+		}
+		if !e.Warning {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d validation errors", failed)
+	}
+	return nil
+}
+
+// writeTables writes the tables found in nodes, or the nodes themselves if
+// they are tables, as CSV or JSON. CSV tables are separated by blank lines;
+// JSON output is an array of tables.
+func writeTables(w io.Writer, nodes []dom.Node, format string) error {
+	var found []*tables.Table
+	for _, node := range nodes {
+		found = append(found, tables.Extract(node)...)
+	}
+	if format == outputFormatJSON {
+		if found == nil {
+			found = []*tables.Table{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(found)
+	}
+	for i, table := range found {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := table.WriteCSV(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func formatHTML(node dom.Node, cfg *config) string {
 	opts := serialize.Options{
 		Pretty:     cfg.pretty,
@@ -209,12 +281,13 @@ func formatHTML(node dom.Node, cfg *config) string {
 	return serialize.ToHTML(node, opts)
 }
 
+// formatText renders the text of node as laid out by a browser, or its raw
+// text data when stripping is disabled.
 func formatText(node dom.Node, cfg *config) string {
-	text := extractText(node)
 	if cfg.strip {
-		text = collapseWhitespace(text)
+		return serialize.ToText(node)
 	}
-	return text
+	return extractText(node)
 }
 
 func formatMarkdown(node dom.Node, _ *config) string {
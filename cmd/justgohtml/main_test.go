@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -969,6 +970,90 @@ func TestMarkdownCodeBlock(t *testing.T) {
 	}
 }
 
+// TestTableCSVFormat tests exporting tables as CSV.
+func TestTableCSVFormat(t *testing.T) {
+	stdin := strings.NewReader(`<table id=a><tr><th colspan=2>Name<tr><td>x<td>1, 2</table>` +
+		`<table><tr><td>second</table>`)
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-f", "csv", "-"}, stdin, &stdout, &stderr); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if got, want := stdout.String(), "Name,Name\nx,\"1, 2\"\n\nsecond\n"; got != want {
+		t.Errorf("CSV output = %q, want %q", got, want)
+	}
+
+	stdin = strings.NewReader(`<div id=d><table><tr><td>in div</table></div><table><tr><td>out</table>`)
+	stdout.Reset()
+	if err := run([]string{"-s", "#d", "-f", "csv", "-"}, stdin, &stdout, &stderr); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if got, want := stdout.String(), "in div\n"; got != want {
+		t.Errorf("CSV output with selector = %q, want %q", got, want)
+	}
+}
+
+// TestTableJSONFormat tests exporting tables as JSON.
+func TestTableJSONFormat(t *testing.T) {
+	stdin := strings.NewReader(`<table><caption>Prices</caption><tr><th>Item<tr><td>Tea</table>`)
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-f", "json", "-"}, stdin, &stdout, &stderr); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	var got []struct {
+		Caption string     `json:"caption"`
+		Rows    [][]string `json:"rows"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", stdout.String(), err)
+	}
+	if len(got) != 1 || got[0].Caption != "Prices" || len(got[0].Rows) != 2 || got[0].Rows[1][0] != "Tea" {
+		t.Errorf("JSON output = %+v", got)
+	}
+
+	stdout.Reset()
+	if err := run([]string{"-f", "json", "-"}, strings.NewReader(`<p>no tables`), &stdout, &stderr); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "[]" {
+		t.Errorf("JSON output without tables = %q, want []", got)
+	}
+}
+
+// TestValidate tests reporting conformance errors.
+func TestValidate(t *testing.T) {
+	stdin := strings.NewReader("<!DOCTYPE html><title>t</title>\n<img src=a.png>")
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"--validate", "-"}, stdin, &stdout, &stderr)
+	if err == nil || err.Error() != "1 validation errors" {
+		t.Errorf("run error = %v, want 1 validation errors", err)
+	}
+	want := "warning: missing-lang: html element should have a lang attribute declaring the language of the document\n" +
+		"missing-required-attribute at 2:1: img element must have the alt attribute\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("validation output = %q, want %q", got, want)
+	}
+
+	stdin = strings.NewReader(`<!DOCTYPE html><html lang=en><title>t</title><p>ok`)
+	stdout.Reset()
+	if err := run([]string{"--validate", "-"}, stdin, &stdout, &stderr); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if got := stdout.String(); got != "" {
+		t.Errorf("validation output of a valid document = %q, want empty", got)
+	}
+
+	// The input is decoded as for parsing, here as UTF-16 from its byte
+	// order mark.
+	input := []byte{0xFF, 0xFE}
+	for _, c := range `<!DOCTYPE html><html lang=en><title>t</title><p>ok` {
+		input = append(input, byte(c), 0)
+	}
+	stdout.Reset()
+	if err := run([]string{"--validate", "-"}, bytes.NewReader(input), &stdout, &stderr); err != nil {
+		t.Fatalf("run of UTF-16 input failed: %v\n%s", err, stdout.String())
+	}
+}
+
 // mustFindGoMod finds the go.mod file by walking up from cwd.
 func mustFindGoMod(t *testing.T) string {
 	t.Helper()
@@ -0,0 +1,204 @@
+package content
+
+import (
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/css"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// removedTags are removed with their content before scoring: they hold no
+// article text.
+var removedTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+	"link": true, "meta": true, "iframe": true, "object": true,
+	"embed": true, "nav": true, "aside": true, "button": true,
+	"input": true, "select": true, "textarea": true, "dialog": true,
+}
+
+// unlikelyRoles are ARIA roles of page furniture.
+var unlikelyRoles = map[string]bool{
+	"menu": true, "menubar": true, "complementary": true, "navigation": true,
+	"alert": true, "alertdialog": true, "dialog": true,
+}
+
+// conditionalTags are the containers clean removes when their content looks
+// like clutter rather than prose.
+var conditionalTags = map[string]bool{
+	"form": true, "fieldset": true, "table": true, "ul": true, "ol": true,
+	"div": true, "section": true, "header": true, "footer": true,
+}
+
+// presentationalAttrs are removed from the content unless classes are kept.
+var presentationalAttrs = []string{
+	"class", "id", "style", "align", "background", "bgcolor", "border",
+	"cellpadding", "cellspacing", "frame", "hspace", "rules", "valign",
+	"vspace", "width", "height",
+}
+
+// prepare removes the elements of doc that never hold article content:
+// scripts, styles, navigation, form controls and hidden elements, including
+// those that the document's stylesheets hide. With stripUnlikely it also
+// removes elements whose class, id or role marks them as page furniture.
+func prepare(doc *dom.Document, stripUnlikely bool) {
+	styles := css.Compute(doc, css.DefaultOptions())
+	var walk func(dom.Node)
+	walk = func(n dom.Node) {
+		for _, elem := range dom.ChildElements(n) {
+			if removedTags[elem.TagName] || isHidden(elem, styles) || stripUnlikely && isUnlikely(elem) {
+				n.RemoveChild(elem)
+				continue
+			}
+			walk(elem)
+		}
+	}
+	walk(doc)
+}
+
+// isHidden reports whether e is hidden by aria-hidden, or by its computed
+// display or visibility, which the hidden attribute and style attributes
+// also set.
+func isHidden(e *dom.Element, styles *css.Styles) bool {
+	if strings.EqualFold(e.Attr("aria-hidden"), "true") {
+		return true
+	}
+	switch strings.ToLower(styles.Value(e, "visibility")) {
+	case "hidden", "collapse":
+		return true
+	}
+	return strings.EqualFold(styles.Value(e, "display"), "none")
+}
+
+func isUnlikely(e *dom.Element) bool {
+	switch e.TagName {
+	case "html", "body", "a", "article", "main":
+		return false
+	}
+	if unlikelyRoles[strings.ToLower(strings.TrimSpace(e.Attr("role")))] {
+		return true
+	}
+	hints := e.Attr("class") + " " + e.ID()
+	if !unlikelyCandidates.MatchString(hints) || maybeCandidate.MatchString(hints) {
+		return false
+	}
+	for anc := e.ParentElement(); anc != nil; anc = anc.ParentElement() {
+		if anc.TagName == "table" || anc.TagName == "code" {
+			return false
+		}
+	}
+	return true
+}
+
+// clean removes clutter from the extracted content: containers that score
+// badly or are dominated by links, images or list items, headings marked as
+// clutter, bylines and empty paragraphs. Elements are visited in reverse
+// tree order so that the children of a container are cleaned before it is
+// judged.
+func (x *extractor) clean(content *dom.Element) {
+	var elems []*dom.Element
+	dom.WalkElements(content, func(e *dom.Element) bool {
+		if e != content {
+			elems = append(elems, e)
+		}
+		return true
+	})
+	for i := len(elems) - 1; i >= 0; i-- {
+		e := elems[i]
+		parent := e.Parent()
+		if parent == nil {
+			continue
+		}
+		if x.shouldRemove(e) {
+			parent.RemoveChild(e)
+		}
+	}
+}
+
+func (x *extractor) shouldRemove(e *dom.Element) bool {
+	switch {
+	case isByline(e) && len(normalizedText(e)) < 100:
+		return true
+	case e.TagName == "h1" || e.TagName == "h2":
+		return x.classWeight(e) < 0
+	case e.TagName == "p":
+		return normalizedText(e) == "" && len(e.GetElementsByTagName("img")) == 0
+	case x.cleanConditionally && conditionalTags[e.TagName]:
+		return x.isClutter(e)
+	}
+	return false
+}
+
+// isClutter applies Readability's conditional cleaning to a container.
+func (x *extractor) isClutter(e *dom.Element) bool {
+	if e.TagName == "table" && isDataTable(e) {
+		return false
+	}
+	weight := x.classWeight(e)
+	if float64(weight)+x.scores[e] < 0 {
+		return true
+	}
+	text := normalizedText(e)
+	if strings.Count(text, ",") >= 10 {
+		return false
+	}
+	paras := float64(len(e.GetElementsByTagName("p")))
+	imgs := float64(len(e.GetElementsByTagName("img")))
+	items := float64(len(e.GetElementsByTagName("li"))) - 100
+	inputs := float64(len(e.GetElementsByTagName("input")))
+	isList := e.TagName == "ul" || e.TagName == "ol"
+	inFigure := e.Ancestor("figure") != nil
+	density := linkDensity(e)
+	switch {
+	case imgs > 1 && paras/imgs < 0.5 && !inFigure:
+		return true
+	case !isList && items > paras:
+		return true
+	case inputs > paras/3:
+		return true
+	case !isList && len(text) < 25 && (imgs == 0 || imgs > 2) && !inFigure:
+		return true
+	case weight < 25 && density > 0.2:
+		return true
+	case weight >= 25 && density > 0.5:
+		return true
+	}
+	return false
+}
+
+// isDataTable reports whether table holds data rather than layout.
+func isDataTable(table *dom.Element) bool {
+	if strings.EqualFold(table.Attr("role"), "presentation") {
+		return false
+	}
+	return table.HasAttr("summary") ||
+		len(table.GetElementsByTagName("caption")) > 0 ||
+		len(table.GetElementsByTagName("th")) > 0 ||
+		len(table.GetElementsByTagName("thead")) > 0
+}
+
+// cleanTitleHeading removes the first heading of content if it repeats the
+// article title.
+func cleanTitleHeading(content *dom.Element, title string) {
+	if title == "" {
+		return
+	}
+	for _, h := range content.GetElementsByTagName("*") {
+		if h.TagName != "h1" && h.TagName != "h2" {
+			continue
+		}
+		if strings.EqualFold(normalizedText(h), title) {
+			h.Parent().RemoveChild(h)
+		}
+		return
+	}
+}
+
+// stripAttributes removes presentational attributes from the content.
+func stripAttributes(content *dom.Element) {
+	dom.WalkElements(content, func(e *dom.Element) bool {
+		for _, name := range presentationalAttrs {
+			e.RemoveAttr(name)
+		}
+		return true
+	})
+}
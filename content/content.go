@@ -0,0 +1,129 @@
+// Package content isolates the main article of a document, in the manner of
+// Mozilla's Readability.
+//
+// Extract scores the blocks of a page by the amount of text they hold, their
+// link density, paragraph counts and class and id hints, picks the best
+// candidate together with related siblings, and cleans the result of
+// navigation, sharing widgets, forms and other clutter. The returned
+// Article also carries the title, byline, publication date and lead image,
+// taken from the page's metadata where available.
+//
+// The cleaned content is a detached element, ready for serialize.ToMarkdown
+// or serialize.ToHTML:
+//
+//	article, err := content.Extract(doc, content.DefaultOptions())
+//	if err != nil {
+//		return err
+//	}
+//	md := serialize.ToMarkdown(article.Content)
+package content
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/metadata"
+)
+
+// ErrNoContent is returned by Extract when a document has no body or no
+// text to extract.
+var ErrNoContent = errors.New("content: no article content found")
+
+// Article is the main content of a document.
+type Article struct {
+	// Title is the article title: the OpenGraph or JSON-LD headline, or the
+	// document title without a trailing site name.
+	Title string
+
+	// Byline is the author, if one is named.
+	Byline string
+
+	// Published is the publication date, or the zero time if none is given
+	// or it cannot be parsed.
+	Published time.Time
+
+	// LeadImage is the absolute URL of the article's main image, if any.
+	LeadImage string
+
+	// Content is a div holding the cleaned article. It is detached from the
+	// document; URLs in href and src attributes are absolute when the
+	// document has a base URL.
+	Content *dom.Element
+}
+
+// Text returns the text of the article content with whitespace collapsed.
+func (a *Article) Text() string {
+	if a.Content == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(a.Content.Text()), " ")
+}
+
+// Options configures content extraction.
+type Options struct {
+	// MinParagraphLength is the number of characters a paragraph needs to
+	// contribute to the score of its ancestors.
+	MinParagraphLength int
+
+	// MinContentLength is the length of text below which Extract retries
+	// with fewer heuristics: first without removing blocks whose class or id
+	// suggests clutter, then also without class and id scoring and without
+	// removing containers that look like clutter. The longest result wins.
+	MinContentLength int
+
+	// KeepClasses keeps the class and id attributes in the content. By
+	// default all presentational attributes are removed.
+	KeepClasses bool
+}
+
+// DefaultOptions returns the options Readability uses: paragraphs of at
+// least 25 characters and articles of at least 500.
+func DefaultOptions() Options {
+	return Options{
+		MinParagraphLength: 25,
+		MinContentLength:   500,
+	}
+}
+
+// Extract returns the main article of doc. The document itself is not
+// modified.
+func Extract(doc *dom.Document, opts Options) (*Article, error) {
+	if doc.Body() == nil {
+		return nil, ErrNoContent
+	}
+	md := metadata.Extract(doc)
+
+	// The clones share the base URL of doc, so it is looked up once.
+	resolve := doc.URLResolver()
+	var best *dom.Element
+	bestLen := -1
+	for _, p := range passes {
+		work := doc.Clone(true).(*dom.Document)
+		prepare(work, p.stripUnlikely)
+		x := &extractor{pass: p, opts: opts, resolve: resolve}
+		content := x.grabArticle(work)
+		n := len(strings.Join(strings.Fields(content.Text()), " "))
+		if n > bestLen {
+			best, bestLen = content, n
+		}
+		if n >= opts.MinContentLength {
+			break
+		}
+	}
+	if bestLen <= 0 {
+		return nil, ErrNoContent
+	}
+
+	article := &Article{Content: best}
+	article.Title = articleTitle(doc, md)
+	article.Byline = byline(doc, md)
+	article.Published = published(doc, md)
+	article.LeadImage = leadImage(doc, md, best)
+	cleanTitleHeading(best, article.Title)
+	if !opts.KeepClasses {
+		stripAttributes(best)
+	}
+	return article, nil
+}
@@ -0,0 +1,207 @@
+package content_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/content"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+)
+
+func mustParse(t *testing.T, html string) *dom.Document {
+	t.Helper()
+	doc, err := JustGoHTML.Parse(html, JustGoHTML.WithBaseURL("https://news.example/2024/05/story.html"))
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", html, err)
+	}
+	return doc
+}
+
+const newsPage = `<!DOCTYPE html><html><head>
+<title>Rivers Return to the Valley After Decade of Drought | Example News</title>
+<meta property="og:image" content="/img/river.jpg">
+<meta name="author" content="Jane Reporter">
+<meta property="article:published_time" content="2024-05-02T08:30:00Z">
+<script>var tracking = 1;</script>
+</head><body>
+<header class="site-header"><a href="/">Example News</a><nav><a href="/world">World</a> <a href="/sport">Sport</a></nav></header>
+<div id="main-wrapper">
+  <aside class="sidebar"><h3>Most read</h3><ul><li><a href="/a">Another story, with a headline</a></li><li><a href="/b">And one more story here</a></li></ul></aside>
+  <article class="article-body">
+    <h1>Rivers Return to the Valley After Decade of Drought</h1>
+    <p class="byline">By Jane Reporter</p>
+    <p>After ten years of drought, the rivers of the valley are flowing again, bringing relief to farmers, fishermen and the towns that depend on them.</p>
+    <p>Heavy winter snow, followed by a wet spring, filled the reservoirs to levels not seen since 2013, according to the regional water authority, which published its figures on Tuesday.</p>
+    <figure><img src="photos/river-1.jpg" alt="The river in spate" width="800"><figcaption>The river in spate.</figcaption></figure>
+    <p>"We had almost forgotten what it sounds like," said one resident, who has lived by the river for forty years, as she watched the water rush past her garden. <a href="/related/drought">Read about the drought</a>.</p>
+    <div class="share-tools"><a href="https://twitter.example/share">Share</a> <a href="https://facebook.example/share">Share</a></div>
+    <p>Scientists warn, however, that one good year does not end a long-term trend, and that water use must still fall across the region if the recovery is to last.</p>
+  </article>
+</div>
+<div class="comments"><p>Great article, thanks for writing it, really enjoyed reading this one!</p></div>
+<footer><p>Copyright Example News. All rights reserved. Contact us, advertise, privacy policy.</p></footer>
+</body></html>`
+
+func TestExtract(t *testing.T) {
+	doc := mustParse(t, newsPage)
+	article, err := content.Extract(doc, content.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Extract error: %v", err)
+	}
+	if want := "Rivers Return to the Valley After Decade of Drought"; article.Title != want {
+		t.Errorf("Title = %q, want %q", article.Title, want)
+	}
+	if article.Byline != "Jane Reporter" {
+		t.Errorf("Byline = %q", article.Byline)
+	}
+	if want := time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC); !article.Published.Equal(want) {
+		t.Errorf("Published = %v, want %v", article.Published, want)
+	}
+	if want := "https://news.example/img/river.jpg"; article.LeadImage != want {
+		t.Errorf("LeadImage = %q, want %q", article.LeadImage, want)
+	}
+
+	text := article.Text()
+	for _, want := range []string{"After ten years of drought", "rush past her garden", "one good year"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("content is missing %q:\n%s", want, text)
+		}
+	}
+	for _, unwanted := range []string{"Most read", "World", "Share", "Great article", "Copyright", "tracking", "By Jane"} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("content contains %q:\n%s", unwanted, text)
+		}
+	}
+
+	md := serialize.ToMarkdown(article.Content)
+	for _, want := range []string{
+		"![The river in spate](https://news.example/2024/05/photos/river-1.jpg)",
+		"[Read about the drought](https://news.example/related/drought)",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown is missing %q:\n%s", want, md)
+		}
+	}
+	if strings.HasPrefix(md, "#") {
+		t.Errorf("Markdown repeats the title heading:\n%s", md)
+	}
+	if strings.Contains(md, "class=") || strings.Contains(serialize.ToHTML(article.Content, serialize.DefaultOptions()), "width=") {
+		t.Errorf("presentational attributes were kept")
+	}
+
+	// The document is not modified.
+	if scripts, _ := doc.Query("script"); len(scripts) != 1 {
+		t.Errorf("Extract modified the document")
+	}
+}
+
+func TestExtractJSONLD(t *testing.T) {
+	doc := mustParse(t, `<html><head><title>Site</title>
+<script type="application/ld+json">{"@context": "https://schema.org", "@graph": [
+  {"@type": "WebSite", "name": "Site"},
+  {"@type": "NewsArticle", "headline": "The Headline", "datePublished": "2023-11-20",
+   "author": [{"@type": "Person", "name": "A. Writer"}, {"@type": "Person", "name": "B. Writer"}],
+   "image": {"@type": "ImageObject", "url": "https://cdn.example/lead.png"}}
+]}</script></head>
+<body><div><p>This paragraph is long enough to be scored as the content of the page, and it has a comma.</p></div></body></html>`)
+	article, err := content.Extract(doc, content.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if article.Title != "The Headline" {
+		t.Errorf("Title = %q", article.Title)
+	}
+	if article.Byline != "A. Writer, B. Writer" {
+		t.Errorf("Byline = %q", article.Byline)
+	}
+	if want := time.Date(2023, 11, 20, 0, 0, 0, 0, time.UTC); !article.Published.Equal(want) {
+		t.Errorf("Published = %v, want %v", article.Published, want)
+	}
+	if article.LeadImage != "https://cdn.example/lead.png" {
+		t.Errorf("LeadImage = %q", article.LeadImage)
+	}
+}
+
+func TestExtractTitle(t *testing.T) {
+	tests := []struct {
+		head, body, want string
+	}{
+		{`<title>A Long Story Title - Site</title>`, ``, "A Long Story Title"},
+		{`<title>Short - Site Name Here</title>`, ``, "Short - Site Name Here"},
+		{`<title>Site: The Real Title</title>`, `<h1>The Real Title</h1>`, "The Real Title"},
+		{`<title>Ignored</title><meta property="og:title" content=" OG  Title ">`, ``, "OG Title"},
+	}
+	for _, tt := range tests {
+		doc := mustParse(t, `<html><head>`+tt.head+`</head><body>`+tt.body+`<p>Some text that is long enough to be kept as article content.</p></body></html>`)
+		article, err := content.Extract(doc, content.DefaultOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if article.Title != tt.want {
+			t.Errorf("Title for %q = %q, want %q", tt.head, article.Title, tt.want)
+		}
+	}
+}
+
+func TestExtractRetriesWithoutUnlikelyStripping(t *testing.T) {
+	// The only text is in a block whose class looks like a sidebar.
+	doc := mustParse(t, `<body><div class="sidebar-layout"><p>The whole article lives here, inside a container whose class name would normally mark it as page furniture.</p></div></body>`)
+	article, err := content.Extract(doc, content.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(article.Text(), "The whole article lives here") {
+		t.Errorf("content = %q", article.Text())
+	}
+}
+
+func TestExtractHidden(t *testing.T) {
+	doc := mustParse(t, `<head><style>.promo { display: none } @media print { .print-only { display: none } }</style></head><body><article>`+
+		`<p>The article text, which is long enough to count as a paragraph, with a comma.</p>`+
+		`<div class="promo"><p>Subscribe now to read the hidden offer, which is long enough, with a comma.</p></div>`+
+		`<p hidden>A paragraph with the hidden attribute, long enough to count, with a comma.</p>`+
+		`<p style="visibility: hidden">An invisible paragraph, long enough to count as well, with a comma.</p>`+
+		`<p class="print-only">Shown on screen only, a paragraph long enough to count, with a comma.</p>`+
+		`</article></body>`)
+	article, err := content.Extract(doc, content.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := article.Text()
+	for _, unwanted := range []string{"Subscribe", "hidden attribute", "invisible"} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("content contains hidden text %q:\n%s", unwanted, text)
+		}
+	}
+	for _, want := range []string{"The article text", "Shown on screen"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("content is missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestExtractKeepClasses(t *testing.T) {
+	doc := mustParse(t, `<body><div class="post"><p class="lead">A paragraph of article text that is long enough, with a comma.</p></div></body>`)
+	opts := content.DefaultOptions()
+	opts.KeepClasses = true
+	article, err := content.Extract(doc, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := article.Content.QueryFirst("p.lead"); p == nil {
+		t.Errorf("class was removed: %s", serialize.ToHTML(article.Content, serialize.DefaultOptions()))
+	}
+}
+
+func TestExtractNoContent(t *testing.T) {
+	for _, html := range []string{`<body></body>`, `<body><script>only()</script><nav>Home</nav></body>`} {
+		doc := mustParse(t, html)
+		if _, err := content.Extract(doc, content.DefaultOptions()); !errors.Is(err, content.ErrNoContent) {
+			t.Errorf("Extract(%q) error = %v, want ErrNoContent", html, err)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package content
+
+import (
+	"strings"
+	"time"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/metadata"
+)
+
+// titleSeparators split a document title from the site name.
+var titleSeparators = []string{" | ", " - ", " – ", " — ", " :: ", " » ", " / "}
+
+// dateMetaNames are the meta names pages use for the publication date.
+var dateMetaNames = []string{
+	"article:published_time", "date", "pubdate", "publishdate", "publish-date",
+	"dc.date", "dc.date.issued", "dcterms.created", "sailthru.date", "parsely-pub-date",
+}
+
+// dateLayouts are the date formats published understands.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"January 2, 2006",
+	"2 January 2006",
+}
+
+// articleTitle returns the headline of the JSON-LD article, the OpenGraph or
+// Twitter title, or the document title without the site name.
+func articleTitle(doc *dom.Document, md *metadata.Metadata) string {
+	if ld := jsonLDArticle(md); ld != nil {
+		if s := jsonString(ld["headline"]); s != "" {
+			return s
+		}
+	}
+	for _, values := range [][]string{md.OpenGraph["og:title"], md.Twitter["twitter:title"]} {
+		if len(values) > 0 && strings.TrimSpace(values[0]) != "" {
+			return strings.Join(strings.Fields(values[0]), " ")
+		}
+	}
+	return cleanDocumentTitle(doc, md.Title)
+}
+
+// cleanDocumentTitle strips a site name from title: if a single h1 repeats
+// part of the title it is used, otherwise the text before the last
+// separator is used if it has at least three words.
+func cleanDocumentTitle(doc *dom.Document, title string) string {
+	if h1s := doc.GetElementsByTagName("h1"); len(h1s) == 1 {
+		if h := normalizedText(h1s[0]); h != "" && strings.Contains(title, h) {
+			return h
+		}
+	}
+	for _, sep := range titleSeparators {
+		if i := strings.LastIndex(title, sep); i > 0 {
+			if head := title[:i]; len(strings.Fields(head)) >= 3 {
+				return head
+			}
+		}
+	}
+	return title
+}
+
+// byline returns the author named by the JSON-LD article, the author meta
+// tag, or an element marked as the byline.
+func byline(doc *dom.Document, md *metadata.Metadata) string {
+	if ld := jsonLDArticle(md); ld != nil {
+		if s := jsonNames(ld["author"]); s != "" {
+			return s
+		}
+	}
+	if s := metaContent(doc, "author"); s != "" {
+		return s
+	}
+	for _, author := range md.OpenGraph["article:author"] {
+		if !strings.Contains(author, "://") && strings.TrimSpace(author) != "" {
+			return strings.TrimSpace(author)
+		}
+	}
+	body := doc.Body()
+	if body == nil {
+		return ""
+	}
+	var found string
+	dom.WalkElements(body, func(e *dom.Element) bool {
+		if !isByline(e) {
+			return true
+		}
+		text := normalizedText(e)
+		if len(text) > 0 && len(text) < 100 {
+			found = trimByPrefix(text)
+		}
+		return found == ""
+	})
+	return found
+}
+
+func isByline(e *dom.Element) bool {
+	if strings.EqualFold(e.Attr("rel"), "author") {
+		return true
+	}
+	for _, prop := range strings.Fields(e.Attr("itemprop")) {
+		if prop == "author" {
+			return true
+		}
+	}
+	hints := strings.ToLower(e.Attr("class") + " " + e.ID())
+	for _, hint := range []string{"byline", "author", "dateline", "writtenby"} {
+		if strings.Contains(hints, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+func trimByPrefix(s string) string {
+	if len(s) > 3 && strings.EqualFold(s[:3], "by ") {
+		return strings.TrimSpace(s[3:])
+	}
+	return s
+}
+
+// published returns the publication date given by the JSON-LD article, the
+// meta tags, microdata, or a time element marked as the publication date.
+func published(doc *dom.Document, md *metadata.Metadata) time.Time {
+	var candidates []string
+	if ld := jsonLDArticle(md); ld != nil {
+		candidates = append(candidates, jsonString(ld["datePublished"]))
+	}
+	candidates = append(candidates, md.OpenGraph["article:published_time"]...)
+	for _, name := range dateMetaNames {
+		candidates = append(candidates, metaContent(doc, name))
+	}
+	for _, item := range md.Microdata {
+		for _, v := range item.Properties["datePublished"] {
+			if s, ok := v.(string); ok {
+				candidates = append(candidates, s)
+			}
+		}
+	}
+	times, _ := doc.Query("time[datetime]")
+	for _, t := range times {
+		if t.HasAttr("pubdate") {
+			candidates = append(candidates, t.Attr("datetime"))
+		}
+	}
+	for _, c := range candidates {
+		if t, ok := parseDate(c); ok {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// leadImage returns the JSON-LD, OpenGraph or Twitter image, or the first
+// image of the content.
+func leadImage(doc *dom.Document, md *metadata.Metadata, content *dom.Element) string {
+	var candidates []string
+	if ld := jsonLDArticle(md); ld != nil {
+		candidates = append(candidates, jsonString(ld["image"]))
+	}
+	candidates = append(candidates, md.OpenGraph["og:image"]...)
+	candidates = append(candidates, md.OpenGraph["og:image:url"]...)
+	candidates = append(candidates, md.Twitter["twitter:image"]...)
+	for _, c := range candidates {
+		if c = strings.TrimSpace(c); c == "" {
+			continue
+		}
+		if u, ok := doc.ResolveURL(c); ok {
+			return u
+		}
+		return c
+	}
+	for _, img := range content.GetElementsByTagName("img") {
+		if src := img.Attr("src"); src != "" {
+			return src
+		}
+	}
+	return ""
+}
+
+// metaContent returns the content of the first meta element with the given
+// name or property, compared case-insensitively.
+func metaContent(doc *dom.Document, name string) string {
+	metas, _ := doc.Query("meta[content]")
+	for _, meta := range metas {
+		if strings.EqualFold(meta.Attr("name"), name) || strings.EqualFold(meta.Attr("property"), name) {
+			return strings.TrimSpace(meta.Attr("content"))
+		}
+	}
+	return ""
+}
+
+// jsonLDArticle returns the first JSON-LD object whose type is an article
+// or blog posting, searching arrays and @graph lists.
+func jsonLDArticle(md *metadata.Metadata) map[string]any {
+	var find func(v any) map[string]any
+	find = func(v any) map[string]any {
+		switch v := v.(type) {
+		case []any:
+			for _, e := range v {
+				if found := find(e); found != nil {
+					return found
+				}
+			}
+		case map[string]any:
+			if isArticleType(v["@type"]) {
+				return v
+			}
+			return find(v["@graph"])
+		}
+		return nil
+	}
+	return find(md.JSONLD)
+}
+
+func isArticleType(v any) bool {
+	switch v := v.(type) {
+	case string:
+		return strings.HasSuffix(v, "Article") || strings.HasSuffix(v, "Posting") || v == "Report"
+	case []any:
+		for _, e := range v {
+			if isArticleType(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonString returns a JSON-LD value as a string: a string itself, the
+// name, url or @id of an object, or the first entry of an array.
+func jsonString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]any:
+		for _, key := range []string{"name", "url", "@id"} {
+			if s := jsonString(v[key]); s != "" {
+				return s
+			}
+		}
+	case []any:
+		for _, e := range v {
+			if s := jsonString(e); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// jsonNames returns the names of a JSON-LD value that is a person or a list
+// of them, joined with commas.
+func jsonNames(v any) string {
+	list, ok := v.([]any)
+	if !ok {
+		return jsonString(v)
+	}
+	var names []string
+	for _, e := range list {
+		if s := jsonString(e); s != "" {
+			names = append(names, s)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,274 @@
+package content
+
+import (
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// The class and id hints of Readability.
+var (
+	unlikelyCandidates = regexp.MustCompile(`(?i)-ad-|ai2html|banner|breadcrumbs|combx|comment|community|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|related|remark|replies|rss|shoutbox|sidebar|skyscraper|social|sponsor|supplemental|ad-break|agegate|pagination|pager|popup|yom-remote`)
+	maybeCandidate     = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveHints      = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+	negativeHints      = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|footer|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|widget`)
+)
+
+// blockTags are the elements that make a div a container rather than a
+// paragraph.
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hr": true, "li": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "ul": true,
+}
+
+// pass selects the heuristics of one extraction attempt. Each retry turns
+// off one more of them, as Readability's flags do.
+type pass struct {
+	stripUnlikely      bool // remove blocks whose hints mark them as furniture
+	weightClasses      bool // score class and id hints
+	cleanConditionally bool // remove containers that look like clutter
+}
+
+// passes are the extraction attempts, from strictest to most lenient.
+var passes = []pass{
+	{stripUnlikely: true, weightClasses: true, cleanConditionally: true},
+	{weightClasses: true, cleanConditionally: true},
+	{},
+}
+
+// extractor holds the state of one extraction attempt.
+type extractor struct {
+	pass
+	opts    Options
+	resolve func(ref string) (string, bool)
+	scores  map[*dom.Element]float64
+}
+
+// grabArticle scores the paragraphs of doc, picks the best candidate and
+// its related siblings, and returns them moved into a new div and cleaned.
+func (x *extractor) grabArticle(doc *dom.Document) *dom.Element {
+	body := doc.Body()
+	x.scores = map[*dom.Element]float64{}
+	scores := x.scores
+	var candidates []*dom.Element
+	for _, elem := range paragraphs(body) {
+		text := normalizedText(elem)
+		if len(text) < x.opts.MinParagraphLength {
+			continue
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		level := 0
+		for anc := elem.ParentElement(); anc != nil && anc.TagName != "html" && level < 5; anc = anc.ParentElement() {
+			if _, ok := scores[anc]; !ok {
+				scores[anc] = x.initialScore(anc)
+				candidates = append(candidates, anc)
+			}
+			divider := 1.0
+			switch {
+			case level == 1:
+				divider = 2
+			case level > 1:
+				divider = float64(level * 3)
+			}
+			scores[anc] += score / divider
+			level++
+		}
+	}
+
+	var top *dom.Element
+	for _, c := range candidates {
+		scores[c] *= 1 - linkDensity(c)
+		if top == nil || scores[c] > scores[top] {
+			top = c
+		}
+	}
+	if top == nil {
+		top = body
+	}
+
+	var nodes []dom.Node
+	if top == body {
+		nodes = slices.Clone(body.Children())
+	} else {
+		nodes = x.relatedSiblings(top)
+	}
+	for _, n := range nodes {
+		if elem, ok := n.(*dom.Element); ok {
+			x.resolveURLs(elem)
+		}
+	}
+
+	content := dom.NewElement("div")
+	for _, n := range nodes {
+		if parent := n.Parent(); parent != nil {
+			parent.RemoveChild(n)
+		}
+		content.AppendChild(n)
+	}
+	x.clean(content)
+	return content
+}
+
+// paragraphs returns the elements whose text is scored: paragraphs,
+// preformatted blocks, table cells, and divs without block-level children.
+func paragraphs(root *dom.Element) []*dom.Element {
+	var out []*dom.Element
+	var walk func(*dom.Element)
+	walk = func(e *dom.Element) {
+		for _, child := range dom.ChildElements(e) {
+			switch child.TagName {
+			case "p", "pre", "td":
+				out = append(out, child)
+			case "div":
+				if !hasBlockChild(child) {
+					out = append(out, child)
+				}
+			}
+			walk(child)
+		}
+	}
+	walk(root)
+	return out
+}
+
+func hasBlockChild(e *dom.Element) bool {
+	for _, child := range dom.ChildElements(e) {
+		if blockTags[child.TagName] {
+			return true
+		}
+	}
+	return false
+}
+
+// initialScore is the score a candidate starts with, from its tag and its
+// class and id hints.
+func (x *extractor) initialScore(e *dom.Element) float64 {
+	score := float64(x.classWeight(e))
+	switch e.TagName {
+	case "div", "article", "main":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	return score
+}
+
+// classWeight scores the class and id of e: +25 for each that hints at
+// content and -25 for each that hints at clutter.
+func (x *extractor) classWeight(e *dom.Element) int {
+	weight := 0
+	if !x.weightClasses {
+		return weight
+	}
+	for _, hint := range []string{e.Attr("class"), e.ID()} {
+		if hint == "" {
+			continue
+		}
+		if negativeHints.MatchString(hint) {
+			weight -= 25
+		}
+		if positiveHints.MatchString(hint) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// linkDensity returns the share of the text of e that is link text. Links
+// to fragments of the page count for less, as they are often footnotes.
+func linkDensity(e *dom.Element) float64 {
+	total := len(normalizedText(e))
+	if total == 0 {
+		return 0
+	}
+	links := 0.0
+	var walk func(*dom.Element)
+	walk = func(e *dom.Element) {
+		for _, child := range dom.ChildElements(e) {
+			if child.TagName != "a" {
+				walk(child)
+				continue
+			}
+			weight := 1.0
+			if strings.HasPrefix(child.Attr("href"), "#") {
+				weight = 0.3
+			}
+			links += weight * float64(len(normalizedText(child)))
+		}
+	}
+	walk(e)
+	return links / float64(total)
+}
+
+// relatedSiblings returns top and those of its siblings that belong to the
+// article: siblings scoring close to it, and paragraphs of prose.
+func (x *extractor) relatedSiblings(top *dom.Element) []dom.Node {
+	scores := x.scores
+	parent := top.Parent()
+	if parent == nil {
+		return []dom.Node{top}
+	}
+	threshold := math.Max(10, scores[top]*0.2)
+	class := top.Attr("class")
+	var out []dom.Node
+	for _, sibling := range dom.ChildElements(parent) {
+		if sibling == top {
+			out = append(out, sibling)
+			continue
+		}
+		bonus := 0.0
+		if class != "" && sibling.Attr("class") == class {
+			bonus = scores[top] * 0.2
+		}
+		if score, ok := scores[sibling]; ok && score+bonus >= threshold {
+			out = append(out, sibling)
+			continue
+		}
+		if sibling.TagName != "p" {
+			continue
+		}
+		text := normalizedText(sibling)
+		density := linkDensity(sibling)
+		switch {
+		case len(text) > 80 && density < 0.25:
+			out = append(out, sibling)
+		case len(text) > 0 && density == 0 && (strings.HasSuffix(text, ".") || strings.Contains(text, ". ")):
+			out = append(out, sibling)
+		}
+	}
+	return out
+}
+
+// resolveURLs makes the href and src attributes under e absolute, so that
+// they survive e being moved out of its document.
+func (x *extractor) resolveURLs(e *dom.Element) {
+	dom.WalkElements(e, func(elem *dom.Element) bool {
+		for _, name := range []string{"href", "src", "poster"} {
+			if !elem.HasAttr(name) {
+				continue
+			}
+			if u, ok := x.resolve(elem.Attr(name)); ok {
+				elem.SetAttr(name, u)
+			}
+		}
+		if elem.TagName == "img" || elem.TagName == "source" {
+			elem.RemoveAttr("srcset")
+		}
+		return true
+	})
+}
+
+func normalizedText(e *dom.Element) string {
+	return strings.Join(strings.Fields(e.Text()), " ")
+}
@@ -0,0 +1,540 @@
+package css
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/selector"
+)
+
+// Options configures Compute.
+type Options struct {
+	// UserAgent includes the default styles of the rendering section of the
+	// HTML standard, such as display:none for the head and for elements
+	// with a hidden attribute, and display:block for block elements.
+	UserAgent bool
+
+	// Media is the media type that media queries and media attributes are
+	// evaluated against, "screen" or "print". The empty string means
+	// "screen".
+	Media string
+
+	// Width and Height are the viewport size in CSS pixels that media
+	// queries are evaluated against.
+	Width, Height float64
+
+	// Load returns the content of the stylesheet at href, for link
+	// elements with rel=stylesheet and @import rules. href is the URL as
+	// written in the document. When Load is nil or fails, the stylesheet
+	// is skipped.
+	Load func(href string) (string, error)
+}
+
+// DefaultOptions returns the options of a desktop browser: user agent
+// styles, screen media and a 1280x720 viewport.
+func DefaultOptions() Options {
+	return Options{UserAgent: true, Media: "screen", Width: 1280, Height: 720}
+}
+
+func (o *Options) media() string {
+	if o.Media == "" {
+		return "screen"
+	}
+	return strings.ToLower(o.Media)
+}
+
+func (o *Options) viewport(dimension string) float64 {
+	if dimension == "height" {
+		return o.Height
+	}
+	return o.Width
+}
+
+// maxImportDepth limits the nesting of @import rules.
+const maxImportDepth = 16
+
+// Styles holds the cascaded styles of the elements of a document.
+type Styles struct {
+	styles map[*dom.Element]Style
+}
+
+// Style maps property names to the specified values of an element: the
+// value of the declaration that wins the cascade, or for inherited
+// properties the value of the parent. CSS-wide keywords (inherit, initial,
+// unset, revert) and var() references are resolved; properties without a
+// value are absent.
+type Style map[string]string
+
+// Compute runs the cascade over the <style> elements, linked stylesheets
+// and style attributes of doc, and the user agent styles if enabled. The
+// content of template elements is not styled.
+//
+// Selectors the selector package does not support, such as those with
+// pseudo-elements, never match. Cascade layers are treated as unlayered
+// styles and @supports conditions as true; @container rules are ignored.
+func Compute(doc *dom.Document, opts Options) *Styles {
+	c := &cascade{opts: &opts, buckets: map[string][]*compiledRule{}}
+	if opts.UserAgent {
+		c.addSheet(uaStylesheet(), originUserAgent, 0, nil)
+	}
+	dom.WalkElements(doc, func(e *dom.Element) bool {
+		switch {
+		case e.TagName == "style" && (e.Namespace == dom.NamespaceHTML || e.Namespace == dom.NamespaceSVG):
+			if t := strings.TrimSpace(e.Attr("type")); t != "" && !strings.EqualFold(t, "text/css") {
+				return true
+			}
+			if e.HasAttr("media") && !matchMediaText(e.Attr("media"), c.opts) {
+				return true
+			}
+			c.addSheet(ParseStylesheet(e.Text()), originAuthor, 0, nil)
+		case e.TagName == "link" && e.Namespace == dom.NamespaceHTML && isStylesheetLink(e):
+			if e.HasAttr("media") && !matchMediaText(e.Attr("media"), c.opts) {
+				return true
+			}
+			c.load(strings.TrimSpace(e.Attr("href")), 0, nil)
+		}
+		return true
+	})
+
+	s := &Styles{styles: map[*dom.Element]Style{}}
+	var walk func(n dom.Node, parent Style)
+	walk = func(n dom.Node, parent Style) {
+		for _, child := range n.Children() {
+			if e, ok := child.(*dom.Element); ok {
+				style := c.style(e, parent)
+				s.styles[e] = style
+				walk(e, style)
+			}
+		}
+	}
+	walk(doc, nil)
+	return s
+}
+
+// Style returns the style of e, or nil if e was not in the document when
+// the styles were computed. The map must not be modified.
+func (s *Styles) Style(e *dom.Element) Style {
+	return s.styles[e]
+}
+
+// Value returns the specified value of property for e, or the initial
+// value of the property if no declaration applies and it is not
+// inherited. It returns "" for properties without a known initial value.
+func (s *Styles) Value(e *dom.Element, property string) string {
+	if v, ok := s.styles[e][property]; ok {
+		return v
+	}
+	return initialValues[property]
+}
+
+// Displayed reports whether e is rendered, which it is not if e or one of
+// its ancestors has display:none.
+func (s *Styles) Displayed(e *dom.Element) bool {
+	for ; e != nil; e = e.ParentElement() {
+		if strings.EqualFold(s.Value(e, "display"), "none") {
+			return false
+		}
+	}
+	return true
+}
+
+// Cascade origins.
+const (
+	originUserAgent = iota
+	originAuthor
+)
+
+// compiledRule is a style rule with a single selector, ready for matching.
+type compiledRule struct {
+	selector     selector.Selector
+	specificity  selector.Specificity
+	declarations []Declaration
+	origin       int
+	order        int
+}
+
+// cascade holds the rules of the stylesheets of a document, indexed by the
+// ID, class or tag name that the rightmost compound selector requires.
+type cascade struct {
+	opts    *Options
+	buckets map[string][]*compiledRule
+	order   int
+}
+
+// load adds the stylesheet at href, tracking the chain of imports that led
+// to it to avoid cycles.
+func (c *cascade) load(href string, depth int, chain []string) {
+	if c.opts.Load == nil || href == "" || depth > maxImportDepth || slices.Contains(chain, href) {
+		return
+	}
+	src, err := c.opts.Load(href)
+	if err != nil {
+		return
+	}
+	c.addSheet(ParseStylesheet(src), originAuthor, depth, append(chain, href))
+}
+
+func (c *cascade) addSheet(sheet *Stylesheet, origin, depth int, chain []string) {
+	importsAllowed := true
+	for _, rule := range sheet.Rules {
+		switch rule.AtKeyword {
+		case "charset":
+			continue
+		case "import":
+			if importsAllowed {
+				href, media := importPrelude(rule.prelude)
+				if matchMedia(media, c.opts) {
+					c.load(href, depth+1, chain)
+				}
+			}
+			continue
+		case "layer":
+			if rule.Rules == nil {
+				// A layer statement, which may precede imports.
+				continue
+			}
+		}
+		importsAllowed = false
+		c.addRule(rule, origin)
+	}
+}
+
+func (c *cascade) addRule(rule *Rule, origin int) {
+	switch rule.AtKeyword {
+	case "":
+		for _, part := range splitCommas(rule.prelude) {
+			sel, err := selector.Parse(serialize(part))
+			if err != nil {
+				continue
+			}
+			key := ruleKey(part)
+			c.buckets[key] = append(c.buckets[key], &compiledRule{
+				selector:     sel,
+				specificity:  selector.SpecificityOf(sel),
+				declarations: rule.Declarations,
+				origin:       origin,
+				order:        c.order,
+			})
+		}
+		c.order++
+	case "media":
+		if !matchMedia(rule.prelude, c.opts) {
+			return
+		}
+		fallthrough
+	case "supports", "layer":
+		for _, nested := range rule.Rules {
+			c.addRule(nested, origin)
+		}
+	}
+}
+
+// ruleKey returns the bucket of a selector: "#id", ".class" or the tag
+// name its rightmost compound selector requires, or "*".
+func ruleKey(sel []ComponentValue) string {
+	sel = trimWhitespace(sel)
+	start := 0
+	for i, v := range sel {
+		if v.Token.Type == WhitespaceToken || v.Token.Type == DelimToken && strings.Contains(">+~", v.Token.Value) {
+			start = i + 1
+		}
+	}
+	compound := sel[start:]
+	for _, v := range compound {
+		if v.Token.Type == HashToken {
+			return "#" + v.Token.Value
+		}
+	}
+	for i, v := range compound {
+		if v.Token.Type == DelimToken && v.Token.Value == "." && i+1 < len(compound) && compound[i+1].Token.Type == IdentToken {
+			return "." + compound[i+1].Token.Value
+		}
+	}
+	if len(compound) > 0 && compound[0].Token.Type == IdentToken {
+		return strings.ToLower(compound[0].Token.Value)
+	}
+	return "*"
+}
+
+// entry is a declaration that applies to an element.
+type entry struct {
+	Declaration
+	origin      int
+	inline      bool
+	specificity selector.Specificity
+	order       int
+}
+
+// precedence returns the rank of the origin and importance of e: user
+// agent, author, important author, important user agent.
+func (e *entry) precedence() int {
+	switch {
+	case !e.Important:
+		return e.origin
+	case e.origin == originAuthor:
+		return 2
+	}
+	return 3
+}
+
+func compareEntries(a, b *entry) int {
+	inline := func(e *entry) int {
+		if e.inline {
+			return 1
+		}
+		return 0
+	}
+	return cmp.Or(
+		cmp.Compare(a.precedence(), b.precedence()),
+		cmp.Compare(inline(a), inline(b)),
+		a.specificity.Compare(b.specificity),
+		cmp.Compare(a.order, b.order),
+	)
+}
+
+// style computes the style of e from its matching rules, its style
+// attribute and the style of its parent.
+func (c *cascade) style(e *dom.Element, parent Style) Style {
+	var entries []*entry
+	add := func(rules []*compiledRule) {
+		for _, r := range rules {
+			if !r.selector.Match(e) {
+				continue
+			}
+			for _, d := range r.declarations {
+				entries = append(entries, &entry{Declaration: d, origin: r.origin, specificity: r.specificity, order: r.order})
+			}
+		}
+	}
+	if id := e.ID(); id != "" {
+		add(c.buckets["#"+id])
+	}
+	for _, class := range e.Classes() {
+		add(c.buckets["."+class])
+	}
+	add(c.buckets[e.TagName])
+	add(c.buckets["*"])
+	if e.HasAttr("style") {
+		for _, d := range ParseDeclarations(e.Attr("style")) {
+			entries = append(entries, &entry{Declaration: d, origin: originAuthor, inline: true})
+		}
+	}
+	slices.SortStableFunc(entries, compareEntries)
+
+	winners := map[string]*entry{}
+	userAgent := map[string]*entry{}
+	for _, en := range entries {
+		winners[en.Property] = en
+		if en.origin == originUserAgent {
+			userAgent[en.Property] = en
+		}
+	}
+
+	r := &resolver{
+		winners:   winners,
+		userAgent: userAgent,
+		parent:    parent,
+		style:     Style{},
+		resolved:  map[string]bool{},
+		resolving: map[string]bool{},
+	}
+	for p, v := range parent {
+		if isInherited(p) {
+			r.style[p] = v
+		}
+	}
+	for p := range winners {
+		r.resolve(p)
+	}
+	return r.style
+}
+
+// resolver resolves the winning declarations of an element into its style.
+type resolver struct {
+	winners   map[string]*entry
+	userAgent map[string]*entry
+	parent    Style
+	style     Style
+
+	// resolved and resolving track the properties already resolved and
+	// being resolved, as custom properties may refer to each other with
+	// var().
+	resolved  map[string]bool
+	resolving map[string]bool
+}
+
+func (r *resolver) resolve(property string) {
+	if r.resolved[property] {
+		return
+	}
+	if r.resolving[property] {
+		// A var() cycle makes the custom properties involved invalid.
+		r.set(property, "unset", originAuthor)
+		return
+	}
+	r.resolving[property] = true
+	en := r.winners[property]
+	value := en.Value
+	if strings.Contains(strings.ToLower(value), "var(") {
+		var ok bool
+		if value, ok = r.substitute(value); !ok {
+			value = "unset"
+		}
+	}
+	r.set(property, value, en.origin)
+	delete(r.resolving, property)
+	r.resolved[property] = true
+}
+
+// set applies a value, resolving the CSS-wide keywords.
+func (r *resolver) set(property, value string, origin int) {
+	switch strings.ToLower(value) {
+	case "inherit":
+		r.inherit(property)
+	case "initial":
+		delete(r.style, property)
+	case "unset":
+		if isInherited(property) {
+			r.inherit(property)
+		} else {
+			delete(r.style, property)
+		}
+	case "revert", "revert-layer":
+		if ua := r.userAgent[property]; origin == originAuthor && ua != nil {
+			r.set(property, ua.Value, originUserAgent)
+		} else {
+			r.set(property, "unset", origin)
+		}
+	default:
+		r.style[property] = value
+	}
+}
+
+func (r *resolver) inherit(property string) {
+	if v, ok := r.parent[property]; ok {
+		r.style[property] = v
+	} else {
+		delete(r.style, property)
+	}
+}
+
+// substitute replaces the var() references of value, reporting false if a
+// reference has neither a value nor a fallback.
+func (r *resolver) substitute(value string) (string, bool) {
+	var sb strings.Builder
+	ok := r.substituteValues(&sb, ParseComponentValues(value))
+	return strings.TrimSpace(sb.String()), ok
+}
+
+func (r *resolver) substituteValues(sb *strings.Builder, values []ComponentValue) bool {
+	for i, v := range values {
+		if i > 0 && needsSeparator(values[i-1].Token, v.Token) {
+			sb.WriteString("/**/")
+		}
+		if v.IsFunction() && strings.EqualFold(v.Token.Value, "var") {
+			if !r.substituteVar(sb, v.Children) {
+				return false
+			}
+			continue
+		}
+		if !v.IsFunction() && !v.IsBlock() {
+			v.write(sb)
+			continue
+		}
+		sb.WriteString(v.Token.String())
+		if !r.substituteValues(sb, v.Children) {
+			return false
+		}
+		sb.WriteString(closingBracket(v.Token.Type))
+	}
+	return true
+}
+
+// substituteVar writes the value of var(--name) or var(--name, fallback).
+func (r *resolver) substituteVar(sb *strings.Builder, args []ComponentValue) bool {
+	args = trimWhitespace(args)
+	if len(args) == 0 || args[0].Token.Type != IdentToken || !strings.HasPrefix(args[0].Token.Value, "--") {
+		return false
+	}
+	name := args[0].Token.Value
+	if _, ok := r.winners[name]; ok {
+		r.resolve(name)
+	}
+	if v, ok := r.style[name]; ok && v != "" {
+		sb.WriteString(v)
+		return true
+	}
+	rest := trimWhitespace(args[1:])
+	if len(rest) == 0 || rest[0].Token.Type != CommaToken {
+		return false
+	}
+	return r.substituteValues(sb, trimWhitespace(rest[1:]))
+}
+
+// importPrelude returns the URL and the media query list of an @import
+// rule. Layer and supports conditions are ignored.
+func importPrelude(prelude []ComponentValue) (string, []ComponentValue) {
+	values := trimWhitespace(prelude)
+	if len(values) == 0 {
+		return "", nil
+	}
+	var href string
+	switch v := values[0]; {
+	case v.Token.Type == StringToken, v.Token.Type == URLToken:
+		href = v.Token.Value
+	case v.IsFunction() && strings.EqualFold(v.Token.Value, "url"):
+		if args := trimWhitespace(v.Children); len(args) == 1 && args[0].Token.Type == StringToken {
+			href = args[0].Token.Value
+		}
+	}
+	media := trimWhitespace(values[1:])
+	for len(media) > 0 && (isIdent(media[0], "layer") || media[0].IsFunction() &&
+		(strings.EqualFold(media[0].Token.Value, "layer") || strings.EqualFold(media[0].Token.Value, "supports"))) {
+		media = trimWhitespace(media[1:])
+	}
+	return href, media
+}
+
+// isStylesheetLink reports whether a link element is an enabled, non
+// alternate stylesheet link.
+func isStylesheetLink(e *dom.Element) bool {
+	rels := strings.Fields(strings.ToLower(e.Attr("rel")))
+	return slices.Contains(rels, "stylesheet") && !slices.Contains(rels, "alternate") && !e.HasAttr("disabled")
+}
+
+// inheritedProperties are the properties that inherit by default, besides
+// custom properties.
+var inheritedProperties = map[string]bool{
+	"border-collapse": true, "border-spacing": true, "caption-side": true,
+	"caret-color": true, "color": true, "color-scheme": true, "cursor": true,
+	"direction": true, "empty-cells": true, "font": true, "font-family": true,
+	"font-feature-settings": true, "font-kerning": true, "font-size": true,
+	"font-stretch": true, "font-style": true, "font-variant": true,
+	"font-weight": true, "hyphens": true, "letter-spacing": true,
+	"line-height": true, "list-style": true, "list-style-image": true,
+	"list-style-position": true, "list-style-type": true, "orphans": true,
+	"overflow-wrap": true, "pointer-events": true, "quotes": true,
+	"tab-size": true, "text-align": true, "text-align-last": true,
+	"text-indent": true, "text-shadow": true, "text-transform": true,
+	"visibility": true, "white-space": true, "widows": true,
+	"word-break": true, "word-spacing": true, "word-wrap": true,
+	"writing-mode": true,
+}
+
+func isInherited(property string) bool {
+	return inheritedProperties[property] || strings.HasPrefix(property, "--")
+}
+
+// initialValues are the initial values of common properties.
+var initialValues = map[string]string{
+	"color": "canvastext", "content-visibility": "visible", "direction": "ltr",
+	"display": "inline", "float": "none", "font-size": "medium",
+	"font-style": "normal", "font-weight": "normal", "height": "auto",
+	"letter-spacing": "normal", "line-height": "normal",
+	"list-style-type": "disc", "opacity": "1", "overflow": "visible",
+	"position": "static", "text-align": "start", "text-transform": "none",
+	"visibility": "visible", "white-space": "normal", "width": "auto",
+	"word-spacing": "normal",
+}
@@ -0,0 +1,282 @@
+package css_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/css"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+func mustParse(t *testing.T, html string) *dom.Document {
+	t.Helper()
+	doc, err := JustGoHTML.Parse("<!DOCTYPE html>" + html)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", html, err)
+	}
+	return doc
+}
+
+// element returns the element with the given id.
+func element(t *testing.T, doc *dom.Document, id string) *dom.Element {
+	t.Helper()
+	e := doc.GetElementByID(id)
+	if e == nil {
+		t.Fatalf("no element with id %s", id)
+	}
+	return e
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`a.b > c`, `Ident(a) Delim(.) Ident(b) Whitespace Delim(>) Whitespace Ident(c)`},
+		{`#main{color:red}`, `Hash(main) OpenCurly Ident(color) Colon Ident(red) CloseCurly`},
+		{`1.5em -2% +3 4e2`, `Dimension(1.5em) Whitespace Percentage(-2%) Whitespace Number(+3) Whitespace Number(4e2)`},
+		{`url( a.png ) url("b.png")`, `URL(a.png) Whitespace Function(url) String(b.png) CloseParen`},
+		{`url(a b)`, `BadURL`},
+		{`"a\"b" 'c` + "\n", `String(a"b) Whitespace BadString(c) Whitespace`},
+		{`/* comment */@media--x`, `AtKeyword(media--x)`},
+		{`\31 0px <!-- -->`, `Ident(10px) Whitespace CDO Whitespace CDC`},
+		{`calc(1px+2px)`, `Function(calc) Dimension(1px) Dimension(+2px) CloseParen`},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, tok := range css.Tokenize(tt.src) {
+			switch tok.Type {
+			case css.IdentToken, css.FunctionToken, css.AtKeywordToken, css.HashToken, css.StringToken,
+				css.BadStringToken, css.URLToken, css.DelimToken, css.NumberToken:
+				got = append(got, fmt.Sprintf("%s(%s)", tok.Type, tok.Value))
+			case css.PercentageToken:
+				got = append(got, fmt.Sprintf("%s(%s%%)", tok.Type, tok.Value))
+			case css.DimensionToken:
+				got = append(got, fmt.Sprintf("%s(%s%s)", tok.Type, tok.Value, tok.Unit))
+			default:
+				got = append(got, tok.Type.String())
+			}
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("Tokenize(%q) = %s, want %s", tt.src, s, tt.want)
+		}
+	}
+}
+
+func TestParseStylesheet(t *testing.T) {
+	sheet := css.ParseStylesheet(`
+		@charset "utf-8";
+		<!-- p , .a>b { color : red ; margin: 0 auto !IMPORTANT; bogus; : x; width: ) }
+		@media screen and (min-width: 600px) { nav { display: none } }
+		@font-face { font-family: "X"; src: url(x.woff) }
+		h1 { --Accent: /* c */ blue; font: 12px/1.5 a/**/b }
+		broken {`)
+	var got []string
+	var dump func(rules []*css.Rule, indent string)
+	dump = func(rules []*css.Rule, indent string) {
+		for _, r := range rules {
+			line := indent + r.Prelude
+			if r.AtKeyword != "" {
+				line = indent + "@" + r.AtKeyword + " " + r.Prelude
+			}
+			for _, d := range r.Declarations {
+				line += " {" + d.String() + "}"
+			}
+			got = append(got, line)
+			dump(r.Rules, indent+"  ")
+		}
+	}
+	dump(sheet.Rules, "")
+	want := []string{
+		`@charset "utf-8"`,
+		`p , .a>b {color: red} {margin: 0 auto !important}`,
+		`@media screen and (min-width: 600px)`,
+		`  nav {display: none}`,
+		`@font-face  {font-family: "X"} {src: url("x.woff")}`,
+		`h1 {--Accent: blue} {font: 12px/1.5 a/**/b}`,
+		`broken`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("rules =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestParseDeclarations(t *testing.T) {
+	decls := css.ParseDeclarations(`display:NONE;; Color: Red !important; @x y; background: url(a.png) no-repeat`)
+	var got []string
+	for _, d := range decls {
+		got = append(got, d.String())
+	}
+	want := []string{"display: NONE", "color: Red !important", `background: url("a.png") no-repeat`}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseDeclarations() = %q, want %q", got, want)
+	}
+}
+
+func TestCascade(t *testing.T) {
+	doc := mustParse(t, `<style>
+		p { color: blue; margin: 1px }
+		.note { color: green }
+		#first { color: purple }
+		div p { margin: 2px }
+		p { margin: 3px !important }
+		.warn { color: orange !important }
+	</style>
+	<div id=d style="color: red; font-size: 20px">
+		<p id=first class=note>a</p>
+		<p id=second class="note warn" style="color: black">b</p>
+		<p id=third style="color: black !important" class=warn>c</p>
+		<span id=s>d</span>
+	</div>`)
+	styles := css.Compute(doc, css.DefaultOptions())
+	tests := []struct {
+		id, property, want string
+	}{
+		{"first", "color", "purple"},       // ID beats class and type
+		{"second", "color", "orange"},      // important beats the style attribute
+		{"third", "color", "black"},        // important style attribute beats important rules
+		{"first", "margin", "3px"},         // important beats specificity
+		{"s", "color", "red"},              // inherited from the div
+		{"first", "font-size", "20px"},     // inherited
+		{"s", "margin", ""},                // not inherited, no initial value known
+		{"d", "display", "block"},          // user agent style
+		{"s", "display", "inline"},         // initial value
+		{"first", "visibility", "visible"}, // initial value
+	}
+	for _, tt := range tests {
+		if got := styles.Value(element(t, doc, tt.id), tt.property); got != tt.want {
+			t.Errorf("Value(#%s, %s) = %q, want %q", tt.id, tt.property, got, tt.want)
+		}
+	}
+}
+
+func TestKeywordsAndVariables(t *testing.T) {
+	doc := mustParse(t, `<style>
+		:root { --gap: 4px; --accent: teal; --loop: var(--loop) }
+		div { color: red; border: 1px solid var(--accent) }
+		#inherit { margin: 5px }
+		#inherit p { margin: inherit; color: initial }
+		#unset p { color: unset; display: unset }
+		.inline { display: inline }
+		#revert { display: revert }
+		#vars { padding: calc(var(--gap) * 2) var(--missing, 1px); --accent: navy }
+		#vars span { border-color: var(--accent); width: var(--missing); height: var(--loop, 3px) }
+	</style>
+	<div id=inherit><p id=p1>x</p></div>
+	<div id=unset><p id=p2>x</p></div>
+	<div id=revert class=inline>x</div>
+	<div id=vars><span id=v1>x</span></div>`)
+	styles := css.Compute(doc, css.DefaultOptions())
+	tests := []struct {
+		id, property, want string
+	}{
+		{"p1", "margin", "5px"},
+		{"p1", "color", "canvastext"},
+		{"p2", "color", "red"},
+		{"p2", "display", "inline"},
+		{"revert", "display", "block"},
+		{"inherit", "border", "1px solid teal"},
+		{"vars", "padding", "calc(4px * 2) 1px"},
+		{"v1", "border-color", "navy"},
+		{"v1", "width", "auto"},
+		{"v1", "height", "3px"},
+	}
+	for _, tt := range tests {
+		if got := styles.Value(element(t, doc, tt.id), tt.property); got != tt.want {
+			t.Errorf("Value(#%s, %s) = %q, want %q", tt.id, tt.property, got, tt.want)
+		}
+	}
+}
+
+func TestDisplayed(t *testing.T) {
+	doc := mustParse(t, `<style>
+		.ad { display: none }
+		@media print { #screen-only { display: none } }
+		@media (max-width: 600px) { #wide-only { display: none } }
+		@media screen and (min-width: 1000px) { #narrow-only { display: none } }
+		@media (400px <= width <= 2000px) { #ranged { display: none } }
+		[hidden] { color: red }
+		#unhidden { display: block }
+	</style>
+	<style media=print>#p { display: none }</style>
+	<div class=ad><p id=in-ad>x</p></div>
+	<p id=visible>x</p>
+	<p id=screen-only>x</p>
+	<p id=wide-only>x</p>
+	<p id=narrow-only>x</p>
+	<p id=ranged>x</p>
+	<p id=p>x</p>
+	<p id=h hidden>x</p>
+	<p id=unhidden hidden>x</p>
+	<details><summary id=summary>s</summary><p id=closed>x</p></details>
+	<p><input id=hidden-input type=hidden style="display: block"></p>`)
+	styles := css.Compute(doc, css.DefaultOptions())
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"in-ad", false},
+		{"visible", true},
+		{"screen-only", true},
+		{"wide-only", true},
+		{"narrow-only", false},
+		{"ranged", false},
+		{"p", true},
+		{"h", false},
+		{"unhidden", true},
+		{"summary", true},
+		{"closed", false},
+		{"hidden-input", false},
+	}
+	for _, tt := range tests {
+		if got := styles.Displayed(element(t, doc, tt.id)); got != tt.want {
+			t.Errorf("Displayed(#%s) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+	if head := doc.Head(); styles.Displayed(head) {
+		t.Error("head should not be displayed")
+	}
+
+	opts := css.DefaultOptions()
+	opts.Media = "print"
+	opts.UserAgent = false
+	styles = css.Compute(doc, opts)
+	if styles.Displayed(element(t, doc, "screen-only")) || styles.Displayed(element(t, doc, "p")) {
+		t.Error("print styles should hide #screen-only and #p")
+	}
+	if !styles.Displayed(element(t, doc, "h")) {
+		t.Error("without user agent styles, hidden elements are displayed")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	sheets := map[string]string{
+		"main.css":  `@import "base.css"; @import url(print.css) print; @import "main.css"; .b { display: none }`,
+		"base.css":  `.a { display: none } .b { display: block }`,
+		"print.css": `.c { display: none }`,
+	}
+	var loaded []string
+	opts := css.DefaultOptions()
+	opts.Load = func(href string) (string, error) {
+		loaded = append(loaded, href)
+		if src, ok := sheets[href]; ok {
+			return src, nil
+		}
+		return "", errors.New("not found")
+	}
+	doc := mustParse(t, `<link rel=stylesheet href=main.css><link rel="alternate stylesheet" href=alt.css>`+
+		`<link rel=stylesheet href=missing.css><p id=a class=a>a<p id=b class=b>b<p id=c class=c>c`)
+	styles := css.Compute(doc, opts)
+	for id, want := range map[string]bool{"a": false, "b": false, "c": true} {
+		if got := styles.Displayed(element(t, doc, id)); got != want {
+			t.Errorf("Displayed(#%s) = %v, want %v", id, got, want)
+		}
+	}
+	if want := []string{"main.css", "base.css", "missing.css"}; !slices.Equal(loaded, want) {
+		t.Errorf("loaded %q, want %q", loaded, want)
+	}
+}
@@ -0,0 +1,289 @@
+package css
+
+import (
+	"strings"
+)
+
+// matchMedia reports whether a media query list matches the environment of
+// opts. An empty list matches; a list matches if any of its queries does.
+// Media features that depend on more than the media type, the viewport
+// size and the user preferences of a default browser do not match.
+func matchMedia(values []ComponentValue, opts *Options) bool {
+	values = trimWhitespace(values)
+	if len(values) == 0 {
+		return true
+	}
+	for _, query := range splitCommas(values) {
+		if matchQuery(query, opts) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMediaText is matchMedia for the text of a media attribute.
+func matchMediaText(text string, opts *Options) bool {
+	return matchMedia(ParseComponentValues(text), opts)
+}
+
+func matchQuery(values []ComponentValue, opts *Options) bool {
+	words := nonWhitespace(values)
+	if len(words) == 0 {
+		return false
+	}
+	negate := false
+	if isIdent(words[0], "not") && len(words) > 1 && words[1].Token.Type == IdentToken {
+		negate = true
+		words = words[1:]
+	} else if isIdent(words[0], "only") {
+		words = words[1:]
+	}
+	matched := true
+	if len(words) > 0 && words[0].Token.Type == IdentToken && !isIdent(words[0], "not") {
+		matched = matchMediaType(words[0].Token.Value, opts)
+		words = words[1:]
+		if len(words) > 0 {
+			if !isIdent(words[0], "and") {
+				return false
+			}
+			words = words[1:]
+		}
+	}
+	if len(words) > 0 {
+		matched = matched && matchCondition(words, opts)
+	}
+	return matched != negate
+}
+
+func matchMediaType(name string, opts *Options) bool {
+	name = strings.ToLower(name)
+	return name == "all" || name == opts.media()
+}
+
+// matchCondition evaluates a media condition: a "not" condition, or media
+// features in parentheses joined by "and" or "or".
+func matchCondition(words []ComponentValue, opts *Options) bool {
+	if len(words) == 0 {
+		return false
+	}
+	if isIdent(words[0], "not") {
+		return len(words) == 2 && !matchInParens(words[1], opts)
+	}
+	result := matchInParens(words[0], opts)
+	for i := 1; i+1 < len(words); i += 2 {
+		next := matchInParens(words[i+1], opts)
+		switch {
+		case isIdent(words[i], "and"):
+			result = result && next
+		case isIdent(words[i], "or"):
+			result = result || next
+		default:
+			return false
+		}
+	}
+	return len(words)%2 == 1 && result
+}
+
+// matchInParens evaluates a media feature or a nested condition in
+// parentheses.
+func matchInParens(v ComponentValue, opts *Options) bool {
+	if v.Token.Type != OpenParenToken {
+		return false
+	}
+	words := nonWhitespace(v.Children)
+	switch {
+	case len(words) == 0:
+		return false
+	case words[0].Token.Type == OpenParenToken || isIdent(words[0], "not"):
+		return matchCondition(words, opts)
+	case len(words) == 1 && words[0].Token.Type == IdentToken:
+		return matchBooleanFeature(strings.ToLower(words[0].Token.Value), opts)
+	case len(words) >= 3 && words[0].Token.Type == IdentToken && words[1].Token.Type == ColonToken:
+		return matchFeature(strings.ToLower(words[0].Token.Value), words[2:], opts)
+	}
+	return matchRange(words, opts)
+}
+
+// matchBooleanFeature evaluates a media feature in a boolean context, such
+// as (hover).
+func matchBooleanFeature(name string, opts *Options) bool {
+	switch name {
+	case "width", "height":
+		return opts.viewport(name) > 0
+	case "color", "hover", "any-hover", "pointer", "any-pointer":
+		return true
+	}
+	return false
+}
+
+// matchFeature evaluates a media feature with a value, such as
+// (min-width: 600px).
+func matchFeature(name string, value []ComponentValue, opts *Options) bool {
+	prefix := ""
+	if strings.HasPrefix(name, "min-") || strings.HasPrefix(name, "max-") {
+		prefix, name = name[:3], name[4:]
+	}
+	switch name {
+	case "width", "height":
+		length, ok := lengthValue(value)
+		if !ok {
+			return false
+		}
+		size := opts.viewport(name)
+		switch prefix {
+		case "min":
+			return size >= length
+		case "max":
+			return size <= length
+		}
+		return size == length
+	}
+	if prefix != "" || len(value) != 1 || value[0].Token.Type != IdentToken {
+		return false
+	}
+	keyword := strings.ToLower(value[0].Token.Value)
+	switch name {
+	case "orientation":
+		if opts.viewport("height") >= opts.viewport("width") {
+			return keyword == "portrait"
+		}
+		return keyword == "landscape"
+	case "hover", "any-hover":
+		return keyword == "hover"
+	case "pointer", "any-pointer":
+		return keyword == "fine"
+	case "prefers-color-scheme":
+		return keyword == "light"
+	case "prefers-reduced-motion", "prefers-reduced-transparency", "prefers-contrast":
+		return keyword == "no-preference"
+	}
+	return false
+}
+
+// matchRange evaluates a media feature in range syntax, such as
+// (width >= 600px) or (400px < width < 800px).
+func matchRange(words []ComponentValue, opts *Options) bool {
+	// Split into operands and comparison operators.
+	var operands [][]ComponentValue
+	var ops []string
+	current := []ComponentValue{}
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if w.Token.Type == DelimToken && strings.Contains("<>=", w.Token.Value) {
+			op := w.Token.Value
+			if i+1 < len(words) && words[i+1].Token.Type == DelimToken && words[i+1].Token.Value == "=" && op != "=" {
+				op += "="
+				i++
+			}
+			operands = append(operands, current)
+			ops = append(ops, op)
+			current = []ComponentValue{}
+			continue
+		}
+		current = append(current, w)
+	}
+	operands = append(operands, current)
+	if len(ops) == 0 || len(ops) > 2 {
+		return false
+	}
+	// Find the feature among the operands.
+	feature := -1
+	for i, operand := range operands {
+		if len(operand) == 1 && operand[0].Token.Type == IdentToken {
+			feature = i
+		}
+	}
+	if feature < 0 {
+		return false
+	}
+	name := strings.ToLower(operands[feature][0].Token.Value)
+	if name != "width" && name != "height" {
+		return false
+	}
+	size := opts.viewport(name)
+	for i, op := range ops {
+		var left, right float64
+		var ok bool
+		switch {
+		case i == feature:
+			left = size
+			right, ok = lengthValue(operands[i+1])
+		case i+1 == feature:
+			left, ok = lengthValue(operands[i])
+			right = size
+		default:
+			return false
+		}
+		if !ok || !compare(left, op, right) {
+			return false
+		}
+	}
+	return true
+}
+
+func compare(left float64, op string, right float64) bool {
+	switch op {
+	case "<":
+		return left < right
+	case "<=":
+		return left <= right
+	case ">":
+		return left > right
+	case ">=":
+		return left >= right
+	case "=":
+		return left == right
+	}
+	return false
+}
+
+// lengthValue returns a length in CSS pixels. Font-relative lengths use the
+// default font size of 16px.
+func lengthValue(values []ComponentValue) (float64, bool) {
+	if len(values) != 1 {
+		return 0, false
+	}
+	tok := values[0].Token
+	switch tok.Type {
+	case NumberToken:
+		return 0, tok.Number == 0
+	case DimensionToken:
+		unit, ok := units[strings.ToLower(tok.Unit)]
+		return tok.Number * unit, ok
+	}
+	return 0, false
+}
+
+// units are the absolute and font-relative length units in CSS pixels.
+var units = map[string]float64{
+	"px": 1, "em": 16, "rem": 16, "ex": 8, "ch": 8, "in": 96, "cm": 96 / 2.54,
+	"mm": 96 / 25.4, "q": 96 / 101.6, "pt": 96.0 / 72, "pc": 16,
+}
+
+// splitCommas splits values at top-level commas.
+func splitCommas(values []ComponentValue) [][]ComponentValue {
+	var parts [][]ComponentValue
+	start := 0
+	for i, v := range values {
+		if v.Token.Type == CommaToken {
+			parts = append(parts, values[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, values[start:])
+}
+
+// nonWhitespace returns values without whitespace tokens.
+func nonWhitespace(values []ComponentValue) []ComponentValue {
+	var out []ComponentValue
+	for _, v := range values {
+		if v.Token.Type != WhitespaceToken {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func isIdent(v ComponentValue, name string) bool {
+	return v.Token.Type == IdentToken && strings.EqualFold(v.Token.Value, name)
+}
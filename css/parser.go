@@ -0,0 +1,339 @@
+// Package css parses CSS stylesheets and computes the cascaded styles of
+// the elements of a document.
+//
+// The tokenizer and parser follow CSS Syntax Level 3: Tokenize splits
+// source into tokens, ParseStylesheet parses a stylesheet into style
+// rules and at-rules, and ParseDeclarations parses the content of a style
+// attribute. Compute runs the cascade over a document's <style> elements,
+// linked stylesheets and style attributes, matching selectors with the
+// selector package, and answers questions such as whether an element is
+// displayed.
+package css
+
+import (
+	"strings"
+)
+
+// Stylesheet is a parsed stylesheet.
+type Stylesheet struct {
+	Rules []*Rule
+}
+
+// Rule is a style rule or an at-rule.
+type Rule struct {
+	// AtKeyword is the lowercase name of an at-rule without the "@", such
+	// as "media", or "" for a style rule.
+	AtKeyword string
+
+	// Prelude is the selector list of a style rule, or the prelude of an
+	// at-rule such as the media query list of @media.
+	Prelude string
+
+	// Declarations are the declarations of a style rule, or of an at-rule
+	// with a declaration block such as @font-face.
+	Declarations []Declaration
+
+	// Rules are the rules of a conditional group or layer at-rule, such as
+	// @media or @supports.
+	Rules []*Rule
+
+	// prelude holds the component values of Prelude, which cascading
+	// evaluates for media queries and imports.
+	prelude []ComponentValue
+}
+
+// Declaration is a property declaration.
+type Declaration struct {
+	// Property is the property name, lowercase unless it is a custom
+	// property such as "--main-color".
+	Property string
+
+	// Value is the value without "!important", with comments removed and
+	// whitespace collapsed.
+	Value string
+
+	// Important reports whether the declaration is !important.
+	Important bool
+}
+
+// String serializes the declaration as CSS.
+func (d Declaration) String() string {
+	if d.Important {
+		return d.Property + ": " + d.Value + " !important"
+	}
+	return d.Property + ": " + d.Value
+}
+
+// ComponentValue is a preserved token, a function or a simple block.
+type ComponentValue struct {
+	// Token is the preserved token, the function token of a function, or
+	// the opening bracket of a block.
+	Token Token
+
+	// Children are the arguments of a function or the content of a block.
+	Children []ComponentValue
+}
+
+// IsFunction reports whether v is a function.
+func (v ComponentValue) IsFunction() bool {
+	return v.Token.Type == FunctionToken
+}
+
+// IsBlock reports whether v is a simple block.
+func (v ComponentValue) IsBlock() bool {
+	switch v.Token.Type {
+	case OpenCurlyToken, OpenSquareToken, OpenParenToken:
+		return true
+	}
+	return false
+}
+
+// String serializes the component value as CSS.
+func (v ComponentValue) String() string {
+	var sb strings.Builder
+	v.write(&sb)
+	return sb.String()
+}
+
+func (v ComponentValue) write(sb *strings.Builder) {
+	sb.WriteString(v.Token.String())
+	if !v.IsFunction() && !v.IsBlock() {
+		return
+	}
+	writeValues(sb, v.Children)
+	sb.WriteString(closingBracket(v.Token.Type))
+}
+
+// closingBracket returns the bracket that ends a function or a block opened
+// by a token of type t.
+func closingBracket(t TokenType) string {
+	switch t {
+	case OpenCurlyToken:
+		return "}"
+	case OpenSquareToken:
+		return "]"
+	}
+	return ")"
+}
+
+// serialize returns the CSS text of values, without leading and trailing
+// whitespace.
+func serialize(values []ComponentValue) string {
+	values = trimWhitespace(values)
+	var sb strings.Builder
+	writeValues(&sb, values)
+	return sb.String()
+}
+
+func writeValues(sb *strings.Builder, values []ComponentValue) {
+	for i, v := range values {
+		if i > 0 && needsSeparator(values[i-1].Token, v.Token) {
+			sb.WriteString("/**/")
+		}
+		v.write(sb)
+	}
+}
+
+// needsSeparator reports whether two adjacent tokens would tokenize
+// differently if serialized without a comment between them, such as two
+// idents that were separated by a comment in the source.
+func needsSeparator(a, b Token) bool {
+	switch a.Type {
+	case IdentToken, AtKeywordToken, HashToken, DimensionToken, NumberToken:
+	default:
+		return false
+	}
+	switch b.Type {
+	case IdentToken, FunctionToken, URLToken, BadURLToken, NumberToken, PercentageToken, DimensionToken, CDCToken:
+		return true
+	case DelimToken:
+		return b.Value == "-" || a.Type == NumberToken && b.Value == "."
+	}
+	return false
+}
+
+// ParseStylesheet parses CSS source as a stylesheet. Invalid rules and
+// declarations are dropped, as browsers do.
+func ParseStylesheet(src string) *Stylesheet {
+	return &Stylesheet{Rules: parseRules(ParseComponentValues(src), true)}
+}
+
+// ParseDeclarations parses a list of declarations, such as the value of a
+// style attribute.
+func ParseDeclarations(src string) []Declaration {
+	return parseDeclarations(ParseComponentValues(src))
+}
+
+// ParseComponentValues parses CSS source into a list of component values.
+func ParseComponentValues(src string) []ComponentValue {
+	p := &parser{tokens: Tokenize(src)}
+	var values []ComponentValue
+	for p.pos < len(p.tokens) {
+		values = append(values, p.consumeComponentValue())
+	}
+	return values
+}
+
+type parser struct {
+	tokens []Token
+	pos    int
+}
+
+func (p *parser) consumeComponentValue() ComponentValue {
+	tok := p.tokens[p.pos]
+	p.pos++
+	var end TokenType
+	switch tok.Type {
+	case OpenCurlyToken:
+		end = CloseCurlyToken
+	case OpenSquareToken:
+		end = CloseSquareToken
+	case OpenParenToken, FunctionToken:
+		end = CloseParenToken
+	default:
+		return ComponentValue{Token: tok}
+	}
+	v := ComponentValue{Token: tok}
+	for p.pos < len(p.tokens) {
+		if p.tokens[p.pos].Type == end {
+			p.pos++
+			break
+		}
+		v.Children = append(v.Children, p.consumeComponentValue())
+	}
+	return v
+}
+
+// groupRules are the at-rules whose block holds rules rather than
+// declarations.
+var groupRules = map[string]bool{
+	"container": true, "document": true, "-moz-document": true, "layer": true,
+	"media": true, "scope": true, "starting-style": true, "supports": true,
+}
+
+// parseRules parses a list of rules. At the top level of a stylesheet,
+// CDO and CDC tokens are ignored.
+func parseRules(values []ComponentValue, topLevel bool) []*Rule {
+	var rules []*Rule
+	for i := 0; i < len(values); i++ {
+		v := values[i]
+		switch v.Token.Type {
+		case WhitespaceToken:
+			continue
+		case CDOToken, CDCToken:
+			if topLevel {
+				continue
+			}
+		case AtKeywordToken:
+			rule := &Rule{AtKeyword: strings.ToLower(v.Token.Value)}
+			for i++; i < len(values); i++ {
+				w := values[i]
+				if w.Token.Type == SemicolonToken {
+					break
+				}
+				if w.Token.Type == OpenCurlyToken {
+					if groupRules[rule.AtKeyword] {
+						rule.Rules = parseRules(w.Children, false)
+					} else {
+						rule.Declarations = parseDeclarations(w.Children)
+					}
+					break
+				}
+				rule.prelude = append(rule.prelude, w)
+			}
+			rule.prelude = trimWhitespace(rule.prelude)
+			rule.Prelude = serialize(rule.prelude)
+			rules = append(rules, rule)
+			continue
+		}
+		// A qualified rule: the prelude runs up to a {} block. Without a
+		// block, the rest of the input is dropped.
+		start := i
+		for ; i < len(values) && values[i].Token.Type != OpenCurlyToken; i++ {
+		}
+		if i == len(values) {
+			break
+		}
+		rule := &Rule{prelude: trimWhitespace(values[start:i])}
+		rule.Prelude = serialize(rule.prelude)
+		rule.Declarations = parseDeclarations(values[i].Children)
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// parseDeclarations parses a list of declarations. Nested at-rules and
+// invalid declarations are dropped.
+func parseDeclarations(values []ComponentValue) []Declaration {
+	var decls []Declaration
+	for i := 0; i < len(values); {
+		switch values[i].Token.Type {
+		case WhitespaceToken, SemicolonToken:
+			i++
+		case AtKeywordToken:
+			// A nested at-rule ends with a semicolon or its block.
+			for i < len(values) && values[i].Token.Type != SemicolonToken && values[i].Token.Type != OpenCurlyToken {
+				i++
+			}
+			i++
+		default:
+			start := i
+			for i < len(values) && values[i].Token.Type != SemicolonToken {
+				i++
+			}
+			if d, ok := parseDeclaration(values[start:i]); ok {
+				decls = append(decls, d)
+			}
+		}
+	}
+	return decls
+}
+
+// parseDeclaration parses a single declaration, reporting false if values
+// are not a valid declaration.
+func parseDeclaration(values []ComponentValue) (Declaration, bool) {
+	values = trimWhitespace(values)
+	if len(values) < 2 || values[0].Token.Type != IdentToken {
+		return Declaration{}, false
+	}
+	d := Declaration{Property: values[0].Token.Value}
+	if !strings.HasPrefix(d.Property, "--") {
+		d.Property = strings.ToLower(d.Property)
+	}
+	rest := trimWhitespace(values[1:])
+	if len(rest) == 0 || rest[0].Token.Type != ColonToken {
+		return Declaration{}, false
+	}
+	value := trimWhitespace(rest[1:])
+	if n := len(value); n >= 2 && value[n-1].Token.Type == IdentToken &&
+		strings.EqualFold(value[n-1].Token.Value, "important") {
+		bang := trimWhitespace(value[:n-1])
+		if m := len(bang); m > 0 && bang[m-1].Token.Type == DelimToken && bang[m-1].Token.Value == "!" {
+			d.Important = true
+			value = bang[:m-1]
+		}
+	}
+	if len(trimWhitespace(value)) == 0 && !strings.HasPrefix(d.Property, "--") {
+		return Declaration{}, false
+	}
+	for _, v := range value {
+		switch v.Token.Type {
+		case BadStringToken, BadURLToken, CloseParenToken, CloseSquareToken, CloseCurlyToken:
+			// These never appear in a valid value.
+			return Declaration{}, false
+		}
+	}
+	d.Value = serialize(value)
+	return d, true
+}
+
+// trimWhitespace returns values without leading and trailing whitespace.
+func trimWhitespace(values []ComponentValue) []ComponentValue {
+	for len(values) > 0 && values[0].Token.Type == WhitespaceToken {
+		values = values[1:]
+	}
+	for len(values) > 0 && values[len(values)-1].Token.Type == WhitespaceToken {
+		values = values[:len(values)-1]
+	}
+	return values
+}
@@ -0,0 +1,592 @@
+package css
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// TokenType identifies the kind of a CSS token.
+type TokenType int
+
+// Token types of CSS Syntax Level 3.
+const (
+	EOFToken TokenType = iota
+	IdentToken
+	FunctionToken
+	AtKeywordToken
+	HashToken
+	StringToken
+	BadStringToken
+	URLToken
+	BadURLToken
+	DelimToken
+	NumberToken
+	PercentageToken
+	DimensionToken
+	WhitespaceToken
+	CDOToken
+	CDCToken
+	ColonToken
+	SemicolonToken
+	CommaToken
+	OpenSquareToken
+	CloseSquareToken
+	OpenParenToken
+	CloseParenToken
+	OpenCurlyToken
+	CloseCurlyToken
+)
+
+var tokenTypeNames = [...]string{
+	"EOF", "Ident", "Function", "AtKeyword", "Hash", "String", "BadString",
+	"URL", "BadURL", "Delim", "Number", "Percentage", "Dimension",
+	"Whitespace", "CDO", "CDC", "Colon", "Semicolon", "Comma", "OpenSquare",
+	"CloseSquare", "OpenParen", "CloseParen", "OpenCurly", "CloseCurly",
+}
+
+// String returns the name of the token type.
+func (t TokenType) String() string {
+	if int(t) < len(tokenTypeNames) {
+		return tokenTypeNames[t]
+	}
+	return "Unknown"
+}
+
+// Token is a CSS token.
+type Token struct {
+	Type TokenType
+
+	// Value is the name of an ident, function, at-keyword or hash token,
+	// the value of a string or URL token, the character of a delim token,
+	// and the number as written (without unit or "%") of numeric tokens.
+	Value string
+
+	// Number is the value of a number, percentage or dimension token.
+	Number float64
+
+	// Integer reports whether a numeric token has the integer type flag.
+	Integer bool
+
+	// Unit is the unit of a dimension token, such as "px".
+	Unit string
+
+	// ID reports whether a hash token has the id type flag, that is,
+	// whether its name would be a valid identifier.
+	ID bool
+}
+
+// String serializes the token as CSS.
+func (t Token) String() string {
+	switch t.Type {
+	case IdentToken:
+		return escapeIdent(t.Value)
+	case FunctionToken:
+		return escapeIdent(t.Value) + "("
+	case AtKeywordToken:
+		return "@" + escapeIdent(t.Value)
+	case HashToken:
+		return "#" + escapeName(t.Value)
+	case StringToken:
+		return quoteString(t.Value)
+	case URLToken:
+		return "url(" + quoteString(t.Value) + ")"
+	case DelimToken:
+		return t.Value
+	case NumberToken:
+		return t.Value
+	case PercentageToken:
+		return t.Value + "%"
+	case DimensionToken:
+		return t.Value + escapeIdent(t.Unit)
+	case WhitespaceToken:
+		return " "
+	case CDOToken:
+		return "<!--"
+	case CDCToken:
+		return "-->"
+	case ColonToken:
+		return ":"
+	case SemicolonToken:
+		return ";"
+	case CommaToken:
+		return ","
+	case OpenSquareToken:
+		return "["
+	case CloseSquareToken:
+		return "]"
+	case OpenParenToken:
+		return "("
+	case CloseParenToken:
+		return ")"
+	case OpenCurlyToken:
+		return "{"
+	case CloseCurlyToken:
+		return "}"
+	}
+	return ""
+}
+
+// Tokenize splits CSS source into tokens, following CSS Syntax Level 3.
+// Comments are dropped. The final EOF token is not included.
+func Tokenize(src string) []Token {
+	t := &tokenizer{src: preprocess(src)}
+	var tokens []Token
+	for {
+		tok := t.next()
+		if tok.Type == EOFToken {
+			return tokens
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+// preprocess normalizes newlines and replaces NUL characters, as the input
+// stream preprocessing of CSS Syntax does.
+func preprocess(src string) string {
+	if !strings.ContainsAny(src, "\r\f\x00") {
+		return src
+	}
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	return strings.NewReplacer("\r", "\n", "\f", "\n", "\x00", "�").Replace(src)
+}
+
+type tokenizer struct {
+	src string
+	pos int
+}
+
+// peek returns the code point at offset code points from the current
+// position, or -1 past the end.
+func (t *tokenizer) peek(offset int) rune {
+	pos := t.pos
+	for ; offset > 0; offset-- {
+		if pos >= len(t.src) {
+			return -1
+		}
+		_, size := utf8.DecodeRuneInString(t.src[pos:])
+		pos += size
+	}
+	if pos >= len(t.src) {
+		return -1
+	}
+	r, _ := utf8.DecodeRuneInString(t.src[pos:])
+	return r
+}
+
+// consume returns the next code point and advances past it.
+func (t *tokenizer) consume() rune {
+	if t.pos >= len(t.src) {
+		return -1
+	}
+	r, size := utf8.DecodeRuneInString(t.src[t.pos:])
+	t.pos += size
+	return r
+}
+
+func (t *tokenizer) next() Token {
+	t.consumeComments()
+	c := t.consume()
+	switch {
+	case c == -1:
+		return Token{Type: EOFToken}
+	case isWhitespace(c):
+		for isWhitespace(t.peek(0)) {
+			t.consume()
+		}
+		return Token{Type: WhitespaceToken}
+	case c == '"' || c == '\'':
+		return t.consumeString(c)
+	case c == '#':
+		if isNameCodePoint(t.peek(0)) || validEscape(t.peek(0), t.peek(1)) {
+			id := startsIdent(t.peek(0), t.peek(1), t.peek(2))
+			return Token{Type: HashToken, Value: t.consumeName(), ID: id}
+		}
+		return delim(c)
+	case c == '(':
+		return Token{Type: OpenParenToken}
+	case c == ')':
+		return Token{Type: CloseParenToken}
+	case c == '+' || c == '.':
+		if startsNumber(c, t.peek(0), t.peek(1)) {
+			t.pos--
+			return t.consumeNumeric()
+		}
+		return delim(c)
+	case c == ',':
+		return Token{Type: CommaToken}
+	case c == '-':
+		switch {
+		case startsNumber(c, t.peek(0), t.peek(1)):
+			t.pos--
+			return t.consumeNumeric()
+		case t.peek(0) == '-' && t.peek(1) == '>':
+			t.pos += 2
+			return Token{Type: CDCToken}
+		case startsIdent(c, t.peek(0), t.peek(1)):
+			t.pos--
+			return t.consumeIdentLike()
+		}
+		return delim(c)
+	case c == ':':
+		return Token{Type: ColonToken}
+	case c == ';':
+		return Token{Type: SemicolonToken}
+	case c == '<':
+		if strings.HasPrefix(t.src[t.pos:], "!--") {
+			t.pos += 3
+			return Token{Type: CDOToken}
+		}
+		return delim(c)
+	case c == '@':
+		if startsIdent(t.peek(0), t.peek(1), t.peek(2)) {
+			return Token{Type: AtKeywordToken, Value: t.consumeName()}
+		}
+		return delim(c)
+	case c == '[':
+		return Token{Type: OpenSquareToken}
+	case c == '\\':
+		if validEscape(c, t.peek(0)) {
+			t.pos--
+			return t.consumeIdentLike()
+		}
+		return delim(c)
+	case c == ']':
+		return Token{Type: CloseSquareToken}
+	case c == '{':
+		return Token{Type: OpenCurlyToken}
+	case c == '}':
+		return Token{Type: CloseCurlyToken}
+	case isDigit(c):
+		t.pos--
+		return t.consumeNumeric()
+	case isIdentStart(c):
+		t.pos -= utf8.RuneLen(c)
+		return t.consumeIdentLike()
+	}
+	return delim(c)
+}
+
+func delim(c rune) Token {
+	return Token{Type: DelimToken, Value: string(c)}
+}
+
+func (t *tokenizer) consumeComments() {
+	for strings.HasPrefix(t.src[t.pos:], "/*") {
+		end := strings.Index(t.src[t.pos+2:], "*/")
+		if end < 0 {
+			t.pos = len(t.src)
+			return
+		}
+		t.pos += end + 4
+	}
+}
+
+func (t *tokenizer) consumeString(quote rune) Token {
+	var sb strings.Builder
+	for {
+		c := t.consume()
+		switch {
+		case c == quote || c == -1:
+			return Token{Type: StringToken, Value: sb.String()}
+		case c == '\n':
+			t.pos--
+			return Token{Type: BadStringToken, Value: sb.String()}
+		case c == '\\':
+			switch t.peek(0) {
+			case -1:
+			case '\n':
+				t.consume()
+			default:
+				sb.WriteRune(t.consumeEscape())
+			}
+		default:
+			sb.WriteRune(c)
+		}
+	}
+}
+
+// consumeNumeric consumes a number, percentage or dimension token.
+func (t *tokenizer) consumeNumeric() Token {
+	repr, value, integer := t.consumeNumber()
+	if startsIdent(t.peek(0), t.peek(1), t.peek(2)) {
+		return Token{Type: DimensionToken, Value: repr, Number: value, Integer: integer, Unit: t.consumeName()}
+	}
+	if t.peek(0) == '%' {
+		t.consume()
+		return Token{Type: PercentageToken, Value: repr, Number: value, Integer: integer}
+	}
+	return Token{Type: NumberToken, Value: repr, Number: value, Integer: integer}
+}
+
+func (t *tokenizer) consumeNumber() (repr string, value float64, integer bool) {
+	start := t.pos
+	integer = true
+	if c := t.peek(0); c == '+' || c == '-' {
+		t.consume()
+	}
+	t.consumeDigits()
+	if t.peek(0) == '.' && isDigit(t.peek(1)) {
+		t.pos++
+		t.consumeDigits()
+		integer = false
+	}
+	if c := t.peek(0); c == 'e' || c == 'E' {
+		sign := t.peek(1)
+		if isDigit(sign) || (sign == '+' || sign == '-') && isDigit(t.peek(2)) {
+			t.pos++
+			if !isDigit(sign) {
+				t.pos++
+			}
+			t.consumeDigits()
+			integer = false
+		}
+	}
+	repr = t.src[start:t.pos]
+	value, _ = strconv.ParseFloat(repr, 64)
+	return repr, value, integer
+}
+
+func (t *tokenizer) consumeDigits() {
+	for isDigit(t.peek(0)) {
+		t.pos++
+	}
+}
+
+// consumeIdentLike consumes an ident, function or URL token.
+func (t *tokenizer) consumeIdentLike() Token {
+	name := t.consumeName()
+	if t.peek(0) != '(' {
+		return Token{Type: IdentToken, Value: name}
+	}
+	t.consume()
+	if !strings.EqualFold(name, "url") {
+		return Token{Type: FunctionToken, Value: name}
+	}
+	// Leave one whitespace before a quoted URL, which becomes an argument
+	// of the url() function.
+	for isWhitespace(t.peek(0)) && isWhitespace(t.peek(1)) {
+		t.consume()
+	}
+	next := t.peek(0)
+	if isWhitespace(next) {
+		next = t.peek(1)
+	}
+	if next == '"' || next == '\'' {
+		return Token{Type: FunctionToken, Value: name}
+	}
+	return t.consumeURL()
+}
+
+func (t *tokenizer) consumeURL() Token {
+	var sb strings.Builder
+	for isWhitespace(t.peek(0)) {
+		t.consume()
+	}
+	for {
+		c := t.consume()
+		switch {
+		case c == ')' || c == -1:
+			return Token{Type: URLToken, Value: sb.String()}
+		case isWhitespace(c):
+			for isWhitespace(t.peek(0)) {
+				t.consume()
+			}
+			if t.peek(0) == ')' || t.peek(0) == -1 {
+				t.consume()
+				return Token{Type: URLToken, Value: sb.String()}
+			}
+			t.consumeBadURL()
+			return Token{Type: BadURLToken}
+		case c == '"' || c == '\'' || c == '(' || isNonPrintable(c):
+			t.consumeBadURL()
+			return Token{Type: BadURLToken}
+		case c == '\\':
+			if !validEscape(c, t.peek(0)) {
+				t.consumeBadURL()
+				return Token{Type: BadURLToken}
+			}
+			sb.WriteRune(t.consumeEscape())
+		default:
+			sb.WriteRune(c)
+		}
+	}
+}
+
+// consumeBadURL consumes the remnants of a bad URL, up to and including
+// the closing parenthesis.
+func (t *tokenizer) consumeBadURL() {
+	for {
+		c := t.consume()
+		switch {
+		case c == ')' || c == -1:
+			return
+		case validEscape(c, t.peek(0)):
+			t.consumeEscape()
+		}
+	}
+}
+
+// consumeName consumes the name code points and escapes at the current
+// position.
+func (t *tokenizer) consumeName() string {
+	var sb strings.Builder
+	for {
+		c := t.peek(0)
+		switch {
+		case isNameCodePoint(c):
+			sb.WriteRune(t.consume())
+		case validEscape(c, t.peek(1)):
+			t.consume()
+			sb.WriteRune(t.consumeEscape())
+		default:
+			return sb.String()
+		}
+	}
+}
+
+// consumeEscape consumes an escape after its backslash and returns the
+// code point it represents.
+func (t *tokenizer) consumeEscape() rune {
+	c := t.consume()
+	if c == -1 {
+		return utf8.RuneError
+	}
+	if !isHexDigit(c) {
+		return c
+	}
+	value := hexValue(c)
+	for i := 1; i < 6 && isHexDigit(t.peek(0)); i++ {
+		value = value*16 + hexValue(t.consume())
+	}
+	if isWhitespace(t.peek(0)) {
+		t.consume()
+	}
+	if value == 0 || value > utf8.MaxRune || 0xD800 <= value && value <= 0xDFFF {
+		return utf8.RuneError
+	}
+	return value
+}
+
+// validEscape reports whether the two code points start a valid escape.
+func validEscape(c1, c2 rune) bool {
+	return c1 == '\\' && c2 != '\n' && c2 != -1
+}
+
+// startsIdent reports whether the three code points would start an
+// identifier.
+func startsIdent(c1, c2, c3 rune) bool {
+	switch {
+	case c1 == '-':
+		return isIdentStart(c2) || c2 == '-' || validEscape(c2, c3)
+	case c1 == '\\':
+		return validEscape(c1, c2)
+	}
+	return isIdentStart(c1)
+}
+
+// startsNumber reports whether the three code points would start a number.
+func startsNumber(c1, c2, c3 rune) bool {
+	switch c1 {
+	case '+', '-':
+		return isDigit(c2) || c2 == '.' && isDigit(c3)
+	case '.':
+		return isDigit(c2)
+	}
+	return isDigit(c1)
+}
+
+func isWhitespace(c rune) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+func isDigit(c rune) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isHexDigit(c rune) bool {
+	return isDigit(c) || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func hexValue(c rune) rune {
+	switch {
+	case isDigit(c):
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
+
+func isIdentStart(c rune) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+func isNameCodePoint(c rune) bool {
+	return isIdentStart(c) || isDigit(c) || c == '-'
+}
+
+func isNonPrintable(c rune) bool {
+	return 0 <= c && c <= 8 || c == 0x0B || 0x0E <= c && c <= 0x1F || c == 0x7F
+}
+
+// escapeIdent serializes an identifier, escaping characters that would
+// otherwise end it or change its meaning.
+func escapeIdent(s string) string {
+	if s == "" {
+		return ""
+	}
+	var sb strings.Builder
+	for i, c := range s {
+		switch {
+		case i == 0 && isDigit(c), i == 1 && isDigit(c) && s[0] == '-':
+			sb.WriteString("\\" + strconv.FormatInt(int64(c), 16) + " ")
+		case i == 0 && c == '-' && len(s) == 1:
+			sb.WriteString("\\-")
+		default:
+			writeNameRune(&sb, c)
+		}
+	}
+	return sb.String()
+}
+
+// escapeName serializes a sequence of name code points, such as the name
+// of a hash token.
+func escapeName(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		writeNameRune(&sb, c)
+	}
+	return sb.String()
+}
+
+func writeNameRune(sb *strings.Builder, c rune) {
+	switch {
+	case isNameCodePoint(c):
+		sb.WriteRune(c)
+	case isNonPrintable(c) || c == '\n':
+		sb.WriteString("\\" + strconv.FormatInt(int64(c), 16) + " ")
+	default:
+		sb.WriteByte('\\')
+		sb.WriteRune(c)
+	}
+}
+
+// quoteString serializes s as a double-quoted CSS string.
+func quoteString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, c := range s {
+		switch {
+		case c == '"' || c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(c)
+		case isNonPrintable(c) || c == '\n':
+			sb.WriteString("\\" + strconv.FormatInt(int64(c), 16) + " ")
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
@@ -0,0 +1,117 @@
+package css
+
+import "sync"
+
+// userAgentCSS is the part of the user agent stylesheet of the HTML
+// standard's rendering section that decides whether and how elements are
+// displayed.
+const userAgentCSS = `
+area, base, basefont, datalist, head, link, meta, noembed, noframes,
+param, rp, script, style, template, title {
+  display: none;
+}
+
+[hidden]:not([hidden=until-found]):not(embed) {
+  display: none;
+}
+
+embed[hidden] {
+  display: inline;
+  height: 0;
+  width: 0;
+}
+
+input[type=hidden] {
+  display: none !important;
+}
+
+html, body {
+  display: block;
+}
+
+address, blockquote, center, dialog, div, figure, figcaption, footer,
+form, header, hr, legend, listing, main, p, plaintext, pre, search, xmp {
+  display: block;
+}
+
+dialog:not([open]) {
+  display: none;
+}
+
+article, aside, h1, h2, h3, h4, h5, h6, hgroup, nav, section {
+  display: block;
+}
+
+dir, dd, dl, dt, menu, ol, ul {
+  display: block;
+}
+
+li {
+  display: list-item;
+}
+
+table {
+  display: table;
+}
+
+caption {
+  display: table-caption;
+}
+
+colgroup, colgroup[hidden] {
+  display: table-column-group;
+}
+
+col, col[hidden] {
+  display: table-column;
+}
+
+thead, thead[hidden] {
+  display: table-header-group;
+}
+
+tbody, tbody[hidden] {
+  display: table-row-group;
+}
+
+tfoot, tfoot[hidden] {
+  display: table-footer-group;
+}
+
+tr, tr[hidden] {
+  display: table-row;
+}
+
+td, th {
+  display: table-cell;
+}
+
+fieldset, details, summary, optgroup {
+  display: block;
+}
+
+details > summary:first-of-type {
+  display: list-item;
+}
+
+details:not([open]) > :not(summary:first-of-type) {
+  display: none;
+}
+
+ruby {
+  display: ruby;
+}
+
+rt {
+  display: ruby-text;
+}
+
+input, button, select, textarea, meter, progress, marquee {
+  display: inline-block;
+}
+`
+
+// uaStylesheet returns the parsed user agent stylesheet.
+var uaStylesheet = sync.OnceValue(func() *Stylesheet {
+	return ParseStylesheet(userAgentCSS)
+})
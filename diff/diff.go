@@ -0,0 +1,463 @@
+// Package diff compares DOM trees.
+//
+// Diff computes an edit script that turns one tree into another, and Unified
+// renders the difference between two trees as a unified diff of their
+// html5lib tree dumps, the format used by the tree-construction tests.
+package diff
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// Op identifies the kind of an Edit.
+type Op int
+
+// Edit operations.
+const (
+	Insert     Op = iota // a node was inserted
+	Remove               // a node was removed
+	Move                 // an unchanged node moved to another position
+	AttrChange           // an attribute was added, removed or changed
+	TextChange           // the data of a text, comment, CDATA or PI node changed
+)
+
+// String returns the name of the operation.
+func (op Op) String() string {
+	switch op {
+	case Insert:
+		return "insert"
+	case Remove:
+		return "remove"
+	case Move:
+		return "move"
+	case AttrChange:
+		return "attr"
+	case TextChange:
+		return "text"
+	default:
+		return "unknown"
+	}
+}
+
+// ContentStep is the Path step that enters the content of a template element.
+const ContentStep = -1
+
+// Path locates a node by the child indices leading to it from the root. The
+// empty path denotes the root itself.
+type Path []int
+
+// String returns the path in "/1/0/2" form, with template content steps
+// written as "content".
+func (p Path) String() string {
+	if len(p) == 0 {
+		return "/"
+	}
+	var sb strings.Builder
+	for _, step := range p {
+		sb.WriteByte('/')
+		if step == ContentStep {
+			sb.WriteString("content")
+		} else {
+			sb.WriteString(strconv.Itoa(step))
+		}
+	}
+	return sb.String()
+}
+
+func (p Path) child(i int) Path {
+	out := make(Path, len(p), len(p)+1)
+	copy(out, p)
+	return append(out, i)
+}
+
+// Edit is a single step of an edit script.
+type Edit struct {
+	Op Op
+
+	// OldPath locates the affected node in the old tree. It is unset for
+	// Insert.
+	OldPath Path
+
+	// NewPath locates the affected node in the new tree. It is unset for
+	// Remove.
+	NewPath Path
+
+	// Node is the inserted node (from the new tree) or the removed, moved or
+	// changed node (from the old tree).
+	Node dom.Node
+
+	// AttrNamespace and AttrName identify the attribute of an AttrChange.
+	AttrNamespace string
+	AttrName      string
+
+	// OldValue and NewValue hold the attribute values of an AttrChange or the
+	// data of a TextChange. For an AttrChange, HasOld and HasNew report
+	// whether the attribute is present in the old and new tree.
+	OldValue string
+	NewValue string
+	HasOld   bool
+	HasNew   bool
+}
+
+// String returns a one-line description of the edit.
+func (e Edit) String() string {
+	switch e.Op {
+	case Insert:
+		return fmt.Sprintf("insert %s %s", e.NewPath, describe(e.Node))
+	case Remove:
+		return fmt.Sprintf("remove %s %s", e.OldPath, describe(e.Node))
+	case Move:
+		return fmt.Sprintf("move %s -> %s %s", e.OldPath, e.NewPath, describe(e.Node))
+	case AttrChange:
+		name := e.AttrName
+		if e.AttrNamespace != "" {
+			name = e.AttrNamespace + " " + name
+		}
+		switch {
+		case !e.HasOld:
+			return fmt.Sprintf("attr %s +%s=%q", e.NewPath, name, e.NewValue)
+		case !e.HasNew:
+			return fmt.Sprintf("attr %s -%s=%q", e.OldPath, name, e.OldValue)
+		default:
+			return fmt.Sprintf("attr %s %s: %q -> %q", e.NewPath, name, e.OldValue, e.NewValue)
+		}
+	case TextChange:
+		return fmt.Sprintf("text %s: %q -> %q", e.NewPath, e.OldValue, e.NewValue)
+	}
+	return "unknown"
+}
+
+// describe returns a short html5lib-style description of n.
+func describe(n dom.Node) string {
+	switch n := n.(type) {
+	case *dom.Element:
+		switch n.Namespace {
+		case "", dom.NamespaceHTML:
+			return "<" + n.TagName + ">"
+		case dom.NamespaceSVG:
+			return "<svg " + n.TagName + ">"
+		case dom.NamespaceMathML:
+			return "<math " + n.TagName + ">"
+		default:
+			return "<" + n.Namespace + " " + n.TagName + ">"
+		}
+	case *dom.Text:
+		return strconv.Quote(n.Data)
+	case *dom.Comment:
+		return "<!-- " + n.Data + " -->"
+	case *dom.CDATASection:
+		return "<![CDATA[" + n.Data + "]]>"
+	case *dom.ProcessingInstruction:
+		return "<?" + n.Target + " " + n.Data + ">"
+	case *dom.DocumentType:
+		return "<!DOCTYPE " + n.Name + ">"
+	case *dom.Document:
+		return "#document"
+	case *dom.DocumentFragment:
+		return "#document-fragment"
+	}
+	return "?"
+}
+
+// Diff returns an edit script transforming the tree rooted at a into the tree
+// rooted at b. It is empty when dom.Equal(a, b) holds.
+//
+// Nodes are matched top-down: children of matched parents are aligned by a
+// longest common subsequence, first of equal subtrees and then of nodes with
+// the same name, and matched pairs are compared recursively. The script is
+// minimal with respect to this alignment. A removed subtree that is inserted
+// unchanged elsewhere is reported as a single Move.
+//
+// Document doctypes are not children; a changed doctype is reported as a
+// Remove and/or Insert of the DocumentType with an empty path.
+func Diff(a, b dom.Node) []Edit {
+	d := &differ{hashes: make(map[dom.Node]uint64)}
+	if sameLabel(a, b) {
+		d.diffNode(a, b, Path{}, Path{})
+	} else {
+		d.remove(a, Path{})
+		d.insert(b, Path{})
+	}
+	return d.detectMoves()
+}
+
+type differ struct {
+	edits  []Edit
+	hashes map[dom.Node]uint64
+}
+
+func (d *differ) insert(n dom.Node, p Path) {
+	d.edits = append(d.edits, Edit{Op: Insert, NewPath: p, Node: n})
+}
+
+func (d *differ) remove(n dom.Node, p Path) {
+	d.edits = append(d.edits, Edit{Op: Remove, OldPath: p, Node: n})
+}
+
+// diffNode compares two nodes with the same label.
+func (d *differ) diffNode(a, b dom.Node, pa, pb Path) {
+	switch an := a.(type) {
+	case *dom.Document:
+		bn := b.(*dom.Document)
+		d.diffDoctype(an.Doctype, bn.Doctype)
+	case *dom.Element:
+		bn := b.(*dom.Element)
+		d.diffAttributes(an, bn, pa, pb)
+		switch {
+		case an.TemplateContent != nil && bn.TemplateContent != nil:
+			d.diffChildren(an.TemplateContent.Children(), bn.TemplateContent.Children(),
+				pa.child(ContentStep), pb.child(ContentStep))
+		case an.TemplateContent != nil:
+			d.remove(an.TemplateContent, pa.child(ContentStep))
+		case bn.TemplateContent != nil:
+			d.insert(bn.TemplateContent, pb.child(ContentStep))
+		}
+	case *dom.Text:
+		d.diffData(a, an.Data, b.(*dom.Text).Data, pa, pb)
+	case *dom.Comment:
+		d.diffData(a, an.Data, b.(*dom.Comment).Data, pa, pb)
+	case *dom.CDATASection:
+		d.diffData(a, an.Data, b.(*dom.CDATASection).Data, pa, pb)
+	case *dom.ProcessingInstruction:
+		d.diffData(a, an.Data, b.(*dom.ProcessingInstruction).Data, pa, pb)
+	case *dom.DocumentType:
+		// Doctypes with the same label are equal.
+		return
+	}
+	d.diffChildren(a.Children(), b.Children(), pa, pb)
+}
+
+func (d *differ) diffDoctype(a, b *dom.DocumentType) {
+	if a != nil && b != nil && dom.Equal(a, b) {
+		return
+	}
+	if a != nil {
+		d.remove(a, nil)
+	}
+	if b != nil {
+		d.insert(b, nil)
+	}
+}
+
+func (d *differ) diffData(n dom.Node, oldData, newData string, pa, pb Path) {
+	if oldData == newData {
+		return
+	}
+	d.edits = append(d.edits, Edit{
+		Op: TextChange, OldPath: pa, NewPath: pb, Node: n,
+		OldValue: oldData, NewValue: newData,
+	})
+}
+
+// diffAttributes reports changed and removed attributes in the order of a,
+// followed by added attributes in the order of b.
+func (d *differ) diffAttributes(a, b *dom.Element, pa, pb Path) {
+	for _, attr := range a.Attributes.All() {
+		value, ok := b.Attributes.GetNS(attr.Namespace, attr.Name)
+		if ok && value == attr.Value {
+			continue
+		}
+		d.edits = append(d.edits, Edit{
+			Op: AttrChange, OldPath: pa, NewPath: pb, Node: a,
+			AttrNamespace: attr.Namespace, AttrName: attr.Name,
+			OldValue: attr.Value, NewValue: value, HasOld: true, HasNew: ok,
+		})
+	}
+	for _, attr := range b.Attributes.All() {
+		if a.Attributes.HasNS(attr.Namespace, attr.Name) {
+			continue
+		}
+		d.edits = append(d.edits, Edit{
+			Op: AttrChange, OldPath: pa, NewPath: pb, Node: a,
+			AttrNamespace: attr.Namespace, AttrName: attr.Name,
+			NewValue: attr.Value, HasNew: true,
+		})
+	}
+}
+
+// diffChildren aligns two child lists. Equal subtrees are matched first and
+// act as anchors; the runs between anchors are then aligned by label, and
+// matched nodes are compared recursively.
+func (d *differ) diffChildren(as, bs []dom.Node, pa, pb Path) {
+	anchors := lcs(len(as), len(bs), func(i, j int) bool { return d.equal(as[i], bs[j]) })
+	i, j := 0, 0
+	for _, anchor := range append(anchors, [2]int{len(as), len(bs)}) {
+		d.diffRun(as, bs, i, anchor[0], j, anchor[1], pa, pb)
+		i, j = anchor[0]+1, anchor[1]+1
+	}
+}
+
+// diffRun aligns as[i0:i1] with bs[j0:j1], which share no equal subtrees.
+func (d *differ) diffRun(as, bs []dom.Node, i0, i1, j0, j1 int, pa, pb Path) {
+	ra, rb := as[i0:i1], bs[j0:j1]
+	pairs := lcs(len(ra), len(rb), func(i, j int) bool { return sameLabel(ra[i], rb[j]) })
+	i, j := 0, 0
+	for _, pair := range append(pairs, [2]int{len(ra), len(rb)}) {
+		for ; i < pair[0]; i++ {
+			d.remove(ra[i], pa.child(i0+i))
+		}
+		for ; j < pair[1]; j++ {
+			d.insert(rb[j], pb.child(j0+j))
+		}
+		if i < len(ra) && j < len(rb) {
+			d.diffNode(ra[i], rb[j], pa.child(i0+i), pb.child(j0+j))
+		}
+		i, j = pair[0]+1, pair[1]+1
+	}
+}
+
+// detectMoves folds each Remove whose node is inserted unchanged elsewhere,
+// together with that Insert, into a Move.
+func (d *differ) detectMoves() []Edit {
+	var inserts []int
+	for i, e := range d.edits {
+		if e.Op == Insert && e.NewPath != nil {
+			inserts = append(inserts, i)
+		}
+	}
+	if len(inserts) == 0 {
+		return d.edits
+	}
+	dropped := make(map[int]bool)
+	for i := range d.edits {
+		e := &d.edits[i]
+		if e.Op != Remove || e.OldPath == nil {
+			continue
+		}
+		for _, k := range inserts {
+			if dropped[k] || !d.equal(e.Node, d.edits[k].Node) {
+				continue
+			}
+			e.Op = Move
+			e.NewPath = d.edits[k].NewPath
+			dropped[k] = true
+			break
+		}
+	}
+	out := d.edits[:0]
+	for i, e := range d.edits {
+		if !dropped[i] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// equal reports whether a and b are equal subtrees, using structural hashes
+// to reject most unequal pairs cheaply.
+func (d *differ) equal(a, b dom.Node) bool {
+	return d.hash(a) == d.hash(b) && dom.Equal(a, b)
+}
+
+// hash returns a structural hash of the subtree rooted at n that agrees with
+// dom.Equal: equal subtrees have equal hashes.
+func (d *differ) hash(n dom.Node) uint64 {
+	if h, ok := d.hashes[n]; ok {
+		return h
+	}
+	h := fnv.New64a()
+	writeString := func(s string) {
+		var length [8]byte
+		binary.LittleEndian.PutUint64(length[:], uint64(len(s)))
+		h.Write(length[:])
+		h.Write([]byte(s))
+	}
+	writeUint := func(v uint64) {
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+
+	switch n := n.(type) {
+	case *dom.Element:
+		writeString("element")
+		writeString(n.Namespace)
+		writeString(n.TagName)
+		// Attribute hashes are summed so that the order does not matter.
+		var attrs uint64
+		for _, attr := range n.Attributes.All() {
+			ah := fnv.New64a()
+			ah.Write([]byte(attr.Namespace))
+			ah.Write([]byte{0})
+			ah.Write([]byte(attr.Name))
+			ah.Write([]byte{0})
+			ah.Write([]byte(attr.Value))
+			attrs += ah.Sum64()
+		}
+		writeUint(attrs)
+		if n.TemplateContent != nil {
+			writeUint(d.hash(n.TemplateContent))
+		}
+	case *dom.Text:
+		writeString("text")
+		writeString(n.Data)
+	case *dom.Comment:
+		writeString("comment")
+		writeString(n.Data)
+	case *dom.CDATASection:
+		writeString("cdata")
+		writeString(n.Data)
+	case *dom.ProcessingInstruction:
+		writeString("pi")
+		writeString(n.Target)
+		writeString(n.Data)
+	case *dom.DocumentType:
+		writeString("doctype")
+		writeString(n.Name)
+		writeString(n.PublicID)
+		writeString(n.SystemID)
+	case *dom.Document:
+		writeString("document")
+		if n.Doctype != nil {
+			writeUint(d.hash(n.Doctype))
+		}
+	case *dom.DocumentFragment:
+		writeString("fragment")
+	}
+	for _, child := range n.Children() {
+		writeUint(d.hash(child))
+	}
+
+	sum := h.Sum64()
+	d.hashes[n] = sum
+	return sum
+}
+
+// sameLabel reports whether a and b may be matched with each other: they
+// are of the same node type and, for elements and processing instructions,
+// have the same name.
+func sameLabel(a, b dom.Node) bool {
+	switch an := a.(type) {
+	case *dom.Element:
+		bn, ok := b.(*dom.Element)
+		return ok && an.Namespace == bn.Namespace && an.TagName == bn.TagName
+	case *dom.Text:
+		_, ok := b.(*dom.Text)
+		return ok
+	case *dom.Comment:
+		_, ok := b.(*dom.Comment)
+		return ok
+	case *dom.CDATASection:
+		_, ok := b.(*dom.CDATASection)
+		return ok
+	case *dom.ProcessingInstruction:
+		bn, ok := b.(*dom.ProcessingInstruction)
+		return ok && an.Target == bn.Target
+	case *dom.DocumentType:
+		bn, ok := b.(*dom.DocumentType)
+		return ok && dom.Equal(an, bn)
+	case *dom.Document:
+		_, ok := b.(*dom.Document)
+		return ok
+	case *dom.DocumentFragment:
+		_, ok := b.(*dom.DocumentFragment)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/diff"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+func mustParse(t *testing.T, html string) *dom.Document {
+	t.Helper()
+	doc, err := JustGoHTML.Parse(html)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", html, err)
+	}
+	return doc
+}
+
+func editStrings(edits []diff.Edit) []string {
+	out := make([]string, len(edits))
+	for i, e := range edits {
+		out[i] = e.String()
+	}
+	return out
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []string
+	}{
+		{
+			name: "identical",
+			a:    `<p id=a class=b>x</p>`,
+			b:    `<p class=b id=a>x</p>`,
+			want: nil,
+		},
+		{
+			name: "text change",
+			a:    `<p>hello</p>`,
+			b:    `<p>world</p>`,
+			want: []string{`text /0/1/0/0: "hello" -> "world"`},
+		},
+		{
+			name: "attribute changes",
+			a:    `<p id=a class=b>x</p>`,
+			b:    `<p id=c title=t>x</p>`,
+			want: []string{
+				`attr /0/1/0 id: "a" -> "c"`,
+				`attr /0/1/0 -class="b"`,
+				`attr /0/1/0 +title="t"`,
+			},
+		},
+		{
+			name: "insert",
+			a:    `<p>a</p><p>c</p>`,
+			b:    `<p>a</p><p>b</p><p>c</p>`,
+			want: []string{`insert /0/1/1 <p>`},
+		},
+		{
+			name: "remove",
+			a:    `<p>a</p><div>b</div><p>c</p>`,
+			b:    `<p>a</p><p>c</p>`,
+			want: []string{`remove /0/1/1 <div>`},
+		},
+		{
+			name: "move",
+			a:    `<h1>t</h1><p>a</p><p>b</p>`,
+			b:    `<p>a</p><p>b</p><h1>t</h1>`,
+			want: []string{`move /0/1/0 -> /0/1/2 <h1>`},
+		},
+		{
+			name: "template content",
+			a:    `<template><b>x</b></template>`,
+			b:    `<template><b>y</b></template>`,
+			want: []string{`text /0/0/0/content/0/0: "x" -> "y"`},
+		},
+		{
+			name: "doctype",
+			a:    `<!DOCTYPE html><p>x</p>`,
+			b:    `<p>x</p>`,
+			want: []string{`remove / <!DOCTYPE html>`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := editStrings(diff.Diff(mustParse(t, tt.a), mustParse(t, tt.b)))
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Diff() =\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestDiffEqualTreesIsEmpty(t *testing.T) {
+	doc := mustParse(t, `<!DOCTYPE html><title>t</title><svg><use xlink:href="#a"/></svg><table><tr><td>1</table>`)
+	if edits := diff.Diff(doc, doc.Clone(true)); len(edits) != 0 {
+		t.Errorf("Diff of a clone = %v, want no edits", editStrings(edits))
+	}
+}
+
+func TestDiffDifferentRoots(t *testing.T) {
+	a := dom.NewElement("p")
+	b := dom.NewText("p")
+	got := editStrings(diff.Diff(a, b))
+	want := []string{`remove / <p>`, `insert / "p"`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a := mustParse(t, `<ul><li>one</li><li>two</li><li>three</li></ul>`)
+	b := mustParse(t, `<ul><li>one</li><li>2</li><li>three</li></ul>`)
+
+	got := diff.Unified(a, b, 1)
+	want := `--- a
++++ b
+@@ -7,3 +7,3 @@
+ |       <li>
+-|         "two"
++|         "2"
+ |       <li>
+`
+	if got != want {
+		t.Errorf("Unified() =\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := diff.Unified(a, a.Clone(true), diff.DefaultContext); got != "" {
+		t.Errorf("Unified() of equal trees = %q, want empty", got)
+	}
+}
+
+func TestUnifiedSeparateHunks(t *testing.T) {
+	var sa, sb strings.Builder
+	for i := 0; i < 10; i++ {
+		sa.WriteString("<p>x</p>")
+		sb.WriteString("<p>x</p>")
+	}
+	a := mustParse(t, "<p>first</p>"+sa.String()+"<p>last</p>")
+	b := mustParse(t, "<p>FIRST</p>"+sb.String()+"<p>LAST</p>")
+
+	got := diff.Unified(a, b, 0)
+	want := `--- a
++++ b
+@@ -5 +5 @@
+-|       "first"
++|       "FIRST"
+@@ -27 +27 @@
+-|       "last"
++|       "LAST"
+`
+	if got != want {
+		t.Errorf("Unified() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedInsertionOnly(t *testing.T) {
+	a := dom.NewDocumentFragment()
+	b := dom.NewDocumentFragment()
+	b.AppendChild(dom.NewComment("new"))
+
+	got := diff.Unified(a, b, diff.DefaultContext)
+	want := "--- a\n+++ b\n@@ -0,0 +1 @@\n+| <!-- new -->\n"
+	if got != want {
+		t.Errorf("Unified() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,72 @@
+package diff
+
+// lcs returns the index pairs of a longest common subsequence of two
+// sequences of length n and m, in increasing order. eq reports whether the
+// i-th element of the first sequence matches the j-th of the second.
+//
+// It uses Myers' O((n+m)·D) algorithm, keeping the part of each round's
+// frontier needed to backtrack, so memory grows with D² rather than n·m.
+func lcs(n, m int, eq func(i, j int) bool) [][2]int {
+	if n == 0 || m == 0 {
+		return nil
+	}
+	maxD := n + m
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		// trace[d] holds the frontier for diagonals -d-1..d+1 as it was
+		// before round d.
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:offset+d+2])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && eq(x, y) {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrack(trace [][]int, n, m int) [][2]int {
+	var pairs [][2]int
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			pairs = append(pairs, [2]int{x, y})
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(pairs)-1; i < j; i, j = i+1, j-1 {
+		pairs[i], pairs[j] = pairs[j], pairs[i]
+	}
+	return pairs
+}
@@ -0,0 +1,50 @@
+package diff
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// lcsLength is the quadratic reference implementation.
+func lcsLength(a, b []byte) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+func TestLCSMatchesReference(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []byte {
+		s := make([]byte, rng.Intn(12))
+		for i := range s {
+			s[i] = "abc"[rng.Intn(3)]
+		}
+		return s
+	}
+	for iter := 0; iter < 2000; iter++ {
+		a, b := random(), random()
+		pairs := lcs(len(a), len(b), func(i, j int) bool { return a[i] == b[j] })
+		if len(pairs) != lcsLength(a, b) {
+			t.Fatalf("lcs(%q, %q) has %d pairs, want %d", a, b, len(pairs), lcsLength(a, b))
+		}
+		for k, p := range pairs {
+			if a[p[0]] != b[p[1]] {
+				t.Fatalf("lcs(%q, %q) pairs unequal elements at %v", a, b, p)
+			}
+			if k > 0 && (p[0] <= pairs[k-1][0] || p[1] <= pairs[k-1][1]) {
+				t.Fatalf("lcs(%q, %q) pairs are not increasing: %v", a, b, pairs)
+			}
+		}
+	}
+}
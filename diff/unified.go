@@ -0,0 +1,137 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/internal/testutil"
+)
+
+// DefaultContext is the number of context lines Unified shows around changes
+// when a negative context is given.
+const DefaultContext = 3
+
+// Tree returns the html5lib tree dump of n. Documents include their doctype,
+// fragments list their children, and any other node is dumped as a single
+// top-level node.
+func Tree(n dom.Node) string {
+	switch n := n.(type) {
+	case *dom.Document:
+		return testutil.SerializeHTML5LibTree(n)
+	case *dom.DocumentFragment:
+		return testutil.SerializeHTML5LibNodes(n.Children())
+	case nil:
+		return ""
+	default:
+		return testutil.SerializeHTML5LibNodes([]dom.Node{n})
+	}
+}
+
+// Unified returns a unified diff between the html5lib tree dumps of a and b,
+// with context lines of unchanged lines around each change. It returns the
+// empty string when the dumps are identical.
+func Unified(a, b dom.Node, context int) string {
+	if context < 0 {
+		context = DefaultContext
+	}
+	return unifiedLines("a", "b", splitLines(Tree(a)), splitLines(Tree(b)), context)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// lineOp is one line of a line-level edit script.
+type lineOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+	// oldLine and newLine are the zero-based positions the line occupies, or
+	// would occupy, in the old and new text.
+	oldLine, newLine int
+}
+
+func unifiedLines(oldName, newName string, as, bs []string, context int) string {
+	pairs := lcs(len(as), len(bs), func(i, j int) bool { return as[i] == bs[j] })
+
+	var ops []lineOp
+	i, j := 0, 0
+	for _, pair := range append(pairs, [2]int{len(as), len(bs)}) {
+		for ; i < pair[0]; i++ {
+			ops = append(ops, lineOp{kind: '-', text: as[i], oldLine: i, newLine: j})
+		}
+		for ; j < pair[1]; j++ {
+			ops = append(ops, lineOp{kind: '+', text: bs[j], oldLine: i, newLine: j})
+		}
+		if i < len(as) && j < len(bs) {
+			ops = append(ops, lineOp{kind: ' ', text: as[i], oldLine: i, newLine: j})
+		}
+		i, j = pair[0]+1, pair[1]+1
+	}
+
+	var sb strings.Builder
+	for start := 0; start < len(ops); {
+		// Find the next change and extend the hunk while the gap of
+		// unchanged lines to the following change is at most 2·context.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for k := first + 1; k < len(ops); k++ {
+			if ops[k].kind == ' ' {
+				continue
+			}
+			if k-last-1 > 2*context {
+				break
+			}
+			last = k
+		}
+
+		lo := max(first-context, start)
+		hi := min(last+context+1, len(ops))
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		writeHunk(&sb, ops[lo:hi])
+		start = hi
+	}
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, ops []lineOp) {
+	oldCount, newCount := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n",
+		hunkRange(ops[0].oldLine, oldCount), hunkRange(ops[0].newLine, newCount))
+	for _, op := range ops {
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.text)
+		sb.WriteByte('\n')
+	}
+}
+
+// hunkRange formats a hunk range starting at the zero-based line start. As in
+// GNU diff, an empty range names the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -20,6 +20,10 @@ type Attribute struct {
 // Attributes are stored in insertion order and accessed case-insensitively for HTML.
 type Attributes struct {
 	items []Attribute
+
+	// owner is the element the attributes belong to, if any. It is used to
+	// report changes to the owning document, see Element.claimAttributes.
+	owner *Element
 }
 
 // NewAttributes creates a new empty Attributes collection.
@@ -61,7 +65,9 @@ func (a *Attributes) SetNS(namespace, name, value string) {
 	// Try to update existing attribute
 	for i := range a.items {
 		if a.items[i].Namespace == namespace && strings.EqualFold(a.items[i].Name, name) {
+			oldValue := a.items[i].Value
 			a.items[i].Value = value
+			a.changed(namespace, a.items[i].Name, oldValue, true)
 			return
 		}
 	}
@@ -72,6 +78,7 @@ func (a *Attributes) SetNS(namespace, name, value string) {
 		Name:      name,
 		Value:     value,
 	})
+	a.changed(namespace, name, "", false)
 }
 
 // Has returns true if an attribute with the given name exists.
@@ -96,7 +103,9 @@ func (a *Attributes) RemoveNS(namespace, name string) {
 	lowerName := strings.ToLower(name)
 	for i := range a.items {
 		if a.items[i].Namespace == namespace && strings.ToLower(a.items[i].Name) == lowerName {
+			removed := a.items[i]
 			a.items = append(a.items[:i], a.items[i+1:]...)
+			a.changed(namespace, removed.Name, removed.Value, true)
 			return
 		}
 	}
@@ -122,3 +131,11 @@ func (a *Attributes) Clone() *Attributes {
 	copy(clone.items, a.items)
 	return clone
 }
+
+// changed reports a modification of the named attribute to the owning
+// element. hadOld reports whether the attribute existed before the change.
+func (a *Attributes) changed(namespace, name, oldValue string, hadOld bool) {
+	if a.owner != nil {
+		a.owner.attributeChanged(namespace, name, oldValue, hadOld)
+	}
+}
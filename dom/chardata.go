@@ -0,0 +1,250 @@
+package dom
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrIndexSize is returned when a character data offset lies beyond the end
+// of the data (the DOM's IndexSizeError).
+var ErrIndexSize = errors.New("index size error: offset is greater than the data length")
+
+// The DOM measures character data in UTF-16 code units, as browsers do. The
+// helpers below convert between Go strings and code unit slices. Offsets that
+// split a surrogate pair leave lone surrogates behind, which decode to U+FFFD.
+
+// utf16Length returns the length of s in UTF-16 code units.
+func utf16Length(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// replaceData implements the DOM "replace data" algorithm on data, returning
+// the new data.
+func replaceData(data string, offset, count int, replacement string) (string, error) {
+	units := utf16.Encode([]rune(data))
+	start, end, err := dataRange(len(units), offset, count)
+	if err != nil {
+		return data, err
+	}
+	var sb strings.Builder
+	sb.WriteString(string(utf16.Decode(units[:start])))
+	sb.WriteString(replacement)
+	sb.WriteString(string(utf16.Decode(units[end:])))
+	return sb.String(), nil
+}
+
+// substringData implements the DOM "substring data" algorithm.
+func substringData(data string, offset, count int) (string, error) {
+	units := utf16.Encode([]rune(data))
+	start, end, err := dataRange(len(units), offset, count)
+	if err != nil {
+		return "", err
+	}
+	return string(utf16.Decode(units[start:end])), nil
+}
+
+// dataRange clamps [offset, offset+count) to a data length of length code
+// units.
+func dataRange(length, offset, count int) (int, int, error) {
+	if offset < 0 || offset > length {
+		return 0, 0, ErrIndexSize
+	}
+	if count < 0 || count > length-offset {
+		count = length - offset
+	}
+	return offset, offset + count, nil
+}
+
+// Length returns the length of the text in UTF-16 code units.
+func (t *Text) Length() int {
+	return utf16Length(t.Data)
+}
+
+// AppendData appends data to the text.
+func (t *Text) AppendData(data string) {
+	t.SetData(t.Data + data)
+}
+
+// InsertData inserts data at the given UTF-16 offset.
+func (t *Text) InsertData(offset int, data string) error {
+	return t.ReplaceData(offset, 0, data)
+}
+
+// DeleteData removes count UTF-16 code units starting at offset.
+func (t *Text) DeleteData(offset, count int) error {
+	return t.ReplaceData(offset, count, "")
+}
+
+// ReplaceData replaces count UTF-16 code units starting at offset with data.
+// A count reaching past the end of the text is clamped.
+func (t *Text) ReplaceData(offset, count int, data string) error {
+	updated, err := replaceData(t.Data, offset, count, data)
+	if err != nil {
+		return err
+	}
+	t.SetData(updated)
+	return nil
+}
+
+// SubstringData returns count UTF-16 code units starting at offset.
+func (t *Text) SubstringData(offset, count int) (string, error) {
+	return substringData(t.Data, offset, count)
+}
+
+// SplitText splits the text at the given UTF-16 offset. The text keeps the
+// data before the offset and a new Text node holding the rest is returned.
+// If the text has a parent, the new node is inserted right after it.
+func (t *Text) SplitText(offset int) (*Text, error) {
+	rest, err := substringData(t.Data, offset, -1)
+	if err != nil {
+		return nil, err
+	}
+	newText := NewText(rest)
+	if parent := t.parent; parent != nil {
+		parent.InsertBefore(newText, nextSiblingOf(parent, t))
+	}
+	if err := t.ReplaceData(offset, -1, ""); err != nil {
+		return nil, err
+	}
+	return newText, nil
+}
+
+// WholeText returns the data of this text node concatenated with the data of
+// its contiguous Text and CDATASection siblings, in tree order.
+func (t *Text) WholeText() string {
+	parent := t.parent
+	if parent == nil {
+		return t.Data
+	}
+	children := parent.Children()
+	idx := -1
+	for i, c := range children {
+		if c == t {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return t.Data
+	}
+	start, end := idx, idx+1
+	for start > 0 {
+		if _, ok := textData(children[start-1]); !ok {
+			break
+		}
+		start--
+	}
+	for end < len(children) {
+		if _, ok := textData(children[end]); !ok {
+			break
+		}
+		end++
+	}
+	var sb strings.Builder
+	for _, c := range children[start:end] {
+		data, _ := textData(c)
+		sb.WriteString(data)
+	}
+	return sb.String()
+}
+
+// textData returns the data of n if it is a Text or CDATASection node, the
+// node types that make up the contiguous text nodes of the DOM.
+func textData(n Node) (string, bool) {
+	switch n := n.(type) {
+	case *Text:
+		return n.Data, true
+	case *CDATASection:
+		return n.Data, true
+	}
+	return "", false
+}
+
+// Length returns the length of the comment in UTF-16 code units.
+func (c *Comment) Length() int {
+	return utf16Length(c.Data)
+}
+
+// AppendData appends data to the comment.
+func (c *Comment) AppendData(data string) {
+	c.SetData(c.Data + data)
+}
+
+// InsertData inserts data at the given UTF-16 offset.
+func (c *Comment) InsertData(offset int, data string) error {
+	return c.ReplaceData(offset, 0, data)
+}
+
+// DeleteData removes count UTF-16 code units starting at offset.
+func (c *Comment) DeleteData(offset, count int) error {
+	return c.ReplaceData(offset, count, "")
+}
+
+// ReplaceData replaces count UTF-16 code units starting at offset with data.
+// A count reaching past the end of the comment is clamped.
+func (c *Comment) ReplaceData(offset, count int, data string) error {
+	updated, err := replaceData(c.Data, offset, count, data)
+	if err != nil {
+		return err
+	}
+	c.SetData(updated)
+	return nil
+}
+
+// SubstringData returns count UTF-16 code units starting at offset.
+func (c *Comment) SubstringData(offset, count int) (string, error) {
+	return substringData(c.Data, offset, count)
+}
+
+// nextSiblingOf returns the sibling following child in parent, or nil.
+func nextSiblingOf(parent, child Node) Node {
+	children := parent.Children()
+	for i, c := range children {
+		if c == child {
+			if i+1 < len(children) {
+				return children[i+1]
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// Normalize removes the empty Text descendants of n and merges runs of
+// adjacent Text nodes into the first node of each run.
+func Normalize(n Node) {
+	for i := 0; i < len(n.Children()); {
+		text, ok := n.Children()[i].(*Text)
+		if !ok {
+			Normalize(n.Children()[i])
+			i++
+			continue
+		}
+		if text.Data == "" {
+			n.RemoveChild(text)
+			continue
+		}
+		data := text.Data
+		for i+1 < len(n.Children()) {
+			next, ok := n.Children()[i+1].(*Text)
+			if !ok {
+				break
+			}
+			data += next.Data
+			n.RemoveChild(next)
+		}
+		if data != text.Data {
+			text.SetData(data)
+		}
+		i++
+	}
+}
@@ -0,0 +1,221 @@
+package dom
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTextCharacterDataMethods(t *testing.T) {
+	text := NewText("Hello")
+
+	text.AppendData(", World")
+	if text.Data != "Hello, World" {
+		t.Fatalf("AppendData: got %q", text.Data)
+	}
+	if err := text.InsertData(5, "!"); err != nil || text.Data != "Hello!, World" {
+		t.Fatalf("InsertData: got %q, %v", text.Data, err)
+	}
+	if err := text.DeleteData(5, 2); err != nil || text.Data != "Hello World" {
+		t.Fatalf("DeleteData: got %q, %v", text.Data, err)
+	}
+	if err := text.ReplaceData(6, 100, "Go"); err != nil || text.Data != "Hello Go" {
+		t.Fatalf("ReplaceData: got %q, %v", text.Data, err)
+	}
+	if got, err := text.SubstringData(1, 3); err != nil || got != "ell" {
+		t.Fatalf("SubstringData: got %q, %v", got, err)
+	}
+	if got, err := text.SubstringData(6, 10); err != nil || got != "Go" {
+		t.Fatalf("SubstringData clamped: got %q, %v", got, err)
+	}
+	if err := text.InsertData(9, "x"); !errors.Is(err, ErrIndexSize) {
+		t.Fatalf("InsertData past end: err = %v, want ErrIndexSize", err)
+	}
+	if _, err := text.SubstringData(-1, 1); !errors.Is(err, ErrIndexSize) {
+		t.Fatalf("SubstringData negative offset: err = %v, want ErrIndexSize", err)
+	}
+}
+
+func TestCharacterDataUTF16Offsets(t *testing.T) {
+	// "a😀b": the emoji occupies two UTF-16 code units.
+	text := NewText("a😀b")
+	if got := text.Length(); got != 4 {
+		t.Fatalf("Length() = %d, want 4", got)
+	}
+	if got, _ := text.SubstringData(1, 2); got != "😀" {
+		t.Errorf("SubstringData(1, 2) = %q, want emoji", got)
+	}
+	if got, _ := text.SubstringData(3, 1); got != "b" {
+		t.Errorf("SubstringData(3, 1) = %q, want b", got)
+	}
+	// Splitting a surrogate pair leaves replacement characters behind.
+	if got, _ := text.SubstringData(1, 1); got != "�" {
+		t.Errorf("SubstringData(1, 1) = %q, want U+FFFD", got)
+	}
+	if err := text.DeleteData(1, 2); err != nil || text.Data != "ab" {
+		t.Errorf("DeleteData(1, 2) = %q, %v", text.Data, err)
+	}
+}
+
+func TestCommentCharacterDataMethods(t *testing.T) {
+	c := NewComment("abc")
+	c.AppendData("def")
+	if err := c.InsertData(0, ">"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ReplaceData(1, 3, "X"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteData(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if c.Data != "Xdef" || c.Length() != 4 {
+		t.Fatalf("comment data = %q (len %d), want Xdef", c.Data, c.Length())
+	}
+	if got, _ := c.SubstringData(1, 2); got != "de" {
+		t.Errorf("SubstringData = %q, want de", got)
+	}
+}
+
+func TestCharacterDataMutationRecords(t *testing.T) {
+	doc, _, body := newObservedDocument()
+	text := NewText("abc")
+	body.AppendChild(text)
+	obs := doc.NewMutationObserver(nil)
+	_ = obs.Observe(text, MutationObserverInit{CharacterDataOldValue: true})
+
+	_ = text.ReplaceData(0, 1, "x")
+	records := obs.TakeRecords()
+	if len(records) != 1 || records[0].OldValue != "abc" {
+		t.Fatalf("records = %+v, want one record with old value abc", records)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	p := NewElement("p")
+	text := NewText("Hello World")
+	tail := NewElement("b")
+	p.AppendChild(text)
+	p.AppendChild(tail)
+
+	rest, err := text.SplitText(6)
+	if err != nil {
+		t.Fatalf("SplitText() error = %v", err)
+	}
+	if text.Data != "Hello " || rest.Data != "World" {
+		t.Fatalf("SplitText data = %q / %q", text.Data, rest.Data)
+	}
+	children := p.Children()
+	if len(children) != 3 || children[0] != text || children[1] != rest || children[2] != tail {
+		t.Fatalf("SplitText did not insert the new node after the original: %v", children)
+	}
+	if rest.Parent() != p {
+		t.Error("new text node should have the original's parent")
+	}
+	if got := rest.WholeText(); got != "Hello World" {
+		t.Errorf("WholeText() = %q, want %q", got, "Hello World")
+	}
+
+	if _, err := text.SplitText(100); !errors.Is(err, ErrIndexSize) {
+		t.Errorf("SplitText past end: err = %v, want ErrIndexSize", err)
+	}
+
+	detached := NewText("ab")
+	second, err := detached.SplitText(1)
+	if err != nil || detached.Data != "a" || second.Data != "b" || second.Parent() != nil {
+		t.Errorf("detached SplitText = %q / %q, %v", detached.Data, second.Data, err)
+	}
+}
+
+func TestWholeTextStopsAtNonText(t *testing.T) {
+	p := NewElement("p")
+	a, b, c := NewText("a"), NewText("b"), NewText("c")
+	p.AppendChild(a)
+	p.AppendChild(NewElement("br"))
+	p.AppendChild(b)
+	p.AppendChild(c)
+
+	if got := a.WholeText(); got != "a" {
+		t.Errorf("a.WholeText() = %q, want a", got)
+	}
+	if got := c.WholeText(); got != "bc" {
+		t.Errorf("c.WholeText() = %q, want bc", got)
+	}
+	if got := NewText("solo").WholeText(); got != "solo" {
+		t.Errorf("detached WholeText() = %q, want solo", got)
+	}
+
+	p.AppendChild(NewCDATASection("d"))
+	p.AppendChild(NewText("e"))
+	if got := b.WholeText(); got != "bcde" {
+		t.Errorf("b.WholeText() = %q, want bcde across the CDATA section", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	div := NewElement("div")
+	first := NewText("a")
+	div.AppendChild(first)
+	div.AppendChild(NewText(""))
+	div.AppendChild(NewText("b"))
+	span := NewElement("span")
+	span.AppendChild(NewText(""))
+	span.AppendChild(NewText("c"))
+	span.AppendChild(NewText("d"))
+	div.AppendChild(span)
+	div.AppendChild(NewText(""))
+	div.AppendChild(NewComment("x"))
+	div.AppendChild(NewText("e"))
+
+	Normalize(div)
+
+	children := div.Children()
+	if len(children) != 4 {
+		t.Fatalf("div has %d children after Normalize, want 4", len(children))
+	}
+	if children[0] != first || first.Data != "ab" {
+		t.Errorf("first run should merge into the first node, got %q", first.Data)
+	}
+	if children[1] != span || children[2].Type() != CommentNodeType {
+		t.Error("non-text children should keep their order")
+	}
+	if text, ok := children[3].(*Text); !ok || text.Data != "e" {
+		t.Errorf("trailing text = %v", children[3])
+	}
+	spanChildren := span.Children()
+	if len(spanChildren) != 1 || spanChildren[0].(*Text).Data != "cd" {
+		t.Errorf("span children after Normalize = %v", spanChildren)
+	}
+}
+
+func TestNormalizeDocumentNotifiesObservers(t *testing.T) {
+	doc, _, body := newObservedDocument()
+	body.AppendChild(NewText("a"))
+	body.AppendChild(NewText("b"))
+	obs := doc.NewMutationObserver(nil)
+	_ = obs.Observe(body, MutationObserverInit{ChildList: true, CharacterData: true, Subtree: true})
+
+	Normalize(doc)
+
+	if len(body.Children()) != 1 {
+		t.Fatalf("body has %d children, want 1", len(body.Children()))
+	}
+	records := obs.TakeRecords()
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want removal and data change", len(records))
+	}
+}
+
+func TestNormalizeKeepsUnchangedData(t *testing.T) {
+	doc, _, body := newObservedDocument()
+	body.AppendChild(NewText("a"))
+	body.AppendChild(NewText(""))
+	obs := doc.NewMutationObserver(nil)
+	_ = obs.Observe(body, MutationObserverInit{ChildList: true, CharacterData: true, Subtree: true})
+
+	Normalize(doc)
+
+	records := obs.TakeRecords()
+	if len(records) != 1 || records[0].Type != ChildListMutation {
+		t.Errorf("records = %+v, want only the removal of the empty text", records)
+	}
+}
@@ -1,5 +1,7 @@
 package dom
 
+import "strings"
+
 // QuirksMode represents the document's quirks mode.
 type QuirksMode int
 
@@ -19,6 +21,19 @@ type Document struct {
 
 	// QuirksMode indicates the document's quirks mode.
 	QuirksMode QuirksMode
+
+	// URL is the address of the document, if known. Relative URLs in the
+	// document resolve against it, unless a base element overrides it (see
+	// BaseURL).
+	URL string
+
+	// index is the optional id/class/tag index, see EnableIndex.
+	index *elementIndex
+
+	// mutationObservers lists the observers created for this document, and
+	// observerRegistry maps observed nodes to their registrations.
+	mutationObservers []*MutationObserver
+	observerRegistry  map[Node][]registeredObserver
 }
 
 // NewDocument creates a new empty document.
@@ -37,6 +52,7 @@ func (d *Document) Type() NodeType {
 func (d *Document) Clone(deep bool) Node {
 	clone := &Document{
 		QuirksMode: d.QuirksMode,
+		URL:        d.URL,
 	}
 	clone.init(clone)
 
@@ -50,16 +66,13 @@ func (d *Document) Clone(deep bool) Node {
 			clone.AppendChild(clonedChild)
 		}
 	}
+	if d.index != nil {
+		clone.EnableIndex()
+	}
 
 	return clone
 }
 
-// AppendChild adds a child node, properly setting the parent.
-func (d *Document) AppendChild(child Node) {
-	child.SetParent(d)
-	d.children = append(d.children, child)
-}
-
 // DocumentElement returns the root element (html element).
 func (d *Document) DocumentElement() *Element {
 	for _, child := range d.children {
@@ -130,6 +143,119 @@ func (d *Document) QueryFirst(selector string) (*Element, error) {
 	return root.QueryFirst(selector)
 }
 
+// EnableIndex builds an index of the document's elements by id, class and
+// tag name. The index is kept up to date as nodes are inserted or removed and
+// as id and class attributes change, and is used by GetElementByID,
+// GetElementsByClassName and GetElementsByTagName(NS). Enabling an already
+// enabled index is a no-op.
+//
+// Attribute changes are only seen while each element keeps its own
+// Attributes; see Element.Attributes for what happens when one is replaced.
+func (d *Document) EnableIndex() {
+	if d.index != nil {
+		return
+	}
+	d.index = newElementIndex()
+	d.index.addSubtree(d)
+	d.updateTracking()
+}
+
+// DisableIndex drops the element index. Lookups fall back to tree walks.
+func (d *Document) DisableIndex() {
+	d.index = nil
+	d.updateTracking()
+}
+
+// checkIndex rebuilds the index when an element of set no longer owns its
+// Attributes, see Element.Attributes.
+func (d *Document) checkIndex(set elementSet) {
+	if !owned(set) {
+		d.index = newElementIndex()
+		d.index.addSubtree(d)
+	}
+}
+
+// IndexEnabled reports whether the element index is enabled.
+func (d *Document) IndexEnabled() bool {
+	return d.index != nil
+}
+
+// GetElementByID returns the first element in tree order whose id attribute
+// equals id, or nil if there is none.
+func (d *Document) GetElementByID(id string) *Element {
+	if id == "" {
+		return nil
+	}
+	if d.index != nil {
+		d.checkIndex(d.index.ids[id])
+		set := d.index.ids[id]
+		switch len(set) {
+		case 0:
+			return nil
+		case 1:
+			for e := range set {
+				return e
+			}
+		}
+		return setToSlice(set, nil)[0]
+	}
+	var found *Element
+	WalkElements(d, func(e *Element) bool {
+		if v, ok := e.Attributes.Get("id"); ok && v == id {
+			found = e
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// GetElementsByClassName returns the elements, in tree order, that carry all
+// of the whitespace-separated classes in classNames.
+func (d *Document) GetElementsByClassName(classNames string) []*Element {
+	classes := splitClassNames(classNames)
+	if len(classes) == 0 {
+		return nil
+	}
+	if d.index != nil {
+		d.checkIndex(d.index.classes[classes[0]])
+		return setToSlice(d.index.classes[classes[0]], func(e *Element) bool {
+			return hasAllClasses(e, classes[1:])
+		})
+	}
+	return collectDescendants(d, func(e *Element) bool {
+		return hasAllClasses(e, classes)
+	})
+}
+
+// GetElementsByTagName returns the elements, in tree order, with the given
+// qualified name. HTML elements are matched case-insensitively and "*"
+// matches all elements.
+func (d *Document) GetElementsByTagName(name string) []*Element {
+	match := tagNameMatcher(name)
+	if d.index == nil || name == "*" {
+		return collectDescendants(d, match)
+	}
+	candidates := make(elementSet)
+	for e := range d.index.tags[name] {
+		candidates[e] = struct{}{}
+	}
+	for e := range d.index.tags[strings.ToLower(name)] {
+		candidates[e] = struct{}{}
+	}
+	return setToSlice(candidates, match)
+}
+
+// GetElementsByTagNameNS returns the elements, in tree order, with the given
+// namespace and local name. Either argument may be "*" to match any value.
+func (d *Document) GetElementsByTagNameNS(namespace, localName string) []*Element {
+	match := tagNameNSMatcher(namespace, localName)
+	if d.index == nil || localName == "*" {
+		return collectDescendants(d, match)
+	}
+	return setToSlice(d.index.tags[localName], match)
+}
+
 // DocumentType represents a DOCTYPE declaration.
 type DocumentType struct {
 	parent Node
@@ -230,9 +356,3 @@ func (df *DocumentFragment) Clone(deep bool) Node {
 
 	return clone
 }
-
-// AppendChild adds a child node, properly setting the parent.
-func (df *DocumentFragment) AppendChild(child Node) {
-	child.SetParent(df)
-	df.children = append(df.children, child)
-}
@@ -1,6 +1,7 @@
 package dom
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -1499,3 +1500,84 @@ func TestDocumentFragmentBaseNodeHasChildNodes(t *testing.T) {
 		t.Error("fragment with child should have children")
 	}
 }
+
+func TestCDATASectionNode(t *testing.T) {
+	c := NewCDATASection("x<y")
+	if c.Type() != CDATASectionNodeType || c.Length() != 3 {
+		t.Fatalf("Type() = %v, Length() = %d", c.Type(), c.Length())
+	}
+	svg := NewElementNS("svg", NamespaceSVG)
+	svg.AppendChild(c)
+	if c.Parent() != svg || svg.Text() != "x<y" {
+		t.Errorf("CDATA content should be part of the element text, got %q", svg.Text())
+	}
+	c.AppendChild(NewText("ignored"))
+	if c.HasChildNodes() || c.Children() != nil {
+		t.Error("CDATA sections have no children")
+	}
+	clone := c.Clone(true).(*CDATASection)
+	if clone.Data != "x<y" || clone.Parent() != nil {
+		t.Errorf("Clone() = %+v", clone)
+	}
+}
+
+func TestProcessingInstructionNode(t *testing.T) {
+	pi := NewProcessingInstruction("xml-stylesheet", `href="a.css"`)
+	if pi.Type() != ProcessingInstructionNodeType {
+		t.Fatalf("Type() = %v", pi.Type())
+	}
+	pi.SetData("x")
+	if pi.Data != "x" || pi.Length() != 1 {
+		t.Errorf("SetData: Data = %q", pi.Data)
+	}
+	clone := pi.Clone(false).(*ProcessingInstruction)
+	if clone.Target != "xml-stylesheet" || clone.Data != "x" {
+		t.Errorf("Clone() = %+v", clone)
+	}
+	if pi.ReplaceChild(nil, nil) != nil || pi.HasChildNodes() {
+		t.Error("processing instructions have no children")
+	}
+}
+
+// =============================================================================
+// Traversal Tests
+// =============================================================================
+
+func TestTraversal(t *testing.T) {
+	doc := NewDocument()
+	span := el("span")
+	svgA := NewElementNS("a", NamespaceSVG)
+	svgA.AppendChild(span)
+	list := appendAll(el("ul"), appendAll(el("li", "id", "1"), NewText("x")), el("li", "id", "2"))
+	html := appendAll(el("html"), appendAll(el("body"), appendAll(el("a"), svgA), list))
+	doc.AppendChild(html)
+
+	var tags []string
+	WalkElements(doc, func(e *Element) bool {
+		tags = append(tags, e.TagName)
+		return e.TagName != "ul"
+	})
+	if got := strings.Join(tags, " "); got != "html body a a span ul" {
+		t.Errorf("WalkElements visited %q", got)
+	}
+
+	if got := ChildElements(list); len(got) != 2 || got[0].ID() != "1" || got[1].ID() != "2" {
+		t.Errorf("ChildElements = %v", got)
+	}
+	if span.ParentElement() != svgA || html.ParentElement() != nil {
+		t.Error("ParentElement returned the wrong parent")
+	}
+	if anc := span.Ancestor("a"); anc == nil || anc.Namespace != NamespaceHTML {
+		t.Errorf("Ancestor(a) = %v, want the HTML a element", anc)
+	}
+	if span.Ancestor("ul") != nil {
+		t.Error("Ancestor(ul) found an element that is not an ancestor")
+	}
+	if Root(span) != doc {
+		t.Error("Root of a connected element is not its document")
+	}
+	list.Parent().RemoveChild(list)
+	if Root(list.Children()[0]) != list {
+		t.Error("Root of a detached subtree is not its topmost node")
+	}
+}
@@ -22,12 +22,25 @@ type Element struct {
 	// For HTML elements, this is NamespaceHTML.
 	Namespace string
 
-	// Attributes contains the element's attributes.
+	// Attributes contains the element's attributes. Changes made through it
+	// keep the document's element index and mutation observers up to date.
+	// Each element needs its own Attributes: one that is assigned here or
+	// shared with another element after the element was indexed only
+	// reports changes once the element is inserted again, changed with
+	// SetAttr or RemoveAttr, or returned by an index lookup.
 	Attributes *Attributes
 
 	// TemplateContent holds the content of <template> elements.
 	// This is nil for non-template elements.
 	TemplateContent *DocumentFragment
+
+	// formOwner is the form the parser associated the element with, see
+	// SetFormOwner.
+	formOwner *Element
+
+	// line and column are the source position of the element's start tag,
+	// see Position.
+	line, column int
 }
 
 // NewElement creates a new element with the given tag name.
@@ -38,6 +51,7 @@ func NewElement(tagName string) *Element {
 		Attributes: NewAttributes(),
 	}
 	e.init(e)
+	e.Attributes.owner = e
 	return e
 }
 
@@ -49,6 +63,7 @@ func NewElementNS(tagName, namespace string) *Element {
 		Attributes: NewAttributes(),
 	}
 	e.init(e)
+	e.Attributes.owner = e
 	return e
 }
 
@@ -65,6 +80,8 @@ func (e *Element) Clone(deep bool) Node {
 		Attributes: e.Attributes.Clone(),
 	}
 	clone.init(clone)
+	clone.Attributes.owner = clone
+	clone.line, clone.column = e.line, e.column
 
 	if deep {
 		for _, child := range e.children {
@@ -79,56 +96,35 @@ func (e *Element) Clone(deep bool) Node {
 	return clone
 }
 
-// AppendChild adds a child node, properly setting the parent.
-func (e *Element) AppendChild(child Node) {
-	child.SetParent(e)
-	e.children = append(e.children, child)
-}
-
-// InsertBefore inserts a new child before a reference child.
-func (e *Element) InsertBefore(newChild, refChild Node) {
-	if refChild == nil {
-		e.AppendChild(newChild)
-		return
-	}
-
-	for i, child := range e.children {
-		if child == refChild {
-			newChild.SetParent(e)
-			e.children = append(e.children[:i], append([]Node{newChild}, e.children[i:]...)...)
-			return
-		}
-	}
-	e.AppendChild(newChild)
+// ParentElement returns the parent of e, or nil if e has no parent or its
+// parent is not an element.
+func (e *Element) ParentElement() *Element {
+	parent, _ := e.Parent().(*Element)
+	return parent
 }
 
-// RemoveChild removes a child node.
-func (e *Element) RemoveChild(child Node) {
-	for i, c := range e.children {
-		if c == child {
-			child.SetParent(nil)
-			e.children = append(e.children[:i], e.children[i+1:]...)
-			return
+// Ancestor returns the nearest ancestor of e that is an HTML element with
+// the given tag name, or nil if there is none.
+func (e *Element) Ancestor(tag string) *Element {
+	for anc := e.ParentElement(); anc != nil; anc = anc.ParentElement() {
+		if isHTML(anc, tag) {
+			return anc
 		}
 	}
+	return nil
 }
 
-// ReplaceChild replaces an old child with a new child.
-func (e *Element) ReplaceChild(newChild, oldChild Node) Node {
-	for i, c := range e.children {
-		if c == oldChild {
-			newChild.SetParent(e)
-			oldChild.SetParent(nil)
-			e.children[i] = newChild
-			return oldChild
-		}
-	}
-	return nil
+// Position returns the 1-based line and column of the "<" of the start tag
+// the parser created the element for. It returns 0, 0 for elements the
+// parser implied, such as a missing body, and for elements created by other
+// means.
+func (e *Element) Position() (line, column int) {
+	return e.line, e.column
 }
 
-// HasChildNodes returns true if this element has any children.
-func (e *Element) HasChildNodes() bool {
-	return len(e.children) > 0
+// SetPosition sets the source position Position returns.
+func (e *Element) SetPosition(line, column int) {
+	e.line, e.column = line, column
 }
 
 // Query finds all descendant elements matching the CSS selector.
@@ -141,6 +137,31 @@ func (e *Element) QueryFirst(selectorStr string) (*Element, error) {
 	return selectorMatchFirst(e, selectorStr)
 }
 
+// GetElementsByClassName returns the descendant elements, in tree order, that
+// carry all of the whitespace-separated classes in classNames.
+func (e *Element) GetElementsByClassName(classNames string) []*Element {
+	classes := splitClassNames(classNames)
+	if len(classes) == 0 {
+		return nil
+	}
+	return collectDescendants(e, func(el *Element) bool {
+		return hasAllClasses(el, classes)
+	})
+}
+
+// GetElementsByTagName returns the descendant elements, in tree order, with
+// the given qualified name. HTML elements are matched case-insensitively and
+// "*" matches all elements.
+func (e *Element) GetElementsByTagName(name string) []*Element {
+	return collectDescendants(e, tagNameMatcher(name))
+}
+
+// GetElementsByTagNameNS returns the descendant elements, in tree order, with
+// the given namespace and local name. Either argument may be "*".
+func (e *Element) GetElementsByTagNameNS(namespace, localName string) []*Element {
+	return collectDescendants(e, tagNameNSMatcher(namespace, localName))
+}
+
 // Text returns the text content of this element and its descendants.
 func (e *Element) Text() string {
 	var sb strings.Builder
@@ -153,6 +174,8 @@ func (e *Element) collectText(sb *strings.Builder) {
 		switch c := child.(type) {
 		case *Text:
 			sb.WriteString(c.Data)
+		case *CDATASection:
+			sb.WriteString(c.Data)
 		case *Element:
 			c.collectText(sb)
 		}
@@ -172,14 +195,31 @@ func (e *Element) HasAttr(name string) bool {
 
 // SetAttr sets an attribute value.
 func (e *Element) SetAttr(name, value string) {
+	e.claimAttributes()
 	e.Attributes.Set(name, value)
 }
 
 // RemoveAttr removes an attribute.
 func (e *Element) RemoveAttr(name string) {
+	e.claimAttributes()
 	e.Attributes.Remove(name)
 }
 
+// claimAttributes makes e the owner of its Attributes, which may have been
+// replaced since e was created, so that their changes are reported. An
+// Attributes still owned by another element is copied first.
+func (e *Element) claimAttributes() {
+	a := e.Attributes
+	if a.owner == e {
+		return
+	}
+	if a.owner != nil && a.owner.Attributes == a {
+		a = a.Clone()
+		e.Attributes = a
+	}
+	a.owner = e
+}
+
 // ID returns the value of the id attribute.
 func (e *Element) ID() string {
 	return e.Attr("id")
@@ -0,0 +1,99 @@
+package dom
+
+// Equal reports whether a and b are equal according to the DOM's isEqualNode
+// algorithm: both nodes have the same type and the same node-specific data
+// (names, namespaces, identifiers, data), their attributes match regardless of
+// order, and their children are pairwise equal.
+//
+// Unlike isEqualNode, the contents of template elements are compared as well,
+// since they are part of the markup. A nil node is only equal to nil.
+func Equal(a, b Node) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if !equalNodeData(a, b) {
+		return false
+	}
+	return equalChildren(a.Children(), b.Children())
+}
+
+func equalNodeData(a, b Node) bool {
+	switch an := a.(type) {
+	case *Element:
+		bn, ok := b.(*Element)
+		if !ok || an.Namespace != bn.Namespace || an.TagName != bn.TagName {
+			return false
+		}
+		if !equalAttributes(an.Attributes, bn.Attributes) {
+			return false
+		}
+		if (an.TemplateContent == nil) != (bn.TemplateContent == nil) {
+			return false
+		}
+		return an.TemplateContent == nil || Equal(an.TemplateContent, bn.TemplateContent)
+	case *Text:
+		bn, ok := b.(*Text)
+		return ok && an.Data == bn.Data
+	case *Comment:
+		bn, ok := b.(*Comment)
+		return ok && an.Data == bn.Data
+	case *CDATASection:
+		bn, ok := b.(*CDATASection)
+		return ok && an.Data == bn.Data
+	case *ProcessingInstruction:
+		bn, ok := b.(*ProcessingInstruction)
+		return ok && an.Target == bn.Target && an.Data == bn.Data
+	case *DocumentType:
+		bn, ok := b.(*DocumentType)
+		return ok && equalDoctype(an, bn)
+	case *Document:
+		bn, ok := b.(*Document)
+		if !ok {
+			return false
+		}
+		if (an.Doctype == nil) != (bn.Doctype == nil) {
+			return false
+		}
+		return an.Doctype == nil || equalDoctype(an.Doctype, bn.Doctype)
+	case *DocumentFragment:
+		_, ok := b.(*DocumentFragment)
+		return ok
+	}
+	return false
+}
+
+func equalDoctype(a, b *DocumentType) bool {
+	return a.Name == b.Name && a.PublicID == b.PublicID && a.SystemID == b.SystemID
+}
+
+// equalAttributes compares two attribute lists without regard to order.
+func equalAttributes(a, b *Attributes) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+	for _, attr := range a.items {
+		found := false
+		for _, other := range b.items {
+			if attr.Namespace == other.Namespace && attr.Name == other.Name {
+				found = attr.Value == other.Value
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func equalChildren(a, b []Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
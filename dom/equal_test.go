@@ -0,0 +1,115 @@
+package dom
+
+import "testing"
+
+func TestEqualAttributeOrder(t *testing.T) {
+	a := NewElement("div")
+	a.SetAttr("id", "x")
+	a.SetAttr("class", "y")
+	a.AppendChild(NewText("hi"))
+
+	b := NewElement("div")
+	b.SetAttr("class", "y")
+	b.SetAttr("id", "x")
+	b.AppendChild(NewText("hi"))
+
+	if !Equal(a, b) {
+		t.Fatal("elements differing only in attribute order should be equal")
+	}
+
+	b.SetAttr("id", "z")
+	if Equal(a, b) {
+		t.Error("elements with different attribute values should not be equal")
+	}
+	b.SetAttr("id", "x")
+	b.Attributes.SetNS("http://www.w3.org/1999/xlink", "xlink:href", "#a")
+	if Equal(a, b) {
+		t.Error("elements with a different number of attributes should not be equal")
+	}
+}
+
+func TestEqualNamespaces(t *testing.T) {
+	html := NewElement("title")
+	svg := NewElementNS("title", NamespaceSVG)
+	if Equal(html, svg) {
+		t.Error("elements in different namespaces should not be equal")
+	}
+
+	a := NewElementNS("use", NamespaceSVG)
+	a.Attributes.SetNS("http://www.w3.org/1999/xlink", "xlink:href", "#a")
+	b := NewElementNS("use", NamespaceSVG)
+	b.Attributes.SetNS("", "xlink:href", "#a")
+	if Equal(a, b) {
+		t.Error("attributes in different namespaces should not be equal")
+	}
+}
+
+func TestEqualNodeTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Node
+		want bool
+	}{
+		{"text", NewText("a"), NewText("a"), true},
+		{"text data", NewText("a"), NewText("b"), false},
+		{"text vs comment", NewText("a"), NewComment("a"), false},
+		{"comment", NewComment("a"), NewComment("a"), true},
+		{"cdata", NewCDATASection("a"), NewCDATASection("a"), true},
+		{"cdata vs text", NewCDATASection("a"), NewText("a"), false},
+		{"pi", NewProcessingInstruction("xml", "v"), NewProcessingInstruction("xml", "v"), true},
+		{"pi target", NewProcessingInstruction("xml", "v"), NewProcessingInstruction("php", "v"), false},
+		{"doctype", NewDocumentType("html", "", ""), NewDocumentType("html", "", ""), true},
+		{"doctype ids", NewDocumentType("html", "p", ""), NewDocumentType("html", "", ""), false},
+		{"fragment vs document", NewDocumentFragment(), NewDocument(), false},
+		{"nil", nil, nil, true},
+		{"nil vs node", NewText(""), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqualDocuments(t *testing.T) {
+	build := func(doctype bool, text string) *Document {
+		doc := NewDocument()
+		if doctype {
+			doc.Doctype = NewDocumentType("html", "", "")
+		}
+		html := NewElement("html")
+		body := NewElement("body")
+		body.AppendChild(NewText(text))
+		html.AppendChild(body)
+		doc.AppendChild(html)
+		return doc
+	}
+
+	a := build(true, "hello")
+	if !Equal(a, a.Clone(true)) {
+		t.Error("a document should equal its deep clone")
+	}
+	if Equal(a, build(false, "hello")) {
+		t.Error("documents with and without doctype should not be equal")
+	}
+	if Equal(a, build(true, "bye")) {
+		t.Error("documents with different text should not be equal")
+	}
+}
+
+func TestEqualTemplateContent(t *testing.T) {
+	build := func(text string) *Element {
+		tmpl := NewElement("template")
+		tmpl.TemplateContent = NewDocumentFragment()
+		tmpl.TemplateContent.AppendChild(NewText(text))
+		return tmpl
+	}
+	if !Equal(build("a"), build("a")) {
+		t.Error("templates with equal content should be equal")
+	}
+	if Equal(build("a"), build("b")) {
+		t.Error("templates with different content should not be equal")
+	}
+}
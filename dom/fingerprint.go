@@ -0,0 +1,39 @@
+package dom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+)
+
+// canonicalWriter is implemented by the serialize package and set via
+// SetCanonicalWriter. This breaks the circular dependency between dom and
+// serialize.
+var canonicalWriter func(w io.Writer, node Node) error
+
+// SetCanonicalWriter sets the function used by Document.Fingerprint to write
+// the canonical serialization of a node.
+// This is called by the serialize package during initialization.
+func SetCanonicalWriter(fn func(w io.Writer, node Node) error) {
+	canonicalWriter = fn
+}
+
+// Fingerprint returns the hex-encoded SHA-256 hash of the canonical
+// serialization of the document, as written by serialize.Canonical with the
+// default options. Documents whose markup differs only in ways the canonical
+// form normalizes, such as attribute order, character references, optional
+// tags or insignificant whitespace, have the same fingerprint.
+//
+// Fingerprint panics if the serialize package is not linked into the
+// program; importing the JustGoHTML package links it.
+func (d *Document) Fingerprint() string {
+	if canonicalWriter == nil {
+		panic("dom: Document.Fingerprint requires the serialize package")
+	}
+	h := sha256.New()
+	if err := canonicalWriter(h, d); err != nil {
+		// Writing to a hash never fails.
+		panic(err)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
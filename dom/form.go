@@ -0,0 +1,322 @@
+package dom
+
+import "strings"
+
+// Form is an HTML form element together with the controls associated with
+// it.
+type Form struct {
+	// Element is the form element.
+	Element *Element
+}
+
+// Forms returns the form elements of the document in tree order.
+func (d *Document) Forms() []*Form {
+	var forms []*Form
+	for _, elem := range d.GetElementsByTagName("form") {
+		if elem.Namespace == NamespaceHTML {
+			forms = append(forms, &Form{Element: elem})
+		}
+	}
+	return forms
+}
+
+// Elements returns the listed elements whose form owner is the form, in tree
+// order: button, fieldset, input, object, output, select and textarea
+// elements, including those outside the form element that name it in their
+// form attribute or that the parser associated with it.
+func (f *Form) Elements() []*Element {
+	var out []*Element
+	WalkElements(Root(f.Element), func(e *Element) bool {
+		if isListed(e) && e.Form() == f.Element {
+			out = append(out, e)
+		}
+		return true
+	})
+	return out
+}
+
+// SetFormOwner associates a form-associated element with a form, as the
+// parser does for controls that follow a form start tag but end up outside
+// the form element, such as inputs in a table after a form that was closed
+// early. Form uses the association while the element has no form attribute
+// and both are in the same tree. Passing nil removes the association.
+//
+// Inserting or removing the element or one of its ancestors drops the
+// association, as it resets the form owner in the HTML standard, so the
+// parser sets it after inserting the element. It is not copied by Clone.
+func (e *Element) SetFormOwner(form *Element) {
+	e.formOwner = form
+	if form == nil {
+		return
+	}
+	for n := Node(e); n != nil; n = n.Parent() {
+		b := container(n)
+		if b == nil || b.formOwners {
+			break
+		}
+		b.formOwners = true
+	}
+}
+
+// resetFormOwners drops the parser form associations in the subtree rooted
+// at n, whose ancestor chain changed. Subtrees without associations are not
+// walked.
+func resetFormOwners(n Node) {
+	b := container(n)
+	if b == nil || !b.formOwners {
+		return
+	}
+	b.formOwners = false
+	WalkElements(n, func(e *Element) bool {
+		e.formOwner = nil
+		e.formOwners = false
+		return true
+	})
+}
+
+// Form returns the form owner of a form-associated element: the form its
+// form attribute names, the form the parser associated it with (see
+// SetFormOwner), or its nearest form ancestor. It returns nil for elements
+// that are not form-associated or have no form owner.
+func (e *Element) Form() *Element {
+	if !isFormAssociated(e) {
+		return nil
+	}
+	if id, ok := e.Attributes.Get("form"); ok && isListed(e) {
+		var owner *Element
+		if id != "" {
+			WalkElements(Root(e), func(el *Element) bool {
+				if el.ID() == id {
+					owner = el
+					return false
+				}
+				return true
+			})
+		}
+		if owner != nil && isHTML(owner, "form") {
+			return owner
+		}
+		return nil
+	}
+	if e.formOwner != nil && Root(e.formOwner) == Root(e) {
+		return e.formOwner
+	}
+	for anc := e.Parent(); anc != nil; anc = anc.Parent() {
+		if elem, ok := anc.(*Element); ok && isHTML(elem, "form") {
+			return elem
+		}
+	}
+	return nil
+}
+
+// Action returns the URL the form submits to: the formaction of the
+// submitter or the form's action, resolved against the document's base URL.
+// An empty action submits to the document's URL. submitter may be nil.
+func (f *Form) Action(submitter *Element) string {
+	action, ok := "", false
+	if submitter != nil {
+		action, ok = submitter.Attributes.Get("formaction")
+	}
+	if !ok {
+		action = f.Element.Attr("action")
+	}
+	doc := ownerDocument(f.Element)
+	if strings.TrimSpace(action) == "" {
+		if doc != nil {
+			return doc.URL
+		}
+		return ""
+	}
+	if doc != nil {
+		if u, ok := doc.ResolveURL(action); ok {
+			return u
+		}
+	}
+	return action
+}
+
+// Method returns the submission method, "get", "post" or "dialog", from the
+// formmethod of the submitter or the form's method. submitter may be nil.
+func (f *Form) Method(submitter *Element) string {
+	return f.enumeratedAttr(submitter, "method", "get", "get", "post", "dialog")
+}
+
+// Enctype returns the encoding of the form data set from the formenctype of
+// the submitter or the form's enctype: "application/x-www-form-urlencoded",
+// "multipart/form-data" or "text/plain". submitter may be nil.
+func (f *Form) Enctype(submitter *Element) string {
+	return f.enumeratedAttr(submitter, "enctype", EnctypeURLEncoded,
+		EnctypeURLEncoded, EnctypeMultipart, EnctypeTextPlain)
+}
+
+// enumeratedAttr returns the value of the form's attribute name, or of the
+// submitter's "form"-prefixed override, if it is one of values, or def.
+func (f *Form) enumeratedAttr(submitter *Element, name, def string, values ...string) string {
+	value, ok := "", false
+	if submitter != nil {
+		value, ok = submitter.Attributes.Get("form" + name)
+	}
+	if !ok {
+		value, ok = f.Element.Attributes.Get(name)
+	}
+	if !ok {
+		return def
+	}
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, v := range values {
+		if value == v {
+			return v
+		}
+	}
+	return def
+}
+
+// isFormAssociated reports whether e is a form-associated element.
+func isFormAssociated(e *Element) bool {
+	return isListed(e) || isHTML(e, "img")
+}
+
+// isListed reports whether e is a listed element, one of those that form
+// elements collections contain.
+func isListed(e *Element) bool {
+	if e.Namespace != NamespaceHTML {
+		return false
+	}
+	switch e.TagName {
+	case "button", "fieldset", "input", "object", "output", "select", "textarea":
+		return true
+	}
+	return false
+}
+
+// isSubmittable reports whether e is a submittable element, one whose value
+// is part of the form data set.
+func isSubmittable(e *Element) bool {
+	if e.Namespace != NamespaceHTML {
+		return false
+	}
+	switch e.TagName {
+	case "button", "input", "select", "textarea":
+		return true
+	}
+	return false
+}
+
+// isSubmitButton reports whether e is a submit button: a button whose type
+// is submit, or an input of type submit or image.
+func isSubmitButton(e *Element) bool {
+	switch {
+	case isHTML(e, "button"):
+		switch buttonType(e) {
+		case "reset", "button":
+			return false
+		}
+		return true
+	case isHTML(e, "input"):
+		t := inputType(e)
+		return t == "submit" || t == "image"
+	}
+	return false
+}
+
+// isButton reports whether e is a button: a button element or an input of
+// type submit, image, reset or button.
+func isButton(e *Element) bool {
+	if isHTML(e, "button") {
+		return true
+	}
+	if isHTML(e, "input") {
+		switch inputType(e) {
+		case "submit", "image", "reset", "button":
+			return true
+		}
+	}
+	return false
+}
+
+func buttonType(e *Element) string {
+	return strings.ToLower(strings.TrimSpace(e.Attr("type")))
+}
+
+// inputType returns the type of an input element, lowercased, with missing
+// and unknown types mapped to "text".
+func inputType(e *Element) string {
+	t := strings.ToLower(strings.TrimSpace(e.Attr("type")))
+	switch t {
+	case "hidden", "search", "tel", "url", "email", "password", "date",
+		"month", "week", "time", "datetime-local", "number", "range", "color",
+		"checkbox", "radio", "file", "submit", "image", "reset", "button":
+		return t
+	}
+	return "text"
+}
+
+// Disabled reports whether e is a disabled form control, as matched by the
+// :disabled pseudo-class: an optgroup or option with a disabled attribute or
+// an option in a disabled optgroup, or a button, input, select, textarea or
+// fieldset with a disabled attribute or inside a disabled fieldset, but not
+// inside that fieldset's first legend.
+func (e *Element) Disabled() bool {
+	if e.Namespace != NamespaceHTML {
+		return false
+	}
+	switch e.TagName {
+	case "optgroup":
+		return e.HasAttr("disabled")
+	case "option":
+		if e.HasAttr("disabled") {
+			return true
+		}
+		parent := e.ParentElement()
+		return parent != nil && isHTML(parent, "optgroup") && parent.HasAttr("disabled")
+	case "button", "input", "select", "textarea", "fieldset":
+	default:
+		return false
+	}
+	if e.HasAttr("disabled") {
+		return true
+	}
+	child := Node(e)
+	for anc := e.Parent(); anc != nil; child, anc = anc, anc.Parent() {
+		fieldset, ok := anc.(*Element)
+		if !ok || !isHTML(fieldset, "fieldset") || !fieldset.HasAttr("disabled") {
+			continue
+		}
+		if legend, ok := child.(*Element); ok && legend == firstLegend(fieldset) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func firstLegend(fieldset *Element) *Element {
+	for _, child := range fieldset.Children() {
+		if elem, ok := child.(*Element); ok && isHTML(elem, "legend") {
+			return elem
+		}
+	}
+	return nil
+}
+
+// direction returns "rtl" if the nearest dir attribute of e or its
+// ancestors is rtl, and "ltr" otherwise.
+func direction(e *Element) string {
+	for n := Node(e); n != nil; n = n.Parent() {
+		if elem, ok := n.(*Element); ok {
+			if dir, ok := elem.Attributes.Get("dir"); ok {
+				switch strings.ToLower(strings.TrimSpace(dir)) {
+				case "rtl":
+					return "rtl"
+				case "ltr":
+					return "ltr"
+				}
+			}
+		}
+	}
+	return "ltr"
+}
+
+func isHTML(e *Element, tag string) bool {
+	return e.Namespace == NamespaceHTML && e.TagName == tag
+}
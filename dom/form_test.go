@@ -0,0 +1,321 @@
+package dom
+
+import (
+	"strings"
+	"testing"
+)
+
+// el builds an HTML element with attributes given as name, value pairs.
+func el(tag string, attrs ...string) *Element {
+	e := NewElement(tag)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		e.SetAttr(attrs[i], attrs[i+1])
+	}
+	return e
+}
+
+// appendAll appends children to parent and returns parent.
+func appendAll(parent *Element, children ...Node) *Element {
+	for _, c := range children {
+		parent.AppendChild(c)
+	}
+	return parent
+}
+
+func entryStrings(entries []FormEntry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.Name + "=" + e.Value
+		if e.IsFile {
+			out[i] += " (file)"
+		}
+	}
+	return out
+}
+
+// buildForm builds a document with a form containing one control of each
+// kind, and returns the document, the form and its submit buttons.
+func buildForm() (*Document, *Form, *Element, *Element) {
+	doc := NewDocument()
+	doc.URL = "https://example.com/app/page"
+	body := el("body")
+	doc.AppendChild(appendAll(el("html"), el("head"), body))
+
+	submit := el("button", "name", "go", "value", "1")
+	image := el("input", "type", "image", "name", "map")
+	form := appendAll(el("form", "id", "f", "action", "submit", "method", "POST"),
+		el("input", "name", "q", "value", "a b\nc"),
+		el("input", "name", "nameless-value"),
+		el("input", "value", "no name"),
+		el("input", "name", "off", "type", "checkbox"),
+		el("input", "name", "on", "type", "checkbox", "checked", ""),
+		el("input", "name", "color", "type", "radio", "value", "red", "checked", ""),
+		el("input", "name", "dis", "disabled", ""),
+		appendAll(el("fieldset", "disabled", ""),
+			appendAll(el("legend"), el("input", "name", "in-legend", "value", "x")),
+			el("input", "name", "in-fieldset")),
+		el("input", "name", "_charset_", "type", "hidden"),
+		el("input", "name", "range", "type", "range", "max", "10"),
+		el("input", "name", "upload", "type", "file"),
+		appendAll(el("select", "name", "single"),
+			appendAll(el("option", "disabled", ""), NewText("Skipped")),
+			appendAll(el("option"), NewText("  First  option ")),
+			appendAll(el("option", "value", "2"), NewText("Second"))),
+		appendAll(el("select", "name", "multi", "multiple", ""),
+			el("option", "value", "m1", "selected", ""),
+			el("option", "value", "m2"),
+			el("option", "value", "m3", "selected", "")),
+		appendAll(el("textarea", "name", "notes", "dirname", "notes.dir"), NewText("line 1\nline 2")),
+		appendAll(el("datalist"), el("input", "name", "in-datalist")),
+		el("input", "type", "reset", "name", "reset"),
+		submit,
+		image,
+	)
+	body.AppendChild(form)
+	body.AppendChild(el("input", "name", "outside", "form", "f", "value", "o"))
+	body.AppendChild(el("input", "name", "elsewhere"))
+	return doc, &Form{Element: form}, submit, image
+}
+
+func TestFormEntryList(t *testing.T) {
+	_, form, submit, image := buildForm()
+	want := []string{
+		"q=a bc",
+		"nameless-value=",
+		"on=on",
+		"color=red",
+		"in-legend=x",
+		"_charset_=UTF-8",
+		"range=5",
+		"upload= (file)",
+		"single=First option",
+		"multi=m1",
+		"multi=m3",
+		"notes=line 1\nline 2",
+		"notes.dir=ltr",
+		"go=1",
+		"outside=o",
+	}
+	if got := entryStrings(form.EntryList(submit)); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("EntryList(submit) =\n%q\nwant\n%q", got, want)
+	}
+
+	got := entryStrings(form.EntryList(image))
+	if got[len(got)-3] != "map.x=0" || got[len(got)-2] != "map.y=0" {
+		t.Errorf("EntryList(image) = %q, want map.x and map.y", got)
+	}
+	for _, e := range entryStrings(form.EntryList(nil)) {
+		if strings.HasPrefix(e, "go=") || strings.HasPrefix(e, "map.") {
+			t.Errorf("EntryList(nil) contains button entry %q", e)
+		}
+	}
+}
+
+func TestFormOwner(t *testing.T) {
+	doc, form, _, _ := buildForm()
+	outside := doc.GetElementsByTagName("input")
+	last := outside[len(outside)-1]
+	if last.Form() != nil {
+		t.Errorf("unassociated input has form owner %v", last.Form())
+	}
+	last.SetFormOwner(form.Element)
+	if last.Form() != form.Element {
+		t.Errorf("SetFormOwner: Form() = %v", last.Form())
+	}
+	last.SetAttr("form", "missing")
+	if last.Form() != nil {
+		t.Errorf("form attribute naming no form: Form() = %v", last.Form())
+	}
+	if got := len(doc.Forms()); got != 1 {
+		t.Errorf("Forms() returned %d forms", got)
+	}
+	if el("p").Form() != nil {
+		t.Errorf("p has a form owner")
+	}
+
+	// Inserting the element drops the association: its owner is the nearest
+	// form ancestor again.
+	last.RemoveAttr("form")
+	last.SetFormOwner(form.Element)
+	other := el("form")
+	doc.Body().AppendChild(other)
+	other.AppendChild(last)
+	if last.Form() != other {
+		t.Errorf("after insertion: Form() = %v, want the new parent form", last.Form())
+	}
+
+	// Moving an ancestor drops it as well, and only subtrees holding an
+	// association are marked for the reset.
+	wrapper := el("div")
+	input := el("input")
+	appendAll(doc.Body(), wrapper)
+	appendAll(wrapper, input)
+	input.SetFormOwner(form.Element)
+	if !wrapper.formOwners || other.formOwners {
+		t.Errorf("formOwners marks: wrapper=%v other=%v", wrapper.formOwners, other.formOwners)
+	}
+	doc.Body().RemoveChild(wrapper)
+	appendAll(other, wrapper)
+	if input.Form() != other || wrapper.formOwners {
+		t.Errorf("after moving the parent: Form() = %v, want the new ancestor form", input.Form())
+	}
+}
+
+func TestFormSubmissionAttributes(t *testing.T) {
+	_, form, submit, _ := buildForm()
+	if got := form.Action(nil); got != "https://example.com/app/submit" {
+		t.Errorf("Action = %q", got)
+	}
+	if got := form.Method(nil); got != "post" {
+		t.Errorf("Method = %q", got)
+	}
+	if got := form.Enctype(nil); got != EnctypeURLEncoded {
+		t.Errorf("Enctype = %q", got)
+	}
+	submit.SetAttr("formaction", "/other")
+	submit.SetAttr("formmethod", "bogus")
+	submit.SetAttr("formenctype", "Multipart/Form-Data")
+	if got := form.Action(submit); got != "https://example.com/other" {
+		t.Errorf("Action(submit) = %q", got)
+	}
+	if got := form.Method(submit); got != "get" {
+		t.Errorf("Method(submit) = %q", got)
+	}
+	if got := form.Enctype(submit); got != EnctypeMultipart {
+		t.Errorf("Enctype(submit) = %q", got)
+	}
+	form.Element.SetAttr("action", "")
+	if got := form.Action(nil); got != "https://example.com/app/page" {
+		t.Errorf("empty Action = %q", got)
+	}
+}
+
+func TestFormEncoding(t *testing.T) {
+	entries := []FormEntry{
+		{Name: "q", Value: "a b&c=d*~é"},
+		{Name: "text", Value: "one\ntwo\r\nthree"},
+		{Name: "f\"ile", IsFile: true, Filename: "a.txt", ContentType: "text/plain", Value: "data\n"},
+	}
+	if got, want := EncodeURLEncoded(entries), "q=a+b%26c%3Dd*%7E%C3%A9&text=one%0D%0Atwo%0D%0Athree&f%22ile=a.txt"; got != want {
+		t.Errorf("EncodeURLEncoded = %q, want %q", got, want)
+	}
+	if got, want := EncodeTextPlain(entries[:1]), "q=a b&c=d*~é\r\n"; got != want {
+		t.Errorf("EncodeTextPlain = %q, want %q", got, want)
+	}
+	body, contentType := EncodeMultipart(entries[1:], "XyZ")
+	want := "--XyZ\r\n" +
+		"Content-Disposition: form-data; name=\"text\"\r\n\r\none\r\ntwo\r\nthree\r\n" +
+		"--XyZ\r\n" +
+		"Content-Disposition: form-data; name=\"f%22ile\"; filename=\"a.txt\"\r\n" +
+		"Content-Type: text/plain\r\n\r\ndata\n\r\n" +
+		"--XyZ--\r\n"
+	if string(body) != want {
+		t.Errorf("EncodeMultipart body =\n%q\nwant\n%q", body, want)
+	}
+	if contentType != "multipart/form-data; boundary=XyZ" {
+		t.Errorf("EncodeMultipart content type = %q", contentType)
+	}
+	if _, ct := EncodeMultipart(nil, ""); !strings.Contains(ct, "boundary=----FormBoundary") {
+		t.Errorf("random boundary content type = %q", ct)
+	}
+}
+
+func TestFormEncode(t *testing.T) {
+	_, form, submit, _ := buildForm()
+	body, contentType := form.Encode(submit)
+	if contentType != EnctypeURLEncoded {
+		t.Errorf("content type = %q", contentType)
+	}
+	if !strings.HasPrefix(string(body), "q=a+bc&nameless-value=&on=on&color=red") {
+		t.Errorf("body = %q", body)
+	}
+	form.Element.SetAttr("enctype", "text/plain")
+	if body, contentType = form.Encode(nil); contentType != EnctypeTextPlain || !strings.HasPrefix(string(body), "q=a bc\r\n") {
+		t.Errorf("text/plain Encode = %q, %q", body, contentType)
+	}
+
+	// The get method always encodes the query string.
+	form.Element.SetAttr("method", "get")
+	form.Element.SetAttr("enctype", "multipart/form-data")
+	if body, contentType = form.Encode(nil); contentType != EnctypeURLEncoded || !strings.HasPrefix(string(body), "q=a+bc&") {
+		t.Errorf("get Encode = %q, %q", body, contentType)
+	}
+	submit.SetAttr("formmethod", "post")
+	if _, contentType = form.Encode(submit); !strings.HasPrefix(contentType, EnctypeMultipart) {
+		t.Errorf("formmethod=post content type = %q", contentType)
+	}
+}
+
+func TestFormRadioGroups(t *testing.T) {
+	doc := NewDocument()
+	form := appendAll(el("form"),
+		el("input", "type", "radio", "name", "a", "value", "1", "checked", ""),
+		el("input", "type", "radio", "name", "a", "value", "2", "checked", ""),
+		el("input", "type", "radio", "name", "a", "value", "3"),
+		el("input", "type", "radio", "name", "b", "value", "1", "checked", ""),
+		el("input", "type", "radio", "name", "b", "value", "2", "checked", "", "disabled", ""),
+		el("input", "type", "checkbox", "name", "c", "value", "1", "checked", ""),
+		el("input", "type", "checkbox", "name", "c", "value", "2", "checked", ""))
+	doc.AppendChild(appendAll(el("html"), appendAll(el("body"), form)))
+
+	got := entryStrings((&Form{Element: form}).EntryList(nil))
+	want := []string{"a=2", "c=1", "c=2"}
+	if strings.Join(got, "&") != strings.Join(want, "&") {
+		t.Errorf("EntryList = %q, want %q", got, want)
+	}
+}
+
+func TestSelectedOptions(t *testing.T) {
+	option := func(value string, attrs ...string) *Element {
+		return el("option", append([]string{"value", value}, attrs...)...)
+	}
+	tests := []struct {
+		name string
+		sel  *Element
+		want string
+	}{
+		{"first enabled", appendAll(el("select"), option("a", "disabled", ""), option("b"), option("c")), "b"},
+		{"last selected", appendAll(el("select"), option("a", "selected", ""), option("b", "selected", ""), option("c")), "b"},
+		{"disabled selected", appendAll(el("select"), option("a"), option("b", "selected", "", "disabled", "")), "b"},
+		{"disabled optgroup", appendAll(el("select"), appendAll(el("optgroup", "disabled", ""), option("a")), option("b")), "b"},
+		{"list box", appendAll(el("select", "size", "3"), option("a"), option("b")), ""},
+		{"multiple", appendAll(el("select", "multiple", ""), option("a", "selected", ""), option("b"), option("c", "selected", "")), "a c"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, option := range tt.sel.SelectedOptions() {
+			got = append(got, option.Attr("value"))
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s: SelectedOptions = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	legendInput := el("input")
+	fieldsetInput := el("input")
+	option := el("option")
+	groupOption := el("option")
+	appendAll(el("fieldset", "disabled", ""),
+		appendAll(el("legend"), legendInput),
+		fieldsetInput,
+		appendAll(el("select"), option, appendAll(el("optgroup", "disabled", ""), groupOption)))
+	tests := []struct {
+		name string
+		elem *Element
+		want bool
+	}{
+		{"disabled attribute", el("button", "disabled", ""), true},
+		{"not a control", el("div", "disabled", ""), false},
+		{"in first legend", legendInput, false},
+		{"in disabled fieldset", fieldsetInput, true},
+		{"option in disabled fieldset", option, false},
+		{"option in disabled optgroup", groupOption, true},
+	}
+	for _, tt := range tests {
+		if got := tt.elem.Disabled(); got != tt.want {
+			t.Errorf("%s: Disabled = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
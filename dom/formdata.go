@@ -0,0 +1,353 @@
+package dom
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// Form encodings, the values of the enctype attribute.
+const (
+	EnctypeURLEncoded = "application/x-www-form-urlencoded"
+	EnctypeMultipart  = "multipart/form-data"
+	EnctypeTextPlain  = "text/plain"
+)
+
+// FormEntry is an entry of a form data set.
+type FormEntry struct {
+	// Name is the name of the entry.
+	Name string
+
+	// Value is the value of the entry. For file entries it holds the file
+	// contents.
+	Value string
+
+	// IsFile marks the entry of a file input. The document holds no files,
+	// so EntryList gives file inputs an entry with an empty Filename and
+	// Value; callers fill them in before encoding.
+	IsFile bool
+
+	// Filename is the name of the file of a file entry.
+	Filename string
+
+	// ContentType is the media type of the file of a file entry.
+	ContentType string
+}
+
+// EntryList returns the form data set of the form, following the
+// "constructing the entry list" algorithm of the HTML standard: the values
+// of its submittable controls in tree order, skipping disabled controls,
+// controls without a name, unchecked checkboxes and radio buttons, and
+// buttons other than the submitter.
+//
+// The state of the controls is taken from their attributes: checked for
+// checkedness, where only the last checked radio button of a group counts,
+// selected for options, value for input values and the text
+// content for textareas. submitter is the submit button used to submit the
+// form, or nil; other elements are ignored.
+func (f *Form) EntryList(submitter *Element) []FormEntry {
+	if submitter != nil && (!isSubmitButton(submitter) || submitter.Form() != f.Element) {
+		submitter = nil
+	}
+	var entries []FormEntry
+	add := func(name, value string) {
+		entries = append(entries, FormEntry{Name: name, Value: value})
+	}
+	fields := f.Elements()
+	checkedRadios := checkedRadioButtons(fields)
+	for _, field := range fields {
+		if !isSubmittable(field) || field.Ancestor("datalist") != nil || field.Disabled() {
+			continue
+		}
+		if isButton(field) && field != submitter {
+			continue
+		}
+		typ := ""
+		if field.TagName == "input" {
+			typ = inputType(field)
+		}
+		if (typ == "checkbox" && !field.HasAttr("checked")) || (typ == "radio" && checkedRadios[field.Attr("name")] != field) {
+			continue
+		}
+		name := field.Attr("name")
+		if typ == "image" {
+			// The coordinates of the click, which a document cannot tell.
+			prefix := ""
+			if name != "" {
+				prefix = name + "."
+			}
+			add(prefix+"x", "0")
+			add(prefix+"y", "0")
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		switch {
+		case field.TagName == "select":
+			for _, option := range field.SelectedOptions() {
+				if !option.Disabled() {
+					add(name, optionValue(option))
+				}
+			}
+		case typ == "checkbox" || typ == "radio":
+			value, ok := field.Attributes.Get("value")
+			if !ok {
+				value = "on"
+			}
+			add(name, value)
+		case typ == "file":
+			entries = append(entries, FormEntry{
+				Name:        name,
+				IsFile:      true,
+				ContentType: "application/octet-stream",
+			})
+		case typ == "hidden" && strings.EqualFold(name, "_charset_"):
+			add(name, "UTF-8")
+		case field.TagName == "textarea":
+			add(name, field.Text())
+		default:
+			add(name, inputValue(field, typ))
+		}
+		if dirname := field.Attr("dirname"); dirname != "" &&
+			(field.TagName == "textarea" || typ == "text" || typ == "search") {
+			add(dirname, direction(field))
+		}
+	}
+	return entries
+}
+
+// Encode returns the form data set for submitter encoded with the form's
+// enctype (see Enctype), and the matching Content-Type. For the get method
+// the enctype is ignored: the data set is always
+// application/x-www-form-urlencoded, as it becomes the query string of the
+// action URL. submitter may be nil.
+func (f *Form) Encode(submitter *Element) (body []byte, contentType string) {
+	entries := f.EntryList(submitter)
+	if f.Method(submitter) == "get" {
+		return []byte(EncodeURLEncoded(entries)), EnctypeURLEncoded
+	}
+	switch f.Enctype(submitter) {
+	case EnctypeMultipart:
+		return EncodeMultipart(entries, "")
+	case EnctypeTextPlain:
+		return []byte(EncodeTextPlain(entries)), EnctypeTextPlain
+	}
+	return []byte(EncodeURLEncoded(entries)), EnctypeURLEncoded
+}
+
+// EncodeURLEncoded encodes entries as application/x-www-form-urlencoded.
+// File entries contribute their filename.
+func EncodeURLEncoded(entries []FormEntry) string {
+	var sb strings.Builder
+	for i, e := range entries {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		value := e.Value
+		if e.IsFile {
+			value = e.Filename
+		}
+		writeURLEncoded(&sb, normalizeNewlines(e.Name))
+		sb.WriteByte('=')
+		writeURLEncoded(&sb, normalizeNewlines(value))
+	}
+	return sb.String()
+}
+
+// writeURLEncoded writes s with the application/x-www-form-urlencoded
+// percent-encode set: spaces become "+", and all bytes other than ASCII
+// alphanumerics and "*-._" are percent-encoded.
+func writeURLEncoded(sb *strings.Builder, s string) {
+	const hexDigits = "0123456789ABCDEF"
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ':
+			sb.WriteByte('+')
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '*', c == '-', c == '.', c == '_':
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte('%')
+			sb.WriteByte(hexDigits[c>>4])
+			sb.WriteByte(hexDigits[c&0xF])
+		}
+	}
+}
+
+// EncodeMultipart encodes entries as multipart/form-data and returns the
+// body and the Content-Type header value. An empty boundary is replaced by
+// a random one.
+func EncodeMultipart(entries []FormEntry, boundary string) (body []byte, contentType string) {
+	if boundary == "" {
+		boundary = randomBoundary()
+	}
+	var sb strings.Builder
+	for _, e := range entries {
+		sb.WriteString("--" + boundary + "\r\n")
+		sb.WriteString(`Content-Disposition: form-data; name="` + escapeMultipartName(normalizeNewlines(e.Name)) + `"`)
+		if e.IsFile {
+			sb.WriteString(`; filename="` + escapeMultipartName(e.Filename) + `"` + "\r\n")
+			contentType := e.ContentType
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			sb.WriteString("Content-Type: " + contentType + "\r\n\r\n")
+			sb.WriteString(e.Value)
+		} else {
+			sb.WriteString("\r\n\r\n")
+			sb.WriteString(normalizeNewlines(e.Value))
+		}
+		sb.WriteString("\r\n")
+	}
+	sb.WriteString("--" + boundary + "--\r\n")
+	return []byte(sb.String()), EnctypeMultipart + "; boundary=" + boundary
+}
+
+// escapeMultipartName escapes a field name or filename for a
+// Content-Disposition header the way browsers do.
+func escapeMultipartName(s string) string {
+	return strings.NewReplacer("\n", "%0A", "\r", "%0D", `"`, "%22").Replace(s)
+}
+
+func randomBoundary() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return "----FormBoundary" + hex.EncodeToString(b[:])
+}
+
+// EncodeTextPlain encodes entries as text/plain: one "name=value" line per
+// entry. File entries contribute their filename.
+func EncodeTextPlain(entries []FormEntry) string {
+	var sb strings.Builder
+	for _, e := range entries {
+		value := e.Value
+		if e.IsFile {
+			value = e.Filename
+		}
+		sb.WriteString(normalizeNewlines(e.Name) + "=" + normalizeNewlines(value) + "\r\n")
+	}
+	return sb.String()
+}
+
+// normalizeNewlines replaces CR, LF and CRLF with CRLF.
+func normalizeNewlines(s string) string {
+	if !strings.ContainsAny(s, "\r\n") {
+		return s
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+// checkedRadioButtons returns the checked radio button of each radio button
+// group among fields, the listed elements of a form, by name. Checking a
+// radio button unchecks the others in its group, so the last one with a
+// checked attribute wins.
+func checkedRadioButtons(fields []*Element) map[string]*Element {
+	checked := make(map[string]*Element)
+	for _, field := range fields {
+		if isHTML(field, "input") && inputType(field) == "radio" && field.HasAttr("checked") {
+			if name := field.Attr("name"); name != "" {
+				checked[name] = field
+			}
+		}
+	}
+	return checked
+}
+
+// SelectedOptions returns the selected options of the select element e, in
+// tree order, taking their selectedness from the selected attributes.
+// Without a multiple attribute at most one option is selected: the last one
+// with a selected attribute or, for a drop-down box, the first option that
+// is not disabled. Disabled options can be selected, but are not submitted.
+func (e *Element) SelectedOptions() []*Element {
+	var options []*Element
+	WalkElements(e, func(el *Element) bool {
+		if isHTML(el, "option") {
+			options = append(options, el)
+		}
+		return true
+	})
+	var selected []*Element
+	for _, option := range options {
+		if option.HasAttr("selected") {
+			selected = append(selected, option)
+		}
+	}
+	if e.HasAttr("multiple") {
+		return selected
+	}
+	if len(selected) > 0 {
+		return selected[len(selected)-1:]
+	}
+	if size, err := strconv.Atoi(strings.TrimSpace(e.Attr("size"))); err == nil && size > 1 {
+		return nil
+	}
+	for _, option := range options {
+		if !option.Disabled() {
+			return []*Element{option}
+		}
+	}
+	return nil
+}
+
+// optionValue returns the value attribute of an option, or its text with
+// whitespace collapsed.
+func optionValue(option *Element) string {
+	if value, ok := option.Attributes.Get("value"); ok {
+		return value
+	}
+	return strings.Join(strings.Fields(option.Text()), " ")
+}
+
+// inputValue returns the value of an input element of the given type: its
+// value attribute after the type's value sanitization.
+func inputValue(input *Element, typ string) string {
+	value := input.Attr("value")
+	stripNewlines := strings.NewReplacer("\r", "", "\n", "")
+	switch typ {
+	case "text", "search", "tel", "password":
+		return stripNewlines.Replace(value)
+	case "url", "email":
+		return strings.Trim(stripNewlines.Replace(value), " \t\n\f\r")
+	case "range":
+		minimum, maximum := parseNumber(input.Attr("min"), 0), parseNumber(input.Attr("max"), 100)
+		if maximum < minimum {
+			maximum = minimum
+		}
+		if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			return formatFloat(min(max(v, minimum), maximum))
+		}
+		return formatFloat(minimum + (maximum-minimum)/2)
+	case "color":
+		if isSimpleColor(value) {
+			return strings.ToLower(value)
+		}
+		return "#000000"
+	}
+	return value
+}
+
+// formatFloat formats a number for a form control value.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func parseNumber(s string, def float64) float64 {
+	if v, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+		return v
+	}
+	return def
+}
+
+// isSimpleColor reports whether s is a "#rrggbb" color.
+func isSimpleColor(s string) bool {
+	if len(s) != 7 || s[0] != '#' {
+		return false
+	}
+	_, err := hex.DecodeString(s[1:])
+	return err == nil
+}
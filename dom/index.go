@@ -0,0 +1,262 @@
+package dom
+
+import (
+	"sort"
+	"strings"
+)
+
+// elementSet is an unordered set of elements.
+type elementSet map[*Element]struct{}
+
+// elementIndex maps id, class and tag names to the elements of a document
+// carrying them. Once enabled via Document.EnableIndex it is maintained
+// incrementally by the node mutation methods and by attribute changes.
+type elementIndex struct {
+	ids     map[string]elementSet
+	classes map[string]elementSet
+	tags    map[string]elementSet
+}
+
+func newElementIndex() *elementIndex {
+	return &elementIndex{
+		ids:     make(map[string]elementSet),
+		classes: make(map[string]elementSet),
+		tags:    make(map[string]elementSet),
+	}
+}
+
+func (idx *elementIndex) addSubtree(n Node) {
+	WalkElements(n, func(e *Element) bool {
+		idx.addElement(e)
+		return true
+	})
+}
+
+func (idx *elementIndex) removeSubtree(n Node) {
+	WalkElements(n, func(e *Element) bool {
+		idx.removeElement(e)
+		return true
+	})
+}
+
+func (idx *elementIndex) addElement(e *Element) {
+	e.claimAttributes()
+	addToSet(idx.tags, e.TagName, e)
+	if id, ok := e.Attributes.Get("id"); ok && id != "" {
+		addToSet(idx.ids, id, e)
+	}
+	for _, class := range e.Classes() {
+		addToSet(idx.classes, class, e)
+	}
+}
+
+func (idx *elementIndex) removeElement(e *Element) {
+	removeFromSet(idx.tags, e.TagName, e)
+	if id, ok := e.Attributes.Get("id"); ok && id != "" {
+		removeFromSet(idx.ids, id, e)
+	}
+	for _, class := range e.Classes() {
+		removeFromSet(idx.classes, class, e)
+	}
+}
+
+// owned reports whether the elements of set still own their Attributes.
+// One that was replaced or shared after indexing has not reported its
+// changes, so the index may be stale.
+func owned(set elementSet) bool {
+	for e := range set {
+		if e.Attributes.owner != e {
+			return false
+		}
+	}
+	return true
+}
+
+// attributeChanged re-indexes e after its id or class attribute changed from
+// oldValue (if hadOld) to its current value.
+func (idx *elementIndex) attributeChanged(e *Element, name, oldValue string, hadOld bool) {
+	switch name {
+	case "id":
+		if hadOld && oldValue != "" {
+			removeFromSet(idx.ids, oldValue, e)
+		}
+		if id, ok := e.Attributes.Get("id"); ok && id != "" {
+			addToSet(idx.ids, id, e)
+		}
+	case "class":
+		if hadOld {
+			for _, class := range strings.Fields(oldValue) {
+				removeFromSet(idx.classes, class, e)
+			}
+		}
+		for _, class := range e.Classes() {
+			addToSet(idx.classes, class, e)
+		}
+	}
+}
+
+func addToSet(m map[string]elementSet, key string, e *Element) {
+	set := m[key]
+	if set == nil {
+		set = make(elementSet)
+		m[key] = set
+	}
+	set[e] = struct{}{}
+}
+
+func removeFromSet(m map[string]elementSet, key string, e *Element) {
+	set := m[key]
+	if set == nil {
+		return
+	}
+	delete(set, e)
+	if len(set) == 0 {
+		delete(m, key)
+	}
+}
+
+// WalkElements calls fn for n, if it is an element, and for the elements
+// below it, in tree order. Template contents are not part of the tree and
+// are skipped. If fn returns false, the walk stops and WalkElements returns
+// false.
+func WalkElements(n Node, fn func(*Element) bool) bool {
+	if e, ok := n.(*Element); ok {
+		if !fn(e) {
+			return false
+		}
+	}
+	for _, child := range n.Children() {
+		if !WalkElements(child, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// collectDescendants returns the descendant elements of root matching fn, in
+// tree order. root itself is never included.
+func collectDescendants(root Node, fn func(*Element) bool) []*Element {
+	var out []*Element
+	for _, child := range root.Children() {
+		WalkElements(child, func(e *Element) bool {
+			if fn(e) {
+				out = append(out, e)
+			}
+			return true
+		})
+	}
+	return out
+}
+
+// splitClassNames splits a getElementsByClassName argument into its class
+// names. It returns nil when the argument contains no class names.
+func splitClassNames(classNames string) []string {
+	return strings.Fields(classNames)
+}
+
+// hasAllClasses reports whether e carries every class in classes.
+func hasAllClasses(e *Element, classes []string) bool {
+	have := e.Classes()
+	for _, want := range classes {
+		found := false
+		for _, c := range have {
+			if c == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// tagNameMatcher returns a predicate implementing the DOM "list of elements
+// with qualified name" matching rules: "*" matches every element, HTML
+// elements are matched against the lowercased name and all other elements
+// against the name as given.
+func tagNameMatcher(name string) func(*Element) bool {
+	if name == "*" {
+		return func(*Element) bool { return true }
+	}
+	lower := strings.ToLower(name)
+	return func(e *Element) bool {
+		if e.Namespace == NamespaceHTML {
+			return e.TagName == lower
+		}
+		return e.TagName == name
+	}
+}
+
+// tagNameNSMatcher returns a predicate matching elements by namespace and
+// local name, where "*" acts as a wildcard for either.
+func tagNameNSMatcher(namespace, localName string) func(*Element) bool {
+	return func(e *Element) bool {
+		if namespace != "*" && e.Namespace != namespace {
+			return false
+		}
+		return localName == "*" || e.TagName == localName
+	}
+}
+
+// sortTreeOrder sorts elements of the same tree into tree order.
+func sortTreeOrder(elems []*Element) {
+	if len(elems) < 2 {
+		return
+	}
+	// Sibling positions are computed once per parent so that sorting many
+	// children of a wide parent stays linear in the number of children.
+	positions := make(map[Node]map[Node]int)
+	position := func(n Node) int {
+		parent := n.Parent()
+		if parent == nil {
+			return 0
+		}
+		pos, ok := positions[parent]
+		if !ok {
+			children := parent.Children()
+			pos = make(map[Node]int, len(children))
+			for i, c := range children {
+				pos[c] = i
+			}
+			positions[parent] = pos
+		}
+		return pos[n]
+	}
+
+	paths := make(map[*Element][]int, len(elems))
+	for _, e := range elems {
+		var path []int
+		for n := Node(e); n != nil; n = n.Parent() {
+			path = append(path, position(n))
+		}
+		// Reverse so the path runs from the root down to the element.
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+		paths[e] = path
+	}
+
+	sort.SliceStable(elems, func(i, j int) bool {
+		a, b := paths[elems[i]], paths[elems[j]]
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+}
+
+// setToSlice returns the members of set that satisfy keep, in tree order.
+func setToSlice(set elementSet, keep func(*Element) bool) []*Element {
+	out := make([]*Element, 0, len(set))
+	for e := range set {
+		if keep == nil || keep(e) {
+			out = append(out, e)
+		}
+	}
+	sortTreeOrder(out)
+	return out
+}
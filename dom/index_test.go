@@ -0,0 +1,230 @@
+package dom
+
+import (
+	"testing"
+)
+
+// buildLookupDocument builds:
+//
+//	<html><body>
+//	  <div id="a" class="x y"><p class="x"></p></div>
+//	  <svg:svg><svg:foreignObject id="b"></svg:foreignObject></svg:svg>
+//	  <p id="a" class="y"></p>
+//	</body></html>
+func buildLookupDocument() (*Document, map[string]*Element) {
+	doc := NewDocument()
+	html := NewElement("html")
+	body := NewElement("body")
+	div := NewElement("div")
+	div.SetAttr("id", "a")
+	div.SetAttr("class", "x y")
+	p1 := NewElement("p")
+	p1.SetAttr("class", "x")
+	svg := NewElementNS("svg", NamespaceSVG)
+	fo := NewElementNS("foreignObject", NamespaceSVG)
+	fo.SetAttr("id", "b")
+	p2 := NewElement("p")
+	p2.SetAttr("id", "a")
+	p2.SetAttr("class", "y")
+
+	doc.AppendChild(html)
+	html.AppendChild(body)
+	body.AppendChild(div)
+	div.AppendChild(p1)
+	body.AppendChild(svg)
+	svg.AppendChild(fo)
+	body.AppendChild(p2)
+
+	return doc, map[string]*Element{
+		"html": html, "body": body, "div": div, "p1": p1, "svg": svg, "fo": fo, "p2": p2,
+	}
+}
+
+func assertElements(t *testing.T, label string, got []*Element, want ...*Element) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %d elements, want %d", label, len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s[%d] = <%s>, want <%s>", label, i, got[i].TagName, want[i].TagName)
+		}
+	}
+}
+
+func TestDocumentLookups(t *testing.T) {
+	for _, indexed := range []bool{false, true} {
+		name := "walk"
+		if indexed {
+			name = "index"
+		}
+		t.Run(name, func(t *testing.T) {
+			doc, els := buildLookupDocument()
+			if indexed {
+				doc.EnableIndex()
+			}
+			if doc.IndexEnabled() != indexed {
+				t.Fatalf("IndexEnabled() = %v, want %v", doc.IndexEnabled(), indexed)
+			}
+
+			if got := doc.GetElementByID("a"); got != els["div"] {
+				t.Errorf("GetElementByID(a) = %v, want first div", got)
+			}
+			if got := doc.GetElementByID("b"); got != els["fo"] {
+				t.Errorf("GetElementByID(b) = %v, want foreignObject", got)
+			}
+			if got := doc.GetElementByID("missing"); got != nil {
+				t.Errorf("GetElementByID(missing) = %v, want nil", got)
+			}
+			if got := doc.GetElementByID(""); got != nil {
+				t.Errorf("GetElementByID(\"\") = %v, want nil", got)
+			}
+
+			assertElements(t, "class x", doc.GetElementsByClassName("x"), els["div"], els["p1"])
+			assertElements(t, "class y", doc.GetElementsByClassName("y"), els["div"], els["p2"])
+			assertElements(t, "class y x", doc.GetElementsByClassName(" y  x "), els["div"])
+			assertElements(t, "class empty", doc.GetElementsByClassName("  "))
+
+			assertElements(t, "tag P", doc.GetElementsByTagName("P"), els["p1"], els["p2"])
+			assertElements(t, "tag foreignObject", doc.GetElementsByTagName("foreignObject"), els["fo"])
+			assertElements(t, "tag foreignobject", doc.GetElementsByTagName("foreignobject"))
+			if got := len(doc.GetElementsByTagName("*")); got != 7 {
+				t.Errorf("GetElementsByTagName(*) returned %d elements, want 7", got)
+			}
+
+			assertElements(t, "ns svg *", doc.GetElementsByTagNameNS(NamespaceSVG, "*"), els["svg"], els["fo"])
+			assertElements(t, "ns * p", doc.GetElementsByTagNameNS("*", "p"), els["p1"], els["p2"])
+			assertElements(t, "ns html svg", doc.GetElementsByTagNameNS(NamespaceHTML, "svg"))
+		})
+	}
+}
+
+func TestDocumentIndexFollowsMutations(t *testing.T) {
+	doc, els := buildLookupDocument()
+	doc.EnableIndex()
+
+	// Removing a subtree removes all of its elements from the index.
+	els["body"].RemoveChild(els["div"])
+	if got := doc.GetElementByID("a"); got != els["p2"] {
+		t.Errorf("after removal GetElementByID(a) = %v, want p2", got)
+	}
+	assertElements(t, "class x after removal", doc.GetElementsByClassName("x"))
+
+	// Re-inserting it before p2 restores tree order.
+	els["body"].InsertBefore(els["div"], els["p2"])
+	assertElements(t, "id a after insert", []*Element{doc.GetElementByID("a")}, els["div"])
+	assertElements(t, "tag p after insert", doc.GetElementsByTagName("p"), els["p1"], els["p2"])
+
+	// Attribute changes are reflected.
+	els["p1"].SetAttr("id", "c")
+	els["p1"].SetAttr("class", "z")
+	if got := doc.GetElementByID("c"); got != els["p1"] {
+		t.Errorf("GetElementByID(c) = %v, want p1", got)
+	}
+	assertElements(t, "class x after change", doc.GetElementsByClassName("x"), els["div"])
+	assertElements(t, "class z", doc.GetElementsByClassName("z"), els["p1"])
+
+	els["p1"].RemoveAttr("id")
+	if got := doc.GetElementByID("c"); got != nil {
+		t.Errorf("GetElementByID(c) after RemoveAttr = %v, want nil", got)
+	}
+
+	// ReplaceChild swaps the indexed elements.
+	span := NewElement("span")
+	span.SetAttr("id", "b")
+	els["svg"].ReplaceChild(span, els["fo"])
+	if got := doc.GetElementByID("b"); got != span {
+		t.Errorf("GetElementByID(b) after replace = %v, want span", got)
+	}
+
+	// Detached subtrees are not indexed.
+	detached := NewElement("div")
+	detached.SetAttr("id", "d")
+	if got := doc.GetElementByID("d"); got != nil {
+		t.Errorf("detached element found: %v", got)
+	}
+
+	// Disabling the index falls back to tree walks with identical results.
+	indexedClass := doc.GetElementsByClassName("y")
+	doc.DisableIndex()
+	assertElements(t, "class y without index", doc.GetElementsByClassName("y"), indexedClass...)
+}
+
+func TestDocumentIndexSkipsTemplateContent(t *testing.T) {
+	doc, els := buildLookupDocument()
+	doc.EnableIndex()
+
+	tmpl := NewElement("template")
+	tmpl.TemplateContent = NewDocumentFragment()
+	inner := NewElement("div")
+	inner.SetAttr("id", "t")
+	tmpl.TemplateContent.AppendChild(inner)
+	els["body"].AppendChild(tmpl)
+
+	if got := doc.GetElementByID("t"); got != nil {
+		t.Errorf("template content should not be indexed, got %v", got)
+	}
+}
+
+func TestDocumentIndexReplacedAttributes(t *testing.T) {
+	doc, els := buildLookupDocument()
+	doc.EnableIndex()
+
+	// A lookup returning an element whose Attributes were replaced rebuilds
+	// the index, after which changes are reported again.
+	els["div"].Attributes = NewAttributes()
+	els["div"].Attributes.Set("id", "z")
+	if got := doc.GetElementByID("a"); got != els["p2"] {
+		t.Errorf("GetElementByID(a) = %v, want p2", got)
+	}
+	if got := doc.GetElementByID("z"); got != els["div"] {
+		t.Errorf("GetElementByID(z) = %v, want div", got)
+	}
+	els["div"].Attributes.Set("class", "w")
+	assertElements(t, "class w", doc.GetElementsByClassName("w"), els["div"])
+
+	// Shared Attributes are copied when the element is changed.
+	els["p2"].Attributes = els["p1"].Attributes
+	els["p2"].SetAttr("id", "s")
+	if got := doc.GetElementByID("s"); got != els["p2"] {
+		t.Errorf("GetElementByID(s) = %v, want p2", got)
+	}
+	if els["p1"].HasAttr("id") {
+		t.Error("changing p2 changed the attributes of p1")
+	}
+
+	// Attributes replaced before insertion are claimed on insertion.
+	span := NewElement("span")
+	span.Attributes = NewAttributes()
+	els["body"].AppendChild(span)
+	span.Attributes.Set("id", "n")
+	if got := doc.GetElementByID("n"); got != span {
+		t.Errorf("GetElementByID(n) = %v, want span", got)
+	}
+}
+
+func TestDocumentCloneKeepsIndex(t *testing.T) {
+	doc, _ := buildLookupDocument()
+	doc.EnableIndex()
+
+	clone := doc.Clone(true).(*Document)
+	if !clone.IndexEnabled() {
+		t.Fatal("clone should keep the index enabled")
+	}
+	got := clone.GetElementByID("b")
+	if got == nil || got.TagName != "foreignObject" {
+		t.Fatalf("clone GetElementByID(b) = %v", got)
+	}
+	if got == doc.GetElementByID("b") {
+		t.Error("clone index should reference cloned elements")
+	}
+}
+
+func TestElementLookups(t *testing.T) {
+	_, els := buildLookupDocument()
+	div := els["div"]
+
+	assertElements(t, "div class x", div.GetElementsByClassName("x"), els["p1"])
+	assertElements(t, "div tag p", div.GetElementsByTagName("p"), els["p1"])
+	assertElements(t, "body ns svg", els["body"].GetElementsByTagNameNS(NamespaceSVG, "foreignObject"), els["fo"])
+}
@@ -0,0 +1,368 @@
+package dom
+
+import "errors"
+
+// ErrInvalidObserverOptions is returned by MutationObserver.Observe when the
+// options do not ask for any kind of mutation.
+var ErrInvalidObserverOptions = errors.New("mutation observer options must include ChildList, Attributes or CharacterData")
+
+// MutationType identifies the kind of change described by a MutationRecord.
+type MutationType int
+
+// Mutation types as defined by the DOM specification.
+const (
+	ChildListMutation MutationType = iota
+	AttributesMutation
+	CharacterDataMutation
+)
+
+// String returns the DOM name of the mutation type.
+func (t MutationType) String() string {
+	names := [...]string{"childList", "attributes", "characterData"}
+	if t >= 0 && int(t) < len(names) {
+		return names[t]
+	}
+	return "unknown"
+}
+
+// MutationRecord describes a single change to the tree.
+type MutationRecord struct {
+	// Type is the kind of mutation.
+	Type MutationType
+
+	// Target is the node whose children, attributes or data changed.
+	Target Node
+
+	// AddedNodes and RemovedNodes list the children inserted into or removed
+	// from Target (child list mutations only).
+	AddedNodes   []Node
+	RemovedNodes []Node
+
+	// PreviousSibling and NextSibling are the siblings surrounding the added
+	// or removed nodes (child list mutations only).
+	PreviousSibling Node
+	NextSibling     Node
+
+	// AttributeName and AttributeNamespace identify the changed attribute
+	// (attribute mutations only).
+	AttributeName      string
+	AttributeNamespace string
+
+	// OldValue is the attribute value or character data before the change.
+	// It is only set when the observer asked for old values, and is empty for
+	// attributes that did not exist before.
+	OldValue string
+}
+
+// MutationObserverInit selects the mutations a MutationObserver is told about.
+type MutationObserverInit struct {
+	// ChildList observes insertion and removal of children.
+	ChildList bool
+
+	// Attributes observes attribute changes. It is implied by
+	// AttributeOldValue and AttributeFilter.
+	Attributes bool
+
+	// CharacterData observes changes to Text and Comment data. It is implied
+	// by CharacterDataOldValue.
+	CharacterData bool
+
+	// Subtree extends observation to all descendants of the target.
+	Subtree bool
+
+	// AttributeOldValue records the previous value of changed attributes.
+	AttributeOldValue bool
+
+	// CharacterDataOldValue records the previous data of changed nodes.
+	CharacterDataOldValue bool
+
+	// AttributeFilter restricts attribute observation to the listed
+	// (non-namespaced) attribute names. A nil filter observes all attributes.
+	AttributeFilter []string
+}
+
+// MutationCallback receives the records queued for an observer when
+// Document.DeliverMutations is called.
+type MutationCallback func(records []MutationRecord, observer *MutationObserver)
+
+// MutationObserver collects MutationRecords for the nodes it observes.
+//
+// Records are queued as mutations happen and handed to the callback by
+// Document.DeliverMutations, which plays the role of the browser's microtask
+// checkpoint. They can also be drained directly with TakeRecords.
+//
+// Only mutations of nodes connected to the observer's document are reported;
+// the DOM's transient observers for removed subtrees are not implemented.
+type MutationObserver struct {
+	doc      *Document
+	callback MutationCallback
+	records  []MutationRecord
+}
+
+// registeredObserver is an observer registration on a target node.
+type registeredObserver struct {
+	observer *MutationObserver
+	options  MutationObserverInit
+}
+
+// NewMutationObserver creates an observer for mutations within d. The
+// callback may be nil when records are only read with TakeRecords.
+func (d *Document) NewMutationObserver(callback MutationCallback) *MutationObserver {
+	o := &MutationObserver{doc: d, callback: callback}
+	d.mutationObservers = append(d.mutationObservers, o)
+	return o
+}
+
+// DeliverMutations invokes the callback of every observer of d that has
+// pending records, in observer creation order.
+func (d *Document) DeliverMutations() {
+	for _, o := range d.mutationObservers {
+		if len(o.records) == 0 || o.callback == nil {
+			continue
+		}
+		o.callback(o.TakeRecords(), o)
+	}
+}
+
+// Observe registers target for observation with the given options. Observing
+// a node that is already observed by o replaces its options.
+func (o *MutationObserver) Observe(target Node, options MutationObserverInit) error {
+	if options.AttributeOldValue || options.AttributeFilter != nil {
+		options.Attributes = true
+	}
+	if options.CharacterDataOldValue {
+		options.CharacterData = true
+	}
+	if !options.ChildList && !options.Attributes && !options.CharacterData {
+		return ErrInvalidObserverOptions
+	}
+
+	d := o.doc
+	if d.observerRegistry == nil {
+		d.observerRegistry = make(map[Node][]registeredObserver)
+	}
+	regs := d.observerRegistry[target]
+	for i := range regs {
+		if regs[i].observer == o {
+			regs[i].options = options
+			return nil
+		}
+	}
+	d.observerRegistry[target] = append(regs, registeredObserver{observer: o, options: options})
+	d.updateTracking()
+	return nil
+}
+
+// Disconnect stops o from observing any node and discards pending records.
+func (o *MutationObserver) Disconnect() {
+	d := o.doc
+	for target, regs := range d.observerRegistry {
+		kept := regs[:0]
+		for _, r := range regs {
+			if r.observer != o {
+				kept = append(kept, r)
+			}
+		}
+		if len(kept) == 0 {
+			delete(d.observerRegistry, target)
+		} else {
+			d.observerRegistry[target] = kept
+		}
+	}
+	o.records = nil
+	d.updateTracking()
+}
+
+// TakeRecords returns and clears the pending records of o.
+func (o *MutationObserver) TakeRecords() []MutationRecord {
+	records := o.records
+	o.records = nil
+	return records
+}
+
+// tracking reports whether d has an element index or observed nodes, which
+// the change hooks keep up to date.
+func (d *Document) tracking() bool {
+	return d.index != nil || len(d.observerRegistry) > 0
+}
+
+// updateTracking marks the nodes of d as tracked when d starts tracking
+// mutations, and unmarks them when it stops. The change hooks return early
+// for unmarked nodes instead of looking up the owner document, which would
+// walk to the root on every insertion.
+func (d *Document) updateTracking() {
+	if tracking := d.tracking(); tracking != d.tracked {
+		setTracked(d, tracking)
+	}
+}
+
+// container returns the baseNode of the node types that can have children,
+// or nil for the others.
+func container(n Node) *baseNode {
+	switch n := n.(type) {
+	case *Element:
+		return &n.baseNode
+	case *Document:
+		return &n.baseNode
+	case *DocumentFragment:
+		return &n.baseNode
+	}
+	return nil
+}
+
+// setTracked sets the tracked mark of n and its descendants, whose elements
+// claim their Attributes to report changes. Template contents are never
+// tracked, as they have no owner document.
+func setTracked(n Node, tracked bool) {
+	b := container(n)
+	if b == nil {
+		return
+	}
+	b.tracked = tracked
+	if e, ok := n.(*Element); ok && tracked {
+		e.claimAttributes()
+	}
+	for _, child := range b.children {
+		setTracked(child, tracked)
+	}
+}
+
+// childListChanged updates the owning document after added was inserted
+// into, or removed was detached from, n between prev and next.
+func (n *baseNode) childListChanged(added, removed, prev, next Node) {
+	if !n.tracked {
+		return
+	}
+	doc := ownerDocument(n.self)
+	if doc == nil {
+		return
+	}
+	if removed != nil {
+		setTracked(removed, false)
+	}
+	if added != nil {
+		setTracked(added, true)
+	}
+	if doc.index != nil {
+		if removed != nil {
+			doc.index.removeSubtree(removed)
+		}
+		if added != nil {
+			doc.index.addSubtree(added)
+		}
+	}
+	if len(doc.observerRegistry) > 0 {
+		rec := MutationRecord{
+			Type:            ChildListMutation,
+			Target:          n.self,
+			PreviousSibling: prev,
+			NextSibling:     next,
+		}
+		if added != nil {
+			rec.AddedNodes = []Node{added}
+		}
+		if removed != nil {
+			rec.RemovedNodes = []Node{removed}
+		}
+		doc.queueMutation(rec, "")
+	}
+}
+
+// attributeChanged is called by the element's Attributes after a change.
+// hadOld reports whether the attribute existed before the change.
+func (e *Element) attributeChanged(namespace, name, oldValue string, hadOld bool) {
+	if !e.tracked {
+		return
+	}
+	doc := ownerDocument(e)
+	if doc == nil {
+		return
+	}
+	if doc.index != nil && namespace == "" && (name == "id" || name == "class") {
+		doc.index.attributeChanged(e, name, oldValue, hadOld)
+	}
+	if len(doc.observerRegistry) > 0 {
+		doc.queueMutation(MutationRecord{
+			Type:               AttributesMutation,
+			Target:             e,
+			AttributeName:      name,
+			AttributeNamespace: namespace,
+		}, oldValue)
+	}
+}
+
+// characterDataChanged is called by Text and Comment after their data
+// changed from oldValue.
+func characterDataChanged(target Node, oldValue string) {
+	if parent := container(target.Parent()); parent == nil || !parent.tracked {
+		return
+	}
+	doc := ownerDocument(target)
+	if doc == nil || len(doc.observerRegistry) == 0 {
+		return
+	}
+	doc.queueMutation(MutationRecord{
+		Type:   CharacterDataMutation,
+		Target: target,
+	}, oldValue)
+}
+
+// queueMutation implements the DOM "queue a mutation record" algorithm: rec
+// is delivered once to every observer registered on an inclusive ancestor of
+// its target whose options match. oldValue is attached for observers that
+// asked for it.
+func (d *Document) queueMutation(rec MutationRecord, oldValue string) {
+	var interested []*MutationObserver
+	wantsOld := make(map[*MutationObserver]bool)
+
+	for node := rec.Target; node != nil; node = node.Parent() {
+		for _, r := range d.observerRegistry[node] {
+			opts := r.options
+			if node != rec.Target && !opts.Subtree {
+				continue
+			}
+			switch rec.Type {
+			case ChildListMutation:
+				if !opts.ChildList {
+					continue
+				}
+			case AttributesMutation:
+				if !opts.Attributes {
+					continue
+				}
+				if opts.AttributeFilter != nil && (rec.AttributeNamespace != "" || !containsString(opts.AttributeFilter, rec.AttributeName)) {
+					continue
+				}
+			case CharacterDataMutation:
+				if !opts.CharacterData {
+					continue
+				}
+			}
+			if _, seen := wantsOld[r.observer]; !seen {
+				interested = append(interested, r.observer)
+				wantsOld[r.observer] = false
+			}
+			if (rec.Type == AttributesMutation && opts.AttributeOldValue) ||
+				(rec.Type == CharacterDataMutation && opts.CharacterDataOldValue) {
+				wantsOld[r.observer] = true
+			}
+		}
+	}
+
+	for _, o := range interested {
+		r := rec
+		if wantsOld[o] {
+			r.OldValue = oldValue
+		}
+		o.records = append(o.records, r)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
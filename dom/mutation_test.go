@@ -0,0 +1,248 @@
+package dom
+
+import (
+	"errors"
+	"testing"
+)
+
+func newObservedDocument() (*Document, *Element, *Element) {
+	doc := NewDocument()
+	html := NewElement("html")
+	body := NewElement("body")
+	doc.AppendChild(html)
+	html.AppendChild(body)
+	return doc, html, body
+}
+
+func TestMutationObserverChildList(t *testing.T) {
+	doc, _, body := newObservedDocument()
+	obs := doc.NewMutationObserver(nil)
+	if err := obs.Observe(body, MutationObserverInit{ChildList: true}); err != nil {
+		t.Fatalf("Observe() error = %v", err)
+	}
+
+	a := NewElement("a")
+	b := NewElement("b")
+	c := NewElement("i")
+	body.AppendChild(a)
+	body.AppendChild(c)
+	body.InsertBefore(b, c)
+	body.RemoveChild(b)
+	d := NewElement("span")
+	body.ReplaceChild(d, a)
+
+	records := obs.TakeRecords()
+	if len(records) != 5 {
+		t.Fatalf("got %d records, want 5", len(records))
+	}
+
+	check := func(i int, added, removed, prev, next Node) {
+		t.Helper()
+		r := records[i]
+		if r.Type != ChildListMutation || r.Target != body {
+			t.Errorf("record %d: type=%v target=%v", i, r.Type, r.Target)
+		}
+		if (added == nil) != (len(r.AddedNodes) == 0) || (added != nil && r.AddedNodes[0] != added) {
+			t.Errorf("record %d: AddedNodes = %v, want %v", i, r.AddedNodes, added)
+		}
+		if (removed == nil) != (len(r.RemovedNodes) == 0) || (removed != nil && r.RemovedNodes[0] != removed) {
+			t.Errorf("record %d: RemovedNodes = %v, want %v", i, r.RemovedNodes, removed)
+		}
+		if r.PreviousSibling != prev || r.NextSibling != next {
+			t.Errorf("record %d: siblings = (%v, %v), want (%v, %v)", i, r.PreviousSibling, r.NextSibling, prev, next)
+		}
+	}
+	check(0, a, nil, nil, nil)
+	check(1, c, nil, a, nil)
+	check(2, b, nil, a, c)
+	check(3, nil, b, a, c)
+	check(4, d, a, nil, c)
+
+	if got := obs.TakeRecords(); len(got) != 0 {
+		t.Errorf("TakeRecords should clear the queue, got %d records", len(got))
+	}
+}
+
+func TestMutationObserverSubtree(t *testing.T) {
+	doc, html, body := newObservedDocument()
+	shallow := doc.NewMutationObserver(nil)
+	deep := doc.NewMutationObserver(nil)
+	_ = shallow.Observe(html, MutationObserverInit{ChildList: true})
+	_ = deep.Observe(html, MutationObserverInit{ChildList: true, Subtree: true})
+
+	body.AppendChild(NewText("x"))
+
+	if got := len(shallow.TakeRecords()); got != 0 {
+		t.Errorf("non-subtree observer got %d records, want 0", got)
+	}
+	if got := len(deep.TakeRecords()); got != 1 {
+		t.Errorf("subtree observer got %d records, want 1", got)
+	}
+}
+
+func TestMutationObserverAttributes(t *testing.T) {
+	doc, _, body := newObservedDocument()
+	div := NewElement("div")
+	body.AppendChild(div)
+
+	withOld := doc.NewMutationObserver(nil)
+	filtered := doc.NewMutationObserver(nil)
+	_ = withOld.Observe(body, MutationObserverInit{Subtree: true, AttributeOldValue: true})
+	_ = filtered.Observe(div, MutationObserverInit{AttributeFilter: []string{"class"}})
+
+	div.SetAttr("id", "one")
+	div.SetAttr("id", "two")
+	div.Attributes.Set("class", "c")
+	div.RemoveAttr("id")
+	div.Attributes.SetNS("http://www.w3.org/1999/xlink", "xlink:href", "#")
+
+	records := withOld.TakeRecords()
+	want := []struct {
+		name, ns, old string
+	}{
+		{"id", "", ""},
+		{"id", "", "one"},
+		{"class", "", ""},
+		{"id", "", "two"},
+		{"xlink:href", "http://www.w3.org/1999/xlink", ""},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i, w := range want {
+		r := records[i]
+		if r.Type != AttributesMutation || r.Target != div || r.AttributeName != w.name ||
+			r.AttributeNamespace != w.ns || r.OldValue != w.old {
+			t.Errorf("record %d = %+v, want %+v", i, r, w)
+		}
+	}
+
+	records = filtered.TakeRecords()
+	if len(records) != 1 || records[0].AttributeName != "class" {
+		t.Fatalf("filtered records = %+v, want single class record", records)
+	}
+}
+
+func TestMutationObserverCharacterData(t *testing.T) {
+	doc, _, body := newObservedDocument()
+	text := NewText("hello")
+	comment := NewComment("note")
+	body.AppendChild(text)
+	body.AppendChild(comment)
+
+	plain := doc.NewMutationObserver(nil)
+	withOld := doc.NewMutationObserver(nil)
+	_ = plain.Observe(body, MutationObserverInit{CharacterData: true, Subtree: true})
+	_ = withOld.Observe(text, MutationObserverInit{CharacterDataOldValue: true})
+
+	text.SetData("world")
+	comment.SetData("changed")
+
+	records := plain.TakeRecords()
+	if len(records) != 2 || records[0].Target != text || records[1].Target != comment {
+		t.Fatalf("plain records = %+v", records)
+	}
+	if records[0].OldValue != "" {
+		t.Errorf("OldValue should be empty when not requested, got %q", records[0].OldValue)
+	}
+
+	records = withOld.TakeRecords()
+	if len(records) != 1 || records[0].Type != CharacterDataMutation || records[0].OldValue != "hello" {
+		t.Fatalf("withOld records = %+v", records)
+	}
+	if text.Data != "world" || comment.Data != "changed" {
+		t.Errorf("SetData did not update data: %q, %q", text.Data, comment.Data)
+	}
+}
+
+func TestMutationObserverDeliverAndDisconnect(t *testing.T) {
+	doc, _, body := newObservedDocument()
+
+	var delivered [][]MutationRecord
+	obs := doc.NewMutationObserver(func(records []MutationRecord, o *MutationObserver) {
+		delivered = append(delivered, records)
+	})
+	_ = obs.Observe(body, MutationObserverInit{ChildList: true})
+
+	body.AppendChild(NewElement("p"))
+	body.AppendChild(NewElement("p"))
+	doc.DeliverMutations()
+	doc.DeliverMutations()
+
+	if len(delivered) != 1 || len(delivered[0]) != 2 {
+		t.Fatalf("delivered = %v, want one batch of 2 records", delivered)
+	}
+
+	body.AppendChild(NewElement("p"))
+	obs.Disconnect()
+	body.AppendChild(NewElement("p"))
+	doc.DeliverMutations()
+	if len(delivered) != 1 {
+		t.Errorf("disconnected observer should not receive records, got %d batches", len(delivered))
+	}
+}
+
+func TestMutationObserverInvalidOptions(t *testing.T) {
+	doc, _, body := newObservedDocument()
+	obs := doc.NewMutationObserver(nil)
+	if err := obs.Observe(body, MutationObserverInit{Subtree: true}); !errors.Is(err, ErrInvalidObserverOptions) {
+		t.Errorf("Observe() error = %v, want ErrInvalidObserverOptions", err)
+	}
+}
+
+func TestMutationObserverIgnoresDetachedNodes(t *testing.T) {
+	doc, _, body := newObservedDocument()
+	obs := doc.NewMutationObserver(nil)
+	_ = obs.Observe(body, MutationObserverInit{ChildList: true, Subtree: true, Attributes: true})
+
+	detached := NewElement("div")
+	detached.AppendChild(NewElement("span"))
+	detached.SetAttr("id", "x")
+
+	if got := len(obs.TakeRecords()); got != 0 {
+		t.Errorf("mutations of detached nodes produced %d records", got)
+	}
+}
+
+func TestMutationTrackingIsPerDocument(t *testing.T) {
+	doc, html, body := newObservedDocument()
+	other, otherHTML, _ := newObservedDocument()
+	obs := doc.NewMutationObserver(nil)
+	_ = obs.Observe(body, MutationObserverInit{Subtree: true, CharacterData: true})
+	other.EnableIndex()
+	other.DisableIndex()
+
+	if !html.tracked || !body.tracked {
+		t.Error("nodes of an observed document are not tracked")
+	}
+	if other.tracked || otherHTML.tracked {
+		t.Error("nodes of a document without index or observers are tracked")
+	}
+
+	// A subtree built while detached is tracked once inserted.
+	div := NewElement("div")
+	text := NewText("a")
+	div.AppendChild(text)
+	body.AppendChild(div)
+	text.SetData("b")
+	if got := len(obs.TakeRecords()); got != 1 {
+		t.Errorf("change inside an inserted subtree produced %d records, want 1", got)
+	}
+
+	body.RemoveChild(div)
+	if div.tracked {
+		t.Error("removed subtree is still tracked")
+	}
+
+	obs.Disconnect()
+	if doc.tracked || html.tracked || body.tracked {
+		t.Error("nodes are still tracked after the last observer disconnected")
+	}
+}
+
+func TestMutationTypeString(t *testing.T) {
+	if ChildListMutation.String() != "childList" || AttributesMutation.String() != "attributes" ||
+		CharacterDataMutation.String() != "characterData" || MutationType(42).String() != "unknown" {
+		t.Error("unexpected MutationType names")
+	}
+}
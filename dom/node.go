@@ -6,11 +6,13 @@ type NodeType int
 
 // Node types as defined by the DOM specification.
 const (
-	ElementNodeType  NodeType = 1
-	TextNodeType     NodeType = 3
-	CommentNodeType  NodeType = 8
-	DocumentNodeType NodeType = 9
-	DoctypeNodeType  NodeType = 10
+	ElementNodeType               NodeType = 1
+	TextNodeType                  NodeType = 3
+	CDATASectionNodeType          NodeType = 4
+	ProcessingInstructionNodeType NodeType = 7
+	CommentNodeType               NodeType = 8
+	DocumentNodeType              NodeType = 9
+	DoctypeNodeType               NodeType = 10
 )
 
 // Node is the interface implemented by all DOM node types.
@@ -53,6 +55,14 @@ type baseNode struct {
 	self     Node
 	parent   Node
 	children []Node
+
+	// tracked marks nodes of a document whose mutations are tracked by an
+	// element index or mutation observers, see Document.updateTracking.
+	tracked bool
+
+	// formOwners marks nodes whose subtree may contain elements with a
+	// parser form owner, see Element.SetFormOwner.
+	formOwners bool
 }
 
 func (n *baseNode) init(self Node) {
@@ -75,7 +85,13 @@ func (n *baseNode) AppendChild(child Node) {
 	if n.self != nil {
 		child.SetParent(n.self)
 	}
+	var prev Node
+	if len(n.children) > 0 {
+		prev = n.children[len(n.children)-1]
+	}
 	n.children = append(n.children, child)
+	resetFormOwners(child)
+	n.childListChanged(child, nil, prev, nil)
 }
 
 func (n *baseNode) InsertBefore(newChild, refChild Node) {
@@ -89,7 +105,10 @@ func (n *baseNode) InsertBefore(newChild, refChild Node) {
 			if n.self != nil {
 				newChild.SetParent(n.self)
 			}
+			prev := n.previousSibling(i)
 			n.children = append(n.children[:i], append([]Node{newChild}, n.children[i:]...)...)
+			resetFormOwners(newChild)
+			n.childListChanged(newChild, nil, prev, refChild)
 			return
 		}
 	}
@@ -100,8 +119,11 @@ func (n *baseNode) InsertBefore(newChild, refChild Node) {
 func (n *baseNode) RemoveChild(child Node) {
 	for i, c := range n.children {
 		if c == child {
+			prev, next := n.previousSibling(i), n.nextSibling(i)
 			child.SetParent(nil)
 			n.children = append(n.children[:i], n.children[i+1:]...)
+			resetFormOwners(child)
+			n.childListChanged(nil, child, prev, next)
 			return
 		}
 	}
@@ -115,12 +137,61 @@ func (n *baseNode) ReplaceChild(newChild, oldChild Node) Node {
 			}
 			oldChild.SetParent(nil)
 			n.children[i] = newChild
+			resetFormOwners(newChild)
+			resetFormOwners(oldChild)
+			n.childListChanged(newChild, oldChild, n.previousSibling(i), n.nextSibling(i))
 			return oldChild
 		}
 	}
 	return nil
 }
 
+func (n *baseNode) previousSibling(i int) Node {
+	if i > 0 {
+		return n.children[i-1]
+	}
+	return nil
+}
+
+func (n *baseNode) nextSibling(i int) Node {
+	if i+1 < len(n.children) {
+		return n.children[i+1]
+	}
+	return nil
+}
+
 func (n *baseNode) HasChildNodes() bool {
 	return len(n.children) > 0
 }
+
+// ownerDocument returns the Document at the root of the tree containing n,
+// or nil if n is not connected to a document.
+func ownerDocument(n Node) *Document {
+	for n != nil {
+		if doc, ok := n.(*Document); ok {
+			return doc
+		}
+		n = n.Parent()
+	}
+	return nil
+}
+
+// Root returns the root of the tree containing n: its Document, or the
+// topmost ancestor of a tree that is not in a document.
+func Root(n Node) Node {
+	for n.Parent() != nil {
+		n = n.Parent()
+	}
+	return n
+}
+
+// ChildElements returns the children of n that are elements, in tree order.
+func ChildElements(n Node) []*Element {
+	var out []*Element
+	for _, child := range n.Children() {
+		if elem, ok := child.(*Element); ok {
+			out = append(out, elem)
+		}
+	}
+	return out
+}
@@ -13,6 +13,14 @@ func NewText(data string) *Text {
 	return &Text{Data: data}
 }
 
+// SetData replaces the text content and notifies mutation observers.
+// Assigning to Data directly bypasses observers.
+func (t *Text) SetData(data string) {
+	old := t.Data
+	t.Data = data
+	characterDataChanged(t, old)
+}
+
 // Type implements Node.
 func (t *Text) Type() NodeType {
 	return TextNodeType
@@ -66,6 +74,14 @@ func NewComment(data string) *Comment {
 	return &Comment{Data: data}
 }
 
+// SetData replaces the comment content and notifies mutation observers.
+// Assigning to Data directly bypasses observers.
+func (c *Comment) SetData(data string) {
+	old := c.Data
+	c.Data = data
+	characterDataChanged(c, old)
+}
+
 // Type implements Node.
 func (c *Comment) Type() NodeType {
 	return CommentNodeType
@@ -105,3 +121,142 @@ func (c *Comment) HasChildNodes() bool { return false }
 func (c *Comment) Clone(_ bool) Node {
 	return &Comment{Data: c.Data}
 }
+
+// CDATASection represents a CDATA section (<![CDATA[...]]>) in foreign
+// content. The parser only creates these nodes when asked to preserve CDATA
+// boundaries; by default CDATA content becomes Text.
+type CDATASection struct {
+	parent Node
+
+	// Data is the section content (without <![CDATA[ and ]]>).
+	Data string
+}
+
+// NewCDATASection creates a new CDATA section node.
+func NewCDATASection(data string) *CDATASection {
+	return &CDATASection{Data: data}
+}
+
+// SetData replaces the section content and notifies mutation observers.
+// Assigning to Data directly bypasses observers.
+func (c *CDATASection) SetData(data string) {
+	old := c.Data
+	c.Data = data
+	characterDataChanged(c, old)
+}
+
+// Length returns the length of the content in UTF-16 code units.
+func (c *CDATASection) Length() int {
+	return utf16Length(c.Data)
+}
+
+// Type implements Node.
+func (c *CDATASection) Type() NodeType {
+	return CDATASectionNodeType
+}
+
+// Parent implements Node.
+func (c *CDATASection) Parent() Node {
+	return c.parent
+}
+
+// SetParent implements Node.
+func (c *CDATASection) SetParent(parent Node) {
+	c.parent = parent
+}
+
+// Children implements Node (CDATA sections have no children).
+func (c *CDATASection) Children() []Node {
+	return nil
+}
+
+// AppendChild implements Node (no-op for CDATA sections).
+func (c *CDATASection) AppendChild(_ Node) {}
+
+// InsertBefore implements Node (no-op for CDATA sections).
+func (c *CDATASection) InsertBefore(_, _ Node) {}
+
+// RemoveChild implements Node (no-op for CDATA sections).
+func (c *CDATASection) RemoveChild(_ Node) {}
+
+// ReplaceChild implements Node (no-op for CDATA sections).
+func (c *CDATASection) ReplaceChild(_, _ Node) Node { return nil }
+
+// HasChildNodes implements Node (CDATA sections have no children).
+func (c *CDATASection) HasChildNodes() bool { return false }
+
+// Clone implements Node.
+func (c *CDATASection) Clone(_ bool) Node {
+	return &CDATASection{Data: c.Data}
+}
+
+// ProcessingInstruction represents a processing instruction (<?target data?>).
+// HTML documents only contain these when the parser is asked to preserve them
+// in foreign content; by default they become comments.
+type ProcessingInstruction struct {
+	parent Node
+
+	// Target is the application the instruction is directed at (e.g. "xml").
+	Target string
+
+	// Data is the instruction content following the target.
+	Data string
+}
+
+// NewProcessingInstruction creates a new processing instruction node.
+func NewProcessingInstruction(target, data string) *ProcessingInstruction {
+	return &ProcessingInstruction{Target: target, Data: data}
+}
+
+// SetData replaces the instruction content and notifies mutation observers.
+// Assigning to Data directly bypasses observers.
+func (pi *ProcessingInstruction) SetData(data string) {
+	old := pi.Data
+	pi.Data = data
+	characterDataChanged(pi, old)
+}
+
+// Length returns the length of the content in UTF-16 code units.
+func (pi *ProcessingInstruction) Length() int {
+	return utf16Length(pi.Data)
+}
+
+// Type implements Node.
+func (pi *ProcessingInstruction) Type() NodeType {
+	return ProcessingInstructionNodeType
+}
+
+// Parent implements Node.
+func (pi *ProcessingInstruction) Parent() Node {
+	return pi.parent
+}
+
+// SetParent implements Node.
+func (pi *ProcessingInstruction) SetParent(parent Node) {
+	pi.parent = parent
+}
+
+// Children implements Node (processing instructions have no children).
+func (pi *ProcessingInstruction) Children() []Node {
+	return nil
+}
+
+// AppendChild implements Node (no-op for processing instructions).
+func (pi *ProcessingInstruction) AppendChild(_ Node) {}
+
+// InsertBefore implements Node (no-op for processing instructions).
+func (pi *ProcessingInstruction) InsertBefore(_, _ Node) {}
+
+// RemoveChild implements Node (no-op for processing instructions).
+func (pi *ProcessingInstruction) RemoveChild(_ Node) {}
+
+// ReplaceChild implements Node (no-op for processing instructions).
+func (pi *ProcessingInstruction) ReplaceChild(_, _ Node) Node { return nil }
+
+// HasChildNodes implements Node (processing instructions have no children).
+func (pi *ProcessingInstruction) HasChildNodes() bool { return false }
+
+// Clone implements Node.
+func (pi *ProcessingInstruction) Clone(_ bool) Node {
+	return &ProcessingInstruction{Target: pi.Target, Data: pi.Data}
+}
@@ -0,0 +1,102 @@
+package dom
+
+import (
+	"net/url"
+	"strings"
+)
+
+// BaseURL returns the URL relative URLs in the document resolve against: the
+// href of the first base element that has one, resolved against the document
+// URL, or the document URL itself. It returns nil if neither is an absolute
+// URL.
+func (d *Document) BaseURL() *url.URL {
+	docURL := parseURL(nil, d.URL)
+	if base := firstBaseHref(d); base != nil {
+		if u := parseURL(docURL, base.Attr("href")); u != nil {
+			return u
+		}
+	}
+	return docURL
+}
+
+// firstBaseHref returns the first HTML base element with an href attribute
+// below n, in tree order.
+func firstBaseHref(n Node) *Element {
+	for _, child := range n.Children() {
+		elem, ok := child.(*Element)
+		if !ok {
+			continue
+		}
+		if elem.TagName == "base" && elem.Namespace == NamespaceHTML && elem.HasAttr("href") {
+			return elem
+		}
+		if found := firstBaseHref(elem); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// ResolveURL resolves ref against the document's base URL and returns the
+// absolute URL. It reports false if ref is not a valid URL, or is relative
+// and the document has no base URL.
+//
+// As in browsers, leading and trailing whitespace and control characters are
+// ignored, as are tabs and newlines within ref.
+func (d *Document) ResolveURL(ref string) (string, bool) {
+	return resolveURL(d.BaseURL(), ref)
+}
+
+// URLResolver returns a function that resolves URLs as ResolveURL does,
+// against the base URL d has when URLResolver is called. ResolveURL looks for
+// the base element on every call; use a resolver to resolve many URLs of a
+// document that does not change in between.
+func (d *Document) URLResolver() func(ref string) (string, bool) {
+	base := d.BaseURL()
+	return func(ref string) (string, bool) {
+		return resolveURL(base, ref)
+	}
+}
+
+// ResolvedAttr returns the value of the named attribute resolved as a URL
+// against the base URL of the element's document, as href or src are
+// resolved by browsers. It reports false if the attribute is missing or
+// cannot be resolved to an absolute URL.
+func (e *Element) ResolvedAttr(name string) (string, bool) {
+	value, ok := e.Attributes.Get(name)
+	if !ok {
+		return "", false
+	}
+	if doc := ownerDocument(e); doc != nil {
+		return doc.ResolveURL(value)
+	}
+	return resolveURL(nil, value)
+}
+
+// resolveURL resolves ref against base, which may be nil, and returns the
+// absolute URL as a string.
+func resolveURL(base *url.URL, ref string) (string, bool) {
+	u := parseURL(base, ref)
+	if u == nil {
+		return "", false
+	}
+	return u.String(), true
+}
+
+// parseURL parses ref relative to base, which may be nil, and returns the
+// resulting absolute URL, or nil if there is none.
+func parseURL(base *url.URL, ref string) *url.URL {
+	ref = strings.TrimFunc(ref, func(r rune) bool { return r <= ' ' })
+	ref = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(ref)
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if !u.IsAbs() {
+		return nil
+	}
+	return u
+}
@@ -0,0 +1,96 @@
+package dom
+
+import "testing"
+
+// buildURLDocument builds a document with the given URL and, if baseHref is
+// not empty, a base element:
+//
+//	<html><head><base href=baseHref></head><body><a href="../x?y#z"></a></body></html>
+func buildURLDocument(docURL, baseHref string) (*Document, *Element) {
+	doc := NewDocument()
+	doc.URL = docURL
+	html := NewElement("html")
+	head := NewElement("head")
+	body := NewElement("body")
+	a := NewElement("a")
+	a.SetAttr("href", "../x?y#z")
+	doc.AppendChild(html)
+	html.AppendChild(head)
+	html.AppendChild(body)
+	body.AppendChild(a)
+	if baseHref != "" {
+		base := NewElement("base")
+		base.SetAttr("href", baseHref)
+		head.AppendChild(base)
+	}
+	return doc, a
+}
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		docURL   string
+		baseHref string
+		ref      string
+		want     string
+		ok       bool
+	}{
+		{"document URL", "https://example.com/a/b/c.html", "", "d.html", "https://example.com/a/b/d.html", true},
+		{"parent path", "https://example.com/a/b/c.html", "", "../d", "https://example.com/a/d", true},
+		{"absolute ref", "https://example.com/", "", "http://other.example/x", "http://other.example/x", true},
+		{"base element", "https://example.com/a/", "https://cdn.example/assets/", "img.png", "https://cdn.example/assets/img.png", true},
+		{"relative base element", "https://example.com/a/b", "/static/", "img.png", "https://example.com/static/img.png", true},
+		{"base without document URL", "", "https://example.com/root/", "x", "https://example.com/root/x", true},
+		{"whitespace", "https://example.com/", "", " \tx\ny \n", "https://example.com/xy", true},
+		{"no base", "", "", "x", "", false},
+		{"invalid ref", "https://example.com/", "", "http://[::1", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, _ := buildURLDocument(tt.docURL, tt.baseHref)
+			got, ok := doc.ResolveURL(tt.ref)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ResolveURL(%q) = %q, %v, want %q, %v", tt.ref, got, ok, tt.want, tt.ok)
+			}
+			if got, ok := doc.URLResolver()(tt.ref); got != tt.want || ok != tt.ok {
+				t.Errorf("URLResolver()(%q) = %q, %v, want %q, %v", tt.ref, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestBaseURLIgnoresBaseWithoutHref(t *testing.T) {
+	doc, _ := buildURLDocument("https://example.com/page", "")
+	head := doc.Head()
+	head.AppendChild(NewElement("base"))
+	second := NewElement("base")
+	second.SetAttr("href", "https://second.example/")
+	head.AppendChild(second)
+	if got := doc.BaseURL().String(); got != "https://second.example/" {
+		t.Errorf("BaseURL = %q, want the first base element with an href", got)
+	}
+}
+
+func TestResolvedAttr(t *testing.T) {
+	doc, a := buildURLDocument("https://example.com/a/b/c", "")
+	if got, ok := a.ResolvedAttr("href"); !ok || got != "https://example.com/a/x?y#z" {
+		t.Errorf("ResolvedAttr(href) = %q, %v", got, ok)
+	}
+	if _, ok := a.ResolvedAttr("src"); ok {
+		t.Error("ResolvedAttr of a missing attribute should report false")
+	}
+
+	detached := NewElement("img")
+	detached.SetAttr("src", "rel.png")
+	if _, ok := detached.ResolvedAttr("src"); ok {
+		t.Error("relative URL of a detached element should not resolve")
+	}
+	detached.SetAttr("src", "https://example.com/abs.png")
+	if got, ok := detached.ResolvedAttr("src"); !ok || got != "https://example.com/abs.png" {
+		t.Errorf("ResolvedAttr of an absolute URL = %q, %v", got, ok)
+	}
+
+	if clone := doc.Clone(false).(*Document); clone.URL != doc.URL {
+		t.Errorf("Clone dropped the document URL")
+	}
+}
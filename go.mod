@@ -3,7 +3,8 @@ module github.com/MeKo-Christian/JustGoHTML
 go 1.24.1
 
 require (
-	github.com/PuerkitoBio/goquery v1.11.0 // indirect
-	github.com/andybalholm/cascadia v1.3.3 // indirect
-	golang.org/x/net v0.48.0 // indirect
+	github.com/PuerkitoBio/goquery v1.11.0
+	golang.org/x/net v0.48.0
 )
+
+require github.com/andybalholm/cascadia v1.3.3 // indirect
@@ -106,6 +106,24 @@ func serializeHTML5LibNode(sb *strings.Builder, node dom.Node, depth int) {
 		sb.WriteString(" -->")
 		sb.WriteByte('\n')
 
+	case *dom.CDATASection:
+		sb.WriteString("| ")
+		sb.WriteString(indent)
+		sb.WriteString("<![CDATA[")
+		sb.WriteString(n.Data)
+		sb.WriteString("]]>")
+		sb.WriteByte('\n')
+
+	case *dom.ProcessingInstruction:
+		sb.WriteString("| ")
+		sb.WriteString(indent)
+		sb.WriteString("<?")
+		sb.WriteString(n.Target)
+		sb.WriteByte(' ')
+		sb.WriteString(n.Data)
+		sb.WriteString(">")
+		sb.WriteByte('\n')
+
 	case *dom.DocumentType:
 		// DocumentType nodes are represented via doc.Doctype; ignore here.
 		return
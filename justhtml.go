@@ -34,7 +34,8 @@ import (
 	"github.com/MeKo-Christian/JustGoHTML/tokenizer"
 	"github.com/MeKo-Christian/JustGoHTML/treebuilder"
 
-	_ "github.com/MeKo-Christian/JustGoHTML/selector" // Register selector functions
+	_ "github.com/MeKo-Christian/JustGoHTML/selector"  // Register selector functions
+	_ "github.com/MeKo-Christian/JustGoHTML/serialize" // Register the canonical serializer
 )
 
 // Version is the current version of JustGoHTML.
@@ -109,6 +110,18 @@ func parse(html string, cfg *config) (*dom.Document, error) {
 	if cfg.iframeSrcdoc {
 		tb.SetIframeSrcdoc(true)
 	}
+	if cfg.xmlNodes {
+		tok.SetPreserveCDATA(true)
+		tb.SetProcessingInstructions(true)
+	}
+	if cfg.scripting {
+		tok.SetScripting(true)
+		tb.SetScripting(true)
+	}
+	if cfg.elementIndex {
+		tb.Document().EnableIndex()
+	}
+	tb.Document().URL = cfg.baseURL
 
 	for {
 		tok.SetAllowCDATA(tb.AllowCDATA())
@@ -142,6 +155,14 @@ func parseFragment(html string, cfg *config) ([]*dom.Element, error) {
 	if cfg.iframeSrcdoc {
 		tb.SetIframeSrcdoc(true)
 	}
+	if cfg.xmlNodes {
+		tok.SetPreserveCDATA(true)
+		tb.SetProcessingInstructions(true)
+	}
+	if cfg.scripting {
+		tok.SetScripting(true)
+		tb.SetScripting(true)
+	}
 
 	for {
 		tok.SetAllowCDATA(tb.AllowCDATA())
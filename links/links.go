@@ -0,0 +1,331 @@
+// Package links extracts the URLs a document refers to.
+//
+// Extract walks a document and reports every URL-bearing attribute: link
+// targets, image sources and srcset candidates, stylesheets, scripts, form
+// actions, meta refresh targets and url() references in style attributes,
+// each with its element, kind and absolute URL.
+package links
+
+import (
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// Kind classifies what a URL is used for.
+type Kind int
+
+// Link kinds.
+const (
+	// Hyperlink is the target of a link: a and area href, and SVG a href.
+	Hyperlink Kind = iota
+	// Image is an image source: img src and srcset, picture source srcset,
+	// input type=image src, video poster and SVG image href.
+	Image
+	// Stylesheet is the href of a link element with rel=stylesheet.
+	Stylesheet
+	// Script is the src of a script element.
+	Script
+	// Form is a form submission target: form action and button or input
+	// formaction.
+	Form
+	// Refresh is the target of a meta http-equiv=refresh element.
+	Refresh
+	// Style is a url() reference in a style attribute.
+	Style
+	// Resource is any other embedded or linked resource: link href with
+	// other rels, iframe, embed, audio, video, source and track src, object
+	// data and SVG use href.
+	Resource
+	// Citation is the cite attribute of blockquote, q, del and ins.
+	Citation
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case Hyperlink:
+		return "hyperlink"
+	case Image:
+		return "image"
+	case Stylesheet:
+		return "stylesheet"
+	case Script:
+		return "script"
+	case Form:
+		return "form"
+	case Refresh:
+		return "refresh"
+	case Style:
+		return "style"
+	case Resource:
+		return "resource"
+	case Citation:
+		return "citation"
+	}
+	return "unknown"
+}
+
+// Link is a URL found in a document.
+type Link struct {
+	// Element is the element carrying the URL.
+	Element *dom.Element
+
+	// Attr is the name of the attribute holding the URL.
+	Attr string
+
+	// Kind is what the URL is used for.
+	Kind Kind
+
+	// Raw is the URL as written in the attribute. For srcset, meta refresh
+	// and style attributes it is the URL part of the value.
+	Raw string
+
+	// URL is Raw resolved against the document's base URL, or "" if it
+	// cannot be resolved (see dom.Document.ResolveURL).
+	URL string
+}
+
+// Extract returns the links of doc in document order. An attribute holding
+// several URLs, such as srcset or a style with several url() references,
+// gives one Link per URL.
+func Extract(doc *dom.Document) []Link {
+	x := &extractor{resolve: doc.URLResolver()}
+	x.walk(doc)
+	return x.links
+}
+
+type extractor struct {
+	resolve func(ref string) (string, bool)
+	links   []Link
+}
+
+func (x *extractor) walk(n dom.Node) {
+	for _, child := range n.Children() {
+		elem, ok := child.(*dom.Element)
+		if !ok {
+			continue
+		}
+		x.element(elem)
+		if elem.TemplateContent != nil {
+			x.walk(elem.TemplateContent)
+		}
+		x.walk(elem)
+	}
+}
+
+func (x *extractor) element(elem *dom.Element) {
+	switch elem.Namespace {
+	case dom.NamespaceHTML:
+		x.htmlElement(elem)
+	case dom.NamespaceSVG:
+		switch elem.TagName {
+		case "a":
+			x.svgHref(elem, Hyperlink)
+		case "image":
+			x.svgHref(elem, Image)
+		case "use":
+			x.svgHref(elem, Resource)
+		}
+	}
+	if style, ok := elem.Attributes.Get("style"); ok {
+		for _, raw := range cssURLs(style) {
+			x.add(elem, "style", Style, raw)
+		}
+	}
+}
+
+func (x *extractor) htmlElement(elem *dom.Element) {
+	switch elem.TagName {
+	case "a", "area":
+		x.attr(elem, "href", Hyperlink)
+	case "img":
+		x.attr(elem, "src", Image)
+		x.srcset(elem, Image)
+	case "source":
+		if parent, ok := elem.Parent().(*dom.Element); ok && parent.TagName == "picture" {
+			x.srcset(elem, Image)
+		} else {
+			x.attr(elem, "src", Resource)
+		}
+	case "input":
+		if strings.EqualFold(elem.Attr("type"), "image") {
+			x.attr(elem, "src", Image)
+		}
+		x.attr(elem, "formaction", Form)
+	case "video":
+		x.attr(elem, "poster", Image)
+		x.attr(elem, "src", Resource)
+	case "link":
+		kind := Resource
+		for _, rel := range strings.Fields(strings.ToLower(elem.Attr("rel"))) {
+			if rel == "stylesheet" {
+				kind = Stylesheet
+			}
+		}
+		x.attr(elem, "href", kind)
+		x.srcset(elem, Resource) // imagesrcset on preloads
+	case "script":
+		x.attr(elem, "src", Script)
+	case "form":
+		x.attr(elem, "action", Form)
+	case "button":
+		x.attr(elem, "formaction", Form)
+	case "meta":
+		if strings.EqualFold(elem.Attr("http-equiv"), "refresh") {
+			if raw, ok := refreshURL(elem.Attr("content")); ok {
+				x.add(elem, "content", Refresh, raw)
+			}
+		}
+	case "iframe", "embed", "audio", "track":
+		x.attr(elem, "src", Resource)
+	case "object":
+		x.attr(elem, "data", Resource)
+	case "blockquote", "q", "del", "ins":
+		x.attr(elem, "cite", Citation)
+	}
+}
+
+// attr adds the URL in the named attribute of elem, if it is present.
+func (x *extractor) attr(elem *dom.Element, name string, kind Kind) {
+	if raw, ok := elem.Attributes.Get(name); ok {
+		x.add(elem, name, kind, raw)
+	}
+}
+
+// srcset adds the candidate URLs of the srcset attribute of elem, or of its
+// imagesrcset attribute for link elements.
+func (x *extractor) srcset(elem *dom.Element, kind Kind) {
+	name := "srcset"
+	if elem.TagName == "link" {
+		name = "imagesrcset"
+	}
+	if value, ok := elem.Attributes.Get(name); ok {
+		for _, raw := range srcsetURLs(value) {
+			x.add(elem, name, kind, raw)
+		}
+	}
+}
+
+// svgHref adds the href of an SVG element, or its legacy xlink:href.
+func (x *extractor) svgHref(elem *dom.Element, kind Kind) {
+	if raw, ok := elem.Attributes.Get("href"); ok {
+		x.add(elem, "href", kind, raw)
+		return
+	}
+	for _, attr := range elem.Attributes.All() {
+		if attr.Name == "xlink:href" || attr.Namespace == "http://www.w3.org/1999/xlink" && attr.Name == "href" {
+			x.add(elem, "xlink:href", kind, attr.Value)
+			return
+		}
+	}
+}
+
+func (x *extractor) add(elem *dom.Element, attr string, kind Kind, raw string) {
+	resolved, _ := x.resolve(raw)
+	x.links = append(x.links, Link{Element: elem, Attr: attr, Kind: kind, Raw: raw, URL: resolved})
+}
+
+// srcsetURLs returns the URLs of the image candidates in a srcset value. A
+// URL is a run of non-whitespace characters; a comma ends a candidate after
+// its descriptors, or ends the URL itself if it is its last character.
+func srcsetURLs(value string) []string {
+	var urls []string
+	for {
+		value = strings.TrimLeft(value, " \t\n\f\r,")
+		if value == "" {
+			return urls
+		}
+		end := strings.IndexAny(value, " \t\n\f\r")
+		if end < 0 {
+			end = len(value)
+		}
+		u := value[:end]
+		value = value[end:]
+		if trimmed := strings.TrimRight(u, ","); trimmed != u {
+			urls = append(urls, trimmed)
+			continue
+		}
+		urls = append(urls, u)
+		// Skip the descriptors, which may contain commas inside parentheses.
+		depth := 0
+		i := 0
+		for ; i < len(value); i++ {
+			switch value[i] {
+			case '(':
+				depth++
+			case ')':
+				if depth > 0 {
+					depth--
+				}
+			}
+			if value[i] == ',' && depth == 0 {
+				break
+			}
+		}
+		value = value[i:]
+	}
+}
+
+// refreshURL extracts the URL of a meta refresh content value such as
+// "5; url='/next'", following the shared declarative refresh steps of the
+// HTML standard.
+func refreshURL(content string) (string, bool) {
+	content = strings.TrimLeft(content, " \t\n\f\r")
+	s := strings.TrimLeft(content, "0123456789.")
+	if s == content {
+		return "", false
+	}
+	s = strings.TrimLeft(s, " \t\n\f\r")
+	if s == "" || (s[0] != ';' && s[0] != ',') {
+		return "", false
+	}
+	s = strings.TrimLeft(s[1:], " \t\n\f\r")
+	if len(s) >= 3 && strings.EqualFold(s[:3], "url") {
+		rest := strings.TrimLeft(s[3:], " \t\n\f\r")
+		if strings.HasPrefix(rest, "=") {
+			s = strings.TrimLeft(rest[1:], " \t\n\f\r")
+		}
+	}
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		quote := s[0]
+		s = s[1:]
+		if i := strings.IndexByte(s, quote); i >= 0 {
+			s = s[:i]
+		}
+	}
+	s = strings.TrimSpace(s)
+	return s, s != ""
+}
+
+// cssURLs returns the arguments of the url() functions in a CSS declaration
+// list, with quotes removed.
+func cssURLs(css string) []string {
+	var urls []string
+	lower := strings.ToLower(css)
+	for {
+		i := strings.Index(lower, "url(")
+		if i < 0 {
+			return urls
+		}
+		css, lower = css[i+4:], lower[i+4:]
+		arg := strings.TrimLeft(css, " \t\n\f\r")
+		var u string
+		if arg != "" && (arg[0] == '"' || arg[0] == '\'') {
+			end := strings.IndexByte(arg[1:], arg[0])
+			if end < 0 {
+				return urls
+			}
+			u = arg[1 : end+1]
+		} else {
+			end := strings.IndexByte(arg, ')')
+			if end < 0 {
+				return urls
+			}
+			u = strings.TrimSpace(arg[:end])
+		}
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package links_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/links"
+)
+
+const linksTestHTML = `<!DOCTYPE html><html><head>
+<base href="/site/">
+<link rel="stylesheet" href="css/main.css">
+<link rel="icon" href="https://cdn.example/favicon.ico">
+<meta http-equiv="refresh" content="5; URL='next.html'">
+<script src="app.js"></script>
+</head><body style="background: url(&quot;bg.png&quot;) no-repeat">
+<a href="page.html#top">Page</a>
+<a name="anchor">no href</a>
+<img src="a.png" srcset="a-1x.png 1x, a-2x.png 2x,data:image/png;base64,AAAA 3x">
+<picture><source srcset="wide.webp 800w"></picture>
+<form action="submit"><button formaction="https://other.example/post">Go</button></form>
+<blockquote cite="https://source.example/quote">q</blockquote>
+<svg><a href="svg-link"><text>x</text></a><image xlink:href="pic.svg"></image></svg>
+<iframe src="frame.html"></iframe>
+<template><img src="in-template.png"></template>
+</body></html>`
+
+func linkStrings(ls []links.Link) []string {
+	out := make([]string, len(ls))
+	for i, l := range ls {
+		out[i] = fmt.Sprintf("%s %s[%s] %s", l.Kind, l.Element.TagName, l.Attr, l.URL)
+	}
+	return out
+}
+
+func TestExtract(t *testing.T) {
+	doc, err := JustGoHTML.Parse(linksTestHTML, JustGoHTML.WithBaseURL("https://example.com/dir/index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := linkStrings(links.Extract(doc))
+	want := []string{
+		"stylesheet link[href] https://example.com/site/css/main.css",
+		"resource link[href] https://cdn.example/favicon.ico",
+		"refresh meta[content] https://example.com/site/next.html",
+		"script script[src] https://example.com/site/app.js",
+		"style body[style] https://example.com/site/bg.png",
+		"hyperlink a[href] https://example.com/site/page.html#top",
+		"image img[src] https://example.com/site/a.png",
+		"image img[srcset] https://example.com/site/a-1x.png",
+		"image img[srcset] https://example.com/site/a-2x.png",
+		"image img[srcset] data:image/png;base64,AAAA",
+		"image source[srcset] https://example.com/site/wide.webp",
+		"form form[action] https://example.com/site/submit",
+		"form button[formaction] https://other.example/post",
+		"citation blockquote[cite] https://source.example/quote",
+		"hyperlink a[href] https://example.com/site/svg-link",
+		"image image[xlink:href] https://example.com/site/pic.svg",
+		"resource iframe[src] https://example.com/site/frame.html",
+		"image img[src] https://example.com/site/in-template.png",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Extract =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestExtractWithoutBaseURL(t *testing.T) {
+	doc, err := JustGoHTML.Parse(`<a href="/relative">x</a><a href="https://example.com/">y</a>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls := links.Extract(doc)
+	if len(ls) != 2 {
+		t.Fatalf("Extract returned %d links, want 2", len(ls))
+	}
+	if ls[0].Raw != "/relative" || ls[0].URL != "" {
+		t.Errorf("relative link = %+v, want an unresolved URL", ls[0])
+	}
+	if ls[1].URL != "https://example.com/" {
+		t.Errorf("absolute link URL = %q", ls[1].URL)
+	}
+}
+
+func TestExtractValueParsing(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{`<meta http-equiv=Refresh content="0;url=/a">`, []string{"/a"}},
+		{`<meta http-equiv=refresh content="3, 'b.html'">`, []string{"b.html"}},
+		{`<meta http-equiv=refresh content="10">`, nil},
+		{`<meta http-equiv=refresh content="url=/c">`, nil},
+		{`<img srcset="x.png, y.png 2x">`, []string{"x.png", "y.png"}},
+		{`<img srcset="z.png (max-width: 1px, 2) 1x, w.png 2x">`, []string{"z.png", "w.png"}},
+		{`<div style="a: URL( 'one.png' ); b: url(two.png), url(&quot;three.png&quot;)">`, []string{"one.png", "two.png", "three.png"}},
+	}
+	for _, tt := range tests {
+		doc, err := JustGoHTML.Parse(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, l := range links.Extract(doc) {
+			got = append(got, l.Raw)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Extract(%q) raw URLs = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
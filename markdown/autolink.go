@@ -0,0 +1,144 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	reExtendedAutolink = regexp.MustCompile(`(?:https?://|ftp://|www\.)[^\s<]*|[a-zA-Z0-9._+-]+@[a-zA-Z0-9_-]+(?:\.[a-zA-Z0-9_-]+)+`)
+	reDomainPrefix     = regexp.MustCompile(`^[a-zA-Z0-9_.-]*`)
+	reValidDomain      = regexp.MustCompile(`^[a-zA-Z0-9_-]+(?:\.[a-zA-Z0-9_-]+)+$`)
+	reTrailingEntity   = regexp.MustCompile(`&[a-zA-Z0-9]+;$`)
+)
+
+// linkifyInlines applies GFM extended autolinks to the text inlines below
+// n, leaving existing links, images and code spans alone.
+func linkifyInlines(n *inline) {
+	mergeTexts(n)
+	for child := n.firstChild; child != nil; {
+		next := child.next
+		switch child.kind {
+		case textInline:
+			linkifyText(child)
+		case emphasisInline, strongInline, strikethroughInline:
+			linkifyInlines(child)
+		}
+		child = next
+	}
+}
+
+// mergeTexts joins adjacent text children of n, which the inline parser
+// leaves split at special characters.
+func mergeTexts(n *inline) {
+	for child := n.firstChild; child != nil; child = child.next {
+		for child.kind == textInline && child.next != nil && child.next.kind == textInline {
+			child.literal += child.next.literal
+			child.next.unlink()
+		}
+	}
+}
+
+// linkifyText splits text at extended autolinks and replaces it with the
+// resulting text and link inlines.
+func linkifyText(text *inline) {
+	s := text.literal
+	var nodes []*inline
+	last := 0
+	for _, loc := range reExtendedAutolink.FindAllStringIndex(s, -1) {
+		start, end := loc[0], loc[1]
+		if start < last {
+			continue
+		}
+		candidate := s[start:end]
+		var dest string
+		if strings.Contains(candidate, "@") && !strings.Contains(candidate, "/") {
+			if !autolinkBoundary(s, start, true) {
+				continue
+			}
+			candidate = strings.TrimSuffix(candidate, ".")
+			if c := candidate[len(candidate)-1]; c == '-' || c == '_' {
+				continue
+			}
+			dest = "mailto:" + candidate
+		} else {
+			if !autolinkBoundary(s, start, false) {
+				continue
+			}
+			candidate = trimAutolink(candidate)
+			domain := candidate
+			if i := strings.Index(domain, "://"); i >= 0 {
+				domain = domain[i+3:]
+			}
+			domain = reDomainPrefix.FindString(domain)
+			if !validAutolinkDomain(domain) {
+				continue
+			}
+			dest = candidate
+			if strings.HasPrefix(candidate, "www.") {
+				dest = "http://" + candidate
+			}
+		}
+
+		if start > last {
+			nodes = append(nodes, newText(s[last:start]))
+		}
+		link := &inline{kind: linkInline, destination: dest}
+		link.appendChild(newText(candidate))
+		nodes = append(nodes, link)
+		last = start + len(candidate)
+	}
+	if nodes == nil {
+		return
+	}
+	if last < len(s) {
+		nodes = append(nodes, newText(s[last:]))
+	}
+	prev := text
+	for _, n := range nodes {
+		prev.insertAfter(n)
+		prev = n
+	}
+	text.unlink()
+}
+
+// autolinkBoundary reports whether an autolink may start at pos: at the
+// start of the text, after whitespace or after one of "*_~(".
+func autolinkBoundary(s string, pos int, email bool) bool {
+	if pos == 0 {
+		return true
+	}
+	c := s[pos-1]
+	if email {
+		return c != '/' && c != ':'
+	}
+	return strings.IndexByte(" \t\n*_~(", c) >= 0
+}
+
+// validAutolinkDomain reports whether domain has at least one period and no
+// underscores in its last two segments.
+func validAutolinkDomain(domain string) bool {
+	if !reValidDomain.MatchString(domain) {
+		return false
+	}
+	segments := strings.Split(domain, ".")
+	return !strings.Contains(segments[len(segments)-1], "_") && !strings.Contains(segments[len(segments)-2], "_")
+}
+
+// trimAutolink removes trailing punctuation, unbalanced closing parentheses
+// and entity references from the end of an autolink.
+func trimAutolink(s string) string {
+	for s != "" {
+		switch c := s[len(s)-1]; {
+		case strings.IndexByte(`?!.,:*_~'"`, c) >= 0:
+			s = s[:len(s)-1]
+		case c == ')' && strings.Count(s, "(") < strings.Count(s, ")"):
+			s = s[:len(s)-1]
+		case c == ';' && reTrailingEntity.MatchString(s):
+			s = s[:reTrailingEntity.FindStringIndex(s)[0]]
+		default:
+			return s
+		}
+	}
+	return s
+}
@@ -0,0 +1,861 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The block parser follows the two-phase strategy of the CommonMark
+// specification: lines are first assembled into a tree of container and leaf
+// blocks, and the text of paragraphs, headings and table cells is parsed into
+// inlines afterwards.
+
+// blockKind identifies the type of a block.
+type blockKind int
+
+const (
+	documentBlock blockKind = iota
+	blockQuoteBlock
+	listBlock
+	itemBlock
+	paragraphBlock
+	headingBlock
+	thematicBreakBlock
+	codeBlock
+	htmlBlock
+	tableBlock
+)
+
+// codeIndent is the indentation of indented code blocks.
+const codeIndent = 4
+
+// block is a node of the block tree.
+type block struct {
+	kind     blockKind
+	parent   *block
+	children []*block
+	open     bool
+
+	// content holds the raw text lines added to leaf blocks.
+	content strings.Builder
+
+	// lastLineBlank is set when the block ended with a blank line, which
+	// decides whether lists are tight.
+	lastLineBlank bool
+	startLine     int
+
+	// Heading level.
+	level int
+
+	// Code blocks.
+	fenced      bool
+	fenceChar   byte
+	fenceLength int
+	fenceOffset int
+	info        string
+	literal     string
+
+	// HTML blocks.
+	htmlType int
+
+	// Lists and list items.
+	list *listData
+
+	// Tables: the header cells, the column alignments and the body rows.
+	header []string
+	aligns []string
+	rows   [][]string
+}
+
+type listData struct {
+	ordered      bool
+	bulletChar   byte
+	delimiter    byte
+	start        int
+	tight        bool
+	markerOffset int
+	padding      int
+}
+
+func (b *block) lastChild() *block {
+	if len(b.children) == 0 {
+		return nil
+	}
+	return b.children[len(b.children)-1]
+}
+
+func (b *block) appendChild(child *block) {
+	child.parent = b
+	b.children = append(b.children, child)
+}
+
+// unlink removes b from its parent.
+func (b *block) unlink() {
+	if b.parent == nil {
+		return
+	}
+	siblings := b.parent.children
+	for i, c := range siblings {
+		if c == b {
+			b.parent.children = append(siblings[:i], siblings[i+1:]...)
+			break
+		}
+	}
+	b.parent = nil
+}
+
+// canContain reports whether blocks of kind can be children of b.
+func (b *block) canContain(kind blockKind) bool {
+	switch b.kind {
+	case documentBlock, blockQuoteBlock, itemBlock:
+		return kind != itemBlock
+	case listBlock:
+		return kind == itemBlock
+	}
+	return false
+}
+
+// acceptsLines reports whether text lines are added to b.
+func (b *block) acceptsLines() bool {
+	switch b.kind {
+	case paragraphBlock, codeBlock, htmlBlock, tableBlock:
+		return true
+	}
+	return false
+}
+
+var (
+	reMaybeSpecial      = regexp.MustCompile(`^[#` + "`" + `~*+_=<>0-9|-]`)
+	reBulletListMarker  = regexp.MustCompile(`^[*+-]`)
+	reOrderedListMarker = regexp.MustCompile(`^(\d{1,9})([.)])`)
+	reATXHeadingMarker  = regexp.MustCompile(`^#{1,6}(?:[ \t]+|$)`)
+	reCodeFence         = regexp.MustCompile("^(?:`{3,}|~{3,})")
+	reClosingCodeFence  = regexp.MustCompile("^(?:`{3,}|~{3,})[ \t]*$")
+	reSetextHeadingLine = regexp.MustCompile(`^(?:=+|-+)[ \t]*$`)
+	reThematicBreak     = regexp.MustCompile(`^(?:(?:\*[ \t]*){3,}|(?:_[ \t]*){3,}|(?:-[ \t]*){3,})$`)
+	reTableDelimiter    = regexp.MustCompile(`^:?-+:?$`)
+)
+
+// blockParser holds the state of the block phase.
+type blockParser struct {
+	opts Options
+	doc  *block
+	tip  *block
+
+	oldTip               *block
+	lastMatchedContainer *block
+	allClosed            bool
+
+	line       string
+	lineNumber int
+
+	offset               int
+	column               int
+	nextNonspace         int
+	nextNonspaceColumn   int
+	indent               int
+	indented             bool
+	blank                bool
+	partiallyConsumedTab bool
+
+	refs map[string]linkReference
+}
+
+// linkReference is a link reference definition.
+type linkReference struct {
+	destination, title string
+}
+
+func newBlockParser(opts Options) *blockParser {
+	doc := &block{kind: documentBlock, open: true}
+	return &blockParser{opts: opts, doc: doc, tip: doc, refs: map[string]linkReference{}}
+}
+
+// parse builds the block tree of src.
+func (p *blockParser) parse(src string) *block {
+	src = strings.ReplaceAll(src, "\x00", "�")
+	lines := splitLines(src)
+	for _, line := range lines {
+		p.incorporateLine(line)
+	}
+	for p.tip != nil {
+		p.finalize(p.tip)
+	}
+	return p.doc
+}
+
+// splitLines splits src at line endings. A final line ending does not start
+// another line.
+func splitLines(src string) []string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.TrimSuffix(src, "\n")
+	if src == "" {
+		return nil
+	}
+	return strings.Split(src, "\n")
+}
+
+func (p *blockParser) peek(pos int) byte {
+	if pos < len(p.line) {
+		return p.line[pos]
+	}
+	return 0
+}
+
+func (p *blockParser) findNextNonspace() {
+	i, cols := p.offset, p.column
+	for i < len(p.line) {
+		switch p.line[i] {
+		case ' ':
+			i++
+			cols++
+			continue
+		case '\t':
+			i++
+			cols += 4 - cols%4
+			continue
+		}
+		break
+	}
+	p.blank = i >= len(p.line)
+	p.nextNonspace = i
+	p.nextNonspaceColumn = cols
+	p.indent = cols - p.column
+	p.indented = p.indent >= codeIndent
+}
+
+func (p *blockParser) advanceNextNonspace() {
+	p.offset = p.nextNonspace
+	p.column = p.nextNonspaceColumn
+	p.partiallyConsumedTab = false
+}
+
+// advanceOffset advances by count characters, or by count columns if
+// columns is set, in which case a tab may be consumed partially.
+func (p *blockParser) advanceOffset(count int, columns bool) {
+	for count > 0 && p.offset < len(p.line) {
+		if p.line[p.offset] != '\t' {
+			p.partiallyConsumedTab = false
+			p.offset++
+			p.column++
+			count--
+			continue
+		}
+		charsToTab := 4 - p.column%4
+		if !columns {
+			p.partiallyConsumedTab = false
+			p.column += charsToTab
+			p.offset++
+			count--
+			continue
+		}
+		p.partiallyConsumedTab = charsToTab > count
+		advance := min(charsToTab, count)
+		p.column += advance
+		if !p.partiallyConsumedTab {
+			p.offset++
+		}
+		count -= advance
+	}
+}
+
+// addLine adds the rest of the current line to the tip.
+func (p *blockParser) addLine() {
+	if p.partiallyConsumedTab {
+		p.offset++
+		p.tip.content.WriteString(strings.Repeat(" ", 4-p.column%4))
+	}
+	p.tip.content.WriteString(p.line[p.offset:])
+	p.tip.content.WriteByte('\n')
+}
+
+// addChild adds a block of kind at the tip, closing blocks that cannot
+// contain it.
+func (p *blockParser) addChild(kind blockKind) *block {
+	for !p.tip.canContain(kind) {
+		p.finalize(p.tip)
+	}
+	child := &block{kind: kind, open: true, startLine: p.lineNumber}
+	p.tip.appendChild(child)
+	p.tip = child
+	return child
+}
+
+func (p *blockParser) closeUnmatchedBlocks() {
+	if p.allClosed {
+		return
+	}
+	for p.oldTip != p.lastMatchedContainer {
+		parent := p.oldTip.parent
+		p.finalize(p.oldTip)
+		p.oldTip = parent
+	}
+	p.allClosed = true
+}
+
+// continueResult is the outcome of matching a line against an open block.
+type continueResult int
+
+const (
+	continueMatched continueResult = iota
+	continueFailed
+	continueLineDone
+)
+
+// incorporateLine adds a line to the block tree.
+func (p *blockParser) incorporateLine(line string) {
+	container := p.doc
+	p.oldTip = p.tip
+	p.offset, p.column = 0, 0
+	p.blank = false
+	p.partiallyConsumedTab = false
+	p.lineNumber++
+	p.line = line
+
+	allMatched := true
+	for {
+		last := container.lastChild()
+		if last == nil || !last.open {
+			break
+		}
+		container = last
+		p.findNextNonspace()
+		switch p.continueBlock(container) {
+		case continueFailed:
+			allMatched = false
+		case continueLineDone:
+			return
+		}
+		if !allMatched {
+			container = container.parent
+			break
+		}
+	}
+
+	p.allClosed = container == p.oldTip
+	p.lastMatchedContainer = container
+
+	matchedLeaf := container.kind == codeBlock || container.kind == htmlBlock
+	for !matchedLeaf {
+		p.findNextNonspace()
+		if !p.indented && !reMaybeSpecial.MatchString(p.line[p.nextNonspace:]) {
+			p.advanceNextNonspace()
+			break
+		}
+		res := p.startBlock(container)
+		if res == startNone {
+			p.advanceNextNonspace()
+			break
+		}
+		container = p.tip
+		if res == startLeaf {
+			matchedLeaf = true
+		}
+	}
+
+	// What remains at the offset is a text line: either a lazy paragraph
+	// continuation or content of the container.
+	if !p.allClosed && !p.blank && p.tip.kind == paragraphBlock {
+		p.addLine()
+		return
+	}
+
+	p.closeUnmatchedBlocks()
+	if p.blank && container.lastChild() != nil {
+		container.lastChild().lastLineBlank = true
+	}
+
+	switch {
+	case container.kind == tableBlock:
+		if !p.blank {
+			p.addLine()
+		}
+	case container.acceptsLines():
+		p.addLine()
+		if container.kind == htmlBlock && container.htmlType >= 1 && container.htmlType <= 5 &&
+			reHTMLBlockClose[container.htmlType].MatchString(p.line[p.offset:]) {
+			p.finalize(container)
+		}
+	case p.offset < len(p.line) && !p.blank:
+		container = p.addChild(paragraphBlock)
+		p.advanceNextNonspace()
+		p.addLine()
+	}
+
+	// Block quote lines are never blank as they start with ">", blank lines
+	// in fenced code do not count for tight lists, and neither does the
+	// first line of an empty list item.
+	lastLineBlank := p.blank &&
+		container.kind != blockQuoteBlock &&
+		!(container.kind == codeBlock && container.fenced) &&
+		!(container.kind == itemBlock && len(container.children) == 0 && container.startLine == p.lineNumber)
+	for b := container; b != nil; b = b.parent {
+		b.lastLineBlank = lastLineBlank
+	}
+}
+
+// continueBlock matches the current line against the open block b.
+func (p *blockParser) continueBlock(b *block) continueResult {
+	switch b.kind {
+	case blockQuoteBlock:
+		if p.indented || p.peek(p.nextNonspace) != '>' {
+			return continueFailed
+		}
+		p.advanceNextNonspace()
+		p.advanceOffset(1, false)
+		if c := p.peek(p.offset); c == ' ' || c == '\t' {
+			p.advanceOffset(1, true)
+		}
+	case itemBlock:
+		switch {
+		case p.blank:
+			if len(b.children) == 0 {
+				// A list item can begin with at most one blank line.
+				return continueFailed
+			}
+			p.advanceNextNonspace()
+		case p.indent >= b.list.markerOffset+b.list.padding:
+			p.advanceOffset(b.list.markerOffset+b.list.padding, true)
+		default:
+			return continueFailed
+		}
+	case headingBlock, thematicBreakBlock:
+		return continueFailed
+	case codeBlock:
+		if b.fenced {
+			rest := p.line[p.nextNonspace:]
+			if p.indent <= 3 && p.peek(p.nextNonspace) == b.fenceChar && reClosingCodeFence.MatchString(rest) &&
+				len(rest)-len(strings.TrimLeft(rest, string(b.fenceChar))) >= b.fenceLength {
+				p.finalize(b)
+				return continueLineDone
+			}
+			for i := b.fenceOffset; i > 0; i-- {
+				if c := p.peek(p.offset); c != ' ' && c != '\t' {
+					break
+				}
+				p.advanceOffset(1, true)
+			}
+			return continueMatched
+		}
+		switch {
+		case p.indent >= codeIndent:
+			p.advanceOffset(codeIndent, true)
+		case p.blank:
+			p.advanceNextNonspace()
+		default:
+			return continueFailed
+		}
+	case htmlBlock:
+		if p.blank && (b.htmlType == 6 || b.htmlType == 7) {
+			return continueFailed
+		}
+	case paragraphBlock, tableBlock:
+		if p.blank {
+			return continueFailed
+		}
+	}
+	return continueMatched
+}
+
+// startResult is the outcome of trying to start new blocks on a line.
+type startResult int
+
+const (
+	startNone startResult = iota
+	startContainer
+	startLeaf
+)
+
+// startBlock tries the block starts in order of precedence.
+func (p *blockParser) startBlock(container *block) startResult {
+	starts := []func(*block) startResult{
+		p.startBlockQuote,
+		p.startATXHeading,
+		p.startFencedCode,
+		p.startHTMLBlock,
+		p.startTable,
+		p.startSetextHeading,
+		p.startThematicBreak,
+		p.startListItem,
+		p.startIndentedCode,
+	}
+	for _, start := range starts {
+		if res := start(container); res != startNone {
+			return res
+		}
+	}
+	return startNone
+}
+
+func (p *blockParser) startBlockQuote(*block) startResult {
+	if p.indented || p.peek(p.nextNonspace) != '>' {
+		return startNone
+	}
+	p.advanceNextNonspace()
+	p.advanceOffset(1, false)
+	if c := p.peek(p.offset); c == ' ' || c == '\t' {
+		p.advanceOffset(1, true)
+	}
+	p.closeUnmatchedBlocks()
+	p.addChild(blockQuoteBlock)
+	return startContainer
+}
+
+var (
+	reATXClosingOnly     = regexp.MustCompile(`^[ \t]*#+[ \t]*$`)
+	reATXClosingSequence = regexp.MustCompile(`[ \t]+#+[ \t]*$`)
+)
+
+func (p *blockParser) startATXHeading(*block) startResult {
+	if p.indented {
+		return startNone
+	}
+	match := reATXHeadingMarker.FindString(p.line[p.nextNonspace:])
+	if match == "" {
+		return startNone
+	}
+	p.advanceNextNonspace()
+	p.advanceOffset(len(match), false)
+	p.closeUnmatchedBlocks()
+	heading := p.addChild(headingBlock)
+	heading.level = len(strings.TrimRight(match, " \t"))
+	text := p.line[p.offset:]
+	text = reATXClosingOnly.ReplaceAllString(text, "")
+	text = reATXClosingSequence.ReplaceAllString(text, "")
+	heading.content.WriteString(text)
+	p.advanceOffset(len(p.line)-p.offset, false)
+	return startLeaf
+}
+
+func (p *blockParser) startFencedCode(*block) startResult {
+	if p.indented {
+		return startNone
+	}
+	rest := p.line[p.nextNonspace:]
+	match := reCodeFence.FindString(rest)
+	if match == "" || match[0] == '`' && strings.Contains(rest[len(match):], "`") {
+		return startNone
+	}
+	p.closeUnmatchedBlocks()
+	code := p.addChild(codeBlock)
+	code.fenced = true
+	code.fenceLength = len(match)
+	code.fenceChar = match[0]
+	code.fenceOffset = p.indent
+	p.advanceNextNonspace()
+	p.advanceOffset(len(match), false)
+	return startLeaf
+}
+
+func (p *blockParser) startHTMLBlock(container *block) startResult {
+	if p.indented || p.peek(p.nextNonspace) != '<' {
+		return startNone
+	}
+	rest := p.line[p.nextNonspace:]
+	for htmlType := 1; htmlType <= 7; htmlType++ {
+		if !reHTMLBlockOpen[htmlType].MatchString(rest) {
+			continue
+		}
+		if htmlType == 7 && (container.kind == paragraphBlock || !p.allClosed && !p.blank && p.tip.kind == paragraphBlock) {
+			// Type 7 blocks cannot interrupt a paragraph.
+			continue
+		}
+		p.closeUnmatchedBlocks()
+		html := p.addChild(htmlBlock)
+		html.htmlType = htmlType
+		return startLeaf
+	}
+	return startNone
+}
+
+// startTable turns the last line of an open paragraph into a table header
+// when the line is a matching delimiter row.
+func (p *blockParser) startTable(container *block) startResult {
+	if !p.opts.Tables || p.indented || container.kind != paragraphBlock {
+		return startNone
+	}
+	rest := p.line[p.nextNonspace:]
+	if !strings.Contains(rest, "|") {
+		return startNone
+	}
+	aligns := parseTableDelimiterRow(rest)
+	if aligns == nil {
+		return startNone
+	}
+	content := strings.TrimSuffix(container.content.String(), "\n")
+	lines := strings.Split(content, "\n")
+	header := splitTableRow(lines[len(lines)-1])
+	if len(header) != len(aligns) {
+		return startNone
+	}
+
+	p.closeUnmatchedBlocks()
+	container.content.Reset()
+	if len(lines) > 1 {
+		container.content.WriteString(strings.Join(lines[:len(lines)-1], "\n") + "\n")
+	}
+	table := p.addChild(tableBlock)
+	table.header = header
+	table.aligns = aligns
+	p.advanceOffset(len(p.line)-p.offset, false)
+	return startLeaf
+}
+
+// parseTableDelimiterRow returns the column alignments of a table delimiter
+// row, or nil if row is not one.
+func parseTableDelimiterRow(row string) []string {
+	cells := splitTableRow(row)
+	if len(cells) == 0 {
+		return nil
+	}
+	aligns := make([]string, len(cells))
+	for i, cell := range cells {
+		if !reTableDelimiter.MatchString(cell) {
+			return nil
+		}
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			aligns[i] = "center"
+		case left:
+			aligns[i] = "left"
+		case right:
+			aligns[i] = "right"
+		}
+	}
+	return aligns
+}
+
+// splitTableRow splits a table row into its trimmed cells. Leading and
+// trailing pipes are optional, and "\|" is a literal pipe inside a cell.
+func splitTableRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = row[:len(row)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func (p *blockParser) startSetextHeading(container *block) startResult {
+	if p.indented || container.kind != paragraphBlock || !reSetextHeadingLine.MatchString(p.line[p.nextNonspace:]) {
+		return startNone
+	}
+	p.closeUnmatchedBlocks()
+	content := p.extractReferences(container.content.String())
+	if strings.TrimSpace(content) == "" {
+		return startNone
+	}
+	heading := &block{kind: headingBlock, open: true, startLine: container.startLine}
+	heading.level = 2
+	if p.peek(p.nextNonspace) == '=' {
+		heading.level = 1
+	}
+	heading.content.WriteString(content)
+
+	parent := container.parent
+	for i, c := range parent.children {
+		if c == container {
+			parent.children[i] = heading
+			heading.parent = parent
+			break
+		}
+	}
+	p.tip = heading
+	p.advanceOffset(len(p.line)-p.offset, false)
+	return startLeaf
+}
+
+func (p *blockParser) startThematicBreak(*block) startResult {
+	if p.indented || !reThematicBreak.MatchString(p.line[p.nextNonspace:]) {
+		return startNone
+	}
+	p.closeUnmatchedBlocks()
+	p.addChild(thematicBreakBlock)
+	p.advanceOffset(len(p.line)-p.offset, false)
+	return startLeaf
+}
+
+func (p *blockParser) startListItem(container *block) startResult {
+	if p.indented && container.kind != listBlock {
+		return startNone
+	}
+	data := p.parseListMarker(container)
+	if data == nil {
+		return startNone
+	}
+	p.closeUnmatchedBlocks()
+	if p.tip.kind != listBlock || !listsMatch(p.tip.list, data) {
+		list := p.addChild(listBlock)
+		list.list = data
+		list.list.tight = true
+	}
+	item := p.addChild(itemBlock)
+	item.list = data
+	return startContainer
+}
+
+// parseListMarker parses a list item marker at the current position and
+// advances past it and the following spaces.
+func (p *blockParser) parseListMarker(container *block) *listData {
+	if p.indent >= codeIndent {
+		return nil
+	}
+	rest := p.line[p.nextNonspace:]
+	data := &listData{markerOffset: p.indent}
+	var marker string
+	if m := reBulletListMarker.FindString(rest); m != "" {
+		marker = m
+		data.bulletChar = m[0]
+	} else if m := reOrderedListMarker.FindStringSubmatch(rest); m != nil && (container.kind != paragraphBlock || m[1] == "1") {
+		marker = m[0]
+		data.ordered = true
+		data.start, _ = strconv.Atoi(m[1])
+		data.delimiter = m[2][0]
+	} else {
+		return nil
+	}
+
+	if c := p.peek(p.nextNonspace + len(marker)); c != 0 && c != ' ' && c != '\t' {
+		return nil
+	}
+	// A list item interrupting a paragraph cannot be empty.
+	if container.kind == paragraphBlock && strings.TrimLeft(rest[len(marker):], " \t") == "" {
+		return nil
+	}
+
+	p.advanceNextNonspace()
+	p.advanceOffset(len(marker), true)
+	spacesStartColumn, spacesStartOffset := p.column, p.offset
+	for {
+		p.advanceOffset(1, true)
+		c := p.peek(p.offset)
+		if p.column-spacesStartColumn >= 5 || c != ' ' && c != '\t' {
+			break
+		}
+	}
+	blankItem := p.offset >= len(p.line)
+	spacesAfterMarker := p.column - spacesStartColumn
+	if spacesAfterMarker >= 5 || spacesAfterMarker < 1 || blankItem {
+		data.padding = len(marker) + 1
+		p.column, p.offset = spacesStartColumn, spacesStartOffset
+		if c := p.peek(p.offset); c == ' ' || c == '\t' {
+			p.advanceOffset(1, true)
+		}
+	} else {
+		data.padding = len(marker) + spacesAfterMarker
+	}
+	return data
+}
+
+func listsMatch(list, item *listData) bool {
+	return list.ordered == item.ordered && list.delimiter == item.delimiter && list.bulletChar == item.bulletChar
+}
+
+func (p *blockParser) startIndentedCode(*block) startResult {
+	if !p.indented || p.tip.kind == paragraphBlock || p.blank {
+		return startNone
+	}
+	p.advanceOffset(codeIndent, true)
+	p.closeUnmatchedBlocks()
+	p.addChild(codeBlock)
+	return startLeaf
+}
+
+// finalize closes b and makes its parent the tip.
+func (p *blockParser) finalize(b *block) {
+	parent := b.parent
+	b.open = false
+	switch b.kind {
+	case paragraphBlock:
+		content := p.extractReferences(b.content.String())
+		b.content.Reset()
+		b.content.WriteString(content)
+		if strings.TrimSpace(content) == "" {
+			b.unlink()
+		}
+	case codeBlock:
+		content := b.content.String()
+		if b.fenced {
+			info, rest, _ := strings.Cut(content, "\n")
+			b.info = unescapeString(strings.TrimSpace(info))
+			b.literal = rest
+		} else {
+			lines := strings.Split(content, "\n")
+			for len(lines) > 0 && strings.Trim(lines[len(lines)-1], " \t") == "" {
+				lines = lines[:len(lines)-1]
+			}
+			b.literal = strings.Join(lines, "\n") + "\n"
+		}
+	case htmlBlock:
+		b.literal = strings.TrimSuffix(b.content.String(), "\n")
+	case tableBlock:
+		for _, line := range strings.Split(strings.TrimSuffix(b.content.String(), "\n"), "\n") {
+			if line != "" {
+				b.rows = append(b.rows, splitTableRow(line))
+			}
+		}
+	case listBlock:
+		b.list.tight = isTightList(b)
+	}
+	p.tip = parent
+}
+
+// isTightList reports whether no items of list are separated by blank
+// lines, and no item contains blocks separated by blank lines.
+func isTightList(list *block) bool {
+	for i, item := range list.children {
+		lastItem := i == len(list.children)-1
+		if endsWithBlankLine(item) && !lastItem {
+			return false
+		}
+		for j, sub := range item.children {
+			if endsWithBlankLine(sub) && (!lastItem || j < len(item.children)-1) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func endsWithBlankLine(b *block) bool {
+	for b != nil {
+		if b.lastLineBlank {
+			return true
+		}
+		if b.kind != listBlock && b.kind != itemBlock {
+			return false
+		}
+		b = b.lastChild()
+	}
+	return false
+}
+
+// extractReferences parses the link reference definitions at the start of
+// paragraph content, records them and returns the remaining content.
+func (p *blockParser) extractReferences(content string) string {
+	for strings.HasPrefix(content, "[") {
+		n := parseReference(content, p.refs)
+		if n == 0 {
+			break
+		}
+		content = content[n:]
+	}
+	return content
+}
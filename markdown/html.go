@@ -0,0 +1,119 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+	"github.com/MeKo-Christian/JustGoHTML/tokenizer"
+	"github.com/MeKo-Christian/JustGoHTML/treebuilder"
+)
+
+const (
+	tagName         = `[A-Za-z][A-Za-z0-9-]*`
+	attributeName   = `[a-zA-Z_:][a-zA-Z0-9:._-]*`
+	attributeValue  = `(?:[^"'=<>` + "`" + `\x00-\x20]+|'[^']*'|"[^"]*")`
+	attribute       = `(?:\s+` + attributeName + `(?:\s*=\s*` + attributeValue + `)?)`
+	openTag         = `<` + tagName + attribute + `*\s*/?>`
+	closeTag        = `</` + tagName + `\s*>`
+	htmlComment     = `<!-->|<!--->|<!--[\s\S]*?-->`
+	processingInstr = `<\?[\s\S]*?\?>`
+	declaration     = `<![A-Za-z]+[^>]*>`
+	cdata           = `<!\[CDATA\[[\s\S]*?\]\]>`
+)
+
+// reHTMLTag matches raw HTML inlines.
+var reHTMLTag = regexp.MustCompile(`^(?:` + openTag + `|` + closeTag + `|` + htmlComment + `|` +
+	processingInstr + `|` + declaration + `|` + cdata + `)`)
+
+// reHTMLBlockOpen and reHTMLBlockClose hold the start and end conditions of
+// the seven kinds of HTML blocks, indexed by kind. Blocks of kinds 6 and 7
+// end at a blank line.
+var (
+	reHTMLBlockOpen = [...]*regexp.Regexp{
+		nil,
+		regexp.MustCompile(`^(?i)<(?:script|pre|textarea|style)(?:\s|>|$)`),
+		regexp.MustCompile(`^<!--`),
+		regexp.MustCompile(`^<\?`),
+		regexp.MustCompile(`^<![A-Za-z]`),
+		regexp.MustCompile(`^<!\[CDATA\[`),
+		regexp.MustCompile(`^(?i)</?(?:address|article|aside|base|basefont|blockquote|body|caption|center|col|colgroup|dd|details|dialog|dir|div|dl|dt|fieldset|figcaption|figure|footer|form|frame|frameset|h[1-6]|head|header|hr|html|iframe|legend|li|link|main|menu|menuitem|nav|noframes|ol|optgroup|option|p|param|search|section|summary|table|tbody|td|tfoot|th|thead|title|tr|track|ul)(?:\s|/?>|$)`),
+		regexp.MustCompile(`^(?i)(?:` + openTag + `|` + closeTag + `)\s*$`),
+	}
+	reHTMLBlockClose = [...]*regexp.Regexp{
+		nil,
+		regexp.MustCompile(`(?i)</(?:script|pre|textarea|style)>`),
+		regexp.MustCompile(`-->`),
+		regexp.MustCompile(`\?>`),
+		regexp.MustCompile(`>`),
+		regexp.MustCompile(`\]\]>`),
+	}
+)
+
+// rawHTML is a piece of raw HTML source among the converted nodes of a
+// container.
+type rawHTML string
+
+// piece is either a dom.Node or a rawHTML string.
+type piece any
+
+// appendPieces appends the converted content of a container to parent.
+// Containers without raw HTML get their nodes directly; otherwise the nodes
+// are serialized, joined with the raw HTML and parsed as a fragment in the
+// context of parent, so that the result is what a browser builds from the
+// rendered HTML.
+func appendPieces(parent dom.Node, pieces []piece) {
+	hasRaw := false
+	for _, p := range pieces {
+		if _, ok := p.(rawHTML); ok {
+			hasRaw = true
+			break
+		}
+	}
+	if !hasRaw {
+		for _, p := range pieces {
+			parent.AppendChild(p.(dom.Node))
+		}
+		return
+	}
+
+	var sb strings.Builder
+	for _, p := range pieces {
+		switch p := p.(type) {
+		case rawHTML:
+			sb.WriteString(string(p))
+		case dom.Node:
+			sb.WriteString(serialize.ToHTML(p, serialize.Options{}))
+		}
+	}
+	context := "body"
+	if el, ok := parent.(*dom.Element); ok {
+		context = el.TagName
+	}
+	for _, n := range parseFragment(sb.String(), context) {
+		parent.AppendChild(n)
+	}
+}
+
+// parseFragment parses src as HTML in the context of an element named
+// context.
+func parseFragment(src, context string) []dom.Node {
+	tok := tokenizer.New(src)
+	tb := treebuilder.NewFragment(tok, &treebuilder.FragmentContext{TagName: context, Namespace: "html"})
+	for {
+		tok.SetAllowCDATA(tb.AllowCDATA())
+		tt := tok.Next()
+		tb.ProcessToken(tt)
+		if tt.Type == tokenizer.EOF {
+			break
+		}
+	}
+	nodes := tb.FragmentChildNodes()
+	for _, n := range nodes {
+		if parent := n.Parent(); parent != nil {
+			parent.RemoveChild(n)
+		}
+	}
+	return nodes
+}
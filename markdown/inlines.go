@@ -0,0 +1,766 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// inlineKind identifies the type of an inline.
+type inlineKind int
+
+const (
+	textInline inlineKind = iota
+	softBreakInline
+	hardBreakInline
+	codeInline
+	emphasisInline
+	strongInline
+	strikethroughInline
+	linkInline
+	imageInline
+	htmlInline
+)
+
+// inline is a node of the inline tree built for the text of a leaf block.
+// Inlines form a doubly linked tree so that the delimiter algorithm can move
+// runs of siblings cheaply.
+type inline struct {
+	kind                  inlineKind
+	parent                *inline
+	firstChild, lastChild *inline
+	prev, next            *inline
+
+	literal            string
+	destination, title string
+}
+
+func newText(s string) *inline {
+	return &inline{kind: textInline, literal: s}
+}
+
+func (n *inline) appendChild(child *inline) {
+	child.unlink()
+	child.parent = n
+	if n.lastChild != nil {
+		n.lastChild.next = child
+		child.prev = n.lastChild
+	} else {
+		n.firstChild = child
+	}
+	n.lastChild = child
+}
+
+func (n *inline) insertAfter(sibling *inline) {
+	sibling.unlink()
+	sibling.next = n.next
+	if sibling.next != nil {
+		sibling.next.prev = sibling
+	}
+	sibling.prev = n
+	n.next = sibling
+	sibling.parent = n.parent
+	if sibling.next == nil && sibling.parent != nil {
+		sibling.parent.lastChild = sibling
+	}
+}
+
+func (n *inline) unlink() {
+	if n.prev != nil {
+		n.prev.next = n.next
+	} else if n.parent != nil {
+		n.parent.firstChild = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	} else if n.parent != nil {
+		n.parent.lastChild = n.prev
+	}
+	n.parent, n.prev, n.next = nil, nil, nil
+}
+
+// delimiter is an entry of the delimiter stack for emphasis runs.
+type delimiter struct {
+	char              byte
+	count, origCount  int
+	node              *inline
+	previous, next    *delimiter
+	canOpen, canClose bool
+}
+
+// bracket is an entry of the stack of potential link and image openers.
+type bracket struct {
+	node              *inline
+	previous          *bracket
+	previousDelimiter *delimiter
+	index             int
+	image             bool
+	active            bool
+	bracketAfter      bool
+}
+
+const escapable = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+var (
+	reEntityHere         = regexp.MustCompile(`^&(?:#[xX][0-9a-fA-F]{1,6}|#[0-9]{1,7}|[A-Za-z][A-Za-z0-9]{1,31});`)
+	reEntityOrEscape     = regexp.MustCompile(`\\[!"#$%&'()*+,./:;<=>?@[\\\]^_` + "`" + `{|}~-]|&(?:#[xX][0-9a-fA-F]{1,6}|#[0-9]{1,7}|[A-Za-z][A-Za-z0-9]{1,31});`)
+	reTicksHere          = regexp.MustCompile("^`+")
+	reTicks              = regexp.MustCompile("`+")
+	reMain               = regexp.MustCompile("^[^\n`\\[\\]\\\\!<&*_~]+")
+	reInitialSpace       = regexp.MustCompile(`^ *`)
+	reSpnl               = regexp.MustCompile(`^ *(?:\n *)?`)
+	reSpaceAtEndOfLine   = regexp.MustCompile(`^[ \t]*(?:\n|$)`)
+	reLinkDestBraces     = regexp.MustCompile(`^<(?:[^<>\n\\\x00]|\\.)*>`)
+	reLinkTitle          = regexp.MustCompile(`^(?:"(?:\\[\s\S]|[^\\"\x00])*"|'(?:\\[\s\S]|[^\\'\x00])*'|\((?:\\[\s\S]|[^\\()\x00])*\))`)
+	reAutolinkURI        = regexp.MustCompile(`^<[A-Za-z][A-Za-z0-9.+-]{1,31}:[^<>\x00-\x20]*>`)
+	reAutolinkEmail      = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>`)
+	reWhitespaceSequence = regexp.MustCompile(`[ \t\r\n]+`)
+)
+
+// unescapeString replaces backslash escapes and entity references in s.
+func unescapeString(s string) string {
+	if !strings.ContainsAny(s, `\&`) {
+		return s
+	}
+	return reEntityOrEscape.ReplaceAllStringFunc(s, func(m string) string {
+		if m[0] == '\\' {
+			return m[1:]
+		}
+		return decodeEntity(m)
+	})
+}
+
+// decodeEntity decodes a single entity reference, leaving unknown names
+// untouched.
+func decodeEntity(s string) string {
+	decoded := html.UnescapeString(s)
+	if decoded == "\x00" {
+		return "�"
+	}
+	return decoded
+}
+
+// normalizeReference normalizes a link label for matching: the brackets
+// are removed, whitespace is collapsed and case is folded.
+func normalizeReference(label string) string {
+	label = strings.TrimSpace(label[1 : len(label)-1])
+	label = reWhitespaceSequence.ReplaceAllString(label, " ")
+	return strings.ToUpper(strings.ToLower(label))
+}
+
+// inlineParser parses the text content of a leaf block into inlines.
+type inlineParser struct {
+	opts       Options
+	subject    string
+	pos        int
+	delimiters *delimiter
+	brackets   *bracket
+	refs       map[string]linkReference
+}
+
+func (p *inlineParser) peek() byte {
+	if p.pos < len(p.subject) {
+		return p.subject[p.pos]
+	}
+	return 0
+}
+
+// match matches re at the current position and advances past the match.
+func (p *inlineParser) match(re *regexp.Regexp) (string, bool) {
+	loc := re.FindStringIndex(p.subject[p.pos:])
+	if loc == nil {
+		return "", false
+	}
+	m := p.subject[p.pos+loc[0] : p.pos+loc[1]]
+	p.pos += loc[1]
+	return m, true
+}
+
+// parse parses content into children of parent.
+func (p *inlineParser) parse(content string, parent *inline) {
+	p.subject = strings.Trim(content, " \t\n")
+	p.pos = 0
+	p.delimiters = nil
+	p.brackets = nil
+	for p.pos < len(p.subject) {
+		p.parseInline(parent)
+	}
+	p.processEmphasis(nil)
+}
+
+func (p *inlineParser) parseInline(parent *inline) {
+	handled := false
+	switch c := p.peek(); c {
+	case '\n':
+		handled = p.parseNewline(parent)
+	case '\\':
+		handled = p.parseBackslash(parent)
+	case '`':
+		handled = p.parseBackticks(parent)
+	case '*', '_':
+		handled = p.handleDelim(c, parent)
+	case '~':
+		if p.opts.Strikethrough {
+			handled = p.handleDelim(c, parent)
+		}
+	case '[':
+		handled = p.parseOpenBracket(parent)
+	case '!':
+		handled = p.parseBang(parent)
+	case ']':
+		handled = p.parseCloseBracket(parent)
+	case '<':
+		handled = p.parseAutolink(parent) || p.parseHTMLTag(parent)
+	case '&':
+		handled = p.parseEntity(parent)
+	default:
+		handled = p.parseString(parent)
+	}
+	if !handled {
+		p.pos++
+		parent.appendChild(newText(p.subject[p.pos-1 : p.pos]))
+	}
+}
+
+func (p *inlineParser) parseNewline(parent *inline) bool {
+	p.pos++
+	last := parent.lastChild
+	if last != nil && last.kind == textInline && strings.HasSuffix(last.literal, " ") {
+		hard := strings.HasSuffix(last.literal, "  ")
+		last.literal = strings.TrimRight(last.literal, " ")
+		if hard {
+			parent.appendChild(&inline{kind: hardBreakInline})
+		} else {
+			parent.appendChild(&inline{kind: softBreakInline})
+		}
+	} else {
+		parent.appendChild(&inline{kind: softBreakInline})
+	}
+	p.match(reInitialSpace)
+	return true
+}
+
+func (p *inlineParser) parseBackslash(parent *inline) bool {
+	p.pos++
+	switch c := p.peek(); {
+	case c == '\n':
+		p.pos++
+		parent.appendChild(&inline{kind: hardBreakInline})
+	case c != 0 && strings.IndexByte(escapable, c) >= 0:
+		p.pos++
+		parent.appendChild(newText(string(c)))
+	default:
+		parent.appendChild(newText(`\`))
+	}
+	return true
+}
+
+func (p *inlineParser) parseBackticks(parent *inline) bool {
+	ticks, ok := p.match(reTicksHere)
+	if !ok {
+		return false
+	}
+	afterOpenTicks := p.pos
+	for {
+		m, ok := p.match(reTicks)
+		if !ok {
+			break
+		}
+		if m != ticks {
+			continue
+		}
+		contents := strings.ReplaceAll(p.subject[afterOpenTicks:p.pos-len(ticks)], "\n", " ")
+		if len(contents) > 1 && contents[0] == ' ' && contents[len(contents)-1] == ' ' && strings.Trim(contents, " ") != "" {
+			contents = contents[1 : len(contents)-1]
+		}
+		parent.appendChild(&inline{kind: codeInline, literal: contents})
+		return true
+	}
+	p.pos = afterOpenTicks
+	parent.appendChild(newText(ticks))
+	return true
+}
+
+// isPunctuation reports whether r is an ASCII or Unicode punctuation
+// character in the sense of the CommonMark specification.
+func isPunctuation(r rune) bool {
+	if r < utf8.RuneSelf {
+		return strings.ContainsRune(escapable, r)
+	}
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+func isUnicodeWhitespace(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\f' || r == '\r' || unicode.Is(unicode.Zs, r)
+}
+
+// scanDelims measures the delimiter run at the current position and decides
+// whether it can open and close emphasis.
+func (p *inlineParser) scanDelims(c byte) (count int, canOpen, canClose bool) {
+	start := p.pos
+	for count = 0; start+count < len(p.subject) && p.subject[start+count] == c; count++ {
+	}
+
+	before, after := '\n', '\n'
+	if start > 0 {
+		before, _ = utf8.DecodeLastRuneInString(p.subject[:start])
+	}
+	if start+count < len(p.subject) {
+		after, _ = utf8.DecodeRuneInString(p.subject[start+count:])
+	}
+	afterIsWhitespace, afterIsPunctuation := isUnicodeWhitespace(after), isPunctuation(after)
+	beforeIsWhitespace, beforeIsPunctuation := isUnicodeWhitespace(before), isPunctuation(before)
+
+	leftFlanking := !afterIsWhitespace && (!afterIsPunctuation || beforeIsWhitespace || beforeIsPunctuation)
+	rightFlanking := !beforeIsWhitespace && (!beforeIsPunctuation || afterIsWhitespace || afterIsPunctuation)
+	if c == '_' {
+		canOpen = leftFlanking && (!rightFlanking || beforeIsPunctuation)
+		canClose = rightFlanking && (!leftFlanking || afterIsPunctuation)
+	} else {
+		canOpen, canClose = leftFlanking, rightFlanking
+	}
+	return count, canOpen, canClose
+}
+
+func (p *inlineParser) handleDelim(c byte, parent *inline) bool {
+	count, canOpen, canClose := p.scanDelims(c)
+	node := newText(p.subject[p.pos : p.pos+count])
+	p.pos += count
+	parent.appendChild(node)
+	// Runs of more than two tildes never form strikethrough.
+	if (canOpen || canClose) && (c != '~' || count <= 2) {
+		p.delimiters = &delimiter{
+			char: c, count: count, origCount: count, node: node,
+			previous: p.delimiters, canOpen: canOpen, canClose: canClose,
+		}
+		if p.delimiters.previous != nil {
+			p.delimiters.previous.next = p.delimiters
+		}
+	}
+	return true
+}
+
+func (p *inlineParser) removeDelimiter(d *delimiter) {
+	if d.previous != nil {
+		d.previous.next = d.next
+	}
+	if d.next == nil {
+		p.delimiters = d.previous
+	} else {
+		d.next.previous = d.previous
+	}
+}
+
+// openersBottomIndex groups delimiters whose searches for an opener share a
+// lower bound.
+func openersBottomIndex(d *delimiter) int {
+	switch d.char {
+	case '_':
+		if d.canOpen {
+			return 3 + d.origCount%3
+		}
+		return d.origCount % 3
+	case '*':
+		if d.canOpen {
+			return 9 + d.origCount%3
+		}
+		return 6 + d.origCount%3
+	}
+	return 12 + d.origCount
+}
+
+// processEmphasis resolves the delimiter runs above stackBottom into
+// emphasis, strong emphasis and strikethrough.
+func (p *inlineParser) processEmphasis(stackBottom *delimiter) {
+	var openersBottom [15]*delimiter
+	for i := range openersBottom {
+		openersBottom[i] = stackBottom
+	}
+
+	closer := p.delimiters
+	for closer != nil && closer.previous != stackBottom {
+		closer = closer.previous
+	}
+	for closer != nil {
+		if !closer.canClose {
+			closer = closer.next
+			continue
+		}
+
+		bottomIndex := openersBottomIndex(closer)
+		opener := closer.previous
+		found := false
+		for opener != nil && opener != stackBottom && opener != openersBottom[bottomIndex] {
+			if opener.char == closer.char && opener.canOpen {
+				if closer.char == '~' {
+					found = opener.count == closer.count
+				} else {
+					// The "rule of 3": a run that can both open and close
+					// does not match if the sum of the lengths is a
+					// multiple of 3, unless both are.
+					oddMatch := (closer.canOpen || opener.canClose) && closer.origCount%3 != 0 &&
+						(opener.origCount+closer.origCount)%3 == 0
+					found = !oddMatch
+				}
+				if found {
+					break
+				}
+			}
+			opener = opener.previous
+		}
+
+		oldCloser := closer
+		if !found {
+			closer = closer.next
+			openersBottom[bottomIndex] = oldCloser.previous
+			if !oldCloser.canOpen {
+				p.removeDelimiter(oldCloser)
+			}
+			continue
+		}
+
+		kind, used := emphasisInline, 1
+		switch {
+		case closer.char == '~':
+			kind, used = strikethroughInline, closer.count
+		case closer.count >= 2 && opener.count >= 2:
+			kind, used = strongInline, 2
+		}
+		openerNode, closerNode := opener.node, closer.node
+		opener.count -= used
+		closer.count -= used
+		openerNode.literal = openerNode.literal[:len(openerNode.literal)-used]
+		closerNode.literal = closerNode.literal[:len(closerNode.literal)-used]
+
+		emph := &inline{kind: kind}
+		for n := openerNode.next; n != nil && n != closerNode; {
+			next := n.next
+			emph.appendChild(n)
+			n = next
+		}
+		openerNode.insertAfter(emph)
+
+		// Delimiters between the opener and the closer can no longer match.
+		if opener.next != closer {
+			opener.next = closer
+			closer.previous = opener
+		}
+		if opener.count == 0 {
+			openerNode.unlink()
+			p.removeDelimiter(opener)
+		}
+		if closer.count == 0 {
+			closerNode.unlink()
+			next := closer.next
+			p.removeDelimiter(closer)
+			closer = next
+		}
+	}
+
+	for p.delimiters != nil && p.delimiters != stackBottom {
+		p.removeDelimiter(p.delimiters)
+	}
+}
+
+func (p *inlineParser) addBracket(node *inline, index int, image bool) {
+	if p.brackets != nil {
+		p.brackets.bracketAfter = true
+	}
+	p.brackets = &bracket{
+		node: node, previous: p.brackets, previousDelimiter: p.delimiters,
+		index: index, image: image, active: true,
+	}
+}
+
+func (p *inlineParser) parseOpenBracket(parent *inline) bool {
+	start := p.pos
+	p.pos++
+	node := newText("[")
+	parent.appendChild(node)
+	p.addBracket(node, start, false)
+	return true
+}
+
+func (p *inlineParser) parseBang(parent *inline) bool {
+	start := p.pos
+	p.pos++
+	if p.peek() != '[' {
+		parent.appendChild(newText("!"))
+		return true
+	}
+	p.pos++
+	node := newText("![")
+	parent.appendChild(node)
+	p.addBracket(node, start+1, true)
+	return true
+}
+
+// parseCloseBracket tries to match a "]" with the last opener to form an
+// inline link, a reference link or an image.
+func (p *inlineParser) parseCloseBracket(parent *inline) bool {
+	p.pos++
+	start := p.pos
+
+	opener := p.brackets
+	if opener == nil {
+		parent.appendChild(newText("]"))
+		return true
+	}
+	if !opener.active {
+		parent.appendChild(newText("]"))
+		p.brackets = opener.previous
+		return true
+	}
+
+	var dest, title string
+	matched := false
+	savePos := p.pos
+
+	if p.peek() == '(' {
+		p.pos++
+		p.match(reSpnl)
+		if d, ok := p.parseLinkDestination(); ok {
+			dest = d
+			p.match(reSpnl)
+			// A title must be separated from the destination by whitespace.
+			if c := p.subject[p.pos-1]; c == ' ' || c == '\t' || c == '\n' {
+				if t, ok := p.parseLinkTitle(); ok {
+					title = t
+				}
+			}
+			p.match(reSpnl)
+			if p.peek() == ')' {
+				p.pos++
+				matched = true
+			}
+		}
+		if !matched {
+			p.pos = savePos
+		}
+	}
+
+	if !matched {
+		beforeLabel := p.pos
+		n := p.parseLinkLabel()
+		var label string
+		switch {
+		case n > 2:
+			label = p.subject[beforeLabel : beforeLabel+n]
+		case !opener.bracketAfter:
+			// An empty or missing second label means the first label is
+			// the reference, which cannot contain brackets.
+			label = p.subject[opener.index:start]
+		}
+		if n == 0 {
+			p.pos = savePos
+		}
+		if label != "" {
+			if ref, ok := p.refs[normalizeReference(label)]; ok {
+				dest, title = ref.destination, ref.title
+				matched = true
+			}
+		}
+	}
+
+	if !matched {
+		p.brackets = opener.previous
+		p.pos = start
+		parent.appendChild(newText("]"))
+		return true
+	}
+
+	kind := linkInline
+	if opener.image {
+		kind = imageInline
+	}
+	node := &inline{kind: kind, destination: dest, title: title}
+	for n := opener.node.next; n != nil; {
+		next := n.next
+		node.appendChild(n)
+		n = next
+	}
+	parent.appendChild(node)
+	p.processEmphasis(opener.previousDelimiter)
+	p.brackets = opener.previous
+	opener.node.unlink()
+
+	// Links cannot contain other links, so earlier link openers are
+	// deactivated.
+	if !opener.image {
+		for b := p.brackets; b != nil; b = b.previous {
+			if !b.image {
+				b.active = false
+			}
+		}
+	}
+	return true
+}
+
+// parseLinkLabel returns the length of the link label at the current
+// position, or 0 if there is none.
+func (p *inlineParser) parseLinkLabel() int {
+	s := p.subject[p.pos:]
+	if !strings.HasPrefix(s, "[") {
+		return 0
+	}
+	for i := 1; i < len(s) && i <= 1000; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			return 0
+		case ']':
+			p.pos += i + 1
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// parseLinkDestination parses a link destination, either in pointy brackets
+// or as a run of non-space characters with balanced parentheses.
+func (p *inlineParser) parseLinkDestination() (string, bool) {
+	if m, ok := p.match(reLinkDestBraces); ok {
+		return unescapeString(m[1 : len(m)-1]), true
+	}
+	if p.peek() == '<' {
+		return "", false
+	}
+	start := p.pos
+	openParens := 0
+loop:
+	for p.pos < len(p.subject) {
+		switch c := p.subject[p.pos]; {
+		case c == '\\' && p.pos+1 < len(p.subject) && strings.IndexByte(escapable, p.subject[p.pos+1]) >= 0:
+			p.pos += 2
+		case c == '(':
+			p.pos++
+			openParens++
+		case c == ')':
+			if openParens < 1 {
+				break loop
+			}
+			p.pos++
+			openParens--
+		case c <= ' ' || c == 0x7f:
+			break loop
+		default:
+			p.pos++
+		}
+	}
+	if p.pos == start && p.peek() != ')' || openParens != 0 {
+		p.pos = start
+		return "", false
+	}
+	return unescapeString(p.subject[start:p.pos]), true
+}
+
+func (p *inlineParser) parseLinkTitle() (string, bool) {
+	m, ok := p.match(reLinkTitle)
+	if !ok {
+		return "", false
+	}
+	return unescapeString(m[1 : len(m)-1]), true
+}
+
+func (p *inlineParser) parseAutolink(parent *inline) bool {
+	if m, ok := p.match(reAutolinkEmail); ok {
+		address := m[1 : len(m)-1]
+		link := &inline{kind: linkInline, destination: "mailto:" + address}
+		link.appendChild(newText(address))
+		parent.appendChild(link)
+		return true
+	}
+	if m, ok := p.match(reAutolinkURI); ok {
+		uri := m[1 : len(m)-1]
+		link := &inline{kind: linkInline, destination: uri}
+		link.appendChild(newText(uri))
+		parent.appendChild(link)
+		return true
+	}
+	return false
+}
+
+func (p *inlineParser) parseHTMLTag(parent *inline) bool {
+	m, ok := p.match(reHTMLTag)
+	if !ok {
+		return false
+	}
+	parent.appendChild(&inline{kind: htmlInline, literal: m})
+	return true
+}
+
+func (p *inlineParser) parseEntity(parent *inline) bool {
+	m, ok := p.match(reEntityHere)
+	if !ok {
+		return false
+	}
+	parent.appendChild(newText(decodeEntity(m)))
+	return true
+}
+
+func (p *inlineParser) parseString(parent *inline) bool {
+	m, ok := p.match(reMain)
+	if !ok {
+		return false
+	}
+	parent.appendChild(newText(m))
+	return true
+}
+
+// parseReference parses a link reference definition at the start of s,
+// records it in refs unless the label is already defined, and returns the
+// number of bytes consumed, or 0 if s does not start with a definition.
+func parseReference(s string, refs map[string]linkReference) int {
+	p := &inlineParser{subject: s}
+
+	n := p.parseLinkLabel()
+	if n == 0 {
+		return 0
+	}
+	label := s[:n]
+	if p.peek() != ':' {
+		return 0
+	}
+	p.pos++
+
+	p.match(reSpnl)
+	dest, ok := p.parseLinkDestination()
+	if !ok {
+		return 0
+	}
+	beforeTitle := p.pos
+	p.match(reSpnl)
+	title := ""
+	if p.pos != beforeTitle {
+		if t, ok := p.parseLinkTitle(); ok {
+			title = t
+		} else {
+			p.pos = beforeTitle
+		}
+	}
+
+	if _, ok := p.match(reSpaceAtEndOfLine); !ok {
+		if title == "" {
+			return 0
+		}
+		// The title is not at the end of the line, but the definition
+		// can still be valid without it.
+		title = ""
+		p.pos = beforeTitle
+		if _, ok := p.match(reSpaceAtEndOfLine); !ok {
+			return 0
+		}
+	}
+
+	key := normalizeReference(label)
+	if key == "" {
+		return 0
+	}
+	if _, ok := refs[key]; !ok {
+		refs[key] = linkReference{destination: dest, title: title}
+	}
+	return p.pos
+}
@@ -0,0 +1,290 @@
+// Package markdown parses Markdown into DOM trees.
+//
+// The parser implements CommonMark together with the GitHub Flavored
+// Markdown extensions for tables, extended autolinks, strikethrough and task
+// list items. The result is a dom.Document with the same structure a browser
+// builds from the HTML a CommonMark renderer produces, so it can be queried
+// with selectors, serialized, or converted back with serialize.ToMarkdown:
+//
+//	doc := markdown.Parse("# Title\n\nSome *text*.", markdown.DefaultOptions())
+//	h1, _ := doc.QueryFirst("h1")
+//
+// Raw HTML in the Markdown source is parsed with the HTML5 tree builder in
+// the context of the element that contains it.
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	_ "github.com/MeKo-Christian/JustGoHTML/selector" // Register selector functions
+)
+
+// Options selects the Markdown extensions to recognize. The zero value
+// parses plain CommonMark.
+type Options struct {
+	// Tables enables pipe tables with a delimiter row below the header.
+	Tables bool
+
+	// Autolinks links URLs starting with "www.", "http://", "https://" or
+	// "ftp://" and email addresses that are not enclosed in angle brackets.
+	Autolinks bool
+
+	// Strikethrough turns text enclosed in "~" or "~~" into <del>.
+	Strikethrough bool
+
+	// TaskLists turns list items starting with "[ ]" or "[x]" into items
+	// with a disabled checkbox.
+	TaskLists bool
+}
+
+// DefaultOptions returns options with all GitHub Flavored Markdown
+// extensions enabled.
+func DefaultOptions() Options {
+	return Options{
+		Tables:        true,
+		Autolinks:     true,
+		Strikethrough: true,
+		TaskLists:     true,
+	}
+}
+
+// Parse parses Markdown source into a document whose body holds the
+// converted content. Parsing never fails: any input is valid Markdown.
+func Parse(src string, opts Options) *dom.Document {
+	p := newBlockParser(opts)
+	root := p.parse(src)
+
+	doc := dom.NewDocument()
+	html := dom.NewElement("html")
+	body := dom.NewElement("body")
+	doc.AppendChild(html)
+	html.AppendChild(dom.NewElement("head"))
+	html.AppendChild(body)
+
+	c := &converter{opts: opts, refs: p.refs}
+	c.appendBlocks(body, root, false)
+	dom.Normalize(doc)
+	return doc
+}
+
+// converter turns the block tree into DOM nodes.
+type converter struct {
+	opts Options
+	refs map[string]linkReference
+}
+
+var reTaskMarker = regexp.MustCompile(`^\[([ xX])\][ \t\n]`)
+
+var emphasisTags = map[inlineKind]string{
+	emphasisInline:      "em",
+	strongInline:        "strong",
+	strikethroughInline: "del",
+}
+
+// appendBlocks appends the converted children of container to parent.
+// Paragraphs in tight list items are unwrapped.
+func (c *converter) appendBlocks(parent dom.Node, container *block, tight bool) {
+	var pieces []piece
+	for _, b := range container.children {
+		pieces = append(pieces, c.block(b, tight)...)
+	}
+	appendPieces(parent, pieces)
+}
+
+func (c *converter) block(b *block, tight bool) []piece {
+	switch b.kind {
+	case paragraphBlock:
+		if tight {
+			return c.inlines(c.parseInlines(b.content.String()))
+		}
+		p := dom.NewElement("p")
+		appendPieces(p, c.inlines(c.parseInlines(b.content.String())))
+		return []piece{p}
+	case headingBlock:
+		h := dom.NewElement("h" + strconv.Itoa(b.level))
+		appendPieces(h, c.inlines(c.parseInlines(b.content.String())))
+		return []piece{h}
+	case thematicBreakBlock:
+		return []piece{dom.NewElement("hr")}
+	case blockQuoteBlock:
+		quote := dom.NewElement("blockquote")
+		c.appendBlocks(quote, b, false)
+		return []piece{quote}
+	case listBlock:
+		return []piece{c.list(b)}
+	case codeBlock:
+		pre, code := dom.NewElement("pre"), dom.NewElement("code")
+		if lang, _, _ := strings.Cut(b.info, " "); lang != "" {
+			code.SetAttr("class", "language-"+lang)
+		}
+		code.AppendChild(dom.NewText(b.literal))
+		pre.AppendChild(code)
+		return []piece{pre}
+	case htmlBlock:
+		return []piece{rawHTML(b.literal)}
+	case tableBlock:
+		return []piece{c.table(b)}
+	}
+	return nil
+}
+
+func (c *converter) list(b *block) *dom.Element {
+	list := dom.NewElement("ul")
+	if b.list.ordered {
+		list = dom.NewElement("ol")
+		if b.list.start != 1 {
+			list.SetAttr("start", strconv.Itoa(b.list.start))
+		}
+	}
+	for _, item := range b.children {
+		li := dom.NewElement("li")
+		if c.opts.TaskLists && len(item.children) > 0 && item.children[0].kind == paragraphBlock {
+			c.taskItem(li, item, b.list.tight)
+		} else {
+			c.appendBlocks(li, item, b.list.tight)
+		}
+		list.AppendChild(li)
+	}
+	return list
+}
+
+// taskItem converts a list item whose first paragraph may start with a task
+// list marker.
+func (c *converter) taskItem(li *dom.Element, item *block, tight bool) {
+	first := item.children[0]
+	content := first.content.String()
+	m := reTaskMarker.FindStringSubmatch(content)
+	if m == nil {
+		c.appendBlocks(li, item, tight)
+		return
+	}
+
+	checkbox := dom.NewElement("input")
+	checkbox.SetAttr("type", "checkbox")
+	checkbox.SetAttr("disabled", "")
+	if m[1] != " " {
+		checkbox.SetAttr("checked", "")
+	}
+	pieces := []piece{checkbox, dom.NewText(" ")}
+	pieces = append(pieces, c.inlines(c.parseInlines(content[len(m[0]):]))...)
+
+	var rest []piece
+	for _, b := range item.children[1:] {
+		rest = append(rest, c.block(b, tight)...)
+	}
+	if tight {
+		appendPieces(li, append(pieces, rest...))
+		return
+	}
+	p := dom.NewElement("p")
+	appendPieces(p, pieces)
+	appendPieces(li, append([]piece{p}, rest...))
+}
+
+func (c *converter) table(b *block) *dom.Element {
+	table := dom.NewElement("table")
+	thead := dom.NewElement("thead")
+	thead.AppendChild(c.tableRow("th", b.header, b.aligns))
+	table.AppendChild(thead)
+	if len(b.rows) > 0 {
+		tbody := dom.NewElement("tbody")
+		for _, row := range b.rows {
+			tbody.AppendChild(c.tableRow("td", row, b.aligns))
+		}
+		table.AppendChild(tbody)
+	}
+	return table
+}
+
+// tableRow converts a row, padding or truncating its cells to the number of
+// columns.
+func (c *converter) tableRow(tag string, cells, aligns []string) *dom.Element {
+	tr := dom.NewElement("tr")
+	for i, align := range aligns {
+		cell := dom.NewElement(tag)
+		if align != "" {
+			cell.SetAttr("align", align)
+		}
+		if i < len(cells) {
+			appendPieces(cell, c.inlines(c.parseInlines(cells[i])))
+		}
+		tr.AppendChild(cell)
+	}
+	return tr
+}
+
+// parseInlines parses the text of a leaf block into an inline tree.
+func (c *converter) parseInlines(content string) *inline {
+	root := &inline{}
+	p := &inlineParser{opts: c.opts, refs: c.refs}
+	p.parse(content, root)
+	if c.opts.Autolinks {
+		linkifyInlines(root)
+	}
+	return root
+}
+
+// inlines converts the children of n.
+func (c *converter) inlines(n *inline) []piece {
+	var pieces []piece
+	for child := n.firstChild; child != nil; child = child.next {
+		switch child.kind {
+		case textInline:
+			if child.literal != "" {
+				pieces = append(pieces, dom.NewText(child.literal))
+			}
+		case softBreakInline:
+			pieces = append(pieces, dom.NewText("\n"))
+		case hardBreakInline:
+			pieces = append(pieces, dom.NewElement("br"), dom.NewText("\n"))
+		case codeInline:
+			code := dom.NewElement("code")
+			code.AppendChild(dom.NewText(child.literal))
+			pieces = append(pieces, code)
+		case emphasisInline, strongInline, strikethroughInline:
+			el := dom.NewElement(emphasisTags[child.kind])
+			appendPieces(el, c.inlines(child))
+			pieces = append(pieces, el)
+		case linkInline:
+			a := dom.NewElement("a")
+			a.SetAttr("href", child.destination)
+			if child.title != "" {
+				a.SetAttr("title", child.title)
+			}
+			appendPieces(a, c.inlines(child))
+			pieces = append(pieces, a)
+		case imageInline:
+			img := dom.NewElement("img")
+			img.SetAttr("src", child.destination)
+			img.SetAttr("alt", plainText(child))
+			if child.title != "" {
+				img.SetAttr("title", child.title)
+			}
+			pieces = append(pieces, img)
+		case htmlInline:
+			pieces = append(pieces, rawHTML(child.literal))
+		}
+	}
+	return pieces
+}
+
+// plainText returns the text content of n, used as the alternative text of
+// images.
+func plainText(n *inline) string {
+	var sb strings.Builder
+	for child := n.firstChild; child != nil; child = child.next {
+		switch child.kind {
+		case textInline, codeInline:
+			sb.WriteString(child.literal)
+		case softBreakInline, hardBreakInline:
+			sb.WriteByte('\n')
+		case htmlInline:
+		default:
+			sb.WriteString(plainText(child))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,180 @@
+package markdown_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/markdown"
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+)
+
+// bodyHTML returns the serialized children of the document body.
+func bodyHTML(t *testing.T, src string, opts markdown.Options) string {
+	t.Helper()
+	var sb strings.Builder
+	for _, child := range markdown.Parse(src, opts).Body().Children() {
+		sb.WriteString(serialize.ToHTML(child, serialize.Options{}))
+	}
+	return sb.String()
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "headings",
+			input: "# One *x*\n\nTwo\n===\n\nThree\n---\n\n### Four ###",
+			want:  "<h1>One <em>x</em></h1><h1>Two</h1><h2>Three</h2><h3>Four</h3>",
+		},
+		{
+			name:  "paragraphs and breaks",
+			input: "one\ntwo  \nthree\\\nfour\n\nfive",
+			want:  "<p>one\ntwo<br>\nthree<br>\nfour</p><p>five</p>",
+		},
+		{
+			name:  "emphasis",
+			input: "*a* _b_ **c** __d__ ***e*** *f **g** h* foo*bar*baz _foo_bar_ **foo*",
+			want:  "<p><em>a</em> <em>b</em> <strong>c</strong> <strong>d</strong> <em><strong>e</strong></em> <em>f <strong>g</strong> h</em> foo<em>bar</em>baz <em>foo_bar</em> *<em>foo</em></p>",
+		},
+		{
+			name:  "code spans and escapes",
+			input: "`` a ` b `` `x` \\*not\\* &copy; &#35; &nope; \\q",
+			want:  "<p><code>a ` b</code> <code>x</code> *not* © # &amp;nope; \\q</p>",
+		},
+		{
+			name:  "links and images",
+			input: "[a](/u \"T\") [b](<c d>) [c][ref] [ref] ![img *x*](i.png) <https://e.com> <me@e.com>\n\n[ref]: /r 'R'",
+			want:  `<p><a href="/u" title="T">a</a> <a href="c d">b</a> <a href="/r" title="R">c</a> <a href="/r" title="R">ref</a> <img src="i.png" alt="img x"> <a href="https://e.com">https://e.com</a> <a href="mailto:me@e.com">me@e.com</a></p>`,
+		},
+		{
+			name:  "no links in links",
+			input: "[a [b](/1)](/2) [undefined]",
+			want:  `<p>[a <a href="/1">b</a>](/2) [undefined]</p>`,
+		},
+		{
+			name:  "code blocks",
+			input: "    indented\n\tcode\n\n```go extra\nfunc()\n\n```\n\n~~~\n<b>\n~~~",
+			want:  "<pre><code>indented\ncode\n</code></pre><pre><code class=\"language-go\">func()\n\n</code></pre><pre><code>&lt;b&gt;\n</code></pre>",
+		},
+		{
+			name:  "block quotes",
+			input: "> # Q\n> para\nlazy\n>\n> > nested",
+			want:  "<blockquote><h1>Q</h1><p>para\nlazy</p><blockquote><p>nested</p></blockquote></blockquote>",
+		},
+		{
+			name:  "tight and loose lists",
+			input: "- a\n- b\n  - c\n\n3. x\n\n4. y\n",
+			want:  "<ul><li>a</li><li>b<ul><li>c</li></ul></li></ul><ol start=\"3\"><li><p>x</p></li><li><p>y</p></li></ol>",
+		},
+		{
+			name:  "list item indentation",
+			input: "1.  a\n\n    b\n\n        code",
+			want:  "<ol><li><p>a</p><p>b</p><pre><code>code\n</code></pre></li></ol>",
+		},
+		{
+			name:  "thematic breaks",
+			input: "***\n- - -\n___",
+			want:  "<hr><hr><hr>",
+		},
+		{
+			name:  "html blocks",
+			input: "<div class=\"note\">\n*raw*\n</div>\n\n<div>\n\n*md*\n\n</div>",
+			want:  "<div class=\"note\">\n*raw*\n</div><div><p><em>md</em></p></div>",
+		},
+		{
+			name:  "inline html",
+			input: "a <span class=x>*b*</span> <!-- c --> d",
+			want:  `<p>a <span class="x"><em>b</em></span> <!-- c --> d</p>`,
+		},
+		{
+			name:  "tables",
+			input: "| a | b | c |\n|:--|:-:|--:|\n| 1 | `\\|` \\| 2 |\n| 3 |\n\nafter",
+			want:  `<table><thead><tr><th align="left">a</th><th align="center">b</th><th align="right">c</th></tr></thead><tbody><tr><td align="left">1</td><td align="center"><code>|</code> | 2</td><td align="right"></td></tr><tr><td align="left">3</td><td align="center"></td><td align="right"></td></tr></tbody></table><p>after</p>`,
+		},
+		{
+			name:  "strikethrough",
+			input: "~~a~~ ~b~ ~~~c~~~ ~d~~",
+			want:  "<p><del>a</del> <del>b</del> ~~~c~~~ ~d~~</p>",
+		},
+		{
+			name:  "task lists",
+			input: "- [ ] todo\n- [x] done\n- [x]no",
+			want:  `<ul><li><input type="checkbox" disabled=""> todo</li><li><input type="checkbox" disabled="" checked=""> done</li><li>[x]no</li></ul>`,
+		},
+		{
+			name:  "extended autolinks",
+			input: "See www.example.com/a_(b)). or https://e.com/x?y=1, mail a.b@e.co.uk. _http://u.com_",
+			want:  `<p>See <a href="http://www.example.com/a_(b)">www.example.com/a_(b)</a>). or <a href="https://e.com/x?y=1">https://e.com/x?y=1</a>, mail <a href="mailto:a.b@e.co.uk">a.b@e.co.uk</a>. <em><a href="http://u.com">http://u.com</a></em></p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bodyHTML(t, tt.input, markdown.DefaultOptions()); got != tt.want {
+				t.Errorf("Parse(%q)\ngot:  %s\nwant: %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWithoutExtensions(t *testing.T) {
+	input := "| a |\n| - |\n\n~~x~~ www.example.com\n\n- [ ] y"
+	want := "<p>| a |\n| - |</p><p>~~x~~ www.example.com</p><ul><li>[ ] y</li></ul>"
+	if got := bodyHTML(t, input, markdown.Options{}); got != want {
+		t.Errorf("got:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestParseDocument(t *testing.T) {
+	doc := markdown.Parse("# Title\n\n- [one](/1)\n- [two](/2)", markdown.DefaultOptions())
+
+	if got, want := serialize.ToHTML(doc, serialize.Options{}), `<html><head></head><body><h1>Title</h1><ul><li><a href="/1">one</a></li><li><a href="/2">two</a></li></ul></body></html>`; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	links, err := doc.Query("li > a[href]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 2 || links[1].Text() != "two" {
+		t.Errorf("Query(li > a[href]) = %v", links)
+	}
+}
+
+// roundTripDocuments are HTML documents whose Markdown conversion must
+// survive parsing and converting again.
+var roundTripDocuments = []string{
+	"<h1>Title</h1><p>Some   <b>bold</b> and <i> italic </i>text.</p><h3>Sub</h3>",
+	`<p>1. not a list, *stars*, _under_ [x] a\b &amp;copy; &lt;tag&gt; a < b</p><p># no heading</p><p>- no item</p><p>+</p>`,
+	"<p>one<br>two<br>---</p>",
+	"<p>Use <code>a `b` c</code>.</p><pre><code class=\"language-go\">func main() {\n\tfmt.Println(\"```\")\n}\n</code></pre>",
+	"<p><del>old</del> x<sup>2</sup></p>",
+	`<p><a href="/a" title="A &quot;t&quot;">text</a> <a href="https://example.com">https://example.com</a> <a href="mailto:me@example.com">me@example.com</a> <img src="x (1).png" alt="an [x]"></p>`,
+	"<ul><li>one<ul><li>inner</li></ul></li><li>two</li></ul><ol start=\"3\"><li>three</li><li>four</li></ol>",
+	"<ol><li><p>para</p><pre>code\n  more</pre></li><li><blockquote><p>q1</p><p>q2</p></blockquote></li></ol>",
+	"<ul><li>a</li></ul><ul><li>b</li></ul>",
+	`<ul><li><input type="checkbox" checked> done</li><li><input type="checkbox"> todo</li></ul>`,
+	"<blockquote><p>first</p><p>second<br>line</p><ul><li>item</li></ul></blockquote>",
+	`<table><thead><tr><th>Item</th><th align="right">Price</th></tr></thead><tbody><tr><td>a|b</td><td><b>x</b><br>y</td></tr></tbody></table>`,
+}
+
+// TestToMarkdownRoundTrip checks that Markdown produced by
+// serialize.ToMarkdown parses into a tree that converts back to the same
+// Markdown.
+func TestToMarkdownRoundTrip(t *testing.T) {
+	for _, input := range roundTripDocuments {
+		doc, err := JustGoHTML.Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", input, err)
+		}
+		md := serialize.ToMarkdown(doc)
+
+		again := serialize.ToMarkdown(markdown.Parse(md, markdown.DefaultOptions()))
+		if again != md {
+			t.Errorf("round trip of %q changed the Markdown\nfirst:\n%s\nsecond:\n%s", input, md, again)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package metadata extracts page metadata from parsed documents: the title,
+// the standard meta tags, the canonical link, OpenGraph and Twitter card
+// properties, JSON-LD blocks, microdata items and RDFa Lite items.
+package metadata
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// Metadata is the metadata of a document.
+type Metadata struct {
+	// Title is the text of the title element.
+	Title string `json:"title,omitempty"`
+
+	// Description is the content of <meta name="description">.
+	Description string `json:"description,omitempty"`
+
+	// Keywords are the comma-separated entries of <meta name="keywords">.
+	Keywords []string `json:"keywords,omitempty"`
+
+	// Robots are the comma-separated directives of <meta name="robots">,
+	// lowercased.
+	Robots []string `json:"robots,omitempty"`
+
+	// Canonical is the href of <link rel="canonical">, resolved against the
+	// document's base URL when possible.
+	Canonical string `json:"canonical,omitempty"`
+
+	// OpenGraph maps OpenGraph property names, such as "og:title" or
+	// "og:image:width", to their values in document order. Properties of the
+	// article, book, profile, music and video namespaces are included.
+	OpenGraph map[string][]string `json:"openGraph,omitempty"`
+
+	// Twitter maps Twitter card property names, such as "twitter:card", to
+	// their values in document order.
+	Twitter map[string][]string `json:"twitter,omitempty"`
+
+	// JSONLD holds the decoded content of the application/ld+json script
+	// blocks. Blocks that are not valid JSON are skipped.
+	JSONLD []any `json:"jsonLD,omitempty"`
+
+	// Microdata holds the top-level microdata items.
+	Microdata []*Item `json:"microdata,omitempty"`
+
+	// RDFa holds the top-level RDFa Lite items.
+	RDFa []*Item `json:"rdfa,omitempty"`
+}
+
+// Item is a microdata or RDFa item. Its JSON form is the one the WHATWG
+// microdata specification defines.
+type Item struct {
+	// Type holds the item types: the itemtype URLs of a microdata item, or
+	// the expanded typeof terms of an RDFa item.
+	Type []string `json:"type,omitempty"`
+
+	// ID is the global identifier of the item: itemid, or the RDFa resource.
+	ID string `json:"id,omitempty"`
+
+	// Properties maps property names to their values in tree order. A value
+	// is either a string or a nested *Item.
+	Properties map[string][]any `json:"properties"`
+}
+
+// openGraphPrefixes are the namespaces of OpenGraph properties.
+var openGraphPrefixes = []string{"og:", "article:", "book:", "profile:", "music:", "video:"}
+
+// Extract returns the metadata of doc.
+func Extract(doc *dom.Document) *Metadata {
+	md := &Metadata{
+		Title:     strings.Join(strings.Fields(doc.Title()), " "),
+		OpenGraph: map[string][]string{},
+		Twitter:   map[string][]string{},
+	}
+	resolve := urlResolver(doc.URLResolver())
+	extractMeta(doc, md)
+	extractCanonical(doc, md, resolve)
+	extractJSONLD(doc, md)
+	md.Microdata = microdataItems(doc, resolve)
+	md.RDFa = rdfaItems(doc, resolve)
+	if len(md.OpenGraph) == 0 {
+		md.OpenGraph = nil
+	}
+	if len(md.Twitter) == 0 {
+		md.Twitter = nil
+	}
+	return md
+}
+
+func extractMeta(doc *dom.Document, md *Metadata) {
+	metas, _ := doc.Query("meta[content]")
+	for _, meta := range metas {
+		content := meta.Attr("content")
+		name := strings.ToLower(strings.TrimSpace(meta.Attr("name")))
+		property := strings.ToLower(strings.TrimSpace(meta.Attr("property")))
+		switch name {
+		case "description":
+			if md.Description == "" {
+				md.Description = strings.TrimSpace(content)
+			}
+		case "keywords":
+			md.Keywords = append(md.Keywords, splitList(content, false)...)
+		case "robots":
+			md.Robots = append(md.Robots, splitList(content, true)...)
+		}
+		// OpenGraph uses property, Twitter uses name; many pages mix them up.
+		for _, key := range []string{property, name} {
+			if strings.HasPrefix(key, "twitter:") {
+				md.Twitter[key] = append(md.Twitter[key], content)
+				break
+			}
+			if isOpenGraph(key) {
+				md.OpenGraph[key] = append(md.OpenGraph[key], content)
+				break
+			}
+		}
+	}
+}
+
+func isOpenGraph(key string) bool {
+	for _, prefix := range openGraphPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string, lower bool) []string {
+	var out []string
+	for entry := range strings.SplitSeq(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if lower {
+			entry = strings.ToLower(entry)
+		}
+		if entry != "" {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+func extractCanonical(doc *dom.Document, md *Metadata, resolve urlResolver) {
+	links, _ := doc.Query("link[rel~=canonical][href]")
+	if len(links) == 0 {
+		return
+	}
+	md.Canonical = resolve.attr(links[0], "href")
+}
+
+func extractJSONLD(doc *dom.Document, md *Metadata) {
+	scripts, _ := doc.Query("script[type]")
+	for _, script := range scripts {
+		if !strings.EqualFold(strings.TrimSpace(script.Attr("type")), "application/ld+json") {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal([]byte(script.Text()), &v); err == nil {
+			md.JSONLD = append(md.JSONLD, v)
+		}
+	}
+}
+
+// urlResolver resolves URLs against the base URL of the document being
+// extracted, which is looked up once rather than for every attribute.
+type urlResolver func(ref string) (string, bool)
+
+// attr returns the named URL attribute of elem resolved against the
+// document's base URL, or as written if it cannot be resolved.
+func (resolve urlResolver) attr(elem *dom.Element, name string) string {
+	value := elem.Attr(name)
+	if u, ok := resolve(value); ok {
+		return u
+	}
+	return value
+}
@@ -0,0 +1,189 @@
+package metadata_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/metadata"
+)
+
+func mustParse(t *testing.T, html string) *dom.Document {
+	t.Helper()
+	doc, err := JustGoHTML.Parse(html, JustGoHTML.WithBaseURL("https://example.com/posts/1"))
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", html, err)
+	}
+	return doc
+}
+
+// toJSON returns v marshalled and unmarshalled again, so that items compare
+// as plain maps.
+func toJSON(t *testing.T, v any) any {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestExtractHead(t *testing.T) {
+	doc := mustParse(t, `<!DOCTYPE html><head>
+<title>  A   Post </title>
+<meta name="Description" content=" About things. ">
+<meta name="keywords" content="go, html, , parsing">
+<meta name="robots" content="NoIndex, follow">
+<link rel="alternate canonical" href="/posts/first">
+<meta property="og:title" content="OG Title">
+<meta property="og:image" content="https://example.com/a.png">
+<meta property="og:image" content="https://example.com/b.png">
+<meta property="article:author" content="Ann">
+<meta name="twitter:card" content="summary">
+<meta property="twitter:site" content="@example">
+<meta name="og:type" content="article">
+<script type="application/ld+json">{"@context": "https://schema.org", "@type": "Article", "headline": "A Post"}</script>
+<script type="application/ld+json">{not json</script>
+</head>`)
+	md := metadata.Extract(doc)
+	if md.Title != "A Post" {
+		t.Errorf("Title = %q", md.Title)
+	}
+	if md.Description != "About things." {
+		t.Errorf("Description = %q", md.Description)
+	}
+	if want := []string{"go", "html", "parsing"}; !slices.Equal(md.Keywords, want) {
+		t.Errorf("Keywords = %q, want %q", md.Keywords, want)
+	}
+	if want := []string{"noindex", "follow"}; !slices.Equal(md.Robots, want) {
+		t.Errorf("Robots = %q, want %q", md.Robots, want)
+	}
+	if md.Canonical != "https://example.com/posts/first" {
+		t.Errorf("Canonical = %q", md.Canonical)
+	}
+	wantOG := map[string][]string{
+		"og:title":       {"OG Title"},
+		"og:image":       {"https://example.com/a.png", "https://example.com/b.png"},
+		"og:type":        {"article"},
+		"article:author": {"Ann"},
+	}
+	if !reflect.DeepEqual(md.OpenGraph, wantOG) {
+		t.Errorf("OpenGraph = %v, want %v", md.OpenGraph, wantOG)
+	}
+	wantTwitter := map[string][]string{"twitter:card": {"summary"}, "twitter:site": {"@example"}}
+	if !reflect.DeepEqual(md.Twitter, wantTwitter) {
+		t.Errorf("Twitter = %v, want %v", md.Twitter, wantTwitter)
+	}
+	wantLD := []any{map[string]any{"@context": "https://schema.org", "@type": "Article", "headline": "A Post"}}
+	if !reflect.DeepEqual(md.JSONLD, wantLD) {
+		t.Errorf("JSONLD = %v, want %v", md.JSONLD, wantLD)
+	}
+}
+
+func TestExtractEmpty(t *testing.T) {
+	md := metadata.Extract(mustParse(t, `<p>nothing here</p>`))
+	if md.OpenGraph != nil || md.Twitter != nil || md.JSONLD != nil || md.Microdata != nil || md.RDFa != nil {
+		t.Errorf("Extract of a plain document = %+v", md)
+	}
+}
+
+func TestMicrodata(t *testing.T) {
+	doc := mustParse(t, `<div itemscope itemtype="https://schema.org/Movie" itemid="urn:isbn:1" itemref="director">
+  <h1 itemprop="name">Avatar</h1>
+  <span>Genre: <span itemprop="genre keywords">Science fiction</span></span>
+  <a itemprop="trailer" href="../trailer.html">Trailer</a>
+  <img itemprop="image" src="poster.jpg">
+  <meta itemprop="duration" content="PT2H42M">
+  <time itemprop="dateCreated" datetime="2009-12-18">December 2009</time>
+  <data itemprop="rating" value="7.8">Good</data>
+  <div itemprop="author" itemscope itemtype="https://schema.org/Person"><span itemprop="name">Jim</span></div>
+</div>
+<p id="director" itemprop="director">James Cameron</p>
+<section itemscope><p itemprop="a">x</p><div itemscope><p itemprop="b">not a property of the outer item</p></div></section>`)
+	got := toJSON(t, metadata.Extract(doc).Microdata)
+	want := toJSON(t, []any{
+		map[string]any{
+			"type": []string{"https://schema.org/Movie"},
+			"id":   "urn:isbn:1",
+			"properties": map[string]any{
+				"name":        []any{"Avatar"},
+				"genre":       []any{"Science fiction"},
+				"keywords":    []any{"Science fiction"},
+				"trailer":     []any{"https://example.com/trailer.html"},
+				"image":       []any{"https://example.com/posts/poster.jpg"},
+				"duration":    []any{"PT2H42M"},
+				"dateCreated": []any{"2009-12-18"},
+				"rating":      []any{"7.8"},
+				"author": []any{map[string]any{
+					"type":       []string{"https://schema.org/Person"},
+					"properties": map[string]any{"name": []any{"Jim"}},
+				}},
+				"director": []any{"James Cameron"},
+			},
+		},
+		map[string]any{"properties": map[string]any{"a": []any{"x"}}},
+		map[string]any{"properties": map[string]any{"b": []any{"not a property of the outer item"}}},
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Microdata =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestMicrodataCycle(t *testing.T) {
+	doc := mustParse(t, `<div itemscope itemref="a"></div>
+<div id="a" itemprop="x" itemscope itemref="b"></div>
+<div id="b" itemprop="y" itemscope itemref="a"></div>`)
+	got := toJSON(t, metadata.Extract(doc).Microdata)
+	want := toJSON(t, []any{
+		map[string]any{"properties": map[string]any{
+			"x": []any{map[string]any{"properties": map[string]any{
+				"y": []any{map[string]any{"properties": map[string]any{
+					"x": []any{"ERROR"},
+				}}},
+			}}},
+		}},
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Microdata =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestRDFa(t *testing.T) {
+	doc := mustParse(t, `<div vocab="https://schema.org/" typeof="Person" resource="#me" prefix="ex: http://example.org/ns#">
+  <span property="name">Alice</span>
+  <a property="url" href="/alice">home</a>
+  <span property="ex:nick" content="al">Al</span>
+  <div property="address" typeof="PostalAddress"><span property="addressLocality">Berlin</span></div>
+  <span typeof="ex:Thing og:Object"><span property="name">detached</span></span>
+</div>`)
+	got := toJSON(t, metadata.Extract(doc).RDFa)
+	want := toJSON(t, []any{
+		map[string]any{
+			"type": []string{"https://schema.org/Person"},
+			"id":   "https://example.com/posts/1#me",
+			"properties": map[string]any{
+				"name":    []any{"Alice"},
+				"url":     []any{"https://example.com/alice"},
+				"ex:nick": []any{"al"},
+				"address": []any{map[string]any{
+					"type":       []string{"https://schema.org/PostalAddress"},
+					"properties": map[string]any{"addressLocality": []any{"Berlin"}},
+				}},
+			},
+		},
+		map[string]any{
+			"type":       []string{"http://example.org/ns#Thing", "http://ogp.me/ns#Object"},
+			"properties": map[string]any{"name": []any{"detached"}},
+		},
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RDFa =\n%v\nwant\n%v", got, want)
+	}
+}
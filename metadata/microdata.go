@@ -0,0 +1,143 @@
+package metadata
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// microdataItems returns the top-level microdata items of doc: the elements
+// with an itemscope attribute that are not the value of a property.
+func microdataItems(doc *dom.Document, resolve urlResolver) []*Item {
+	scopes, _ := doc.Query("[itemscope]")
+	m := &microdata{doc: doc, resolve: resolve, order: treeOrder(doc)}
+	var items []*Item
+	for _, scope := range scopes {
+		if !scope.HasAttr("itemprop") {
+			items = append(items, m.item(scope, nil))
+		}
+	}
+	return items
+}
+
+type microdata struct {
+	doc     *dom.Document
+	resolve urlResolver
+	order   map[*dom.Element]int
+}
+
+// item returns the item of the itemscope element elem. ancestors lists the
+// elements of the items being built, to stop items that contain themselves.
+func (m *microdata) item(elem *dom.Element, ancestors []*dom.Element) *Item {
+	item := &Item{Properties: map[string][]any{}}
+	item.Type = strings.Fields(elem.Attr("itemtype"))
+	if len(item.Type) > 0 && elem.HasAttr("itemid") {
+		item.ID = m.resolve.attr(elem, "itemid")
+	}
+	ancestors = append(ancestors, elem)
+	for _, prop := range m.properties(elem) {
+		var value any
+		if prop.HasAttr("itemscope") {
+			if slices.Contains(ancestors, prop) {
+				value = "ERROR"
+			} else {
+				value = m.item(prop, ancestors)
+			}
+		} else {
+			value = m.propertyValue(prop)
+		}
+		for _, name := range uniqueFields(prop.Attr("itemprop")) {
+			item.Properties[name] = append(item.Properties[name], value)
+		}
+	}
+	return item
+}
+
+// properties returns the elements holding the properties of the item root,
+// in tree order, following the "properties of an item" algorithm: the
+// descendants of root and of the elements its itemref attribute names,
+// without descending into nested items.
+func (m *microdata) properties(root *dom.Element) []*dom.Element {
+	memory := map[*dom.Element]bool{root: true}
+	var pending, results []*dom.Element
+	pending = append(pending, dom.ChildElements(root)...)
+	for _, id := range strings.Fields(root.Attr("itemref")) {
+		if ref := m.doc.GetElementByID(id); ref != nil {
+			pending = append(pending, ref)
+		}
+	}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		if memory[current] {
+			continue
+		}
+		memory[current] = true
+		if !current.HasAttr("itemscope") {
+			pending = append(pending, dom.ChildElements(current)...)
+		}
+		if len(strings.Fields(current.Attr("itemprop"))) > 0 {
+			results = append(results, current)
+		}
+	}
+	slices.SortFunc(results, func(a, b *dom.Element) int {
+		return m.order[a] - m.order[b]
+	})
+	return results
+}
+
+// propertyValue returns the value of the property element elem, which does
+// not have an itemscope attribute.
+func (m *microdata) propertyValue(elem *dom.Element) string {
+	if elem.Namespace != dom.NamespaceHTML {
+		return elem.Text()
+	}
+	switch elem.TagName {
+	case "meta":
+		return elem.Attr("content")
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		return m.urlValue(elem, "src")
+	case "a", "area", "link":
+		return m.urlValue(elem, "href")
+	case "object":
+		return m.urlValue(elem, "data")
+	case "data", "meter":
+		return elem.Attr("value")
+	case "time":
+		if elem.HasAttr("datetime") {
+			return elem.Attr("datetime")
+		}
+	}
+	return elem.Text()
+}
+
+// urlValue returns the value of a URL property: the named attribute
+// resolved to an absolute URL, or "" if it has none.
+func (m *microdata) urlValue(elem *dom.Element, name string) string {
+	if !elem.HasAttr(name) {
+		return ""
+	}
+	return m.resolve.attr(elem, name)
+}
+
+// uniqueFields splits s on whitespace and drops repeated tokens.
+func uniqueFields(s string) []string {
+	var out []string
+	for _, f := range strings.Fields(s) {
+		if !slices.Contains(out, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// treeOrder numbers the elements of doc in tree order.
+func treeOrder(doc *dom.Document) map[*dom.Element]int {
+	order := map[*dom.Element]int{}
+	dom.WalkElements(doc, func(e *dom.Element) bool {
+		order[e] = len(order)
+		return true
+	})
+	return order
+}
@@ -0,0 +1,138 @@
+package metadata
+
+import (
+	"maps"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// defaultPrefixes are the prefixes of the RDFa initial context that pages
+// commonly use without declaring them.
+var defaultPrefixes = map[string]string{
+	"dc":     "http://purl.org/dc/terms/",
+	"foaf":   "http://xmlns.com/foaf/0.1/",
+	"og":     "http://ogp.me/ns#",
+	"schema": "http://schema.org/",
+}
+
+// rdfaContext holds the vocabulary and prefix mappings in scope.
+type rdfaContext struct {
+	vocab    string
+	prefixes map[string]string
+}
+
+// rdfaItems returns the top-level RDFa Lite items of doc: the elements with a
+// typeof attribute that are not the value of a property of another item.
+//
+// Item types are expanded to IRIs with the vocab and prefix attributes in
+// scope. Property names are kept as written, as microdata property names are.
+func rdfaItems(doc *dom.Document, resolve urlResolver) []*Item {
+	var items []*Item
+	var walk func(n dom.Node, ctx rdfaContext, inItem bool)
+	walk = func(n dom.Node, ctx rdfaContext, inItem bool) {
+		for _, elem := range dom.ChildElements(n) {
+			c := ctx.enter(elem)
+			if elem.HasAttr("typeof") && !(inItem && elem.HasAttr("property")) {
+				item := &Item{Properties: map[string][]any{}}
+				fillRDFaItem(item, elem, c, resolve)
+				items = append(items, item)
+				walk(elem, c, true)
+				continue
+			}
+			walk(elem, c, inItem)
+		}
+	}
+	walk(doc, rdfaContext{prefixes: defaultPrefixes}, false)
+	return items
+}
+
+// fillRDFaItem sets the type, id and properties of the item of the typeof
+// element elem.
+func fillRDFaItem(item *Item, elem *dom.Element, ctx rdfaContext, resolve urlResolver) {
+	for _, term := range strings.Fields(elem.Attr("typeof")) {
+		item.Type = append(item.Type, ctx.expand(term))
+	}
+	if elem.HasAttr("resource") {
+		item.ID = resolve.attr(elem, "resource")
+	}
+	var walk func(n *dom.Element, ctx rdfaContext)
+	walk = func(n *dom.Element, ctx rdfaContext) {
+		for _, child := range dom.ChildElements(n) {
+			c := ctx.enter(child)
+			names := uniqueFields(child.Attr("property"))
+			if child.HasAttr("typeof") {
+				// A nested item: the value of the property, if any, whose own
+				// properties are its descendants.
+				if len(names) > 0 {
+					nested := &Item{Properties: map[string][]any{}}
+					fillRDFaItem(nested, child, c, resolve)
+					for _, name := range names {
+						item.Properties[name] = append(item.Properties[name], nested)
+					}
+				}
+				continue
+			}
+			if len(names) > 0 {
+				value := rdfaValue(child, resolve)
+				for _, name := range names {
+					item.Properties[name] = append(item.Properties[name], value)
+				}
+			}
+			walk(child, c)
+		}
+	}
+	walk(elem, ctx)
+}
+
+// rdfaValue returns the value of a property element that does not start a
+// new item.
+func rdfaValue(elem *dom.Element, resolve urlResolver) string {
+	switch {
+	case elem.HasAttr("content"):
+		return elem.Attr("content")
+	case elem.HasAttr("resource"):
+		return resolve.attr(elem, "resource")
+	case elem.HasAttr("href"):
+		return resolve.attr(elem, "href")
+	case elem.HasAttr("src"):
+		return resolve.attr(elem, "src")
+	case elem.TagName == "time" && elem.HasAttr("datetime"):
+		return elem.Attr("datetime")
+	}
+	return elem.Text()
+}
+
+// enter returns the context inside elem, applying its vocab and prefix
+// attributes.
+func (ctx rdfaContext) enter(elem *dom.Element) rdfaContext {
+	if vocab, ok := elem.Attributes.Get("vocab"); ok {
+		ctx.vocab = strings.TrimSpace(vocab)
+	}
+	if prefix, ok := elem.Attributes.Get("prefix"); ok {
+		prefixes := maps.Clone(ctx.prefixes)
+		// The value is a list of "name: IRI" pairs.
+		fields := strings.Fields(prefix)
+		for i := 0; i+1 < len(fields); i += 2 {
+			name, ok := strings.CutSuffix(fields[i], ":")
+			if !ok {
+				i--
+				continue
+			}
+			prefixes[strings.ToLower(name)] = fields[i+1]
+		}
+		ctx.prefixes = prefixes
+	}
+	return ctx
+}
+
+// expand returns the IRI of an RDFa term, CURIE or IRI.
+func (ctx rdfaContext) expand(term string) string {
+	if prefix, local, ok := strings.Cut(term, ":"); ok {
+		if iri, known := ctx.prefixes[strings.ToLower(prefix)]; known {
+			return iri + local
+		}
+		return term
+	}
+	return ctx.vocab + term
+}
@@ -0,0 +1,157 @@
+// Package minify writes DOM trees as the smallest HTML that parses into an
+// equivalent document.
+//
+// HTML rewrites a copy of the tree, dropping comments, insignificant
+// whitespace and default attributes, and serializes the result with
+// serialize.ToHTML using optional tag omission, minimal attribute quoting and
+// boolean attribute minimization.
+package minify
+
+import (
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+)
+
+// Options selects the minifications to apply.
+type Options struct {
+	// OmitOptionalTags omits start and end tags the HTML syntax allows to be
+	// left out, such as </p>, </li> and <tbody>.
+	OmitOptionalTags bool
+
+	// UnquoteAttributes leaves attribute values unquoted where the syntax
+	// allows it.
+	UnquoteAttributes bool
+
+	// CollapseBooleanAttributes writes boolean attributes such as
+	// disabled="disabled" as disabled, and empty values such as class="" as
+	// class. Other attributes keep their value, even if it is their name.
+	CollapseBooleanAttributes bool
+
+	// CollapseWhitespace collapses runs of whitespace to a single space and
+	// removes whitespace that is not rendered, such as whitespace next to
+	// block boundaries. Preformatted, raw text and foreign content is left
+	// unchanged.
+	CollapseWhitespace bool
+
+	// RemoveComments removes comments. Conditional comments
+	// (<!--[if IE]>...<![endif]-->) are kept.
+	RemoveComments bool
+
+	// RemoveDefaultAttributes removes attributes set to their default value,
+	// such as type="text/javascript" on scripts and method="get" on forms.
+	RemoveDefaultAttributes bool
+}
+
+// DefaultOptions returns options with every minification enabled.
+func DefaultOptions() Options {
+	return Options{
+		OmitOptionalTags:          true,
+		UnquoteAttributes:         true,
+		CollapseBooleanAttributes: true,
+		CollapseWhitespace:        true,
+		RemoveComments:            true,
+		RemoveDefaultAttributes:   true,
+	}
+}
+
+// HTML returns the minified HTML of node. The node itself is not modified.
+func HTML(node dom.Node, opts Options) string {
+	node = node.Clone(true)
+	rewrite(node, opts)
+	if opts.CollapseWhitespace {
+		normalize(node)
+		serialize.CollapseWhitespace(node)
+	}
+
+	return serialize.ToHTML(node, serialize.Options{
+		OmitOptionalTags:          opts.OmitOptionalTags,
+		MinimizeAttributeQuotes:   opts.UnquoteAttributes,
+		MinimizeBooleanAttributes: opts.CollapseBooleanAttributes,
+	})
+}
+
+// rewrite removes comments and rewrites the attributes in the subtree rooted
+// at node.
+func rewrite(node dom.Node, opts Options) {
+	if elem, ok := node.(*dom.Element); ok {
+		rewriteAttributes(elem, opts)
+		if elem.TemplateContent != nil {
+			rewrite(elem.TemplateContent, opts)
+		}
+	}
+	for _, child := range childNodes(node) {
+		if c, ok := child.(*dom.Comment); ok {
+			if opts.RemoveComments && !isConditionalComment(c.Data) {
+				node.RemoveChild(c)
+			}
+			continue
+		}
+		rewrite(child, opts)
+	}
+}
+
+// normalize merges the text nodes left adjacent by comment removal, including
+// inside template contents.
+func normalize(node dom.Node) {
+	dom.Normalize(node)
+	dom.WalkElements(node, func(elem *dom.Element) bool {
+		if elem.TemplateContent != nil {
+			normalize(elem.TemplateContent)
+		}
+		return true
+	})
+}
+
+// rewriteAttributes removes default attributes of elem and collapses its
+// boolean attributes.
+func rewriteAttributes(elem *dom.Element, opts Options) {
+	if elem.Namespace != dom.NamespaceHTML {
+		return
+	}
+	for _, attr := range elem.Attributes.All() {
+		if attr.Namespace != "" {
+			continue
+		}
+		switch {
+		case opts.RemoveDefaultAttributes && isDefaultAttribute(elem, attr.Name, attr.Value):
+			elem.RemoveAttr(attr.Name)
+		case opts.CollapseBooleanAttributes && attr.Value != "" && serialize.IsBooleanAttribute(elem.TagName, attr.Name):
+			elem.SetAttr(attr.Name, "")
+		}
+	}
+}
+
+// isDefaultAttribute reports whether the attribute name="value" on elem has
+// the same effect as leaving it out.
+func isDefaultAttribute(elem *dom.Element, name, value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch elem.TagName + " " + name {
+	case "script type":
+		return value == "text/javascript"
+	case "style type":
+		return value == "text/css"
+	case "link type":
+		return value == "text/css" && strings.EqualFold(strings.TrimSpace(elem.Attr("rel")), "stylesheet")
+	case "form method":
+		return value == "get"
+	case "form enctype":
+		return value == "application/x-www-form-urlencoded"
+	}
+	return false
+}
+
+// isConditionalComment reports whether a comment with the given data belongs
+// to an Internet Explorer conditional comment, which old browsers treat as
+// markup. The closers are <!--<![endif]--> and, after a downlevel-revealed
+// <![if !IE]> opener, the bogus comment <![endif]>.
+func isConditionalComment(data string) bool {
+	return strings.HasPrefix(data, "[if") || strings.HasPrefix(data, "<![endif]") || strings.HasPrefix(data, "[endif]")
+}
+
+// childNodes returns a copy of the children of node, so that they can be
+// removed while iterating.
+func childNodes(node dom.Node) []dom.Node {
+	return append([]dom.Node(nil), node.Children()...)
+}
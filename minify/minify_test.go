@@ -0,0 +1,241 @@
+package minify_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/minify"
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+)
+
+func mustParse(t *testing.T, html string) *dom.Document {
+	t.Helper()
+	doc, err := JustGoHTML.Parse(html)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", html, err)
+	}
+	return doc
+}
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "document",
+			input: "<!DOCTYPE html>\n<html>\n<head>\n  <title>Title</title>\n</head>\n<body>\n  <p>Hello,   <b>world</b> !</p>\n</body>\n</html>\n",
+			want:  "<!DOCTYPE html><title>Title</title><p>Hello, <b>world</b> !",
+		},
+		{
+			name:  "optional tags",
+			input: "<ul><li>one</li><li>two</li></ul><table><tr><td>a</td><td>b</td></tr></table>",
+			want:  "<ul><li>one<li>two</ul><table><tr><td>a<td>b</table>",
+		},
+		{
+			name:  "unquoted attributes",
+			input: `<a href="/x" title="two words" class="">x</a>`,
+			want:  `<a href=/x title="two words" class>x</a>`,
+		},
+		{
+			name:  "boolean attributes",
+			input: `<input type="checkbox" checked="checked" disabled="disabled"><div hidden="until-found">x</div>`,
+			want:  `<input type=checkbox checked disabled><div hidden=until-found>x</div>`,
+		},
+		{
+			name:  "attributes named like their value",
+			input: `<img alt="alt" src="a.png"><a title="title" href="/">x</a><input value="value"><div disabled="disabled">y</div>`,
+			want:  `<img alt=alt src=a.png><a title=title href=/>x</a><input value=value><div disabled=disabled>y</div>`,
+		},
+		{
+			name:  "default attributes",
+			input: `<script type="text/javascript">f()</script><form method="GET" action="/s"><input type="text"></form>`,
+			want:  `<script>f()</script><form action=/s><input type=text></form>`,
+		},
+		{
+			name:  "stylesheet link type",
+			input: `<link rel="stylesheet" type="text/css" href="a.css"><link rel="icon" type="text/css" href="b">`,
+			want:  `<link rel=stylesheet href=a.css><link rel=icon type=text/css href=b>`,
+		},
+		{
+			name:  "comments",
+			input: "<p>a<!-- note -->b</p><!--[if IE]><p>old</p><![endif]-->",
+			want:  "<p>ab</p><!--[if IE]><p>old</p><![endif]-->",
+		},
+		{
+			name:  "downlevel-revealed conditional comments",
+			input: "<![if !IE]><p>new</p><![endif]><!--[if !IE]><!--><p>y</p><!--<![endif]-->",
+			want:  "<!--[if !IE]--><p>new</p><!--[endif]--><!--[if !IE]><!--><p>y</p><!--<![endif]-->",
+		},
+		{
+			name:  "whitespace between inline elements",
+			input: "<div>  <span>a </span> <span> b</span>  </div>",
+			want:  "<div><span>a </span><span>b</span></div>",
+		},
+		{
+			name:  "line breaks",
+			input: "<p>one <br> two <img src=x.png> three</p>",
+			want:  "<p>one<br>two <img src=x.png> three",
+		},
+		{
+			name:  "preformatted content",
+			input: "<div> <pre>\n  a   b\n</pre> <textarea>  x  </textarea> <script>var  a;</script> </div>",
+			want:  "<div><pre>  a   b\n</pre><textarea>  x  </textarea> <script>var  a;</script></div>",
+		},
+		{
+			name:  "white-space style",
+			input: `<p>a  <span style="white-space: pre">b   c</span>  d</p>`,
+			want:  `<p>a <span style="white-space: pre">b   c</span> d`,
+		},
+		{
+			name:  "foreign content",
+			input: "<div><svg><text> a   b </text></svg></div>",
+			want:  "<div><svg><text> a   b </text></svg></div>",
+		},
+		{
+			name:  "template content",
+			input: "<template>\n  <p> x </p>\n  <!-- c -->\n</template>",
+			want:  "<template><p>x</template>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, tt.input)
+			if got := minify.HTML(doc, minify.DefaultOptions()); got != tt.want {
+				t.Errorf("HTML(%q)\ngot:  %q\nwant: %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLOptions(t *testing.T) {
+	doc := mustParse(t, "<p class=\"a\">x  <!-- c --> y</p><script type=\"text/javascript\"></script>")
+	body := doc.Body()
+
+	got := minify.HTML(body, minify.Options{RemoveComments: true})
+	want := "<body><p class=\"a\">x   y</p><script type=\"text/javascript\"></script></body>"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if got := minify.HTML(body, minify.Options{}); got != serialize.ToHTML(body, serialize.Options{}) {
+		t.Errorf("zero Options changed the output: %q", got)
+	}
+}
+
+func TestHTMLDoesNotModifyInput(t *testing.T) {
+	doc := mustParse(t, "<p> a <!-- c --> b </p>")
+	before := serialize.ToHTML(doc, serialize.DefaultOptions())
+
+	minify.HTML(doc, minify.DefaultOptions())
+
+	if after := serialize.ToHTML(doc, serialize.DefaultOptions()); after != before {
+		t.Errorf("input changed from %q to %q", before, after)
+	}
+}
+
+var equivalenceDocuments = []string{
+	`<!DOCTYPE html><html lang="en"><head><meta charset="utf-8"><title> T </title></head><body><p>x</p></body></html>`,
+	`<div>text<b>bold</b> <i>italic</i><p>para</p>tail</div>`,
+	`<ul><li>one<li>two <ul><li>nested</ul></ul><ol><li><p>x</p></ol>`,
+	`<table><caption>c</caption><tr><td>a<td> b </table>`,
+	`<p>a&nbsp;&nbsp;b &lt;c&gt; &amp;</p><p title="a'b&quot;c">q</p>`,
+	`<dl><dt>term<dd>def</dl><select><option selected="selected">a<option>b</select>`,
+	`<body> <!-- lead --> text <p>p</p> <div> d </div> </body>`,
+	`<p>a <em> b </em> c<br> d</p><button> e </button>`,
+}
+
+// TestHTMLEquivalence checks that parsing the minified HTML gives a document
+// that renders the same text as the original.
+func TestHTMLEquivalence(t *testing.T) {
+	for _, input := range equivalenceDocuments {
+		doc := mustParse(t, input)
+		minified := minify.HTML(doc, minify.DefaultOptions())
+		again := mustParse(t, minified)
+
+		if got, want := renderedText(again), renderedText(doc); got != want {
+			t.Errorf("rendering changed for %q\nminified: %s\nwant: %q\ngot:  %q", input, minified, want, got)
+		}
+		if twice := minify.HTML(again, minify.DefaultOptions()); twice != minified {
+			t.Errorf("minifying is not idempotent for %q\nfirst:  %s\nsecond: %s", input, minified, twice)
+		}
+	}
+}
+
+func TestHTMLKeepsAttributeValues(t *testing.T) {
+	doc := mustParse(t, `<img alt="alt" src="a.png"><a title="title" href="/">x</a><input value="value" name="name"><input type="checkbox" checked="checked">`)
+	again := mustParse(t, minify.HTML(doc, minify.DefaultOptions()))
+	want := map[string]string{"alt": "alt", "title": "title", "value": "value", "name": "name", "checked": ""}
+	for name, value := range want {
+		elems, _ := again.Query("[" + name + "]")
+		if len(elems) != 1 || elems[0].Attr(name) != value {
+			t.Errorf("after minifying, %s = %v, want %q", name, elems, value)
+		}
+	}
+}
+
+var blockTags = map[string]bool{}
+
+func init() {
+	for _, tag := range strings.Fields(`address article aside blockquote body caption center
+		colgroup dd details dialog dir div dl dt fieldset figcaption figure footer form
+		frameset h1 h2 h3 h4 h5 h6 header hgroup hr html legend li main menu nav ol
+		optgroup option p search section summary table tbody td tfoot th thead tr ul`) {
+		blockTags[tag] = true
+	}
+}
+
+var (
+	lineBreakSpace = regexp.MustCompile(`[ \n]*\n[ \n]*`)
+	spaceRun       = regexp.MustCompile(`[ \t\n\f\r]+`)
+)
+
+// renderedText approximates the text a browser renders for n: whitespace is
+// collapsed, blocks and <br> start new lines, and attributes are shown so
+// that attribute changes are noticed.
+func renderedText(n dom.Node) string {
+	var sb strings.Builder
+	writeRenderedText(&sb, n)
+	s := lineBreakSpace.ReplaceAllString(sb.String(), "\n")
+	return strings.Trim(regexp.MustCompile(` +`).ReplaceAllString(s, " "), " \n")
+}
+
+func writeRenderedText(sb *strings.Builder, n dom.Node) {
+	switch n := n.(type) {
+	case *dom.Text:
+		sb.WriteString(spaceRun.ReplaceAllString(n.Data, " "))
+		return
+	case *dom.Element:
+		if n.TagName == "br" {
+			sb.WriteByte('\n')
+			return
+		}
+		for _, attr := range n.Attributes.All() {
+			if attr.Name == "selected" {
+				// The value of a boolean attribute does not matter.
+				attr.Value = ""
+			}
+			sb.WriteString("[" + attr.Name + "=" + attr.Value + "]")
+		}
+		switch n.TagName {
+		case "script", "style", "title", "textarea", "pre":
+			sb.WriteString("[" + n.Text() + "]")
+			return
+		case "button":
+			sb.WriteString("[" + strings.TrimSpace(spaceRun.ReplaceAllString(n.Text(), " ")) + "]")
+			return
+		}
+		if blockTags[n.TagName] {
+			sb.WriteByte('\n')
+			defer sb.WriteByte('\n')
+		}
+	}
+	for _, child := range n.Children() {
+		writeRenderedText(sb, child)
+	}
+}
@@ -12,6 +12,10 @@ type config struct {
 	strict          bool
 	collectErrors   bool
 	xmlCoercion     bool
+	elementIndex    bool
+	xmlNodes        bool
+	scripting       bool
+	baseURL         string
 }
 
 // newConfig creates a new config with defaults and applies options.
@@ -92,3 +96,44 @@ func WithXMLCoercion() Option {
 		c.xmlCoercion = true
 	}
 }
+
+// WithElementIndex builds the document's id/class/tag index while the tree is
+// constructed, so that GetElementByID, GetElementsByClassName and
+// GetElementsByTagName are answered without walking the tree.
+// See dom.Document.EnableIndex.
+func WithElementIndex() Option {
+	return func(c *config) {
+		c.elementIndex = true
+	}
+}
+
+// WithXMLNodes preserves XML constructs found in foreign (SVG and MathML)
+// content: CDATA sections become dom.CDATASection nodes instead of being
+// merged into the surrounding text, and "<?target data?>" markup becomes a
+// dom.ProcessingInstruction instead of a comment. HTML content is unaffected.
+func WithXMLNodes() Option {
+	return func(c *config) {
+		c.xmlNodes = true
+	}
+}
+
+// WithScripting parses as a browser with scripting enabled does: the content
+// of noscript elements becomes raw text instead of markup. By default the
+// parser behaves as if scripting were disabled, so that noscript content is
+// available as a tree. Serialize such documents with serialize.Options
+// Scripting set, so that the noscript text is not escaped.
+func WithScripting() Option {
+	return func(c *config) {
+		c.scripting = true
+	}
+}
+
+// WithBaseURL sets the address the document was loaded from (Document.URL).
+// Relative URLs in the document resolve against it, or against the href of
+// its base element, if it has one: see Document.ResolveURL and
+// Element.ResolvedAttr.
+func WithBaseURL(url string) Option {
+	return func(c *config) {
+		c.baseURL = url
+	}
+}
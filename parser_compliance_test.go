@@ -260,12 +260,6 @@ func runComplianceFile(path string, parseFunc parseAndSerializeFunc) (passed, fa
 	fileName := filepath.Base(path)
 
 	for _, test := range tests {
-		// Skip script tests (no JS engine)
-		if test.ScriptDirective == "script-on" {
-			skipped++
-			continue
-		}
-
 		// Skip fragment parsing tests (requires special handling)
 		if test.FragmentContext != "" {
 			skipped++
@@ -315,7 +309,8 @@ func netHTMLParseFunc(test testutil.TreeConstructionTest) (string, error, bool)
 		return "", nil, true
 	}
 
-	doc, err := html.Parse(strings.NewReader(test.Data))
+	scripting := html.ParseOptionEnableScripting(test.ScriptDirective == "script-on")
+	doc, err := html.ParseWithOptions(strings.NewReader(test.Data), scripting)
 	if err != nil {
 		return "", err, false
 	}
@@ -331,6 +326,9 @@ func justGoHTMLParseFunc(test testutil.TreeConstructionTest) (string, error, boo
 	if test.XMLCoercion {
 		opts = append(opts, WithXMLCoercion())
 	}
+	if test.ScriptDirective == "script-on" {
+		opts = append(opts, WithScripting())
+	}
 
 	doc, err := Parse(test.Data, opts...)
 	if err != nil {
@@ -426,7 +424,7 @@ func TestParserComplianceComparison(t *testing.T) {
 		"golang.org/x/net/html", netHTMLResult.Passed, netHTMLResult.Failed, netHTMLResult.Skipped, netHTMLResult.Percentage)
 	t.Logf("╚═══════════════════════════╩═════════╩═════════╩═════════╩═══════════════════╝")
 	t.Logf("\nNote: goquery uses golang.org/x/net/html as its parser, so compliance is identical.")
-	t.Logf("Tests skipped: script-on tests (no JS engine) and fragment parsing tests.")
+	t.Logf("Tests skipped: fragment parsing tests. script-on tests run with scripting enabled.")
 }
 
 // =============================================================================
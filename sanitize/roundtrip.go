@@ -0,0 +1,130 @@
+package sanitize
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/diff"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+	"github.com/MeKo-Christian/JustGoHTML/tokenizer"
+	"github.com/MeKo-Christian/JustGoHTML/treebuilder"
+)
+
+// ErrUnstable is returned by HTML when the sanitized markup does not parse
+// back into the sanitized tree, even after sanitizing it again.
+var ErrUnstable = errors.New("sanitize: serialized markup does not parse back into the same tree")
+
+// RoundTrip serializes node with serialize.ToHTML, parses the markup again
+// and returns the differences between node and the reparsed tree, as an edit
+// script from the first to the second (see diff.Diff). An empty script means
+// the serialized form is stable.
+//
+// A document is reparsed as a document. The children of an element are
+// reparsed in the context of the element, as assigning its innerHTML would
+// parse them, and the children of other nodes in the context of a body
+// element.
+//
+// Even trees the parser built itself can change: it repairs markup it could
+// not have produced, so a p element that ended up inside another p, or an
+// element of one namespace serialized inside another (a </p> in SVG content
+// breaks out into HTML, an HTML element inside math becomes MathML), parse
+// differently the second time. These differences are how mutation XSS slips
+// past sanitizers, which inspect one tree while the browser builds another.
+//
+// With scripting, the markup is parsed as by a browser with scripting enabled,
+// which reads noscript content as raw text (see JustGoHTML.WithScripting),
+// and node is serialized as a tree parsed that way (see
+// serialize.Options.Scripting).
+func RoundTrip(node dom.Node, scripting bool) []diff.Edit {
+	switch n := node.(type) {
+	case *dom.Document:
+		return diff.Diff(n, parse(serialize.ToHTML(n, serializeOptions(scripting)), nil, scripting))
+	case *dom.Element:
+		if n.TemplateContent != nil && n.Namespace == dom.NamespaceHTML {
+			return roundTripChildren(n.TemplateContent, n.TemplateContent.Clone(false), n, scripting)
+		}
+		return roundTripChildren(n, n.Clone(false), n, scripting)
+	case *dom.DocumentFragment:
+		return roundTripChildren(n, n.Clone(false), nil, scripting)
+	}
+	frag := dom.NewDocumentFragment()
+	frag.AppendChild(node.Clone(true))
+	return roundTripChildren(frag, dom.NewDocumentFragment(), nil, scripting)
+}
+
+// Stable reports whether node parses back into an identical tree after
+// serialization, both with scripting disabled and enabled.
+func Stable(node dom.Node) bool {
+	return len(RoundTrip(node, false)) == 0 && len(RoundTrip(node, true)) == 0
+}
+
+// roundTripChildren reparses the serialized children of node into the empty
+// copy of it and compares the two. The children are parsed in the context of
+// the element context, or of a body element if it is nil.
+func roundTripChildren(node, copied dom.Node, context *dom.Element, scripting bool) []diff.Edit {
+	var sb strings.Builder
+	opts := serializeOptions(scripting)
+	for _, child := range node.Children() {
+		sb.WriteString(serialize.ToHTML(child, opts))
+	}
+	ctx := &treebuilder.FragmentContext{TagName: "body", Namespace: "html"}
+	if context != nil {
+		ctx.TagName = context.TagName
+		switch context.Namespace {
+		case dom.NamespaceSVG:
+			ctx.Namespace = "svg"
+		case dom.NamespaceMathML:
+			ctx.Namespace = "mathml"
+		}
+	}
+	reparsed := parse(sb.String(), ctx, scripting)
+	for _, child := range slices.Clone(reparsed.Children()) {
+		reparsed.RemoveChild(child)
+		copied.AppendChild(child)
+	}
+	return diff.Diff(node, copied)
+}
+
+// serializeOptions returns the options RoundTrip serializes with.
+func serializeOptions(scripting bool) serialize.Options {
+	opts := serialize.DefaultOptions()
+	opts.Scripting = scripting
+	return opts
+}
+
+// parse parses src as a document, or as a fragment in the given context,
+// which it returns as a document fragment.
+func parse(src string, ctx *treebuilder.FragmentContext, scripting bool) dom.Node {
+	tok := tokenizer.New(src)
+	var tb *treebuilder.TreeBuilder
+	if ctx == nil {
+		tb = treebuilder.New(tok)
+	} else {
+		tb = treebuilder.NewFragment(tok, ctx)
+	}
+	if scripting {
+		tok.SetScripting(true)
+		tb.SetScripting(true)
+	}
+	for {
+		tok.SetAllowCDATA(tb.AllowCDATA())
+		tt := tok.Next()
+		tb.ProcessToken(tt)
+		if tt.Type == tokenizer.EOF {
+			break
+		}
+	}
+	if ctx == nil {
+		return tb.Document()
+	}
+	frag := dom.NewDocumentFragment()
+	for _, n := range tb.FragmentChildNodes() {
+		if parent := n.Parent(); parent != nil {
+			parent.RemoveChild(n)
+		}
+		frag.AppendChild(n)
+	}
+	return frag
+}
@@ -0,0 +1,125 @@
+package sanitize_test
+
+import (
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/diff"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/sanitize"
+)
+
+func mustParse(t *testing.T, html string) *dom.Document {
+	t.Helper()
+	doc, err := JustGoHTML.Parse(html)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", html, err)
+	}
+	return doc
+}
+
+func hasInsert(edits []diff.Edit, tag string) bool {
+	for _, e := range edits {
+		if elem, ok := e.Node.(*dom.Element); ok && e.Op == diff.Insert && elem.TagName == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		scripting bool
+		stable    bool
+	}{
+		{"plain markup", `<!DOCTYPE html><p class=a>x <b>y</b><ul><li>1<li>2</ul>`, false, true},
+		{"foreign content", `<svg viewBox="0 0 1 1"><circle r=1 /></svg><math><mi>x</mi></math>`, true, true},
+		{"nested paragraph", `<p><table><p>x`, false, false},
+		{"noscript without scripting", `<body><noscript><p title="</noscript><img src=x onerror=alert(1)>">`, false, true},
+		{"noscript with scripting", `<body><noscript><p title="</noscript><img src=x onerror=alert(1)>">`, true, false},
+		{"mglyph in mtext", `<math><mtext><table><mglyph><style><img src=x onerror=alert(1)>`, false, false},
+		{"nested forms", `<form><math><mtext></form><form><mglyph><style></math><img src onerror=alert(1)>`, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edits := sanitize.RoundTrip(mustParse(t, tt.input), tt.scripting)
+			if (len(edits) == 0) != tt.stable {
+				t.Errorf("RoundTrip(%q, %v) = %v, want stable = %v", tt.input, tt.scripting, edits, tt.stable)
+			}
+		})
+	}
+}
+
+func TestRoundTripScripting(t *testing.T) {
+	doc, err := JustGoHTML.Parse(`<p>a &lt; b</p><noscript><img src=x></noscript>`, JustGoHTML.WithScripting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edits := sanitize.RoundTrip(doc, true); len(edits) != 0 {
+		t.Errorf("RoundTrip(doc, true) = %v, want no edits", edits)
+	}
+	body := doc.Body()
+	if edits := sanitize.RoundTrip(body, true); len(edits) != 0 {
+		t.Errorf("RoundTrip(body, true) = %v, want no edits", edits)
+	}
+}
+
+func TestRoundTripReportsInjectedElements(t *testing.T) {
+	doc := mustParse(t, `<math><mtext><table><mglyph><style><img src=x onerror=alert(1)>`)
+	if edits := sanitize.RoundTrip(doc, false); !hasInsert(edits, "img") {
+		t.Errorf("RoundTrip did not report the img element the reparse creates: %v", edits)
+	}
+}
+
+func TestRoundTripForeignBreakout(t *testing.T) {
+	// An HTML p inside svg cannot come from the parser: </p> and <p> break
+	// out of foreign content.
+	svg := dom.NewElementNS("svg", dom.NamespaceSVG)
+	p := dom.NewElement("p")
+	p.AppendChild(dom.NewText("x"))
+	svg.AppendChild(p)
+	frag := dom.NewDocumentFragment()
+	frag.AppendChild(svg)
+	if sanitize.Stable(frag) {
+		t.Errorf("HTML p inside svg reported as stable")
+	}
+	if !sanitize.Stable(dom.NewText("a < b")) {
+		t.Errorf("text node reported as unstable")
+	}
+}
+
+func TestRoundTripElementContext(t *testing.T) {
+	doc := mustParse(t, `<table id=t><tr><td>a</td></tr></table><select id=s><option>x</option></select>`)
+	for _, id := range []string{"t", "s"} {
+		if edits := sanitize.RoundTrip(doc.GetElementByID(id), false); len(edits) != 0 {
+			t.Errorf("RoundTrip(#%s) = %v, want no edits", id, edits)
+		}
+	}
+
+	// Table rows are only stable inside a table context.
+	div := dom.NewElement("div")
+	tr := dom.NewElement("tr")
+	div.AppendChild(tr)
+	if edits := sanitize.RoundTrip(div, false); len(edits) == 0 {
+		t.Error("tr inside div reported as stable")
+	}
+}
+
+func TestHTMLRepairsUnstableOutput(t *testing.T) {
+	// Unwrapping the button leaves a p inside a p, and the nested links are
+	// split differently when read back; HTML keeps sanitizing until the
+	// output parses into the tree it describes.
+	p := sanitize.RichText()
+	for _, input := range []string{`<p><button><p>x</p></button></p>`, `<a href="/1"><div><a href="/2">x</a></div></a>`} {
+		out, err := sanitize.HTML(input, p)
+		if err != nil {
+			t.Fatalf("HTML(%q) error: %v", input, err)
+		}
+		again, err := sanitize.HTML(out, p)
+		if err != nil || again != out {
+			t.Errorf("HTML(%q) = %q, which sanitizes to %q (error %v)", input, out, again, err)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+// Package sanitize removes untrusted markup from DOM trees.
+//
+// Sanitize walks a parsed tree and keeps only what a Policy allows: the
+// allowed elements of each namespace, the allowed attributes of each element,
+// URLs with an allowed scheme and style declarations of allowed properties.
+// Since it works on the tree a spec-compliant parser built, rather than on the
+// markup, it sees the document the way a browser does. HTML parses, sanitizes
+// and serializes in one step.
+package sanitize
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+	"github.com/MeKo-Christian/JustGoHTML/treebuilder"
+)
+
+// Policy describes the markup that survives sanitization. The zero Policy
+// keeps only text.
+//
+// Some things are removed whatever the policy says: comments, processing
+// instructions, event handler attributes (onclick and the like), and the
+// elements that run code, load resources into the page or whose content the
+// parser treats as raw text, such as script, style, iframe, object, noscript
+// and template.
+type Policy struct {
+	// Elements lists the allowed elements of each namespace, keyed by
+	// namespace URI (dom.NamespaceHTML, dom.NamespaceSVG or
+	// dom.NamespaceMathML). An element of a foreign namespace is only kept
+	// inside an element of the same namespace, or if it is the svg or math
+	// root directly inside HTML content.
+	//
+	// A disallowed HTML element inside HTML content is replaced by its
+	// sanitized children. Other disallowed elements are removed together
+	// with their content.
+	Elements map[string][]string
+
+	// Attributes lists the allowed attributes of each element, keyed by tag
+	// name. Attributes listed under "*" are allowed on every element that is
+	// kept. Namespaced attributes are named with their usual prefix, as in
+	// "xlink:href".
+	Attributes map[string][]string
+
+	// URLSchemes lists the allowed schemes of URLs in attributes such as
+	// href, src, srcset and action, without the colon. Attributes containing
+	// a URL with another scheme are removed.
+	URLSchemes []string
+
+	// AllowRelativeURLs keeps URLs that have no scheme.
+	AllowRelativeURLs bool
+
+	// StyleProperties lists the CSS properties allowed in style attributes.
+	// Declarations of other properties, and declarations whose value could
+	// load a resource or hide an escape, are removed. The style attribute
+	// itself must be allowed by Attributes.
+	StyleProperties []string
+
+	// AddNoopener adds noopener to the rel attribute of links and forms that
+	// have a target, so that the page they open cannot reach back through
+	// window.opener.
+	AddNoopener bool
+}
+
+// BasicFormatting returns a policy for short texts such as comments: inline
+// formatting, paragraphs, line breaks and links to http, https and mailto
+// URLs.
+func BasicFormatting() Policy {
+	return Policy{
+		Elements: map[string][]string{
+			dom.NamespaceHTML: {
+				"a", "abbr", "b", "br", "code", "del", "em", "i", "ins", "mark",
+				"p", "q", "s", "small", "strong", "sub", "sup", "u",
+			},
+		},
+		Attributes: map[string][]string{
+			"a":    {"href", "title"},
+			"abbr": {"title"},
+			"del":  {"cite", "datetime"},
+			"ins":  {"cite", "datetime"},
+			"q":    {"cite"},
+		},
+		URLSchemes:        []string{"http", "https", "mailto"},
+		AllowRelativeURLs: true,
+		AddNoopener:       true,
+	}
+}
+
+// RichText returns a policy for documents written in a rich text editor:
+// everything BasicFormatting allows plus headings, lists, quotes, code
+// blocks, tables, images, figures and a small set of text styles.
+func RichText() Policy {
+	p := BasicFormatting()
+	p.Elements[dom.NamespaceHTML] = append(p.Elements[dom.NamespaceHTML],
+		"blockquote", "caption", "cite", "col", "colgroup", "dd", "details",
+		"div", "dfn", "dl", "dt", "figcaption", "figure", "h1", "h2", "h3",
+		"h4", "h5", "h6", "hr", "img", "kbd", "li", "ol", "pre", "samp",
+		"span", "summary", "table", "tbody", "td", "tfoot", "th", "thead",
+		"time", "tr", "ul", "var",
+	)
+	p.Attributes["*"] = []string{"dir", "lang", "style", "title"}
+	p.Attributes["a"] = append(p.Attributes["a"], "target")
+	p.Attributes["blockquote"] = []string{"cite"}
+	p.Attributes["col"] = []string{"span"}
+	p.Attributes["colgroup"] = []string{"span"}
+	p.Attributes["details"] = []string{"open"}
+	p.Attributes["img"] = []string{"alt", "height", "src", "srcset", "width"}
+	p.Attributes["li"] = []string{"value"}
+	p.Attributes["ol"] = []string{"reversed", "start", "type"}
+	p.Attributes["td"] = []string{"colspan", "headers", "rowspan"}
+	p.Attributes["th"] = []string{"abbr", "colspan", "headers", "rowspan", "scope"}
+	p.Attributes["time"] = []string{"datetime"}
+	p.URLSchemes = append(p.URLSchemes, "tel")
+	p.StyleProperties = []string{
+		"background-color", "color", "font-style", "font-weight",
+		"text-align", "text-decoration",
+	}
+	return p
+}
+
+// maxPasses bounds the number of times HTML sanitizes its own output.
+const maxPasses = 4
+
+// HTML parses html as the content of a body element, sanitizes it with
+// policy and returns the serialized result.
+//
+// Serializing a sanitized tree does not always give markup that parses back
+// into the same tree (see RoundTrip). HTML therefore parses and sanitizes its
+// output again until it is stable, so that what is returned is what a
+// browser will see. If that does not happen within a few passes, it returns
+// ErrUnstable.
+func HTML(html string, policy Policy) (string, error) {
+	body := &treebuilder.FragmentContext{TagName: "body", Namespace: "html"}
+	frag := parse(html, body, false)
+	for pass := 1; ; pass++ {
+		Sanitize(frag, policy)
+		out := serialize.ToHTML(frag, serialize.DefaultOptions())
+		if Stable(frag) {
+			return out, nil
+		}
+		if pass == maxPasses {
+			return "", ErrUnstable
+		}
+		frag = parse(out, body, false)
+	}
+}
+
+// Sanitize removes everything policy does not allow from the children of
+// node, which is modified in place. The node itself is kept as it is: pass a
+// body element or a document fragment holding the untrusted content.
+func Sanitize(node dom.Node, policy Policy) {
+	s := newSanitizer(policy)
+	s.children(node)
+	dom.Normalize(node)
+}
+
+// forbiddenElements are removed with their content under any policy. They
+// run code, embed other documents, change how the page or its URLs are
+// interpreted, or have raw text content that reparses differently depending
+// on where it ends up (the source of most mutation XSS).
+var forbiddenElements = map[string]bool{
+	"applet": true, "base": true, "basefont": true, "embed": true,
+	"frame": true, "frameset": true, "iframe": true, "link": true,
+	"meta": true, "noembed": true, "noframes": true, "noscript": true,
+	"object": true, "param": true, "plaintext": true, "script": true,
+	"style": true, "template": true, "title": true, "xmp": true,
+}
+
+// urlAttributes are the attributes whose values are URLs.
+var urlAttributes = map[string]bool{
+	"action": true, "background": true, "cite": true, "codebase": true,
+	"data": true, "formaction": true, "href": true, "longdesc": true,
+	"poster": true, "src": true, "xlink:href": true,
+}
+
+// sanitizer holds a policy in the form of lookup tables. Names are
+// lowercased, since foreign tag and attribute names keep their case.
+type sanitizer struct {
+	elements   map[string]map[string]bool
+	attributes map[string]map[string]bool
+	schemes    map[string]bool
+	properties map[string]bool
+	policy     Policy
+}
+
+func newSanitizer(policy Policy) *sanitizer {
+	s := &sanitizer{
+		elements:   make(map[string]map[string]bool, len(policy.Elements)),
+		attributes: make(map[string]map[string]bool, len(policy.Attributes)),
+		schemes:    lowerSet(policy.URLSchemes),
+		properties: lowerSet(policy.StyleProperties),
+		policy:     policy,
+	}
+	for ns, tags := range policy.Elements {
+		s.elements[ns] = lowerSet(tags)
+	}
+	for tag, names := range policy.Attributes {
+		s.attributes[strings.ToLower(tag)] = lowerSet(names)
+	}
+	return s
+}
+
+func lowerSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[strings.ToLower(name)] = true
+	}
+	return set
+}
+
+func (s *sanitizer) children(parent dom.Node) {
+	for _, child := range slices.Clone(parent.Children()) {
+		switch c := child.(type) {
+		case *dom.Text:
+		case *dom.CDATASection:
+			parent.ReplaceChild(dom.NewText(c.Data), c)
+		case *dom.Element:
+			s.element(parent, c)
+		default:
+			parent.RemoveChild(child)
+		}
+	}
+}
+
+func (s *sanitizer) element(parent dom.Node, elem *dom.Element) {
+	parentNS := namespaceOf(parent)
+	if s.allows(parentNS, elem) {
+		s.sanitizeAttributes(elem)
+		s.children(elem)
+		return
+	}
+	if elem.Namespace != dom.NamespaceHTML || parentNS != dom.NamespaceHTML ||
+		forbiddenElements[strings.ToLower(elem.TagName)] {
+		parent.RemoveChild(elem)
+		return
+	}
+	s.children(elem)
+	for _, child := range slices.Clone(elem.Children()) {
+		elem.RemoveChild(child)
+		parent.InsertBefore(child, elem)
+	}
+	parent.RemoveChild(elem)
+}
+
+// allows reports whether elem may stay in a parent of namespace parentNS.
+func (s *sanitizer) allows(parentNS string, elem *dom.Element) bool {
+	tag := strings.ToLower(elem.TagName)
+	if forbiddenElements[tag] || !s.elements[elem.Namespace][tag] {
+		return false
+	}
+	if elem.Namespace == parentNS {
+		return true
+	}
+	// Foreign content may only be entered through its root element, which
+	// is where the parser switches namespaces when the output is reparsed.
+	return parentNS == dom.NamespaceHTML &&
+		(elem.Namespace == dom.NamespaceSVG && tag == "svg" ||
+			elem.Namespace == dom.NamespaceMathML && tag == "math")
+}
+
+// namespaceOf returns the namespace children of node are parsed in.
+func namespaceOf(node dom.Node) string {
+	if elem, ok := node.(*dom.Element); ok {
+		return elem.Namespace
+	}
+	return dom.NamespaceHTML
+}
+
+func (s *sanitizer) sanitizeAttributes(elem *dom.Element) {
+	tag := strings.ToLower(elem.TagName)
+	for _, attr := range elem.Attributes.All() {
+		name, ok := qualifiedName(attr)
+		if ok {
+			ok = s.allowsAttribute(tag, name, attr.Value)
+		}
+		if !ok {
+			elem.Attributes.RemoveNS(attr.Namespace, attr.Name)
+			continue
+		}
+		if name == "style" {
+			style := s.sanitizeStyle(attr.Value)
+			if style == "" {
+				elem.Attributes.RemoveNS(attr.Namespace, attr.Name)
+			} else if style != attr.Value {
+				elem.Attributes.SetNS(attr.Namespace, attr.Name, style)
+			}
+		}
+	}
+	if s.policy.AddNoopener && elem.Namespace == dom.NamespaceHTML && elem.HasAttr("target") {
+		switch tag {
+		case "a", "area", "form":
+			rel := strings.Fields(strings.ToLower(elem.Attr("rel")))
+			if !slices.Contains(rel, "noopener") {
+				elem.SetAttr("rel", strings.Join(append(rel, "noopener"), " "))
+			}
+		}
+	}
+}
+
+// qualifiedName returns the lowercased name of attr as it appears in markup,
+// with the prefix of its namespace. It reports false for attributes in
+// unknown namespaces.
+func qualifiedName(attr dom.Attribute) (string, bool) {
+	name := strings.ToLower(attr.Name)
+	if attr.Namespace == "" {
+		return name, true
+	}
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name = name[i+1:]
+	}
+	switch attr.Namespace {
+	case "http://www.w3.org/1999/xlink":
+		return "xlink:" + name, true
+	case "http://www.w3.org/XML/1998/namespace":
+		return "xml:" + name, true
+	}
+	return "", false
+}
+
+func (s *sanitizer) allowsAttribute(tag, name, value string) bool {
+	if isEventHandler(name) || !s.attributes[tag][name] && !s.attributes["*"][name] {
+		return false
+	}
+	switch {
+	case urlAttributes[name]:
+		return s.allowsURL(value)
+	case name == "srcset":
+		for candidate := range strings.SplitSeq(value, ",") {
+			fields := strings.Fields(candidate)
+			if len(fields) > 0 && !s.allowsURL(fields[0]) {
+				return false
+			}
+		}
+	case name == "ping":
+		for _, u := range strings.Fields(value) {
+			if !s.allowsURL(u) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isEventHandler reports whether name is an event handler attribute such as
+// onclick or onerror.
+func isEventHandler(name string) bool {
+	return strings.HasPrefix(name, "on") && name != "open"
+}
+
+// allowsURL reports whether the scheme of raw is allowed. Like a browser's
+// URL parser, it ignores tabs and newlines anywhere in the URL and control
+// characters and spaces around it, so "java&#9;script:" is still recognized
+// as javascript.
+func (s *sanitizer) allowsURL(raw string) bool {
+	u := strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, raw)
+	u = strings.TrimFunc(u, func(r rune) bool { return r <= ' ' })
+	i := strings.IndexAny(u, ":/?#")
+	if i <= 0 || u[i] != ':' || !isScheme(u[:i]) {
+		return s.policy.AllowRelativeURLs
+	}
+	return s.schemes[strings.ToLower(u[:i])]
+}
+
+// isScheme reports whether s is a syntactically valid URL scheme.
+func isScheme(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i] | 0x20
+		switch {
+		case c >= 'a' && c <= 'z':
+		case i > 0 && (s[i] >= '0' && s[i] <= '9' || s[i] == '+' || s[i] == '-' || s[i] == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizeStyle returns the declarations of style that are allowed,
+// separated by "; ".
+func (s *sanitizer) sanitizeStyle(style string) string {
+	var kept []string
+	for decl := range strings.SplitSeq(style, ";") {
+		prop, value, ok := strings.Cut(decl, ":")
+		prop = strings.ToLower(strings.TrimSpace(prop))
+		value = strings.TrimSpace(value)
+		if !ok || value == "" || !s.properties[prop] || !isSafeStyleValue(value) {
+			continue
+		}
+		kept = append(kept, prop+": "+value)
+	}
+	return strings.Join(kept, "; ")
+}
+
+// isSafeStyleValue reports whether a CSS value is free of constructs that
+// load resources, run script in old browsers or hide other constructs behind
+// escapes and comments.
+func isSafeStyleValue(value string) bool {
+	if strings.ContainsAny(value, `\<>"'`) || strings.Contains(value, "/*") {
+		return false
+	}
+	lower := strings.ToLower(value)
+	for _, bad := range []string{"url(", "image(", "image-set(", "expression(", "javascript:", "@import"} {
+		if strings.Contains(lower, bad) {
+			return false
+		}
+	}
+	return true
+}
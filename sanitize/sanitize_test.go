@@ -0,0 +1,322 @@
+package sanitize_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/sanitize"
+)
+
+func mustSanitize(t *testing.T, html string, policy sanitize.Policy) string {
+	t.Helper()
+	got, err := sanitize.HTML(html, policy)
+	if err != nil {
+		t.Fatalf("HTML(%q) error: %v", html, err)
+	}
+	return got
+}
+
+// foreignPolicy extends RichText with a little SVG and MathML, so that the
+// foreign content rules are exercised.
+func foreignPolicy() sanitize.Policy {
+	p := sanitize.RichText()
+	p.Elements[dom.NamespaceSVG] = []string{"svg", "a", "circle", "desc", "foreignObject", "g", "path", "style", "text", "title"}
+	p.Elements[dom.NamespaceMathML] = []string{"math", "annotation-xml", "mglyph", "mi", "mn", "mo", "mrow", "mtext", "semantics", "style"}
+	p.Attributes["svg"] = []string{"viewBox", "width", "height"}
+	p.Attributes["circle"] = []string{"cx", "cy", "r"}
+	p.Attributes["a"] = append(p.Attributes["a"], "xlink:href")
+	return p
+}
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy sanitize.Policy
+		input  string
+		want   string
+	}{
+		{
+			name:   "basic formatting",
+			policy: sanitize.BasicFormatting(),
+			input:  `<p class="x">Hello <b>bold</b> <span>and</span> <a href="https://example.com/" onclick="x()">link</a></p>`,
+			want:   `<p>Hello <b>bold</b> and <a href="https://example.com/">link</a></p>`,
+		},
+		{
+			name:   "unwrapped elements",
+			policy: sanitize.BasicFormatting(),
+			input:  `<div><h1>Title</h1><ul><li>one</li><li>two</li></ul></div>`,
+			want:   `Titleonetwo`,
+		},
+		{
+			name:   "removed content",
+			policy: sanitize.RichText(),
+			input:  `<p>a<script>alert(1)</script><style>p{}</style><iframe src="x"></iframe><template><b>t</b></template>b</p>`,
+			want:   `<p>ab</p>`,
+		},
+		{
+			name:   "comments",
+			policy: sanitize.RichText(),
+			input:  `<p>a<!-- secret -->b</p>`,
+			want:   `<p>ab</p>`,
+		},
+		{
+			name:   "rich text",
+			policy: sanitize.RichText(),
+			input:  `<h2 id="x">T</h2><table class="t"><tr><td colspan="2" width="5">c</td></tr></table><img src="/a.png" alt="A" onerror="x()">`,
+			want:   `<h2>T</h2><table><tbody><tr><td colspan="2">c</td></tr></tbody></table><img src="/a.png" alt="A">`,
+		},
+		{
+			name:   "details open",
+			policy: sanitize.RichText(),
+			input:  `<details open><summary>s</summary>d</details>`,
+			want:   `<details open="">` + `<summary>s</summary>d</details>`,
+		},
+		{
+			name:   "zero policy",
+			policy: sanitize.Policy{},
+			input:  `<p>a <b>b</b> <script>c</script></p>`,
+			want:   `a b `,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustSanitize(t, tt.input, tt.policy); got != tt.want {
+				t.Errorf("HTML(%q) =\n%s\nwant\n%s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLSchemes(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`<a href="https://example.com/">x</a>`, `<a href="https://example.com/">x</a>`},
+		{`<a href="/relative?a=b#c">x</a>`, `<a href="/relative?a=b#c">x</a>`},
+		{`<a href="mailto:a@example.com">x</a>`, `<a href="mailto:a@example.com">x</a>`},
+		{`<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="JaVaScRiPt:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href=" &#14; javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="java&#9;scr&#10;ipt:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="&#106;avascript:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="data:text/html,<script>alert(1)</script>">x</a>`, `<a>x</a>`},
+		{`<a href="vbscript:msgbox(1)">x</a>`, `<a>x</a>`},
+		{`<a href="java script:alert(1)">x</a>`, `<a href="java script:alert(1)">x</a>`},
+		{`<img src="https://example.com/a.png" srcset="a.png 1x, https://example.com/b.png 2x">`, `<img src="https://example.com/a.png" srcset="a.png 1x, https://example.com/b.png 2x">`},
+		{`<img src="a.png" srcset="a.png 1x, javascript:alert(1) 2x">`, `<img src="a.png">`},
+		{`<blockquote cite="javascript:alert(1)">q</blockquote>`, `<blockquote>q</blockquote>`},
+	}
+	for _, tt := range tests {
+		if got := mustSanitize(t, tt.input, sanitize.RichText()); got != tt.want {
+			t.Errorf("HTML(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	strict := sanitize.RichText()
+	strict.AllowRelativeURLs = false
+	if got := mustSanitize(t, `<a href="/x">x</a>`, strict); got != `<a>x</a>` {
+		t.Errorf("relative URL without AllowRelativeURLs = %q", got)
+	}
+}
+
+func TestStyleProperties(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`<p style="color: red; TEXT-ALIGN:center">x</p>`, `<p style="color: red; text-align: center">x</p>`},
+		{`<p style="color: red; position: fixed; top: 0">x</p>`, `<p style="color: red">x</p>`},
+		{`<p style="position: absolute">x</p>`, `<p>x</p>`},
+		{`<p style="background-color: url(https://evil.example/)">x</p>`, `<p>x</p>`},
+		{`<p style="color: expression(alert(1))">x</p>`, `<p>x</p>`},
+		{`<p style="color: re\64">x</p>`, `<p>x</p>`},
+		{`<p style="color: red/**/">x</p>`, `<p>x</p>`},
+		{`<p style="color: rgb(0, 0, 0) !important">x</p>`, `<p style="color: rgb(0, 0, 0) !important">x</p>`},
+	}
+	for _, tt := range tests {
+		if got := mustSanitize(t, tt.input, sanitize.RichText()); got != tt.want {
+			t.Errorf("HTML(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+	if got := mustSanitize(t, `<p style="color: red">x</p>`, sanitize.BasicFormatting()); got != `<p>x</p>` {
+		t.Errorf("style without an allowed style attribute = %q", got)
+	}
+}
+
+func TestAddNoopener(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`<a href="/x" target="_blank">x</a>`, `<a href="/x" target="_blank" rel="noopener">x</a>`},
+		{`<a href="/x">x</a>`, `<a href="/x">x</a>`},
+	}
+	for _, tt := range tests {
+		if got := mustSanitize(t, tt.input, sanitize.RichText()); got != tt.want {
+			t.Errorf("HTML(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	p := sanitize.RichText()
+	p.Attributes["a"] = append(p.Attributes["a"], "rel")
+	input := `<a href="/x" target="w" rel="nofollow">x</a>`
+	if got, want := mustSanitize(t, input, p), `<a href="/x" target="w" rel="nofollow noopener">x</a>`; got != want {
+		t.Errorf("HTML(%q) = %q, want %q", input, got, want)
+	}
+}
+
+func TestForeignContent(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{
+			`<svg viewBox="0 0 10 10" onload="x()"><circle cx="5" cy="5" r="4"></circle><script>alert(1)</script></svg>`,
+			`<svg viewBox="0 0 10 10"><circle cx="5" cy="5" r="4"></circle></svg>`,
+		},
+		{
+			`<svg><a xlink:href="javascript:alert(1)"><text>x</text></a><a xlink:href="#ok"><text>y</text></a></svg>`,
+			`<svg><a><text>x</text></a><a xlink:href="#ok"><text>y</text></a></svg>`,
+		},
+		{
+			`<svg><foreignObject><p>html inside svg</p></foreignObject><unknown><circle></circle></unknown></svg>`,
+			`<svg><foreignObject></foreignObject></svg>`,
+		},
+		{
+			`<math><mi>x</mi><mo>=</mo><mn>1</mn><mtext><b>bold</b></mtext></math>`,
+			`<math><mi>x</mi><mo>=</mo><mn>1</mn><mtext></mtext></math>`,
+		},
+		{
+			`<p><svg><circle r="1"></circle></svg></p>`,
+			`<p></p>`,
+		},
+	}
+	for i, tt := range tests {
+		policy := foreignPolicy()
+		if i == len(tests)-1 {
+			policy = sanitize.RichText()
+		}
+		if got := mustSanitize(t, tt.input, policy); got != tt.want {
+			t.Errorf("HTML(%q) =\n%s\nwant\n%s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	doc, err := JustGoHTML.Parse(`<div id="c"><p onclick="x()">a<script>b</script></p><font>c</font></div>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := doc.GetElementByID("c")
+	sanitize.Sanitize(container, sanitize.BasicFormatting())
+	if got, want := container.Attr("id"), "c"; got != want {
+		t.Errorf("container id = %q, want %q", got, want)
+	}
+	if got, want := len(container.Children()), 2; got != want {
+		t.Fatalf("container has %d children, want %d", got, want)
+	}
+	if text, ok := container.Children()[1].(*dom.Text); !ok || text.Data != "c" {
+		t.Errorf("unwrapped font element = %#v", container.Children()[1])
+	}
+}
+
+// mxssVectors are known mutation XSS payloads: markup that a DOM-based
+// sanitizer may consider harmless, but whose serialization parses into a
+// different, dangerous tree.
+var mxssVectors = []string{
+	// noscript content is parsed as markup with scripting disabled and as
+	// raw text with scripting enabled.
+	`<noscript><p title="</noscript><img src=x onerror=alert(1)>">`,
+	`<noscript><style></noscript><img src=x onerror=alert(1)></style></noscript>`,
+	// Namespace confusion between HTML, SVG and MathML.
+	`<svg></p><style><a id="</style><img src=1 onerror=alert(1)>">`,
+	`<svg><p><style><a id="</style><img src=1 onerror=alert(1)>">`,
+	`<math><mtext><table><mglyph><style><img src=x onerror=alert(1)>`,
+	`<math><mtext><table><mglyph><style><!--</style><img title="--&gt;&lt;/mglyph&gt;&lt;img&Tab;src=1&Tab;onerror=alert(1)&gt;">`,
+	`<form><math><mtext></form><form><mglyph><style></math><img src onerror=alert(1)>`,
+	`<math><annotation-xml encoding="text/html"><style><img src=x onerror=alert(1)></style></annotation-xml></math>`,
+	`<svg><foreignObject><p><style><img src=x onerror=alert(1)></style></p></foreignObject></svg>`,
+	`<math><mi><svg><style><img src=x onerror=alert(1)></style></svg></mi></math>`,
+	`<svg><desc><svg><style><a title="</style><img src onerror=alert(1)>"></style></svg></desc></svg>`,
+	`<math><style><img src=x onerror=alert(1)></style></math>`,
+	// Raw text and comments.
+	`<xmp><p title="</xmp><img src=x onerror=alert(1)>"></xmp>`,
+	`<noembed><p title="</noembed><img src=x onerror=alert(1)>"></noembed>`,
+	`<iframe><p title="</iframe><img src=x onerror=alert(1)>"></iframe>`,
+	`<!--><img src=x onerror=alert(1)>-->`,
+	`<p><!--</p><img src=x onerror=alert(1)>--></p>`,
+	`<?xml-stylesheet href="javascript:alert(1)"?><p>x</p>`,
+	`<svg><![CDATA[</svg><img src=x onerror=alert(1)>]]></svg>`,
+	`<template><style></template><img src=x onerror=alert(1)></style></template>`,
+	`<select><template><style><!--</style><a rel="--></style></template></select><img src=x onerror=alert(1)>">`,
+	`<textarea></textarea><img src=x onerror=alert(1)></textarea>`,
+	`<title><img src=x onerror=alert(1)></title>`,
+	// Attribute values that break out when written unescaped.
+	`<a title="</a><img src=x onerror=alert(1)>">x</a>`,
+	"<a title=\"`x`\">x</a>",
+	// Dangerous URLs.
+	`<a href="javascript&colon;alert(1)">x</a>`,
+	`<a href="&#x6A;avascript:alert(1)">x</a>`,
+	`<svg><a xlink:href="javascript:alert(1)"><circle r=5></circle></a></svg>`,
+	`<math href="javascript:alert(1)">x</math>`,
+	`<img srcset="javascript:alert(1) 1x">`,
+	`<form action="javascript:alert(1)"><button formaction="javascript:alert(1)">x</button></form>`,
+	`<base href="javascript:/"><a href="x">x</a>`,
+	// Nesting the parser repairs differently on the second pass.
+	`<table><a href="/x">link</a><tr><td>cell</td></tr></table>`,
+	`<p><table><p>x</p></table></p>`,
+	`<a href="/1"><div><a href="/2">nested</a></div></a>`,
+	`<b><p>misnested</b></p>`,
+}
+
+func TestMXSSVectors(t *testing.T) {
+	policies := map[string]sanitize.Policy{
+		"basic formatting": sanitize.BasicFormatting(),
+		"rich text":        sanitize.RichText(),
+		"foreign content":  foreignPolicy(),
+	}
+	for name, policy := range policies {
+		for _, vector := range mxssVectors {
+			out := mustSanitize(t, vector, policy)
+			checkSafe(t, vector, out)
+			if again := mustSanitize(t, out, policy); again != out {
+				t.Errorf("%s: %q does not round-trip:\n%s\n%s", name, vector, out, again)
+			}
+		}
+	}
+}
+
+// checkSafe reparses the sanitized output out of vector and fails if the
+// resulting tree can run script.
+func checkSafe(t *testing.T, vector, out string) {
+	t.Helper()
+	doc, err := JustGoHTML.Parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var walk func(dom.Node)
+	walk = func(n dom.Node) {
+		for _, child := range n.Children() {
+			switch c := child.(type) {
+			case *dom.Comment:
+				t.Errorf("%q: comment in output %q", vector, out)
+			case *dom.Element:
+				switch strings.ToLower(c.TagName) {
+				case "script", "style", "iframe", "noscript", "xmp", "noembed", "template", "base":
+					t.Errorf("%q: <%s> in output %q", vector, c.TagName, out)
+				}
+				for _, attr := range c.Attributes.All() {
+					value := strings.ToLower(strings.Join(strings.Fields(attr.Value), ""))
+					if strings.HasPrefix(strings.ToLower(attr.Name), "on") || strings.Contains(value, "javascript:") {
+						t.Errorf("%q: attribute %s=%q in output %q", vector, attr.Name, attr.Value, out)
+					}
+				}
+				walk(c)
+			}
+		}
+	}
+	walk(doc)
+}
@@ -890,3 +890,29 @@ func BenchmarkMatch(b *testing.B) {
 		})
 	}
 }
+
+func TestSpecificity(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     Specificity
+	}{
+		{"*", Specificity{0, 0, 0}},
+		{"p", Specificity{0, 0, 1}},
+		{"div p.intro", Specificity{0, 1, 2}},
+		{"#main > ul li:first-child", Specificity{1, 1, 2}},
+		{"a[href]:not(.x, #y)", Specificity{1, 1, 1}},
+		{"p, .a, #b span", Specificity{1, 0, 1}},
+	}
+	for _, tt := range tests {
+		sel, err := Parse(tt.selector)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.selector, err)
+		}
+		if got := SpecificityOf(sel); got != tt.want {
+			t.Errorf("SpecificityOf(%q) = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+	if (Specificity{0, 2, 0}).Compare(Specificity{1, 0, 0}) >= 0 {
+		t.Error("(0,2,0) should be lower than (1,0,0)")
+	}
+}
@@ -0,0 +1,87 @@
+package selector
+
+// Specificity is the specificity of a selector: the number of ID
+// selectors, the number of class, attribute and pseudo-class selectors, and
+// the number of type selectors. Specificities compare component by
+// component.
+type Specificity [3]int
+
+// Compare returns -1, 0 or +1 depending on whether s is lower than, equal
+// to or higher than other.
+func (s Specificity) Compare(other Specificity) int {
+	for i := range s {
+		switch {
+		case s[i] < other[i]:
+			return -1
+		case s[i] > other[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// SpecificityOf returns the specificity of sel, which for a selector list
+// is the highest specificity of its selectors. It returns zero for
+// selectors not created by Parse.
+func SpecificityOf(sel Selector) Specificity {
+	ps, ok := sel.(*parsedSelector)
+	if !ok {
+		return Specificity{}
+	}
+	switch s := ps.ast.(type) {
+	case ComplexSelector:
+		return complexSpecificity(s)
+	case SelectorList:
+		return listSpecificity(s)
+	}
+	return Specificity{}
+}
+
+func listSpecificity(list SelectorList) Specificity {
+	var best Specificity
+	for _, sel := range list.Selectors {
+		if s := complexSpecificity(sel); s.Compare(best) > 0 {
+			best = s
+		}
+	}
+	return best
+}
+
+func complexSpecificity(sel ComplexSelector) Specificity {
+	var s Specificity
+	for _, part := range sel.Parts {
+		for _, simple := range part.Compound.Selectors {
+			switch simple.Kind {
+			case KindID:
+				s[0]++
+			case KindClass, KindAttr:
+				s[1]++
+			case KindTag:
+				s[2]++
+			case KindPseudo:
+				s = s.add(pseudoSpecificity(simple))
+			}
+		}
+	}
+	return s
+}
+
+// pseudoSpecificity returns the specificity of a pseudo-class. That of
+// :not(), :is() and :has() is the highest specificity of their arguments;
+// that of :where() is zero.
+func pseudoSpecificity(sel SimpleSelector) Specificity {
+	switch sel.Name {
+	case "where":
+		return Specificity{}
+	case "not", "is", "has":
+		if inner, err := Parse(sel.Value); err == nil {
+			return SpecificityOf(inner)
+		}
+		return Specificity{}
+	}
+	return Specificity{0, 1, 0}
+}
+
+func (s Specificity) add(other Specificity) Specificity {
+	return Specificity{s[0] + other[0], s[1] + other[1], s[2] + other[2]}
+}
@@ -1,10 +1,11 @@
-package serialize
+package serialize_test
 
 import (
 	"testing"
 
 	"github.com/MeKo-Christian/JustGoHTML"
 	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
 )
 
 // Test HTML samples for serialization benchmarks
@@ -138,12 +139,12 @@ func BenchmarkToHTML_Simple(b *testing.B) {
 		b.Fatal(err)
 	}
 
-	opts := DefaultOptions()
+	opts := serialize.DefaultOptions()
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for range b.N {
-		html := ToHTML(doc, opts)
+		html := serialize.ToHTML(doc, opts)
 		_ = html
 	}
 }
@@ -155,12 +156,12 @@ func BenchmarkToHTML_Medium(b *testing.B) {
 		b.Fatal(err)
 	}
 
-	opts := DefaultOptions()
+	opts := serialize.DefaultOptions()
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for range b.N {
-		html := ToHTML(doc, opts)
+		html := serialize.ToHTML(doc, opts)
 		_ = html
 	}
 }
@@ -172,12 +173,12 @@ func BenchmarkToHTML_Complex(b *testing.B) {
 		b.Fatal(err)
 	}
 
-	opts := DefaultOptions()
+	opts := serialize.DefaultOptions()
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for range b.N {
-		html := ToHTML(doc, opts)
+		html := serialize.ToHTML(doc, opts)
 		_ = html
 	}
 }
@@ -189,12 +190,12 @@ func BenchmarkToHTML_Pretty(b *testing.B) {
 		b.Fatal(err)
 	}
 
-	opts := Options{Pretty: true, IndentSize: 2}
+	opts := serialize.Options{Pretty: true, IndentSize: 2}
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for range b.N {
-		html := ToHTML(doc, opts)
+		html := serialize.ToHTML(doc, opts)
 		_ = html
 	}
 }
@@ -213,12 +214,12 @@ func BenchmarkToHTML_Element(b *testing.B) {
 	}
 
 	elem := elements[0]
-	opts := DefaultOptions()
+	opts := serialize.DefaultOptions()
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for range b.N {
-		html := ToHTML(elem, opts)
+		html := serialize.ToHTML(elem, opts)
 		_ = html
 	}
 }
@@ -240,12 +241,12 @@ func BenchmarkToHTML_DeepNesting(b *testing.B) {
 		current = child
 	}
 
-	opts := DefaultOptions()
+	opts := serialize.DefaultOptions()
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for range b.N {
-		html := ToHTML(doc, opts)
+		html := serialize.ToHTML(doc, opts)
 		_ = html
 	}
 }
@@ -262,12 +263,12 @@ func BenchmarkToHTML_ManyAttributes(b *testing.B) {
 	elem.AppendChild(dom.NewText("Content"))
 	doc.AppendChild(elem)
 
-	opts := DefaultOptions()
+	opts := serialize.DefaultOptions()
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for range b.N {
-		html := ToHTML(doc, opts)
+		html := serialize.ToHTML(doc, opts)
 		_ = html
 	}
 }
@@ -285,12 +286,12 @@ func BenchmarkToHTML_LargeText(b *testing.B) {
 	elem.AppendChild(dom.NewText(string(largeText)))
 	doc.AppendChild(elem)
 
-	opts := DefaultOptions()
+	opts := serialize.DefaultOptions()
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for range b.N {
-		html := ToHTML(doc, opts)
+		html := serialize.ToHTML(doc, opts)
 		_ = html
 	}
 }
@@ -303,12 +304,12 @@ func BenchmarkToHTML_SpecialChars(b *testing.B) {
 	elem.AppendChild(dom.NewText(`Text with <special> & "characters" that need escaping`))
 	doc.AppendChild(elem)
 
-	opts := DefaultOptions()
+	opts := serialize.DefaultOptions()
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for range b.N {
-		html := ToHTML(doc, opts)
+		html := serialize.ToHTML(doc, opts)
 		_ = html
 	}
 }
@@ -326,12 +327,12 @@ func BenchmarkToHTML_ManyChildren(b *testing.B) {
 	}
 	doc.AppendChild(ul)
 
-	opts := DefaultOptions()
+	opts := serialize.DefaultOptions()
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for range b.N {
-		html := ToHTML(doc, opts)
+		html := serialize.ToHTML(doc, opts)
 		_ = html
 	}
 }
@@ -349,12 +350,12 @@ func BenchmarkToHTML_Script(b *testing.B) {
 	`))
 	doc.AppendChild(script)
 
-	opts := DefaultOptions()
+	opts := serialize.DefaultOptions()
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for range b.N {
-		html := ToHTML(doc, opts)
+		html := serialize.ToHTML(doc, opts)
 		_ = html
 	}
 }
@@ -370,12 +371,12 @@ func BenchmarkToHTML_Style(b *testing.B) {
 	`))
 	doc.AppendChild(style)
 
-	opts := DefaultOptions()
+	opts := serialize.DefaultOptions()
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for range b.N {
-		html := ToHTML(doc, opts)
+		html := serialize.ToHTML(doc, opts)
 		_ = html
 	}
 }
@@ -387,11 +388,11 @@ func BenchmarkToHTML_Parallel(b *testing.B) {
 		b.Fatal(err)
 	}
 
-	opts := DefaultOptions()
+	opts := serialize.DefaultOptions()
 	b.ReportAllocs()
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
-			html := ToHTML(doc, opts)
+			html := serialize.ToHTML(doc, opts)
 			_ = html
 		}
 	})
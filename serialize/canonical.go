@@ -0,0 +1,137 @@
+package serialize
+
+import (
+	"io"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+func init() {
+	dom.SetCanonicalWriter(func(w io.Writer, node dom.Node) error {
+		return WriteCanonical(w, node, CanonicalOptions{})
+	})
+}
+
+// CanonicalOptions configures Canonical.
+type CanonicalOptions struct {
+	// StripComments removes comments.
+	StripComments bool
+}
+
+// Canonical returns a canonical serialization of node, for hashing and
+// deduplication. Markup that differs only in the following ways gives
+// identical bytes:
+//
+//   - Character references and literal characters (&eacute; and é).
+//   - Case of HTML tag and attribute names, and the order and quoting of
+//     attributes.
+//   - Optional tags: all start and end tags are written explicitly.
+//   - Whitespace that does not affect rendering. Runs of whitespace collapse
+//     to a single space, and whitespace at the start and end of lines, next
+//     to block boundaries and between head elements is removed (see
+//     CollapseWhitespace). Preformatted, raw text and foreign content is
+//     kept.
+//   - Values of boolean attributes (disabled, disabled="" and
+//     disabled="disabled") and the whitespace between class names.
+//   - The declared character encoding: charset declarations are rewritten,
+//     or added to the head, to declare UTF-8.
+//   - Comments, with StripComments.
+//
+// The output is valid HTML that parses into the canonical tree. The node
+// itself is not modified.
+func Canonical(node dom.Node, opts CanonicalOptions) string {
+	var sb strings.Builder
+	writeCanonical(&sb, node, opts)
+	return sb.String()
+}
+
+// WriteCanonical writes the canonical serialization of node to w, as
+// Canonical would return it, and returns the first error of w.
+func WriteCanonical(w io.Writer, node dom.Node, opts CanonicalOptions) error {
+	out := newStreamWriter(w)
+	writeCanonical(out.buf, node, opts)
+	return out.flush()
+}
+
+func writeCanonical(sb markupSink, node dom.Node, opts CanonicalOptions) {
+	node = node.Clone(true)
+	if text, ok := node.(*dom.Text); ok {
+		// Give the text a parent, so that its whitespace is collapsed.
+		frag := dom.NewDocumentFragment()
+		frag.AppendChild(text)
+		node = frag
+	}
+	canonicalizeTree(node, opts)
+	CollapseWhitespace(node)
+
+	mw := newMarkupWriter(sb, &markupOptions{
+		quoteChar:     '"',
+		sortAttrs:     true,
+		injectCharset: true,
+		encoding:      "utf-8",
+		specEscaping:  true,
+	})
+	for tok := range domTokens(node) {
+		mw.push(canonicalToken(tok))
+	}
+	mw.close()
+}
+
+// canonicalizeTree lowercases HTML tag names, removes comments if asked to
+// and merges adjacent text nodes below node.
+func canonicalizeTree(node dom.Node, opts CanonicalOptions) {
+	if doc, ok := node.(*dom.Document); ok && doc.Doctype != nil {
+		doc.Doctype.Name = strings.ToLower(doc.Doctype.Name)
+	}
+	canonicalizeChildren(node, opts)
+	dom.Normalize(node)
+}
+
+func canonicalizeChildren(node dom.Node, opts CanonicalOptions) {
+	for _, child := range append([]dom.Node(nil), node.Children()...) {
+		switch c := child.(type) {
+		case *dom.Comment:
+			if opts.StripComments {
+				node.RemoveChild(c)
+			}
+		case *dom.Element:
+			if c.Namespace == dom.NamespaceHTML {
+				c.TagName = strings.ToLower(c.TagName)
+			}
+			if c.TemplateContent != nil {
+				canonicalizeTree(c.TemplateContent, opts)
+			}
+			canonicalizeChildren(c, opts)
+		}
+	}
+}
+
+// canonicalToken normalizes the attributes of a tag: names of HTML
+// attributes are lowercased, with later duplicates dropped, boolean
+// attributes get an empty value and class names are separated by single
+// spaces.
+func canonicalToken(tok markupToken) markupToken {
+	if (tok.kind != startTagToken && tok.kind != emptyTagToken) || tok.foreign || len(tok.attrs) == 0 {
+		return tok
+	}
+	attrs := make([]tokenAttr, 0, len(tok.attrs))
+	seen := make(map[string]bool, len(tok.attrs))
+	for _, attr := range tok.attrs {
+		name := strings.ToLower(attr.Name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		value := attr.Value
+		switch {
+		case IsBooleanAttribute(tok.name, name):
+			value = ""
+		case name == "class":
+			value = strings.Join(strings.FieldsFunc(value, isWhitespaceChar), " ")
+		}
+		attrs = append(attrs, tokenAttr{Name: name, Value: value})
+	}
+	tok.attrs = attrs
+	return tok
+}
@@ -0,0 +1,85 @@
+package serialize_test
+
+import (
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+)
+
+func TestCanonical(t *testing.T) {
+	doc := mustParse(t, `<!doctype HTML><HTML><Head><META charset=latin1>
+<title>T</title></head><BODY>
+<P CLASS="  b   a " id=x>caf&eacute;&nbsp;&amp;  <b> bold </b> text
+<input DISABLED=disabled type=checkbox>
+<pre>
+  keep   this</pre>`)
+	want := `<!DOCTYPE html><html><head><meta charset="utf-8"><title>T</title></head><body>` +
+		`<p class="b a" id="x">café&nbsp;&amp; <b>bold </b>text <input disabled="" type="checkbox"></p>` +
+		`<pre>  keep   this</pre></body></html>`
+	if got := serialize.Canonical(doc, serialize.CanonicalOptions{}); got != want {
+		t.Errorf("Canonical =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCanonicalEquivalence(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"attribute order and quoting", `<p id=a class='b'>x</p>`, `<p class="b" id="a">x</p>`, true},
+		{"character references", `<p>&eacute;&#x26;&lt;</p>`, `<p>é&amp;&lt;</p>`, true},
+		{"optional tags", `<ul><li>a<li>b</ul><p>c`, `<html><head></head><body><ul><li>a</li><li>b</li></ul><p>c</p></body></html>`, true},
+		{"tag case", `<DIV><SPAN>x</SPAN></DIV>`, `<div><span>x</span></div>`, true},
+		{"whitespace", "<div>\n  <p>a   b</p>\n  <p> c </p>\n</div>", `<div><p>a b</p><p>c</p></div>`, true},
+		{"boolean attributes", `<input checked="checked">`, `<input checked>`, true},
+		{"charset", `<meta charset="windows-1252"><p>x`, `<p>x`, true},
+		{"comments kept", `<p>x<!-- a --></p>`, `<p>x</p>`, false},
+		{"significant space", `<p><b>a</b> <i>b</i></p>`, `<p><b>a</b><i>b</i></p>`, false},
+		{"preformatted", `<pre>a  b</pre>`, `<pre>a b</pre>`, false},
+		{"attribute value", `<p title="a  b">x</p>`, `<p title="a b">x</p>`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := serialize.Canonical(mustParse(t, tt.a), serialize.CanonicalOptions{})
+			b := serialize.Canonical(mustParse(t, tt.b), serialize.CanonicalOptions{})
+			if (a == b) != tt.same {
+				t.Errorf("Canonical forms equal = %v, want %v:\n%s\n%s", a == b, tt.same, a, b)
+			}
+		})
+	}
+}
+
+func TestCanonicalStripComments(t *testing.T) {
+	a := serialize.Canonical(mustParse(t, `<p>a <!-- x --> b</p><!-- y -->`), serialize.CanonicalOptions{StripComments: true})
+	b := serialize.Canonical(mustParse(t, `<p>a b</p>`), serialize.CanonicalOptions{StripComments: true})
+	if a != b {
+		t.Errorf("Canonical with StripComments: %q != %q", a, b)
+	}
+}
+
+func TestCanonicalIsStable(t *testing.T) {
+	doc := mustParse(t, writerTestHTML)
+	first := serialize.Canonical(doc, serialize.CanonicalOptions{})
+	if second := serialize.Canonical(mustParse(t, first), serialize.CanonicalOptions{}); second != first {
+		t.Errorf("Canonical is not a fixpoint:\n%s\n%s", first, second)
+	}
+	if again := serialize.Canonical(doc, serialize.CanonicalOptions{}); again != first {
+		t.Error("Canonical modified the document")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := mustParse(t, `<p class=x id=y>caf&eacute;  <b>!</b>`).Fingerprint()
+	b := mustParse(t, `<html><body><p id="y" class="x">café <b>!</b></p></body></html>`).Fingerprint()
+	c := mustParse(t, `<p class=x id=y>cafe <b>!</b>`).Fingerprint()
+	if a != b {
+		t.Errorf("equivalent documents have different fingerprints %s and %s", a, b)
+	}
+	if a == c {
+		t.Error("different documents have the same fingerprint")
+	}
+	if len(a) != 64 {
+		t.Errorf("Fingerprint length = %d, want 64", len(a))
+	}
+}
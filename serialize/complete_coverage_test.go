@@ -2,47 +2,9 @@ package serialize
 
 import (
 	"encoding/json"
-	"strings"
 	"testing"
-
-	"github.com/MeKo-Christian/JustGoHTML/dom"
 )
 
-// TestSerializeTextPrettyWhitespaceOnly tests early return for whitespace-only text in pretty mode
-func TestSerializeTextPrettyWhitespaceOnly(t *testing.T) {
-	text := dom.NewText("   \n\t  ")
-
-	var sb strings.Builder
-	serializeText(&sb, text, Options{Pretty: true, IndentSize: 2}, 0)
-
-	// Should return early without writing anything
-	if sb.String() != "" {
-		t.Fatalf("unexpected output for whitespace-only text in pretty mode: %q", sb.String())
-	}
-}
-
-// TestCollapseWhitespaceTrimTrailing tests trimming trailing space after collapse
-func TestCollapseWhitespaceTrimTrailing(t *testing.T) {
-	// Input with internal whitespace that will create trailing space during collapse
-	result := collapseWhitespace("a  ")
-	// Should be "a " (trailing space preserved from original)
-	expected := "a "
-	if result != expected {
-		t.Fatalf("unexpected collapsed whitespace: %q, want %q", result, expected)
-	}
-}
-
-// TestCollapseWhitespaceInternalWhitespace tests internal whitespace handling
-func TestCollapseWhitespaceInternalWhitespace(t *testing.T) {
-	// This should trigger the path where we trim trailing space from collapsed content
-	// then restore it based on hasTrailingSpace
-	result := collapseWhitespace("a b  ")
-	expected := "a b "
-	if result != expected {
-		t.Fatalf("unexpected collapsed whitespace: %q, want %q", result, expected)
-	}
-}
-
 // TestSerializeTokensInvalidTokenTypeJSON tests invalid JSON in token type field
 func TestSerializeTokensInvalidTokenTypeJSON(t *testing.T) {
 	// Array with invalid token type field (can't unmarshal)
@@ -122,39 +84,40 @@ func TestSerializeTokensPreformattedDepthTracking(t *testing.T) {
 	}
 }
 
-// TestGetNextTokenInfoEmptyTagFormat tests getNextTokenInfo with EmptyTag format
-func TestGetNextTokenInfoEmptyTagFormat(t *testing.T) {
-	tokens := []json.RawMessage{
-		json.RawMessage(`["StartTag"]`),
+// TestDecodeTokensEmptyTagFormat tests decoding the EmptyTag format
+func TestDecodeTokensEmptyTagFormat(t *testing.T) {
+	tokens := mustDecodeTokens(t, []json.RawMessage{
+		json.RawMessage(`["StartTag", "html", "p"]`),
 		json.RawMessage(`["EmptyTag", "br"]`),
-	}
+	})
 
-	typ, tag := getNextTokenInfo(tokens, 0)
-	if typ != "EmptyTag" || tag != "br" {
-		t.Fatalf("expected type=EmptyTag, tag=br, got type=%q, tag=%q", typ, tag)
+	next := tokenAt(tokens, 1)
+	if !isTag(next, emptyTagToken, "br") {
+		t.Fatalf("expected EmptyTag br, got %+v", next)
 	}
 }
 
-// TestGetPrevTokenInfoEmptyTagFormat tests getPrevTokenInfo with EmptyTag format
-func TestGetPrevTokenInfoEmptyTagFormat(t *testing.T) {
-	tokens := []json.RawMessage{
+// TestTokenAtOutOfRange tests tokenAt before the first and after the last token
+func TestTokenAtOutOfRange(t *testing.T) {
+	tokens := mustDecodeTokens(t, []json.RawMessage{
 		json.RawMessage(`["EmptyTag", "br"]`),
-		json.RawMessage(`["StartTag"]`),
-	}
+	})
 
-	typ, tag := getPrevTokenInfo(tokens, 1)
-	if typ != "EmptyTag" || tag != "br" {
-		t.Fatalf("expected type=EmptyTag, tag=br, got type=%q, tag=%q", typ, tag)
+	if tokenAt(tokens, -1) != nil || tokenAt(tokens, 1) != nil {
+		t.Fatal("expected nil for out of range indexes")
+	}
+	if !isTag(tokenAt(tokens, 0), emptyTagToken, "br") {
+		t.Fatalf("expected EmptyTag br, got %+v", tokenAt(tokens, 0))
 	}
 }
 
 // TestHasCharsetMetaAheadEmptyTagMeta tests hasCharsetMetaAhead with EmptyTag meta
 func TestHasCharsetMetaAheadEmptyTagMeta(t *testing.T) {
-	tokens := []json.RawMessage{
+	tokens := mustDecodeTokens(t, []json.RawMessage{
 		json.RawMessage(`["StartTag", "html", "head", []]`),
 		json.RawMessage(`["EmptyTag", "meta", [{"namespace": null, "name": "charset", "value": "UTF-8"}]]`),
 		json.RawMessage(`["EndTag", "html", "head"]`),
-	}
+	})
 
 	result := hasCharsetMetaAhead(tokens, 0)
 	if !result {
@@ -164,11 +127,11 @@ func TestHasCharsetMetaAheadEmptyTagMeta(t *testing.T) {
 
 // TestHasCharsetMetaAheadHTTPEquivContentType tests http-equiv detection
 func TestHasCharsetMetaAheadHTTPEquivContentType(t *testing.T) {
-	tokens := []json.RawMessage{
+	tokens := mustDecodeTokens(t, []json.RawMessage{
 		json.RawMessage(`["StartTag", "html", "head", []]`),
 		json.RawMessage(`["StartTag", "html", "meta", [{"namespace": null, "name": "http-equiv", "value": "content-type"}]]`),
 		json.RawMessage(`["EndTag", "html", "head"]`),
-	}
+	})
 
 	result := hasCharsetMetaAhead(tokens, 0)
 	if !result {
@@ -268,12 +231,12 @@ func TestSerializeTokenAttrValueAmpersandEscaping(t *testing.T) {
 
 // TestHasCharsetMetaAheadReturnsOnEndTag tests early return when encountering end head tag
 func TestHasCharsetMetaAheadReturnsOnEndTag(t *testing.T) {
-	tokens := []json.RawMessage{
+	tokens := mustDecodeTokens(t, []json.RawMessage{
 		json.RawMessage(`["StartTag", "html", "head", []]`),
 		json.RawMessage(`["StartTag", "html", "title", []]`),
 		json.RawMessage(`["EndTag", "html", "head"]`), // Should stop here and return false
 		json.RawMessage(`["EmptyTag", "meta", [{"namespace": null, "name": "charset", "value": "UTF-8"}]]`),
-	}
+	})
 
 	result := hasCharsetMetaAhead(tokens, 0)
 	if result {
@@ -283,11 +246,11 @@ func TestHasCharsetMetaAheadReturnsOnEndTag(t *testing.T) {
 
 // TestHasCharsetMetaAheadEmptyRawAttrs tests handling of empty rawAttrs in hasCharsetMetaAhead
 func TestHasCharsetMetaAheadEmptyRawAttrs(t *testing.T) {
-	tokens := []json.RawMessage{
+	tokens := mustDecodeTokens(t, []json.RawMessage{
 		json.RawMessage(`["StartTag", "html", "head", []]`),
 		json.RawMessage(`["StartTag", "html", "meta"]`), // No attrs field (too short array)
 		json.RawMessage(`["EndTag", "html", "head"]`),
-	}
+	})
 
 	result := hasCharsetMetaAhead(tokens, 0)
 	if result {
@@ -2,6 +2,7 @@ package serialize
 
 import (
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 
@@ -66,20 +67,6 @@ func TestSerializeDoctypeSystemID(t *testing.T) {
 	}
 }
 
-// TestSerializeTextPrettyModeCollapseWhitespace tests text serialization in pretty mode
-func TestSerializeTextPrettyModeCollapseWhitespace(t *testing.T) {
-	text := dom.NewText("  hello   world  ")
-
-	var sb strings.Builder
-	serializeText(&sb, text, Options{Pretty: true, IndentSize: 2}, 0)
-
-	// Pretty mode should collapse whitespace
-	expected := " hello world "
-	if sb.String() != expected {
-		t.Fatalf("unexpected text output: %q, want %q", sb.String(), expected)
-	}
-}
-
 // TestSerializeCommentPrettyModeWithDepth tests comment serialization in pretty mode with indentation
 func TestSerializeCommentPrettyModeWithDepth(t *testing.T) {
 	comment := dom.NewComment("test comment")
@@ -164,140 +151,89 @@ func TestParseTokenAttrsInvalidJSON(t *testing.T) {
 	}
 }
 
-// TestGetTokenInfoInvalidJSON tests getTokenInfo with invalid JSON
-func TestGetTokenInfoInvalidJSON(t *testing.T) {
-	raw := json.RawMessage(`invalid`)
-	typ, tag := getTokenInfo(raw)
-
-	if typ != "" || tag != "" {
-		t.Fatalf("expected empty strings for invalid JSON, got type=%q, tag=%q", typ, tag)
+// TestDecodeTokensInvalidJSON tests decodeTokens with invalid JSON
+func TestDecodeTokensInvalidJSON(t *testing.T) {
+	_, err := decodeTokens([]json.RawMessage{json.RawMessage(`invalid`)})
+	if !errors.Is(err, ErrInvalidTokenFormat) {
+		t.Fatalf("expected invalid token format error for invalid JSON, got %v", err)
 	}
 }
 
-// TestGetTokenInfoEmptyArray tests getTokenInfo with empty array
-func TestGetTokenInfoEmptyArray(t *testing.T) {
-	raw := json.RawMessage(`[]`)
-	typ, tag := getTokenInfo(raw)
+// TestDecodeTokensEmptyArray tests that decodeTokens skips empty arrays
+func TestDecodeTokensEmptyArray(t *testing.T) {
+	tokens := mustDecodeTokens(t, []json.RawMessage{json.RawMessage(`[]`)})
 
-	if typ != "" || tag != "" {
-		t.Fatalf("expected empty strings for empty array, got type=%q, tag=%q", typ, tag)
+	if len(tokens) != 0 {
+		t.Fatalf("expected no tokens for empty array, got %+v", tokens)
 	}
 }
 
-// TestGetTokenInfoInvalidTokenType tests getTokenInfo with invalid token type
-func TestGetTokenInfoInvalidTokenType(t *testing.T) {
-	raw := json.RawMessage(`[123]`)
-	typ, tag := getTokenInfo(raw)
-
-	if typ != "" || tag != "" {
-		t.Fatalf("expected empty strings for invalid token type, got type=%q, tag=%q", typ, tag)
+// TestDecodeTokensInvalidTokenType tests decodeTokens with invalid token type
+func TestDecodeTokensInvalidTokenType(t *testing.T) {
+	_, err := decodeTokens([]json.RawMessage{json.RawMessage(`[123]`)})
+	if !errors.Is(err, ErrInvalidTokenFormat) {
+		t.Fatalf("expected invalid token format error for invalid token type, got %v", err)
 	}
 }
 
-// TestStartsWithSpaceErrorCases tests startsWithSpace error handling
-func TestStartsWithSpaceErrorCases(t *testing.T) {
-	// Test with invalid index
-	if startsWithSpace([]json.RawMessage{}, 0) {
-		t.Fatal("expected false for out of bounds index")
+// TestStartsWithSpaceEdgeCases tests startsWithSpace on tokens without leading space
+func TestStartsWithSpaceEdgeCases(t *testing.T) {
+	// Test with no token
+	if startsWithSpace(nil) {
+		t.Fatal("expected false for missing token")
 	}
 
-	// Test with invalid JSON
-	tokens := []json.RawMessage{
-		json.RawMessage(`["StartTag"]`),
-		json.RawMessage(`invalid`),
-	}
-	if startsWithSpace(tokens, 0) {
-		t.Fatal("expected false for invalid JSON")
-	}
-
-	// Test with array too short
-	tokens = []json.RawMessage{
-		json.RawMessage(`["StartTag"]`),
-		json.RawMessage(`["Characters"]`),
-	}
-	if startsWithSpace(tokens, 0) {
-		t.Fatal("expected false for array too short")
-	}
-
-	// Test with invalid data field
-	tokens = []json.RawMessage{
-		json.RawMessage(`["StartTag"]`),
-		json.RawMessage(`["Characters", 123]`),
-	}
-	if startsWithSpace(tokens, 0) {
-		t.Fatal("expected false for invalid data field")
+	// Test with a non-text token
+	if startsWithSpace(&markupToken{kind: commentToken, data: " x"}) {
+		t.Fatal("expected false for comment token")
 	}
 
 	// Test with empty data
-	tokens = []json.RawMessage{
-		json.RawMessage(`["StartTag"]`),
-		json.RawMessage(`["Characters", ""]`),
-	}
-	if startsWithSpace(tokens, 0) {
+	if startsWithSpace(&markupToken{kind: textToken}) {
 		t.Fatal("expected false for empty data")
 	}
-}
 
-// TestGetPrevTokenInfoErrorCases tests getPrevTokenInfo error handling
-func TestGetPrevTokenInfoErrorCases(t *testing.T) {
-	// Test with idx at 0 (no previous token)
-	tokens := []json.RawMessage{json.RawMessage(`["StartTag"]`)}
-	typ, tag := getPrevTokenInfo(tokens, 0)
-	if typ != "" || tag != "" {
-		t.Fatalf("expected empty strings for idx=0, got type=%q, tag=%q", typ, tag)
-	}
-
-	// Test with invalid JSON
-	tokens = []json.RawMessage{
-		json.RawMessage(`invalid`),
-		json.RawMessage(`["StartTag"]`),
-	}
-	typ, tag = getPrevTokenInfo(tokens, 1)
-	if typ != "" || tag != "" {
-		t.Fatalf("expected empty strings for invalid JSON, got type=%q, tag=%q", typ, tag)
+	// Test with leading space
+	if !startsWithSpace(&markupToken{kind: textToken, data: "\tx"}) {
+		t.Fatal("expected true for leading tab")
 	}
+}
 
-	// Test with empty array
-	tokens = []json.RawMessage{
-		json.RawMessage(`[]`),
-		json.RawMessage(`["StartTag"]`),
-	}
-	typ, tag = getPrevTokenInfo(tokens, 1)
-	if typ != "" || tag != "" {
-		t.Fatalf("expected empty strings for empty array, got type=%q, tag=%q", typ, tag)
+// TestDecodeTokensCharactersErrors tests Characters tokens that cannot be decoded
+func TestDecodeTokensCharactersErrors(t *testing.T) {
+	// Test with array too short
+	_, err := decodeTokens([]json.RawMessage{json.RawMessage(`["Characters"]`)})
+	if !errors.Is(err, ErrCharactersMissing) {
+		t.Fatalf("expected missing characters error, got %v", err)
 	}
 
-	// Test with invalid token type
-	tokens = []json.RawMessage{
-		json.RawMessage(`[123]`),
-		json.RawMessage(`["StartTag"]`),
-	}
-	typ, tag = getPrevTokenInfo(tokens, 1)
-	if typ != "" || tag != "" {
-		t.Fatalf("expected empty strings for invalid token type, got type=%q, tag=%q", typ, tag)
+	// Test with invalid data field
+	_, err = decodeTokens([]json.RawMessage{json.RawMessage(`["Characters", 123]`)})
+	if err == nil {
+		t.Fatal("expected error for invalid data field")
 	}
 }
 
-// TestSerializeInjectedMetaEmptyEncoding tests serializeInjectedMeta with empty encoding
-func TestSerializeInjectedMetaEmptyEncoding(t *testing.T) {
+// TestWriteInjectedMetaEmptyEncoding tests writeInjectedMeta with empty encoding
+func TestWriteInjectedMetaEmptyEncoding(t *testing.T) {
 	var sb strings.Builder
 	opts := DefaultSerializeTokenOptions()
 	opts.Encoding = "" // Empty encoding should return early
 
-	serializeInjectedMeta(&sb, opts)
+	writeInjectedMeta(&sb, opts.markupOptions())
 
 	if sb.String() != "" {
 		t.Fatalf("expected empty output for empty encoding, got %q", sb.String())
 	}
 }
 
-// TestSerializeInjectedMetaWithEncoding tests serializeInjectedMeta with valid encoding
-func TestSerializeInjectedMetaWithEncoding(t *testing.T) {
+// TestWriteInjectedMetaWithEncoding tests writeInjectedMeta with valid encoding
+func TestWriteInjectedMetaWithEncoding(t *testing.T) {
 	var sb strings.Builder
 	opts := DefaultSerializeTokenOptions()
 	opts.Encoding = testEncodingUTF8
 
-	serializeInjectedMeta(&sb, opts)
+	writeInjectedMeta(&sb, opts.markupOptions())
 
 	// Unquoted because testEncodingUTF8 doesn't contain special chars requiring quotes
 	expected := `<meta charset=UTF-8>`
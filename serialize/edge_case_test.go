@@ -93,18 +93,15 @@ func TestSerializeTokensRawTextDepthAtZero(t *testing.T) {
 	}
 }
 
-// TestHasAttributesShortArray tests hasAttributes with array too short
-func TestHasAttributesShortArray(t *testing.T) {
+// TestDecodeTokensShortStartTag tests decoding a start tag without attrs field
+func TestDecodeTokensShortStartTag(t *testing.T) {
 	// Array with only 3 elements (no attrs field)
+	tokens := mustDecodeTokens(t, []json.RawMessage{
+		json.RawMessage(`["StartTag", "html", "div"]`),
+	})
 
-	arr := []json.RawMessage{
-		json.RawMessage(`"StartTag"`),
-		json.RawMessage(`"html"`),
-		json.RawMessage(`"div"`),
-	}
-
-	if hasAttributes(arr) {
-		t.Fatal("expected false for array too short")
+	if len(tokens[0].attrs) != 0 {
+		t.Fatalf("expected no attributes for array too short, got %+v", tokens[0].attrs)
 	}
 }
 
@@ -146,11 +143,11 @@ func TestSerializeEmptyTagTokenWithEmptyAttrs(t *testing.T) {
 // TestHasCharsetMetaAheadEmptyTagWithShortArray tests EmptyTag meta with short array (no attrs)
 
 func TestHasCharsetMetaAheadEmptyTagWithShortArray(t *testing.T) {
-	tokens := []json.RawMessage{
+	tokens := mustDecodeTokens(t, []json.RawMessage{
 		json.RawMessage(`["StartTag", "html", "head", []]`),
 		json.RawMessage(`["EmptyTag", "meta"]`), // Too short, no attrs
 		json.RawMessage(`["EndTag", "html", "head"]`),
-	}
+	})
 
 	result := hasCharsetMetaAhead(tokens, 0)
 
@@ -186,16 +183,14 @@ func TestSerializeTokensComplexMetaInjectionScenario(t *testing.T) {
 	}
 }
 
-// TestGetNextTokenInfoAtEnd tests getNextTokenInfo when at the last token
-func TestGetNextTokenInfoAtEnd(t *testing.T) {
-	tokens := []json.RawMessage{
-		json.RawMessage(`["StartTag"]`),
-	}
-
-	typ, tag := getNextTokenInfo(tokens, 0)
+// TestShouldOmitEndTagAtEnd tests end tag omission when at the last token
+func TestShouldOmitEndTagAtEnd(t *testing.T) {
+	tokens := mustDecodeTokens(t, []json.RawMessage{
+		json.RawMessage(`["EndTag", "html", "li"]`),
+	})
 
-	// At end, should return empty strings
-	if typ != "" || tag != "" {
-		t.Fatalf("expected empty strings at end, got type=%q, tag=%q", typ, tag)
+	// At end there is no more content in the parent element
+	if !shouldOmitEndTag(tokens, 0) {
+		t.Fatal("expected </li> to be omitted at end")
 	}
 }
@@ -2,6 +2,7 @@ package serialize
 
 import (
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 
@@ -17,124 +18,52 @@ func TestEscapeTextGreaterThan(t *testing.T) {
 	}
 }
 
-// TestSerializeTextNonPrettyMode tests text serialization in non-pretty mode
-func TestSerializeTextNonPrettyMode(t *testing.T) {
-	text := dom.NewText("  hello   world  ")
+// TestDecodeTokensEmptyAttrArray tests decoding a start tag with empty attrs array
+func TestDecodeTokensEmptyAttrArray(t *testing.T) {
+	tokens := mustDecodeTokens(t, []json.RawMessage{
+		json.RawMessage(`["StartTag", "html", "div", []]`),
+	})
 
-	var sb strings.Builder
-	serializeText(&sb, text, Options{Pretty: false, IndentSize: 2}, 0)
-
-	// Non-pretty mode should preserve whitespace
-	expected := "  hello   world  "
-	if sb.String() != expected {
-		t.Fatalf("unexpected text output: %q, want %q", sb.String(), expected)
-	}
-}
-
-// TestCollapseWhitespaceEmptyResult tests collapsing whitespace with empty result
-func TestCollapseWhitespaceEmptyResult(t *testing.T) {
-	result := collapseWhitespace("   ")
-	// All whitespace collapses to empty (leading/trailing space only restored if result has content)
-	expected := ""
-	if result != expected {
-		t.Fatalf("unexpected collapsed whitespace: %q, want %q", result, expected)
-	}
-}
-
-// TestCollapseWhitespaceNoLeadingSpace tests collapsing whitespace without leading space
-func TestCollapseWhitespaceNoLeadingSpace(t *testing.T) {
-	result := collapseWhitespace("a   b ")
-	// No leading space, has trailing space
-	expected := "a b "
-	if result != expected {
-		t.Fatalf("unexpected collapsed whitespace: %q, want %q", result, expected)
-	}
-}
-
-// TestCollapseWhitespaceNoTrailingSpace tests collapsing whitespace without trailing space
-func TestCollapseWhitespaceNoTrailingSpace(t *testing.T) {
-	result := collapseWhitespace(" a   b")
-	// Has leading space, no trailing space
-	expected := " a b"
-	if result != expected {
-		t.Fatalf("unexpected collapsed whitespace: %q, want %q", result, expected)
-	}
-}
-
-// TestHasAttributesEmptyArray tests hasAttributes with empty array
-func TestHasAttributesEmptyArray(t *testing.T) {
-	// ["StartTag", "html", "div", []]
-	arr := []json.RawMessage{
-		json.RawMessage(`"StartTag"`),
-		json.RawMessage(`"html"`),
-		json.RawMessage(`"div"`),
-		json.RawMessage(`[]`),
-	}
-
-	if hasAttributes(arr) {
-		t.Fatal("expected false for empty array")
+	if len(tokens[0].attrs) != 0 {
+		t.Fatalf("expected no attributes for empty array, got %+v", tokens[0].attrs)
 	}
 }
 
-// TestHasAttributesEmptyObject tests hasAttributes with empty object
-func TestHasAttributesEmptyObject(t *testing.T) {
-	// ["StartTag", "html", "div", {}]
-	arr := []json.RawMessage{
-		json.RawMessage(`"StartTag"`),
-		json.RawMessage(`"html"`),
-		json.RawMessage(`"div"`),
-		json.RawMessage(`{}`),
-	}
+// TestDecodeTokensEmptyAttrObject tests decoding a start tag with empty attrs object
+func TestDecodeTokensEmptyAttrObject(t *testing.T) {
+	tokens := mustDecodeTokens(t, []json.RawMessage{
+		json.RawMessage(`["StartTag", "html", "div", {}]`),
+	})
 
-	if hasAttributes(arr) {
-		t.Fatal("expected false for empty object")
+	if len(tokens[0].attrs) != 0 {
+		t.Fatalf("expected no attributes for empty object, got %+v", tokens[0].attrs)
 	}
 }
 
 // TestSerializeNodeWithInlineDocumentFragment tests serializing a DocumentFragment
 func TestSerializeNodeWithInlineDocumentFragment(t *testing.T) {
-	// Create a DocumentFragment (currently not handled in serializeNodeWithInline)
-	// This tests the default case where node type is not explicitly handled
+	// A DocumentFragment serializes as its children
 	fragment := &dom.DocumentFragment{}
 	fragment.AppendChild(dom.NewText("test"))
 
 	var sb strings.Builder
-	// This should not panic, just do nothing for unhandled node types
 	serializeNodeWithInline(&sb, fragment, Options{}, 0, false)
 
-	// DocumentFragment is not handled in serializeNodeWithInline, so output should be empty
-	if sb.String() != "" {
+	if sb.String() != "test" {
 		t.Fatalf("unexpected output for DocumentFragment: %q", sb.String())
 	}
 }
 
-// TestGetNextTokenInfoErrorInTagNameUnmarshal tests error handling in getNextTokenInfo
-func TestGetNextTokenInfoErrorInTagNameUnmarshal(t *testing.T) {
-	// Create a token where tag name unmarshal will fail
-	tokens := []json.RawMessage{
-		json.RawMessage(`["StartTag"]`),
-		json.RawMessage(`["StartTag", "html", 123]`), // tag name is a number, not a string
-	}
-
-	typ, tag := getNextTokenInfo(tokens, 0)
-	// Should return type but empty tag due to unmarshal error
-	if typ != "StartTag" || tag != "" {
-		t.Fatalf("expected type=StartTag, tag=empty, got type=%q, tag=%q", typ, tag)
-	}
-}
-
-// TestGetPrevTokenInfoErrorInTagNameUnmarshal tests error handling in getPrevTokenInfo
-func TestGetPrevTokenInfoErrorInTagNameUnmarshal(t *testing.T) {
-	// Create a token where tag name unmarshal will fail
-	tokens := []json.RawMessage{
-		json.RawMessage(`["EndTag", "html", 123]`), // tag name is a number, not a string
-		json.RawMessage(`["StartTag"]`),
-	}
-
-	typ, tag := getPrevTokenInfo(tokens, 1)
-	// Should return type but empty tag due to unmarshal error
-	if typ != "EndTag" || tag != "" {
-		t.Fatalf("expected type=EndTag, tag=empty, got type=%q, tag=%q", typ, tag)
+// TestDecodeTokensInvalidTagName tests decoding tags whose name is not a string
+func TestDecodeTokensInvalidTagName(t *testing.T) {
+	for _, raw := range []string{
+		`["StartTag", "html", 123]`,
+		`["EndTag", "html", 123]`,
+		`["EmptyTag", 123]`,
+	} {
+		if _, err := decodeTokens([]json.RawMessage{json.RawMessage(raw)}); err == nil {
+			t.Fatalf("expected error for tag name that is a number in %s", raw)
+		}
 	}
 }
 
@@ -256,26 +185,28 @@ func TestSerializeTokenAttrValueEmptyValue(t *testing.T) {
 	}
 }
 
-// TestHasCharsetMetaAheadInvalidTokenJSON tests error handling in hasCharsetMetaAhead
-func TestHasCharsetMetaAheadInvalidTokenJSON(t *testing.T) {
+// TestSerializeTokensInvalidJSONInHead tests that invalid JSON after a head start tag is reported
+func TestSerializeTokensInvalidJSONInHead(t *testing.T) {
 	tokens := []json.RawMessage{
 		json.RawMessage(`["StartTag", "html", "head", []]`),
 		json.RawMessage(`invalid`), // Invalid JSON
 	}
 
-	result := hasCharsetMetaAhead(tokens, 0)
-	if result {
-		t.Fatal("expected false when token JSON is invalid")
+	opts := DefaultSerializeTokenOptions()
+	opts.InjectMetaCharset = true
+	opts.Encoding = testEncodingUTF8
+	if _, err := SerializeTokensWithOptions(tokens, opts); !errors.Is(err, ErrInvalidTokenFormat) {
+		t.Fatalf("expected invalid token format error, got %v", err)
 	}
 }
 
 // TestHasCharsetMetaAheadCaseInsensitiveCharset tests case-insensitive charset detection
 func TestHasCharsetMetaAheadCaseInsensitiveCharset(t *testing.T) {
-	tokens := []json.RawMessage{
+	tokens := mustDecodeTokens(t, []json.RawMessage{
 		json.RawMessage(`["StartTag", "html", "head", []]`),
 		json.RawMessage(`["StartTag", "html", "meta", [{"namespace": null, "name": "CHARSET", "value": "UTF-8"}]]`),
 		json.RawMessage(`["EndTag", "html", "head"]`),
-	}
+	})
 
 	result := hasCharsetMetaAhead(tokens, 0)
 	if !result {
@@ -8,28 +8,6 @@ import (
 	"github.com/MeKo-Christian/JustGoHTML/dom"
 )
 
-// TestCollapseWhitespaceLeadingAndTrailing tests both leading and trailing space restoration
-func TestCollapseWhitespaceLeadingAndTrailing(t *testing.T) {
-	// This specifically tests the path where both leading and trailing spaces are restored
-	result := collapseWhitespace(" a ")
-
-	expected := " a "
-	if result != expected {
-		t.Fatalf("unexpected collapsed whitespace: %q, want %q", result, expected)
-	}
-}
-
-// TestCollapseWhitespaceComplexCase tests a complex case with multiple spaces
-func TestCollapseWhitespaceComplexCase(t *testing.T) {
-	// This tests the trimming of trailing space from collapsed content
-	result := collapseWhitespace("  a   b   c  ")
-
-	expected := " a b c "
-	if result != expected {
-		t.Fatalf("unexpected collapsed whitespace: %q, want %q", result, expected)
-	}
-}
-
 // TestSerializeStartTagTokenVoidWithoutTrailingSolidus tests void element without trailing solidus
 func TestSerializeStartTagTokenVoidWithoutTrailingSolidus(t *testing.T) {
 	opts := DefaultSerializeTokenOptions()
@@ -76,29 +54,30 @@ func TestSerializeEmptyTagTokenWithoutTrailingSolidus(t *testing.T) {
 	}
 }
 
-// TestGetNextTokenInfoCharactersToken tests getNextTokenInfo with Characters token
-func TestGetNextTokenInfoCharactersToken(t *testing.T) {
-	tokens := []json.RawMessage{
-		json.RawMessage(`["StartTag"]`),
+// TestStartsWithSpaceCharactersToken tests startsWithSpace with a decoded Characters token
+func TestStartsWithSpaceCharactersToken(t *testing.T) {
+	tokens := mustDecodeTokens(t, []json.RawMessage{
+		json.RawMessage(`["StartTag", "html", "p"]`),
 		json.RawMessage(`["Characters", "test"]`),
-	}
-
-	typ, tag := getNextTokenInfo(tokens, 0)
+	})
 
-	// Characters tokens don't have a tag name
-	if typ != "Characters" || tag != "" {
-		t.Fatalf("expected type=Characters, tag=empty, got type=%q, tag=%q", typ, tag)
+	next := tokenAt(tokens, 1)
+	if next == nil || next.kind != textToken || next.data != "test" {
+		t.Fatalf("expected Characters token with data test, got %+v", next)
+	}
+	if startsWithSpace(next) {
+		t.Fatal("expected false for text without leading space")
 	}
 }
 
 // TestHasCharsetMetaAheadNonMetaStartTag tests hasCharsetMetaAhead skipping non-meta start tags
 func TestHasCharsetMetaAheadNonMetaStartTag(t *testing.T) {
-	tokens := []json.RawMessage{
+	tokens := mustDecodeTokens(t, []json.RawMessage{
 		json.RawMessage(`["StartTag", "html", "head", []]`),
 		json.RawMessage(`["StartTag", "html", "title", []]`), // Non-meta tag, should skip
 		json.RawMessage(`["StartTag", "html", "meta", [{"namespace": null, "name": "charset", "value": "UTF-8"}]]`),
 		json.RawMessage(`["EndTag", "html", "head"]`),
-	}
+	})
 
 	result := hasCharsetMetaAhead(tokens, 0)
 
@@ -107,17 +86,17 @@ func TestHasCharsetMetaAheadNonMetaStartTag(t *testing.T) {
 	}
 }
 
-// TestHasCharsetMetaAheadReturnsOnEmptyType tests hasCharsetMetaAhead early return on empty type
-func TestHasCharsetMetaAheadReturnsOnEmptyType(t *testing.T) {
-	tokens := []json.RawMessage{
+// TestHasCharsetMetaAheadUnclosedHead tests hasCharsetMetaAhead running off the end of the tokens
+func TestHasCharsetMetaAheadUnclosedHead(t *testing.T) {
+	tokens := mustDecodeTokens(t, []json.RawMessage{
 		json.RawMessage(`["StartTag", "html", "head", []]`),
-		json.RawMessage(`invalid`), // This will cause getTokenInfo to return empty type
-	}
+		json.RawMessage(`["StartTag", "html", "title", []]`),
+	})
 
 	result := hasCharsetMetaAhead(tokens, 0)
 
 	if result {
-		t.Fatal("expected false when encountering invalid token (empty type)")
+		t.Fatal("expected false when the tokens end without a meta charset")
 	}
 }
 
@@ -204,33 +183,25 @@ func TestSerializeTokensEndTagBeforeMetaInjection(t *testing.T) {
 	}
 }
 
-// TestHasAttributesWithNonEmptyArray tests hasAttributes with non-empty array
-func TestHasAttributesWithNonEmptyArray(t *testing.T) {
-	// This specifically tests the path where unmarshal succeeds AND len > 0
-	arr := []json.RawMessage{
-		json.RawMessage(`"StartTag"`),
-		json.RawMessage(`"html"`),
-		json.RawMessage(`"div"`),
-		json.RawMessage(`[{"namespace": null, "name": "class", "value": "test"}]`),
-	}
+// TestDecodeTokensNonEmptyAttrArray tests decoding a non-empty attribute array
+func TestDecodeTokensNonEmptyAttrArray(t *testing.T) {
+	tokens := mustDecodeTokens(t, []json.RawMessage{
+		json.RawMessage(`["StartTag", "html", "div", [{"namespace": null, "name": "class", "value": "test"}]]`),
+	})
 
-	if !hasAttributes(arr) {
-		t.Fatal("expected true for non-empty attribute array")
+	if len(tokens[0].attrs) != 1 || tokens[0].attrs[0] != (tokenAttr{Name: "class", Value: "test"}) {
+		t.Fatalf("expected class attribute, got %+v", tokens[0].attrs)
 	}
 }
 
-// TestHasAttributesWithNonEmptyObject tests hasAttributes with non-empty object
-func TestHasAttributesWithNonEmptyObject(t *testing.T) {
-	// This specifically tests the path where unmarshal to object succeeds AND len > 0
-	arr := []json.RawMessage{
-		json.RawMessage(`"StartTag"`),
-		json.RawMessage(`"html"`),
-		json.RawMessage(`"div"`),
-		json.RawMessage(`{"class": "test"}`),
-	}
+// TestDecodeTokensNonEmptyAttrObject tests decoding a non-empty attribute object
+func TestDecodeTokensNonEmptyAttrObject(t *testing.T) {
+	tokens := mustDecodeTokens(t, []json.RawMessage{
+		json.RawMessage(`["StartTag", "html", "div", {"class": "test"}]`),
+	})
 
-	if !hasAttributes(arr) {
-		t.Fatal("expected true for non-empty attribute object")
+	if len(tokens[0].attrs) != 1 || tokens[0].attrs[0] != (tokenAttr{Name: "class", Value: "test"}) {
+		t.Fatalf("expected class attribute, got %+v", tokens[0].attrs)
 	}
 }
 
@@ -0,0 +1,1001 @@
+package serialize
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// HeadingStyle selects how headings are written in Markdown.
+type HeadingStyle int
+
+const (
+	// HeadingATX writes headings with leading hashes: "## Heading".
+	HeadingATX HeadingStyle = iota
+
+	// HeadingSetext underlines h1 and h2 headings with "=" and "-". Lower
+	// levels, which have no setext form, use the ATX style.
+	HeadingSetext
+)
+
+// LinkStyle selects how links and images are written in Markdown.
+type LinkStyle int
+
+const (
+	// LinkInline writes the destination inside the link: "[text](url)".
+	LinkInline LinkStyle = iota
+
+	// LinkReferenced writes numbered references, "[text][1]", and lists the
+	// destinations at the end of the output: "[1]: url".
+	LinkReferenced
+)
+
+// MarkdownOptions configures Markdown conversion.
+type MarkdownOptions struct {
+	// HeadingStyle selects ATX or setext headings.
+	HeadingStyle HeadingStyle
+
+	// LinkStyle selects inline or referenced links and images.
+	LinkStyle LinkStyle
+
+	// BaseURL, if set, is used to resolve relative link and image URLs.
+	BaseURL string
+}
+
+// DefaultMarkdownOptions returns the default Markdown options: ATX headings
+// and inline links.
+func DefaultMarkdownOptions() MarkdownOptions {
+	return MarkdownOptions{}
+}
+
+// ToMarkdown serializes a node to GitHub Flavored Markdown using the default
+// options.
+func ToMarkdown(node dom.Node) string {
+	return ToMarkdownWithOptions(node, DefaultMarkdownOptions())
+}
+
+// ToMarkdownWithOptions serializes a node to GitHub Flavored Markdown.
+//
+// Text is escaped so that it does not turn into Markdown syntax. Tables
+// become pipe tables, using the first row as the header row. Code blocks are
+// fenced and keep the language of a "language-*" or "lang-*" class. Lists,
+// block quotes and code blocks nest inside each other, and list items
+// starting with a checkbox become task list items. Strikethrough uses "~~";
+// superscripts and subscripts, which have no Markdown syntax, are kept as
+// HTML. Definition lists are written as a term line followed by ": "
+// definitions.
+func ToMarkdownWithOptions(node dom.Node, opts MarkdownOptions) string {
+	w := &markdownWriter{opts: opts}
+	if opts.BaseURL != "" {
+		if base, err := url.Parse(opts.BaseURL); err == nil {
+			w.base = base
+		}
+	}
+
+	var sb strings.Builder
+	writeMarkdown(&sb, node, opts)
+	return sb.String()
+}
+
+// writeMarkdown writes the Markdown of node to sb. Top-level blocks are
+// written as soon as they are rendered.
+func writeMarkdown(sb markupSink, node dom.Node, opts MarkdownOptions) {
+	w := &markdownWriter{opts: opts}
+	if opts.BaseURL != "" {
+		if base, err := url.Parse(opts.BaseURL); err == nil {
+			w.base = base
+		}
+	}
+
+	out := &markdownOutput{sb: sb}
+	switch n := node.(type) {
+	case *dom.Document, *dom.DocumentFragment:
+		w.eachBlock(n.Children(), out.block)
+	default:
+		w.eachBlock([]dom.Node{n}, out.block)
+	}
+
+	for i, ref := range w.refs {
+		switch {
+		case i > 0:
+			sb.WriteByte('\n')
+		case out.started:
+			sb.WriteString(out.pending)
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString("[" + strconv.Itoa(i+1) + "]: " + markdownDestination(ref.url))
+		if ref.title != "" {
+			sb.WriteString(" " + markdownTitle(ref.title))
+		}
+	}
+}
+
+// markdownOutput joins top-level blocks as they are rendered. The result is
+// that of joinMarkdownBlocks with the surrounding whitespace trimmed:
+// whitespace at the end of a block is held back until a block with content
+// follows.
+type markdownOutput struct {
+	sb      markupSink
+	prev    markdownBlock
+	started bool   // set once a block with content has been written
+	pending string // held back whitespace and separators
+}
+
+func (o *markdownOutput) block(b markdownBlock) {
+	text := b.text
+	if o.started {
+		o.pending += markdownSeparator(o.prev, b, false)
+	} else {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+	}
+	o.prev = b
+
+	body := strings.TrimRightFunc(text, unicode.IsSpace)
+	if body == "" {
+		if o.started {
+			o.pending += text
+		}
+		return
+	}
+	o.sb.WriteString(o.pending)
+	o.sb.WriteString(body)
+	o.pending = text[len(body):]
+	o.started = true
+}
+
+// markdownWriter holds the state of a Markdown conversion.
+type markdownWriter struct {
+	opts MarkdownOptions
+	base *url.URL
+
+	// refs are the link references collected in LinkReferenced style.
+	refs []markdownRef
+
+	// inCell is set while writing the content of a table cell, where line
+	// breaks are written as <br>.
+	inCell bool
+}
+
+type markdownRef struct {
+	url, title string
+}
+
+// markdownBlock is a rendered Markdown block.
+type markdownBlock struct {
+	text string
+
+	// inline is set for paragraphs made of inline content that is not
+	// wrapped in an element, such as the text of a tight list item.
+	inline bool
+
+	// list is '-' for bullet lists, '.' for ordered lists and 0 otherwise.
+	list byte
+}
+
+// blocks renders nodes as a sequence of blocks. Runs of inline content
+// between block elements become paragraphs.
+func (w *markdownWriter) blocks(nodes []dom.Node) []markdownBlock {
+	var blocks []markdownBlock
+	w.eachBlock(nodes, func(b markdownBlock) {
+		blocks = append(blocks, b)
+	})
+	return blocks
+}
+
+// eachBlock renders nodes like blocks, passing each block to emit as soon as
+// it is complete.
+func (w *markdownWriter) eachBlock(nodes []dom.Node, emit func(markdownBlock)) {
+	var run strings.Builder
+	flush := func() {
+		if text := markdownParagraph(run.String()); text != "" {
+			emit(markdownBlock{text: text, inline: true})
+		}
+		run.Reset()
+	}
+	for _, node := range nodes {
+		elem, ok := node.(*dom.Element)
+		if !ok || !isMarkdownBlock(elem) {
+			w.writeInline(&run, node)
+			continue
+		}
+		flush()
+		w.block(elem, emit)
+	}
+	flush()
+}
+
+// joinMarkdownBlocks joins blocks with blank lines. In tight list items a
+// list directly following the item text is only put on the next line.
+func joinMarkdownBlocks(blocks []markdownBlock, tight bool) string {
+	var sb strings.Builder
+	for i, b := range blocks {
+		if i > 0 {
+			sb.WriteString(markdownSeparator(blocks[i-1], b, tight))
+		}
+		sb.WriteString(b.text)
+	}
+	return sb.String()
+}
+
+// markdownSeparator returns the text written between the blocks prev and b.
+func markdownSeparator(prev, b markdownBlock, tight bool) string {
+	switch {
+	case tight && prev.inline && interruptsParagraph(b):
+		return "\n"
+	case b.list != 0 && b.list == prev.list:
+		// Adjacent lists of the same kind would merge into one.
+		return "\n\n<!-- -->\n\n"
+	default:
+		return "\n\n"
+	}
+}
+
+// interruptsParagraph reports whether b is a list that can start directly
+// after a paragraph line.
+func interruptsParagraph(b markdownBlock) bool {
+	return b.list != 0 && (strings.HasPrefix(b.text, "- ") || strings.HasPrefix(b.text, "1. "))
+}
+
+// isLooseItem reports whether the blocks of a list item are separated by
+// blank lines, which makes the list loose.
+func isLooseItem(blocks []markdownBlock) bool {
+	for i := 1; i < len(blocks); i++ {
+		if !blocks[i-1].inline || !interruptsParagraph(blocks[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// block renders a block element, passing its blocks to emit.
+func (w *markdownWriter) block(elem *dom.Element, emit func(markdownBlock)) {
+	var blocks []markdownBlock
+	switch elem.TagName {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		if text := w.heading(elem); text != "" {
+			emit(markdownBlock{text: text})
+		}
+		return
+	case "p":
+		if text := markdownParagraph(w.inlineString(elem.Children())); text != "" {
+			emit(markdownBlock{text: text})
+		}
+		return
+	case "pre", "listing", "xmp", "plaintext":
+		emit(w.codeBlock(elem))
+		return
+	case "hr":
+		emit(markdownBlock{text: "---"})
+		return
+	case "ul", "ol":
+		blocks = w.list(elem)
+	case "li":
+		blocks = w.listItems([]*dom.Element{elem}, false, 1)
+	case "blockquote":
+		blocks = w.blockquote(elem)
+	case "table":
+		blocks = w.table(elem)
+	case "dl":
+		blocks = w.definitionList(elem)
+	default:
+		if !isMarkdownHidden(elem.TagName) {
+			w.eachBlock(elem.Children(), emit)
+		}
+		return
+	}
+	for _, b := range blocks {
+		emit(b)
+	}
+}
+
+// heading renders a heading element in the configured style.
+func (w *markdownWriter) heading(elem *dom.Element) string {
+	text := strings.Join(markdownLines(w.inlineString(elem.Children())), " ")
+	if text == "" {
+		return ""
+	}
+	level := int(elem.TagName[1] - '0')
+	if w.opts.HeadingStyle == HeadingSetext && level <= 2 {
+		underline := "="
+		if level == 2 {
+			underline = "-"
+		}
+		width := max(utf8.RuneCountInString(text), 3)
+		return escapeMarkdownLineStart(text) + "\n" + strings.Repeat(underline, width)
+	}
+	if trimmed := strings.TrimRight(text, "#"); trimmed != text && (trimmed == "" || strings.HasSuffix(trimmed, " ")) {
+		// A trailing run of hashes would be taken as a closing sequence.
+		text = trimmed + `\` + text[len(trimmed):]
+	}
+	return strings.Repeat("#", level) + " " + text
+}
+
+// codeBlock renders a preformatted element as a fenced code block.
+func (w *markdownWriter) codeBlock(pre *dom.Element) markdownBlock {
+	code := strings.TrimSuffix(pre.Text(), "\n")
+	fence := strings.Repeat("`", max(longestRun(code, '`')+1, 3))
+	return markdownBlock{text: fence + codeLanguage(pre) + "\n" + code + "\n" + fence}
+}
+
+// codeLanguage returns the language of a code block, taken from a
+// "language-*" or "lang-*" class of the pre element or of a code element that
+// is its only content.
+func codeLanguage(pre *dom.Element) string {
+	candidates := []*dom.Element{pre}
+	if code, ok := onlyChildElement(pre); ok && code.TagName == "code" {
+		candidates = append(candidates, code)
+	}
+	for _, elem := range candidates {
+		for _, class := range elem.Classes() {
+			for _, prefix := range []string{"language-", "lang-"} {
+				if lang, ok := strings.CutPrefix(class, prefix); ok && lang != "" && !strings.Contains(lang, "`") {
+					return lang
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// onlyChildElement returns the single element child of elem if elem has no
+// other content besides whitespace.
+func onlyChildElement(elem *dom.Element) (*dom.Element, bool) {
+	var only *dom.Element
+	for _, child := range elem.Children() {
+		switch c := child.(type) {
+		case *dom.Element:
+			if only != nil {
+				return nil, false
+			}
+			only = c
+		case *dom.Text:
+			if !isWhitespaceOnly(c.Data) {
+				return nil, false
+			}
+		}
+	}
+	return only, only != nil
+}
+
+// list renders a ul or ol element. Lists nested directly inside the list,
+// without an li, are attached to the preceding item.
+func (w *markdownWriter) list(elem *dom.Element) []markdownBlock {
+	start := 1
+	if elem.TagName == "ol" {
+		if n, err := strconv.Atoi(strings.TrimSpace(elem.Attr("start"))); err == nil && n >= 0 {
+			start = n
+		}
+	}
+	var items []*dom.Element
+	for _, child := range elem.Children() {
+		if li, ok := child.(*dom.Element); ok && (li.TagName == "li" || (li.TagName == "ul" || li.TagName == "ol") && len(items) > 0) {
+			items = append(items, li)
+		}
+	}
+	return w.listItems(items, elem.TagName == "ol", start)
+}
+
+// listItems renders the items of a list.
+func (w *markdownWriter) listItems(items []*dom.Element, ordered bool, number int) []markdownBlock {
+	kind := byte('-')
+	if ordered {
+		kind = '.'
+	}
+
+	var rendered []string
+	loose := false
+	for _, item := range items {
+		if item.TagName != "li" {
+			// A list misplaced directly in its parent list belongs to the
+			// previous item.
+			nested := joinMarkdownBlocks(w.list(item), false)
+			marker := strings.IndexByte(rendered[len(rendered)-1], ' ')
+			rendered[len(rendered)-1] += "\n" + indentMarkdown(nested, strings.Repeat(" ", max(marker+1, 2)))
+			continue
+		}
+
+		marker := "-"
+		if ordered {
+			marker = strconv.Itoa(number) + "."
+			number++
+		}
+		blocks := w.blocks(item.Children())
+		if isLooseItem(blocks) {
+			loose = true
+		}
+		body := joinMarkdownBlocks(blocks, true)
+		if checkbox := taskCheckbox(item); checkbox != nil {
+			if checkbox.HasAttr("checked") {
+				body = "[x] " + body
+			} else {
+				body = "[ ] " + body
+			}
+		}
+		rendered = append(rendered, marker+" "+indentMarkdown(body, strings.Repeat(" ", len(marker)+1)))
+	}
+	if len(rendered) == 0 {
+		return nil
+	}
+
+	sep := "\n"
+	if loose {
+		sep = "\n\n"
+	}
+	for i, item := range rendered {
+		rendered[i] = strings.TrimRight(item, " ")
+	}
+	return []markdownBlock{{text: strings.Join(rendered, sep), list: kind}}
+}
+
+// taskCheckbox returns the checkbox that starts a task list item, or nil.
+func taskCheckbox(li *dom.Element) *dom.Element {
+	parent := li
+	for range 2 {
+		elem := firstContentElement(parent)
+		if elem == nil {
+			return nil
+		}
+		if elem.TagName == "input" && strings.EqualFold(elem.Attr("type"), "checkbox") {
+			return elem
+		}
+		if elem.TagName != "p" && elem.TagName != "label" {
+			return nil
+		}
+		parent = elem
+	}
+	return nil
+}
+
+// firstContentElement returns the first child of elem if it is an element
+// preceded only by whitespace and comments.
+func firstContentElement(elem *dom.Element) *dom.Element {
+	for _, child := range elem.Children() {
+		switch c := child.(type) {
+		case *dom.Element:
+			return c
+		case *dom.Text:
+			if !isWhitespaceOnly(c.Data) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// blockquote renders a blockquote element, marking every line.
+func (w *markdownWriter) blockquote(elem *dom.Element) []markdownBlock {
+	text := joinMarkdownBlocks(w.blocks(elem.Children()), false)
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return []markdownBlock{{text: strings.Join(lines, "\n")}}
+}
+
+// table renders a table as a GFM pipe table. The caption, if any, becomes a
+// paragraph before the table.
+func (w *markdownWriter) table(table *dom.Element) []markdownBlock {
+	var blocks []markdownBlock
+	var rows [][]*dom.Element
+	for _, child := range table.Children() {
+		elem, ok := child.(*dom.Element)
+		if !ok {
+			continue
+		}
+		switch elem.TagName {
+		case "caption":
+			if text := markdownParagraph(w.inlineString(elem.Children())); text != "" {
+				blocks = append(blocks, markdownBlock{text: text})
+			}
+		case "thead", "tbody", "tfoot":
+			for _, tr := range elem.Children() {
+				if tr, ok := tr.(*dom.Element); ok && tr.TagName == "tr" {
+					rows = append(rows, tableCells(tr))
+				}
+			}
+		case "tr":
+			rows = append(rows, tableCells(elem))
+		}
+	}
+	if len(rows) == 0 {
+		return blocks
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return blocks
+	}
+
+	var sb strings.Builder
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteByte('|')
+		for col := range columns {
+			text := ""
+			if col < len(row) && row[col] != nil {
+				text = w.cellText(row[col])
+			}
+			sb.WriteString(" " + text + " |")
+		}
+		if i == 0 {
+			sb.WriteString("\n|")
+			for col := range columns {
+				var cell *dom.Element
+				if col < len(row) {
+					cell = row[col]
+				}
+				sb.WriteString(" " + alignmentDelimiter(cell) + " |")
+			}
+		}
+	}
+	return append(blocks, markdownBlock{text: sb.String()})
+}
+
+// tableCells returns the cells of a row. A cell spanning several columns is
+// followed by nil entries for the columns it covers.
+func tableCells(tr *dom.Element) []*dom.Element {
+	var cells []*dom.Element
+	for _, child := range tr.Children() {
+		cell, ok := child.(*dom.Element)
+		if !ok || (cell.TagName != "td" && cell.TagName != "th") {
+			continue
+		}
+		cells = append(cells, cell)
+		if span, err := strconv.Atoi(cell.Attr("colspan")); err == nil {
+			for i := 1; i < min(span, 1000); i++ {
+				cells = append(cells, nil)
+			}
+		}
+	}
+	return cells
+}
+
+// cellText renders the content of a table cell on a single line.
+func (w *markdownWriter) cellText(cell *dom.Element) string {
+	w.inCell = true
+	text := w.inlineString(cell.Children())
+	w.inCell = false
+	text = strings.Join(markdownLines(text), " ")
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// alignmentDelimiter returns the delimiter row cell for the alignment of
+// cell, taken from its align attribute or text-align style.
+func alignmentDelimiter(cell *dom.Element) string {
+	if cell == nil {
+		return "---"
+	}
+	align := strings.ToLower(strings.TrimSpace(cell.Attr("align")))
+	style := strings.ToLower(cell.Attr("style"))
+	if _, value, ok := strings.Cut(style, "text-align"); ok {
+		if _, value, ok = strings.Cut(value, ":"); ok {
+			value, _, _ = strings.Cut(value, ";")
+			align = strings.TrimSpace(value)
+		}
+	}
+	switch align {
+	case "left":
+		return ":---"
+	case "center":
+		return ":---:"
+	case "right":
+		return "---:"
+	}
+	return "---"
+}
+
+// definitionList renders a dl element as terms followed by ": " definitions.
+func (w *markdownWriter) definitionList(dl *dom.Element) []markdownBlock {
+	var groups []string
+	var group strings.Builder
+	inDefinitions := false
+	for _, child := range dl.Children() {
+		elem, ok := child.(*dom.Element)
+		if !ok {
+			continue
+		}
+		switch elem.TagName {
+		case "dt":
+			if inDefinitions && group.Len() > 0 {
+				groups = append(groups, group.String())
+				group.Reset()
+			}
+			inDefinitions = false
+			if text := markdownParagraph(w.inlineString(elem.Children())); text != "" {
+				if group.Len() > 0 {
+					group.WriteByte('\n')
+				}
+				group.WriteString(text)
+			}
+		case "dd":
+			inDefinitions = true
+			text := joinMarkdownBlocks(w.blocks(elem.Children()), false)
+			if group.Len() > 0 {
+				group.WriteByte('\n')
+			}
+			group.WriteString(strings.TrimRight(": "+indentMarkdown(text, "  "), " "))
+		}
+	}
+	if group.Len() > 0 {
+		groups = append(groups, group.String())
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	return []markdownBlock{{text: strings.Join(groups, "\n\n")}}
+}
+
+// indentMarkdown indents all lines of text but the first. Empty lines stay
+// empty.
+func indentMarkdown(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inlineString renders nodes as inline content. Whitespace is collapsed to
+// single spaces, and a "\n" marks a line break.
+func (w *markdownWriter) inlineString(nodes []dom.Node) string {
+	var sb strings.Builder
+	for _, node := range nodes {
+		w.writeInline(&sb, node)
+	}
+	return sb.String()
+}
+
+func (w *markdownWriter) writeInline(sb *strings.Builder, node dom.Node) {
+	switch n := node.(type) {
+	case *dom.Text:
+		sb.WriteString(escapeMarkdownText(collapseMarkdownWhitespace(n.Data)))
+	case *dom.CDATASection:
+		sb.WriteString(escapeMarkdownText(collapseMarkdownWhitespace(n.Data)))
+	case *dom.Element:
+		w.writeInlineElement(sb, n)
+	}
+}
+
+func (w *markdownWriter) writeInlineElement(sb *strings.Builder, elem *dom.Element) {
+	if isMarkdownHidden(elem.TagName) {
+		return
+	}
+	switch elem.TagName {
+	case "br":
+		if w.inCell {
+			sb.WriteString("<br>")
+		} else {
+			sb.WriteByte('\n')
+		}
+	case "strong", "b":
+		w.writeDelimited(sb, elem, "**", "**")
+	case "em", "i":
+		w.writeDelimited(sb, elem, "*", "*")
+	case "del", "s", "strike":
+		w.writeDelimited(sb, elem, "~~", "~~")
+	case "sup", "sub":
+		w.writeDelimited(sb, elem, "<"+elem.TagName+">", "</"+elem.TagName+">")
+	case "code", "kbd", "samp", "tt":
+		sb.WriteString(markdownCodeSpan(collapseMarkdownWhitespace(elem.Text())))
+	case "a":
+		w.writeLink(sb, elem)
+	case "img":
+		w.writeImage(sb, elem)
+	default:
+		if isMarkdownBlock(elem) {
+			// Block content inside inline content is kept on the same line.
+			sb.WriteByte(' ')
+			sb.WriteString(w.inlineString(elem.Children()))
+			sb.WriteByte(' ')
+			return
+		}
+		for _, child := range elem.Children() {
+			w.writeInline(sb, child)
+		}
+	}
+}
+
+// writeDelimited writes the content of elem between open and close. Spaces
+// at the edges of the content are moved outside the delimiters, where they
+// do not prevent emphasis from being recognized.
+func (w *markdownWriter) writeDelimited(sb *strings.Builder, elem *dom.Element, open, closing string) {
+	inner := w.inlineString(elem.Children())
+	core := strings.Trim(inner, " ")
+	if core == "" {
+		sb.WriteString(inner)
+		return
+	}
+	if strings.HasPrefix(inner, " ") {
+		sb.WriteByte(' ')
+	}
+	sb.WriteString(open + core + closing)
+	if strings.HasSuffix(inner, " ") {
+		sb.WriteByte(' ')
+	}
+}
+
+// writeLink writes an a element. Links whose text is their absolute URL
+// become autolinks.
+func (w *markdownWriter) writeLink(sb *strings.Builder, elem *dom.Element) {
+	inner := w.inlineString(elem.Children())
+	href, ok := elem.Attributes.Get("href")
+	core := strings.Trim(inner, " ")
+	if !ok || core == "" {
+		sb.WriteString(inner)
+		return
+	}
+	href = w.resolveURL(href)
+	title := collapseMarkdownWhitespace(elem.Attr("title"))
+
+	if strings.HasPrefix(inner, " ") {
+		sb.WriteByte(' ')
+	}
+	switch {
+	case title == "" && isMarkdownAutolink(href) && core == escapeMarkdownText(href):
+		sb.WriteString("<" + href + ">")
+	case title == "" && strings.HasPrefix(href, "mailto:") && isMarkdownAutolink(href) && core == escapeMarkdownText(href[len("mailto:"):]):
+		sb.WriteString("<" + href[len("mailto:"):] + ">")
+	default:
+		sb.WriteString("[" + core + "]" + w.linkTarget(href, title))
+	}
+	if strings.HasSuffix(inner, " ") {
+		sb.WriteByte(' ')
+	}
+}
+
+// writeImage writes an img element.
+func (w *markdownWriter) writeImage(sb *strings.Builder, elem *dom.Element) {
+	src, ok := elem.Attributes.Get("src")
+	if !ok {
+		return
+	}
+	alt := escapeMarkdownText(collapseMarkdownWhitespace(elem.Attr("alt")))
+	title := collapseMarkdownWhitespace(elem.Attr("title"))
+	sb.WriteString("![" + strings.Trim(alt, " ") + "]" + w.linkTarget(w.resolveURL(src), title))
+}
+
+// linkTarget returns the part of a link or image after the text: the
+// destination in parentheses, or a reference in LinkReferenced style.
+func (w *markdownWriter) linkTarget(dest, title string) string {
+	if w.opts.LinkStyle == LinkReferenced {
+		ref := markdownRef{url: dest, title: title}
+		for i, r := range w.refs {
+			if r == ref {
+				return "[" + strconv.Itoa(i+1) + "]"
+			}
+		}
+		w.refs = append(w.refs, ref)
+		return "[" + strconv.Itoa(len(w.refs)) + "]"
+	}
+	if title != "" {
+		return "(" + markdownDestination(dest) + " " + markdownTitle(title) + ")"
+	}
+	return "(" + markdownDestination(dest) + ")"
+}
+
+// resolveURL resolves ref against the base URL, if one is set.
+func (w *markdownWriter) resolveURL(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if w.base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return w.base.ResolveReference(u).String()
+}
+
+// isMarkdownAutolink reports whether href can be written as an autolink.
+func isMarkdownAutolink(href string) bool {
+	scheme, rest, ok := strings.Cut(href, ":")
+	if !ok || rest == "" || strings.ContainsAny(href, " <>\t\n") {
+		return false
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https", "ftp", "mailto":
+		return true
+	}
+	return false
+}
+
+// markdownDestination formats a link destination.
+func markdownDestination(dest string) string {
+	if dest == "" {
+		return "<>"
+	}
+	return strings.NewReplacer(" ", "%20", "<", "%3C", ">", "%3E", "(", `\(`, ")", `\)`).Replace(dest)
+}
+
+// markdownTitle formats a link title.
+func markdownTitle(title string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(title) + `"`
+}
+
+// markdownCodeSpan formats code as a code span, using a backtick string
+// longer than any run of backticks in the code.
+func markdownCodeSpan(code string) string {
+	if strings.Trim(code, " ") == "" {
+		return code
+	}
+	fence := strings.Repeat("`", longestRun(code, '`')+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") ||
+		(strings.HasPrefix(code, " ") && strings.HasSuffix(code, " ")) {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+// longestRun returns the length of the longest run of c in s.
+func longestRun(s string, c byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != c {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	return longest
+}
+
+// markdownParagraph turns inline content into the lines of a paragraph:
+// spaces are collapsed and trimmed, line starts that would be read as block
+// syntax are escaped, and line breaks become hard breaks.
+func markdownParagraph(inline string) string {
+	lines := markdownLines(inline)
+	for i, line := range lines {
+		lines[i] = escapeMarkdownLineStart(line)
+	}
+	return strings.Join(lines, "\\\n")
+}
+
+// markdownLines splits inline content at line breaks, collapsing and
+// trimming the spaces of each line and dropping empty lines.
+func markdownLines(inline string) []string {
+	var lines []string
+	for _, line := range strings.Split(inline, "\n") {
+		var sb strings.Builder
+		space := false
+		for _, r := range line {
+			if r == ' ' {
+				space = true
+				continue
+			}
+			if space && sb.Len() > 0 {
+				sb.WriteByte(' ')
+			}
+			space = false
+			sb.WriteRune(r)
+		}
+		if sb.Len() > 0 {
+			lines = append(lines, sb.String())
+		}
+	}
+	return lines
+}
+
+// escapeMarkdownText escapes the characters of text that could start
+// inline Markdown syntax.
+func escapeMarkdownText(text string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch c {
+		case '\\', '`', '*', '_', '[', ']', '~':
+			sb.WriteByte('\\')
+		case '<':
+			if i+1 < len(text) && (isASCIILetter(text[i+1]) || strings.IndexByte("/!?", text[i+1]) >= 0) {
+				sb.WriteByte('\\')
+			}
+		case '&':
+			if isEntityAhead(text[i+1:]) {
+				sb.WriteByte('\\')
+			}
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// isEntityAhead reports whether s starts like the rest of a character
+// reference, such as "amp;" or "#39;".
+func isEntityAhead(s string) bool {
+	s = strings.TrimPrefix(s, "#")
+	n := 0
+	for n < len(s) && (isASCIILetter(s[n]) || s[n] >= '0' && s[n] <= '9') {
+		n++
+	}
+	return n > 0 && n < len(s) && s[n] == ';'
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// escapeMarkdownLineStart escapes the start of a paragraph line that would
+// otherwise be read as a heading, list item, block quote or setext heading
+// underline.
+func escapeMarkdownLineStart(line string) string {
+	rest := strings.TrimLeft(line, "#")
+	if hashes := len(line) - len(rest); hashes >= 1 && hashes <= 6 && (rest == "" || rest[0] == ' ') {
+		return `\` + line
+	}
+	if strings.Trim(line, "-= ") == "" || line[0] == '>' ||
+		(line[0] == '-' || line[0] == '+') && (len(line) == 1 || line[1] == ' ') {
+		return `\` + line
+	}
+	digits := 0
+	for digits < len(line) && digits < 9 && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 && digits < len(line) && (line[digits] == '.' || line[digits] == ')') &&
+		(digits+1 == len(line) || line[digits+1] == ' ') {
+		return line[:digits] + `\` + line[digits:]
+	}
+	return line
+}
+
+// isMarkdownBlock reports whether elem is rendered as a block in Markdown:
+// block-level elements, and inline elements that contain blocks.
+func isMarkdownBlock(elem *dom.Element) bool {
+	if elem.Namespace != dom.NamespaceHTML {
+		return false
+	}
+	if IsBlockElement(elem.TagName) {
+		return true
+	}
+	for _, child := range elem.Children() {
+		if c, ok := child.(*dom.Element); ok && isMarkdownBlock(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMarkdownHidden reports whether elements with the given tag are left out
+// of the Markdown output.
+func isMarkdownHidden(tag string) bool {
+	switch tag {
+	case "head", "script", "style", "template", "title", "noscript", "meta", "link", "base":
+		return true
+	}
+	return false
+}
+
+// collapseMarkdownWhitespace replaces runs of whitespace with single spaces.
+func collapseMarkdownWhitespace(s string) string {
+	var sb strings.Builder
+	inWhitespace := false
+	for _, r := range s {
+		if isWhitespaceChar(r) {
+			if !inWhitespace {
+				sb.WriteByte(' ')
+			}
+			inWhitespace = true
+			continue
+		}
+		sb.WriteRune(r)
+		inWhitespace = false
+	}
+	return sb.String()
+}
@@ -0,0 +1,122 @@
+package serialize_test
+
+import (
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+)
+
+func TestToMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "headings and paragraphs",
+			input: "<h1>Title</h1><p>Some   <b>bold</b> and <i> italic </i>text.</p><h3>Sub</h3>",
+			want:  "# Title\n\nSome **bold** and *italic* text.\n\n### Sub",
+		},
+		{
+			name:  "escaping",
+			input: `<p>1. not a list, *stars*, _under_ [x] a\b &amp;copy; &lt;tag&gt; a < b</p><p># no heading</p><p>- no item</p><p>+</p>`,
+			want:  "1\\. not a list, \\*stars\\*, \\_under\\_ \\[x\\] a\\\\b \\&copy; \\<tag> a < b\n\n\\# no heading\n\n\\- no item\n\n\\+",
+		},
+		{
+			name:  "line breaks",
+			input: "<p>one<br>two<br>---</p>",
+			want:  "one\\\ntwo\\\n\\---",
+		},
+		{
+			name:  "code",
+			input: "<p>Use <code>a `b` c</code>.</p><pre><code class=\"language-go\">func main() {\n\tfmt.Println(\"```\")\n}\n</code></pre>",
+			want:  "Use ``a `b` c``.\n\n````go\nfunc main() {\n\tfmt.Println(\"```\")\n}\n````",
+		},
+		{
+			name:  "strikethrough superscript and subscript",
+			input: "<p><del>old</del> <s>gone</s> x<sup>2</sup> H<sub>2</sub>O</p>",
+			want:  "~~old~~ ~~gone~~ x<sup>2</sup> H<sub>2</sub>O",
+		},
+		{
+			name:  "links and images",
+			input: `<p><a href="/a" title="A &quot;t&quot;">text</a> <a href="https://example.com">https://example.com</a> <a href="mailto:me@example.com">me@example.com</a> <img src="x (1).png" alt="an [x]"></p>`,
+			want:  `[text](/a "A \"t\"") <https://example.com> <me@example.com> ![an \[x\]](x%20\(1\).png)`,
+		},
+		{
+			name:  "nested lists",
+			input: "<ul><li>one<ul><li>inner</li></ul></li><li>two</li></ul><ol start=\"3\"><li>three</li><li>four</li></ol>",
+			want:  "- one\n  - inner\n- two\n\n3. three\n4. four",
+		},
+		{
+			name:  "loose list with blocks",
+			input: "<ol><li><p>para</p><pre>code\n  more</pre></li><li><blockquote><p>q1</p><p>q2</p></blockquote></li></ol>",
+			want:  "1. para\n\n   ```\n   code\n     more\n   ```\n\n2. > q1\n   >\n   > q2",
+		},
+		{
+			name:  "adjacent lists",
+			input: "<ul><li>a</li></ul><ul><li>b</li></ul>",
+			want:  "- a\n\n<!-- -->\n\n- b",
+		},
+		{
+			name:  "task list",
+			input: `<ul><li><input type="checkbox" checked> done</li><li><input type="checkbox"> todo</li></ul>`,
+			want:  "- [x] done\n- [ ] todo",
+		},
+		{
+			name:  "blockquote",
+			input: "<blockquote><p>first</p><p>second<br>line</p><ul><li>item</li></ul></blockquote>",
+			want:  "> first\n>\n> second\\\n> line\n>\n> - item",
+		},
+		{
+			name:  "table",
+			input: `<table><caption>Prices</caption><thead><tr><th>Item</th><th align="right">Price</th><th style="text-align: center">Note</th></tr></thead><tbody><tr><td>a|b</td><td>1</td><td><b>x</b><br>y</td></tr><tr><td colspan="2">wide</td><td>z</td></tr></tbody></table>`,
+			want:  "Prices\n\n| Item | Price | Note |\n| --- | ---: | :---: |\n| a\\|b | 1 | **x**<br>y |\n| wide |  | z |",
+		},
+		{
+			name:  "definition list",
+			input: "<dl><dt>Term</dt><dd>First</dd><dd>Second</dd><dt>Other</dt><dd>Def</dd></dl>",
+			want:  "Term\n: First\n: Second\n\nOther\n: Def",
+		},
+		{
+			name:  "document",
+			input: "<!DOCTYPE html><html><head><title>T</title><style>p {}</style></head><body><div>intro<p>para</p>tail</div><hr><script>x()</script></body></html>",
+			want:  "intro\n\npara\n\ntail\n\n---",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, tt.input)
+			if got := serialize.ToMarkdown(doc); got != tt.want {
+				t.Errorf("ToMarkdown(%q)\ngot:\n%s\nwant:\n%s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToMarkdownWithOptions(t *testing.T) {
+	doc := mustParse(t, `<h1>Title</h1><h2>Sub</h2><h3>Deep</h3><p><a href="guide.html">Guide</a>, <a href="/faq" title="FAQ">FAQ</a> and <a href="guide.html">again</a> <img src="img/a.png" alt="A"></p>`)
+
+	got := serialize.ToMarkdownWithOptions(doc, serialize.MarkdownOptions{
+		HeadingStyle: serialize.HeadingSetext,
+		LinkStyle:    serialize.LinkReferenced,
+		BaseURL:      "https://example.com/docs/index.html",
+	})
+	want := "Title\n=====\n\nSub\n---\n\n### Deep\n\n" +
+		"[Guide][1], [FAQ][2] and [again][1] ![A][3]\n\n" +
+		"[1]: https://example.com/docs/guide.html\n" +
+		"[2]: https://example.com/faq \"FAQ\"\n" +
+		"[3]: https://example.com/docs/img/a.png"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestToMarkdownElement(t *testing.T) {
+	doc := mustParse(t, `<p>before <a href="/x">link <em>text</em></a> after</p>`)
+	a := queryFirst(t, doc, "a")
+
+	if got, want := serialize.ToMarkdown(a), "[link *text*](/x)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
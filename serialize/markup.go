@@ -0,0 +1,678 @@
+package serialize
+
+import (
+	"iter"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// The DOM serializer and the html5lib token serializer share one markup
+// writer. Both inputs are first turned into a flat stream of markupTokens,
+// which a markupWriter renders according to markupOptions. Working on a token
+// stream gives the optional tag rules the lookahead they need, and since the
+// writer holds back only the few tokens it looks ahead at, the output can be
+// streamed.
+
+// tokenKind identifies the kind of a markupToken.
+type tokenKind int
+
+const (
+	startTagToken tokenKind = iota
+	endTagToken
+	emptyTagToken // a start tag that has no end tag (void elements)
+	textToken
+	commentToken
+	doctypeToken
+	verbatimToken // markup written as is (CDATA sections, processing instructions)
+)
+
+// markupToken is a single token of the markup stream.
+type markupToken struct {
+	kind  tokenKind
+	name  string // tag or doctype name
+	attrs []tokenAttr
+	data  string // text, comment data or raw markup
+
+	publicID string
+	systemID string
+
+	// foreign marks tags of elements outside the HTML namespace. The HTML
+	// rules for raw text and optional tags do not apply to them.
+	foreign bool
+
+	// raw marks text that is written without escaping regardless of the
+	// surrounding tags, such as the text of a DOM script element.
+	raw bool
+}
+
+// markupOptions configures writeMarkup. It is derived from Options or
+// SerializeTokenOptions.
+type markupOptions struct {
+	quoteChar       rune
+	minimalQuotes   bool // leave values unquoted where possible
+	trailingSolidus bool
+	minimizeBoolean bool
+	anyBooleanName  bool // any attribute whose value is its name is boolean
+	escapeLtInAttrs bool
+	escapeRcdata    bool
+	stripWhitespace bool
+	omitOptional    bool
+	injectCharset   bool
+	encoding        string
+	sortAttrs       bool
+	scripting       bool // noscript is a raw text element
+
+	// specEscaping selects the WHATWG escaping rules: U+00A0 is escaped in
+	// text and attributes, and < and > in attributes. Without it the
+	// html5lib serializer test rules apply.
+	specEscaping bool
+}
+
+// isRawTextElement reports whether the content of elements with the given
+// tag is written without escaping.
+func (o *markupOptions) isRawTextElement(tag string) bool {
+	return isRawTextElement(tag) || (o.scripting && tag == "noscript")
+}
+
+// writeMarkup writes the token stream to sb.
+// markupSink receives the output of the markup writer: a strings.Builder,
+// or a bufio.Writer when streaming to an io.Writer.
+type markupSink interface {
+	WriteString(s string) (int, error)
+	WriteByte(c byte) error
+	WriteRune(r rune) (int, error)
+}
+
+// writeMarkup writes a complete token stream to sb.
+func writeMarkup(sb markupSink, tokens []markupToken, o *markupOptions) {
+	w := newMarkupWriter(sb, o)
+	for _, tok := range tokens {
+		w.push(tok)
+	}
+	w.close()
+}
+
+// markupWriter writes tokens as they are pushed. It only holds back the
+// tokens the optional tag rules need to look at: the token before the next
+// one to write, the token after it, and the tokens up to </head> when a
+// charset declaration may have to be injected.
+type markupWriter struct {
+	sb     markupSink
+	o      *markupOptions
+	inject bool
+
+	// window holds the last written token, if any, followed by the tokens
+	// not written yet.
+	window  []markupToken
+	written int // number of written tokens in window, 0 or 1
+
+	rawTextDepth      int
+	preformattedDepth int
+}
+
+func newMarkupWriter(sb markupSink, o *markupOptions) *markupWriter {
+	return &markupWriter{sb: sb, o: o, inject: o.injectCharset && o.encoding != ""}
+}
+
+// push adds the next token of the stream and writes the tokens whose
+// lookahead is complete.
+func (w *markupWriter) push(tok markupToken) {
+	w.window = append(w.window, tok)
+	w.drain(false)
+}
+
+// close writes the remaining tokens at the end of the stream.
+func (w *markupWriter) close() {
+	w.drain(true)
+}
+
+func (w *markupWriter) drain(final bool) {
+	for w.written < len(w.window) && (final || w.ready(w.written)) {
+		w.writeToken(w.written)
+		w.written++
+	}
+	if w.written > 1 {
+		n := copy(w.window, w.window[w.written-1:])
+		clear(w.window[n:])
+		w.window = w.window[:n]
+		w.written = 1
+	}
+}
+
+// ready reports whether the tokens following window[i] that decide how it
+// is written have arrived.
+func (w *markupWriter) ready(i int) bool {
+	if i+1 >= len(w.window) {
+		return false
+	}
+	if w.inject && isTag(&w.window[i], startTagToken, "head") && !w.window[i].foreign {
+		for j := i + 1; j < len(w.window); j++ {
+			if isTag(&w.window[j], endTagToken, "head") {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (w *markupWriter) writeToken(i int) {
+	sb, o, tokens := w.sb, w.o, w.window
+	tok := &tokens[i]
+	switch tok.kind {
+	case startTagToken:
+		if !(o.omitOptional && !tok.foreign && shouldOmitStartTag(tokens, i)) {
+			writeStartTag(sb, tok.name, tok.attrs, isVoidElement(tok.name) && !tok.foreign, tok.foreign, o)
+		}
+		if tok.foreign {
+			return
+		}
+		if tok.name == "head" && w.inject && !hasCharsetMetaAhead(tokens, i) {
+			writeInjectedMeta(sb, o)
+		}
+		if isPreformattedElement(tok.name) {
+			w.preformattedDepth++
+		}
+		if o.isRawTextElement(tok.name) {
+			w.rawTextDepth++
+		}
+	case endTagToken:
+		if !(o.omitOptional && !tok.foreign && shouldOmitEndTag(tokens, i)) {
+			sb.WriteString("</")
+			sb.WriteString(tok.name)
+			sb.WriteByte('>')
+		}
+		if tok.foreign {
+			return
+		}
+		if isPreformattedElement(tok.name) && w.preformattedDepth > 0 {
+			w.preformattedDepth--
+		}
+		if o.isRawTextElement(tok.name) && w.rawTextDepth > 0 {
+			w.rawTextDepth--
+		}
+	case emptyTagToken:
+		writeStartTag(sb, tok.name, tok.attrs, true, tok.foreign, o)
+	case textToken:
+		data := tok.data
+		if o.stripWhitespace && w.rawTextDepth == 0 && w.preformattedDepth == 0 {
+			data = collapseTokenWhitespace(data)
+		}
+		if (w.rawTextDepth > 0 || tok.raw) && !o.escapeRcdata {
+			sb.WriteString(data)
+		} else {
+			writeEscapedText(sb, data, o)
+		}
+	case commentToken:
+		sb.WriteString("<!--")
+		sb.WriteString(tok.data)
+		sb.WriteString("-->")
+	case doctypeToken:
+		writeDoctype(sb, tok.name, tok.publicID, tok.systemID)
+	case verbatimToken:
+		sb.WriteString(tok.data)
+	}
+}
+
+// writeStartTag writes a start tag. solidus marks tags that get a trailing
+// solidus when UseTrailingSolidus is set, and foreign the tags of elements
+// outside the HTML namespace.
+func writeStartTag(sb markupSink, name string, attrs []tokenAttr, solidus, foreign bool, o *markupOptions) {
+	sb.WriteByte('<')
+	sb.WriteString(name)
+
+	if o.injectCharset && o.encoding != "" && name == "meta" {
+		attrs = normalizeMetaCharsetAttrs(attrs, o.encoding)
+	}
+	if o.sortAttrs {
+		sortTokenAttrs(attrs)
+	}
+	for _, attr := range attrs {
+		sb.WriteByte(' ')
+		sb.WriteString(attr.Name)
+		if !o.minimizesAttr(name, foreign, attr) {
+			writeAttrValue(sb, attr.Value, o)
+		}
+	}
+
+	if solidus && o.trailingSolidus {
+		sb.WriteString(" /")
+	}
+	sb.WriteByte('>')
+}
+
+// minimizesAttr reports whether the value of attr on the tag element is left
+// out: with minimizeBoolean, an empty value, or a value equal to the name of
+// a boolean attribute. The html5lib token serializer treats every attribute
+// whose value is its name as boolean; for elements, only the boolean
+// attributes of HTML elements are, as other values would be lost.
+func (o *markupOptions) minimizesAttr(tag string, foreign bool, attr tokenAttr) bool {
+	if !o.minimizeBoolean {
+		return false
+	}
+	if attr.Value == "" {
+		return true
+	}
+	if attr.Value != attr.Name {
+		return false
+	}
+	return o.anyBooleanName || (!foreign && IsBooleanAttribute(tag, attr.Name))
+}
+
+// writeAttrValue writes "=value" for an attribute.
+//
+// Values are double-quoted unless QuoteChar asks for single quotes. With
+// minimal quoting, values are left unquoted when the syntax allows it and
+// single-quoted when that avoids escaping double quotes.
+func writeAttrValue(sb markupSink, value string, o *markupOptions) {
+	if value == "" {
+		sb.WriteString(`=""`)
+		return
+	}
+
+	quote := byte('"')
+	switch {
+	case o.quoteChar == '\'':
+		quote = '\''
+	case o.minimalQuotes:
+		if !needsTokenAttrQuoting(value) {
+			quote = 0
+		} else if strings.ContainsRune(value, '"') && !strings.ContainsRune(value, '\'') {
+			quote = '\''
+		}
+	}
+
+	sb.WriteByte('=')
+	if quote != 0 {
+		sb.WriteByte(quote)
+	}
+	for _, r := range value {
+		switch {
+		case r == '&' && (quote != 0 || o.specEscaping):
+			sb.WriteString("&amp;")
+		case r == '"' && quote == '"':
+			sb.WriteString("&quot;")
+		case r == '\'' && quote == '\'':
+			sb.WriteString("&#39;")
+		case r == '<' && (o.specEscaping || (o.escapeLtInAttrs && quote == '"')):
+			sb.WriteString("&lt;")
+		case r == '>' && o.specEscaping:
+			sb.WriteString("&gt;")
+		case r == ' ' && o.specEscaping:
+			sb.WriteString("&nbsp;")
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if quote != 0 {
+		sb.WriteByte(quote)
+	}
+}
+
+// writeEscapedText writes character data, escaping &, < and > (and U+00A0
+// with spec escaping).
+func writeEscapedText(sb markupSink, data string, o *markupOptions) {
+	if o.specEscaping {
+		sb.WriteString(escapeText(data))
+		return
+	}
+	for _, r := range data {
+		switch r {
+		case '&':
+			sb.WriteString("&amp;")
+		case '<':
+			sb.WriteString("&lt;")
+		case '>':
+			sb.WriteString("&gt;")
+		default:
+			sb.WriteRune(r)
+		}
+	}
+}
+
+// writeDOMMarkup writes the subtree rooted at node.
+func writeDOMMarkup(sb markupSink, node dom.Node, opts Options) {
+	w := newMarkupWriter(sb, opts.markupOptions())
+	for tok := range domTokens(node) {
+		w.push(tok)
+	}
+	w.close()
+}
+
+// writeDoctype writes a doctype with optional public and system identifiers.
+func writeDoctype(sb markupSink, name, publicID, systemID string) {
+	sb.WriteString("<!DOCTYPE ")
+	sb.WriteString(name)
+	if publicID != "" {
+		sb.WriteString(" PUBLIC ")
+		writeDoctypeID(sb, publicID)
+		if systemID != "" {
+			sb.WriteByte(' ')
+			writeDoctypeID(sb, systemID)
+		}
+	} else if systemID != "" {
+		sb.WriteString(" SYSTEM ")
+		writeDoctypeID(sb, systemID)
+	}
+	sb.WriteByte('>')
+}
+
+// writeDoctypeID writes a quoted doctype identifier, using single quotes when
+// the identifier contains a double quote.
+func writeDoctypeID(sb markupSink, id string) {
+	quote := byte('"')
+	if strings.IndexByte(id, '"') >= 0 {
+		quote = '\''
+	}
+	sb.WriteByte(quote)
+	sb.WriteString(id)
+	sb.WriteByte(quote)
+}
+
+// writeInjectedMeta writes the <meta charset> element added by
+// InjectMetaCharset.
+func writeInjectedMeta(sb markupSink, o *markupOptions) {
+	if o.encoding == "" {
+		return
+	}
+	sb.WriteString("<meta charset")
+	writeAttrValue(sb, o.encoding, o)
+	sb.WriteByte('>')
+}
+
+// isPreformattedElement reports whether whitespace inside tag is significant.
+func isPreformattedElement(tag string) bool {
+	return tag == "pre" || tag == "textarea" || tag == "listing"
+}
+
+// tokenAt returns the token at index i, or nil when i is out of range.
+func tokenAt(tokens []markupToken, i int) *markupToken {
+	if i < 0 || i >= len(tokens) {
+		return nil
+	}
+	return &tokens[i]
+}
+
+// isTag reports whether tok is a tag of the given kind, and of one of the
+// given names if any are listed.
+func isTag(tok *markupToken, kind tokenKind, names ...string) bool {
+	if tok == nil || tok.kind != kind {
+		return false
+	}
+	if len(names) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if tok.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isComment reports whether tok is a comment.
+func isComment(tok *markupToken) bool {
+	return tok != nil && tok.kind == commentToken
+}
+
+// startsWithSpace reports whether tok is text starting with a space character.
+func startsWithSpace(tok *markupToken) bool {
+	if tok == nil || tok.kind != textToken || tok.data == "" {
+		return false
+	}
+	switch tok.data[0] {
+	case ' ', '\t', '\n', '\r', '\f':
+		return true
+	}
+	return false
+}
+
+// endsParent reports whether tok ends the content of the parent element,
+// either as the end of the stream or as the parent's end tag.
+func endsParent(tok *markupToken) bool {
+	return tok == nil || tok.kind == endTagToken
+}
+
+// shouldOmitStartTag checks if the start tag at tokens[idx] can be omitted.
+// Per https://html.spec.whatwg.org/multipage/syntax.html#optional-tags
+func shouldOmitStartTag(tokens []markupToken, idx int) bool {
+	tok := &tokens[idx]
+	next := tokenAt(tokens, idx+1)
+
+	// Start tags carrying attributes are never omitted.
+	if len(tok.attrs) > 0 {
+		return false
+	}
+
+	switch tok.name {
+	case "html":
+		// An html element's start tag can be omitted if the first thing inside
+		// the html element is not a comment or a space character.
+		return !isComment(next) && !startsWithSpace(next)
+	case "head":
+		// A head element's start tag can be omitted if the element is empty,
+		// or if the first thing inside the head element is an element.
+		return isTag(next, startTagToken) || isTag(next, emptyTagToken) || isTag(next, endTagToken)
+	case "body":
+		// A body element's start tag can be omitted if the element is empty,
+		// or if the first thing inside the body element is not a space
+		// character or a comment, or an element the parser would put into
+		// the head instead.
+		return !isComment(next) && !startsWithSpace(next) &&
+			!isTag(next, startTagToken, headContentTags...) && !isTag(next, emptyTagToken, headContentTags...)
+	case "colgroup":
+		// A colgroup element's start tag can be omitted if the first thing
+		// inside the colgroup element is a col element.
+		return isTag(next, startTagToken, "col") || isTag(next, emptyTagToken, "col")
+	case "tbody":
+		// A tbody element's start tag can be omitted if the first thing inside
+		// the tbody element is a tr element and the tbody is the first in a table.
+		return isTag(next, startTagToken, "tr") && isTag(tokenAt(tokens, idx-1), startTagToken, "table")
+	}
+	return false
+}
+
+// shouldOmitEndTag checks if the end tag at tokens[idx] can be omitted.
+//
+//nolint:gocyclo,cyclop // Tag omission rules follow the HTML5 optional tags spec case by case
+func shouldOmitEndTag(tokens []markupToken, idx int) bool {
+	next := tokenAt(tokens, idx+1)
+
+	switch tokens[idx].name {
+	case "html", "head", "body":
+		// These end tags can be omitted if not immediately followed by a
+		// comment or a space character.
+		return !isComment(next) && !startsWithSpace(next)
+	case "li":
+		// An li element's end tag can be omitted if the li element is
+		// immediately followed by another li element or if there is no more
+		// content in the parent element.
+		return endsParent(next) || isTag(next, startTagToken, "li")
+	case "dt":
+		// A dt element's end tag can be omitted if the dt element is
+		// immediately followed by another dt element or a dd element.
+		return isTag(next, startTagToken, "dt", "dd")
+	case "dd":
+		// A dd element's end tag can be omitted if the dd element is
+		// immediately followed by another dd element or a dt element, or
+		// if there is no more content in the parent element.
+		return endsParent(next) || isTag(next, startTagToken, "dd", "dt")
+	case "p":
+		// A p element's end tag can be omitted if the p element is immediately
+		// followed by certain elements, or if there is no more content in a
+		// parent other than a, audio, del, ins, map, noscript or video.
+		if next == nil {
+			return true
+		}
+		if next.kind == endTagToken {
+			return !isTag(next, endTagToken, "a", "audio", "del", "ins", "map", "noscript", "video")
+		}
+		return isTag(next, startTagToken, pClosingTags...) || isTag(next, emptyTagToken, pClosingTags...)
+	case "optgroup":
+		// An optgroup element's end tag can be omitted if the optgroup element
+		// is immediately followed by another optgroup element, or if there is
+		// no more content in the parent element.
+		return endsParent(next) || isTag(next, startTagToken, "optgroup")
+	case "option":
+		// An option element's end tag can be omitted if the option element is
+		// immediately followed by another option element, or an optgroup element,
+		// or if there is no more content in the parent element.
+		return endsParent(next) || isTag(next, startTagToken, "option", "optgroup")
+	case "colgroup":
+		// A colgroup element's end tag can be omitted if it is not immediately
+		// followed by a comment, a space character or another colgroup.
+		return !isComment(next) && !startsWithSpace(next) && !isTag(next, startTagToken, "colgroup")
+	case "thead":
+		// End tag can be omitted if immediately followed by tbody or tfoot.
+		return isTag(next, startTagToken, "tbody", "tfoot")
+	case "tbody":
+		// End tag can be omitted if immediately followed by tbody or tfoot,
+		// or if there is no more content.
+		return endsParent(next) || isTag(next, startTagToken, "tbody", "tfoot")
+	case "tfoot":
+		// End tag can be omitted if immediately followed by tbody,
+		// or if there is no more content.
+		return endsParent(next) || isTag(next, startTagToken, "tbody")
+	case "tr":
+		// End tag can be omitted if immediately followed by another tr,
+		// or if there is no more content.
+		return endsParent(next) || isTag(next, startTagToken, "tr")
+	case "td", "th":
+		// End tag can be omitted if immediately followed by td/th,
+		// or if there is no more content.
+		return endsParent(next) || isTag(next, startTagToken, "td", "th")
+	}
+	return false
+}
+
+// headContentTags are the elements that keep a body start tag in place when
+// they come first in the body.
+var headContentTags = []string{
+	"base", "basefont", "bgsound", "link", "meta", "noframes", "noscript",
+	"script", "style", "template", "title",
+}
+
+// pClosingTags are the start tags that allow omitting a preceding </p>.
+var pClosingTags = []string{
+	"address", "article", "aside", "blockquote", "details", "dialog",
+	"dir", "div", "dl", "fieldset", "figcaption", "figure", "footer",
+	"form", "h1", "h2", "h3", "h4", "h5", "h6", "header", "hgroup",
+	"hr", "main", "menu", "nav", "ol", "p", "pre", "search", "section",
+	"table", "ul", "datagrid",
+}
+
+// hasCharsetMetaAhead reports whether the head started at tokens[idx]
+// contains a meta element declaring the character encoding.
+func hasCharsetMetaAhead(tokens []markupToken, idx int) bool {
+	for i := idx + 1; i < len(tokens); i++ {
+		tok := &tokens[i]
+		if isTag(tok, endTagToken, "head") {
+			return false
+		}
+		if !isTag(tok, startTagToken, "meta") && !isTag(tok, emptyTagToken, "meta") {
+			continue
+		}
+		for _, attr := range tok.attrs {
+			if strings.EqualFold(attr.Name, "charset") {
+				return true
+			}
+		}
+		for _, attr := range tok.attrs {
+			if strings.EqualFold(attr.Name, "http-equiv") && strings.EqualFold(attr.Value, "content-type") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// appendDOMTokens appends the markup tokens of the subtree rooted at n.
+func appendDOMTokens(tokens []markupToken, n dom.Node) []markupToken {
+	for tok := range domTokens(n) {
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+// domTokens returns the markup tokens of the subtree rooted at n. They are
+// generated while the tree is walked, so the stream is never held in memory
+// as a whole.
+func domTokens(n dom.Node) iter.Seq[markupToken] {
+	return func(yield func(markupToken) bool) {
+		yieldDOMTokens(n, yield)
+	}
+}
+
+// yieldDOMTokens passes the tokens of n to yield and reports whether the
+// walk should continue.
+func yieldDOMTokens(n dom.Node, yield func(markupToken) bool) bool {
+	switch n := n.(type) {
+	case *dom.Document:
+		if n.Doctype != nil && !yieldDOMTokens(n.Doctype, yield) {
+			return false
+		}
+		for _, child := range n.Children() {
+			if !yieldDOMTokens(child, yield) {
+				return false
+			}
+		}
+		return true
+	case *dom.DocumentFragment:
+		for _, child := range n.Children() {
+			if !yieldDOMTokens(child, yield) {
+				return false
+			}
+		}
+		return true
+	case *dom.DocumentType:
+		return yield(markupToken{kind: doctypeToken, name: n.Name, publicID: n.PublicID, systemID: n.SystemID})
+	case *dom.Element:
+		attrs := make([]tokenAttr, 0, n.Attributes.Len())
+		for _, attr := range n.Attributes.All() {
+			attrs = append(attrs, tokenAttr{Name: serializedAttrName(attr), Value: attr.Value})
+		}
+		foreign := n.Namespace != dom.NamespaceHTML
+		if serializesAsVoid(n) {
+			return yield(markupToken{kind: emptyTagToken, name: n.TagName, attrs: attrs})
+		}
+		if !yield(markupToken{kind: startTagToken, name: n.TagName, attrs: attrs, foreign: foreign}) {
+			return false
+		}
+		children := serializedChildren(n)
+		for i, child := range children {
+			var ok bool
+			if i == 0 && needsLeadingNewline(n, children) {
+				tok := domTextToken(child.(*dom.Text))
+				tok.data = "\n" + tok.data
+				ok = yield(tok)
+			} else {
+				ok = yieldDOMTokens(child, yield)
+			}
+			if !ok {
+				return false
+			}
+		}
+		return yield(markupToken{kind: endTagToken, name: n.TagName, foreign: foreign})
+	case *dom.Text:
+		return yield(domTextToken(n))
+	case *dom.Comment:
+		return yield(markupToken{kind: commentToken, data: n.Data})
+	case *dom.CDATASection:
+		var sb strings.Builder
+		serializeCDATASection(&sb, n)
+		return yield(markupToken{kind: verbatimToken, data: sb.String()})
+	case *dom.ProcessingInstruction:
+		var sb strings.Builder
+		serializeProcessingInstruction(&sb, n)
+		return yield(markupToken{kind: verbatimToken, data: sb.String()})
+	}
+	return true
+}
+
+// domTextToken returns the token of a text node. The text of raw text
+// elements such as script is marked raw.
+func domTextToken(t *dom.Text) markupToken {
+	parent, ok := t.Parent().(*dom.Element)
+	return markupToken{kind: textToken, data: t.Data, raw: ok && isRawTextParent(parent)}
+}
@@ -0,0 +1,266 @@
+package serialize
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// The pretty printer only adds, removes or changes whitespace where it cannot
+// affect rendering:
+//
+//   - Around block-level children, where whitespace is collapsed away, it
+//     puts each block and each run of inline content on its own line.
+//   - Inside inline content it collapses whitespace runs to a single space
+//     and, with LineWidth, breaks lines at those spaces. It never introduces
+//     whitespace where there was none.
+//   - Preformatted, raw text and foreign content, and elements whose style
+//     sets white-space, are written unchanged.
+
+// prettyMarkupOptions returns the markup options used in Pretty mode, where
+// only the attribute options apply.
+func (opts Options) prettyMarkupOptions() *markupOptions {
+	o := opts.markupOptions()
+	o.omitOptional = false
+	o.stripWhitespace = false
+	o.injectCharset = false
+	return o
+}
+
+func serializeElement(sb *strings.Builder, elem *dom.Element, opts Options, depth int, inline bool) {
+	// Only add indentation for block elements on their own line, not inline elements
+	if depth > 0 && !inline {
+		sb.WriteString(strings.Repeat(" ", depth*opts.IndentSize))
+	}
+
+	if opts.isVerbatim(elem) {
+		writeMarkup(sb, appendDOMTokens(nil, elem), opts.prettyMarkupOptions())
+		return
+	}
+
+	writeStartTag(sb, elem.TagName, serializedAttrs(elem), serializesAsVoid(elem), elem.Namespace != dom.NamespaceHTML, opts.prettyMarkupOptions())
+	if serializesAsVoid(elem) {
+		return
+	}
+
+	serializeChildrenPretty(sb, elem, serializedChildren(elem), opts, depth)
+
+	sb.WriteString("</")
+	sb.WriteString(elem.TagName)
+	sb.WriteByte('>')
+}
+
+// serializedAttrs returns the attributes of elem with their serialized names.
+func serializedAttrs(elem *dom.Element) []tokenAttr {
+	attrs := make([]tokenAttr, 0, elem.Attributes.Len())
+	for _, attr := range elem.Attributes.All() {
+		attrs = append(attrs, tokenAttr{Name: serializedAttrName(attr), Value: attr.Value})
+	}
+	return attrs
+}
+
+// serializeChildrenPretty handles pretty-printing of element children.
+//
+// If any child is a block boundary, each block child and each run of inline
+// content between them goes on its own indented line, and whitespace at the
+// edges of the runs is dropped. Otherwise the children stay on the line of
+// the start tag.
+func serializeChildrenPretty(sb *strings.Builder, parent *dom.Element, children []dom.Node, opts Options, depth int) {
+	indent := strings.Repeat(" ", (depth+1)*opts.IndentSize)
+
+	hasBlock := false
+	for _, child := range children {
+		if isBlockBoundary(parent, child) {
+			hasBlock = true
+			break
+		}
+	}
+	if !hasBlock {
+		var pieces []inlinePiece
+		for _, child := range children {
+			pieces = appendInlinePieces(pieces, child, opts)
+		}
+		writeInlinePieces(sb, pieces, indent, opts.LineWidth)
+		return
+	}
+
+	var run []inlinePiece
+	flush := func() {
+		if run = trimSpacePieces(run); len(run) > 0 {
+			sb.WriteByte('\n')
+			sb.WriteString(indent)
+			writeInlinePieces(sb, run, indent, opts.LineWidth)
+		}
+		run = run[:0]
+	}
+	for _, child := range children {
+		if !isBlockBoundary(parent, child) {
+			run = appendInlinePieces(run, child, opts)
+			continue
+		}
+		flush()
+		sb.WriteByte('\n')
+		serializeNodeWithInline(sb, child, opts, depth+1, false)
+	}
+	flush()
+
+	sb.WriteByte('\n')
+	sb.WriteString(strings.Repeat(" ", depth*opts.IndentSize))
+}
+
+// isBlockBoundary reports whether whitespace next to child inside parent is
+// insignificant because child is laid out as a block. All children of html
+// and head qualify, as head is not rendered.
+func isBlockBoundary(parent *dom.Element, child dom.Node) bool {
+	elem, ok := child.(*dom.Element)
+	if !ok || elem.Namespace != dom.NamespaceHTML {
+		return false
+	}
+	if parent != nil && parent.Namespace == dom.NamespaceHTML && (parent.TagName == "html" || parent.TagName == "head") {
+		return true
+	}
+	return IsBlockElement(elem.TagName)
+}
+
+// isVerbatimElement reports whether the content of elem is written without
+// any whitespace changes: preformatted and raw text elements, foreign
+// content, and elements whose inline style may change white-space handling.
+func isVerbatimElement(elem *dom.Element) bool {
+	if elem.Namespace != dom.NamespaceHTML {
+		return true
+	}
+	if isPreformattedElement(elem.TagName) || isRawTextParent(elem) {
+		return true
+	}
+	style, _ := elem.Attributes.Get("style")
+	return strings.Contains(strings.ToLower(style), "white-space")
+}
+
+// isVerbatim reports whether Pretty mode writes elem unchanged: the
+// verbatim elements, and noscript with Scripting.
+func (opts Options) isVerbatim(elem *dom.Element) bool {
+	return isVerbatimElement(elem) || (opts.Scripting && elem.TagName == "noscript")
+}
+
+// inlinePiece is a piece of inline content: either markup that must stay
+// together, or a collapsible space where a line may be broken.
+type inlinePiece struct {
+	text  string
+	space bool
+}
+
+// appendInlinePieces appends the pieces of node laid out as inline content.
+func appendInlinePieces(pieces []inlinePiece, node dom.Node, opts Options) []inlinePiece {
+	var sb strings.Builder
+	switch n := node.(type) {
+	case *dom.Element:
+		if opts.isVerbatim(n) {
+			writeMarkup(&sb, appendDOMTokens(nil, n), opts.prettyMarkupOptions())
+			return append(pieces, inlinePiece{text: sb.String()})
+		}
+		writeStartTag(&sb, n.TagName, serializedAttrs(n), serializesAsVoid(n), n.Namespace != dom.NamespaceHTML, opts.prettyMarkupOptions())
+		pieces = append(pieces, inlinePiece{text: sb.String()})
+		if serializesAsVoid(n) {
+			return pieces
+		}
+		for _, child := range serializedChildren(n) {
+			pieces = appendInlinePieces(pieces, child, opts)
+		}
+		return append(pieces, inlinePiece{text: "</" + n.TagName + ">"})
+	case *dom.Text:
+		return appendTextPieces(pieces, n.Data)
+	case *dom.Comment:
+		serializeComment(&sb, n, opts, 0, true)
+	case *dom.CDATASection:
+		serializeCDATASection(&sb, n)
+	case *dom.ProcessingInstruction:
+		serializeProcessingInstruction(&sb, n)
+	}
+	if sb.Len() == 0 {
+		return pieces
+	}
+	return append(pieces, inlinePiece{text: sb.String()})
+}
+
+// appendTextPieces appends the words of data and a single space for each
+// run of whitespace between them.
+func appendTextPieces(pieces []inlinePiece, data string) []inlinePiece {
+	start := 0
+	for i, r := range data {
+		if !isWhitespaceChar(r) {
+			continue
+		}
+		if i > start {
+			pieces = append(pieces, inlinePiece{text: escapeText(data[start:i])})
+		}
+		start = i + 1
+		if len(pieces) == 0 || !pieces[len(pieces)-1].space {
+			pieces = append(pieces, inlinePiece{space: true})
+		}
+	}
+	if start < len(data) {
+		pieces = append(pieces, inlinePiece{text: escapeText(data[start:])})
+	}
+	return pieces
+}
+
+// trimSpacePieces drops spaces at the start and end of pieces.
+func trimSpacePieces(pieces []inlinePiece) []inlinePiece {
+	for len(pieces) > 0 && pieces[0].space {
+		pieces = pieces[1:]
+	}
+	for len(pieces) > 0 && pieces[len(pieces)-1].space {
+		pieces = pieces[:len(pieces)-1]
+	}
+	return pieces
+}
+
+// writeInlinePieces writes pieces, breaking lines at spaces when the next
+// word would end beyond width. Continuation lines start with indent. A zero
+// width disables wrapping.
+func writeInlinePieces(sb *strings.Builder, pieces []inlinePiece, indent string, width int) {
+	column := currentColumn(sb)
+	for i, piece := range pieces {
+		if !piece.space {
+			sb.WriteString(piece.text)
+			if idx := strings.LastIndexByte(piece.text, '\n'); idx >= 0 {
+				column = utf8.RuneCountInString(piece.text[idx+1:])
+			} else {
+				column += utf8.RuneCountInString(piece.text)
+			}
+			continue
+		}
+		if width > 0 && column > len(indent) && column+1+wordWidth(pieces[i+1:]) > width {
+			sb.WriteByte('\n')
+			sb.WriteString(indent)
+			column = len(indent)
+			continue
+		}
+		sb.WriteByte(' ')
+		column++
+	}
+}
+
+// wordWidth returns the width of the pieces up to the next space, counting
+// only up to the first line break.
+func wordWidth(pieces []inlinePiece) int {
+	width := 0
+	for _, piece := range pieces {
+		if piece.space {
+			break
+		}
+		if idx := strings.IndexByte(piece.text, '\n'); idx >= 0 {
+			return width + utf8.RuneCountInString(piece.text[:idx])
+		}
+		width += utf8.RuneCountInString(piece.text)
+	}
+	return width
+}
+
+// currentColumn returns the number of characters written to sb since the last
+// line break.
+func currentColumn(sb *strings.Builder) int {
+	s := sb.String()
+	return utf8.RuneCountInString(s[strings.LastIndexByte(s, '\n')+1:])
+}
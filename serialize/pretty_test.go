@@ -0,0 +1,156 @@
+package serialize_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+)
+
+var prettyDocuments = []string{
+	`<!DOCTYPE html><html><head><title>T</title><style>a  { }</style></head><body><p>x</p></body></html>`,
+	`<div>text<b>bold</b> <i>italic</i><p>para</p>tail</div>`,
+	`<pre>
+  keep   this
+</pre><textarea>  a  </textarea><listing>x  y</listing>`,
+	`<p>a<script>var  x;</script>b<style>p {}</style>c</p>`,
+	`<ul><li>one<li>two <ul><li>nested</ul></ul><ol><li><p>x</p></ol>`,
+	`<table><caption>c</caption><tr><td>a<td> b </table>`,
+	`<div><span>a</span> <span>b</span><span>c</span></div>`,
+	`<div><svg><text> a  b </text><g> </g></svg> <math><mi> x </mi></math></div>`,
+	`<div><span style="white-space: pre">a   b</span>  <code>c   d</code></div>`,
+	`<div>a<!-- c -->b<p>x</p><!-- d --></div>`,
+	`<p>a&nbsp;&nbsp;b &lt;c&gt; &amp;</p><template><div><p>t</p></div></template>`,
+	`<dl><dt>term<dd>def</dl><select><option>a<option>b</select>`,
+}
+
+func mustParse(t *testing.T, html string) *dom.Document {
+	t.Helper()
+	doc, err := JustGoHTML.Parse(html)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", html, err)
+	}
+	return doc
+}
+
+func queryFirst(t *testing.T, doc *dom.Document, selector string) *dom.Element {
+	t.Helper()
+	elem, err := doc.QueryFirst(selector)
+	if err != nil || elem == nil {
+		t.Fatalf("QueryFirst(%q) = %v, %v", selector, elem, err)
+	}
+	return elem
+}
+
+func TestPrettyPreservesRendering(t *testing.T) {
+	for _, width := range []int{0, 20} {
+		opts := serialize.Options{Pretty: true, IndentSize: 2, LineWidth: width}
+		for _, input := range prettyDocuments {
+			doc := mustParse(t, input)
+			pretty := serialize.ToHTML(doc, opts)
+			again := mustParse(t, pretty)
+
+			if got, want := renderedText(again), renderedText(doc); got != want {
+				t.Errorf("rendering changed for %q (width %d)\npretty:\n%s\nwant: %q\ngot:  %q", input, width, pretty, want, got)
+			}
+			if twice := serialize.ToHTML(again, opts); twice != pretty {
+				t.Errorf("pretty printing is not idempotent for %q (width %d)\nfirst:\n%s\nsecond:\n%s", input, width, pretty, twice)
+			}
+		}
+	}
+}
+
+func TestPrettyKeepsInlineWhitespace(t *testing.T) {
+	doc := mustParse(t, `<div>text<b>x</b> <i>y</i><p>z</p></div>`)
+	div := queryFirst(t, doc, "div")
+
+	got := serialize.ToHTML(div, serialize.Options{Pretty: true, IndentSize: 2})
+	want := "<div>\n  text<b>x</b> <i>y</i>\n  <p>z</p>\n</div>"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPrettyVerbatimContent(t *testing.T) {
+	doc := mustParse(t, "<div><pre>\n\n a  b</pre><textarea> x  </textarea><script>if (a  < b) {}</script><p style=\"white-space:pre-wrap\">c   d</p></div>")
+	div := queryFirst(t, doc, "div")
+
+	got := serialize.ToHTML(div, serialize.Options{Pretty: true, IndentSize: 2})
+	want := "<div>\n  <pre>\n\n a  b</pre>\n  <textarea> x  </textarea><script>if (a  < b) {}</script>\n  <p style=\"white-space:pre-wrap\">c   d</p>\n</div>"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPrettyLineWidth(t *testing.T) {
+	doc := mustParse(t, `<div><p>one two three four five six <a href="#">seven</a>eight</p></div>`)
+	div := queryFirst(t, doc, "div")
+
+	got := serialize.ToHTML(div, serialize.Options{Pretty: true, IndentSize: 2, LineWidth: 24})
+	want := "<div>\n  <p>one two three four\n    five six\n    <a href=\"#\">seven</a>eight</p>\n</div>"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// blockElements are the elements the default style sheet displays as blocks.
+var blockElements = map[string]bool{}
+
+func init() {
+	for _, tag := range strings.Fields(`address article aside blockquote body caption center
+		colgroup dd details dialog dir div dl dt fieldset figcaption figure footer form
+		frameset h1 h2 h3 h4 h5 h6 header hgroup hr html legend li main menu nav ol
+		optgroup option p search section summary table tbody td tfoot th thead tr ul`) {
+		blockElements[tag] = true
+	}
+}
+
+var lineBreakSpace = regexp.MustCompile(`[ \n]*\n[ \n]*`)
+
+// renderedText approximates the text a browser renders for n: whitespace is
+// collapsed except in preformatted and foreign content, and blocks start on
+// new lines.
+func renderedText(n dom.Node) string {
+	var sb strings.Builder
+	writeRenderedText(&sb, n)
+	s := lineBreakSpace.ReplaceAllString(sb.String(), "\n")
+	return strings.Trim(regexp.MustCompile(` +`).ReplaceAllString(s, " "), " \n")
+}
+
+func writeRenderedText(sb *strings.Builder, n dom.Node) {
+	switch n := n.(type) {
+	case *dom.Text:
+		// Runs of whitespace render as one space.
+		sb.WriteString(regexp.MustCompile(`[ \t\n\f\r]+`).ReplaceAllString(n.Data, " "))
+		return
+	case *dom.Element:
+		if n.Namespace != dom.NamespaceHTML {
+			sb.WriteString("[" + n.Text() + "]")
+			return
+		}
+		switch n.TagName {
+		case "head", "script", "style", "template", "title", "noembed", "noframes":
+			return
+		case "pre", "listing", "xmp", "plaintext":
+			sb.WriteString("\n[" + n.Text() + "]\n")
+			return
+		case "textarea":
+			sb.WriteString("[" + n.Text() + "]")
+			return
+		}
+		if style := n.Attr("style"); strings.Contains(style, "white-space") {
+			sb.WriteString("[" + n.Text() + "]")
+			return
+		}
+		if blockElements[n.TagName] {
+			sb.WriteByte('\n')
+			defer sb.WriteByte('\n')
+		}
+	}
+	for _, child := range n.Children() {
+		writeRenderedText(sb, child)
+	}
+}
@@ -0,0 +1,310 @@
+package serialize_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/internal/testutil"
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+	"github.com/MeKo-Christian/JustGoHTML/tokenizer"
+	"github.com/MeKo-Christian/JustGoHTML/treebuilder"
+)
+
+const (
+	html5libTreeTestsDir = "../testdata/html5lib-tests/tree-construction"
+	justHTMLTreeTestsDir = "../testdata/justhtml-tests"
+)
+
+// TestHTML5LibRoundTrip parses every tree-construction test input, serializes
+// the result with ToHTML and checks that parsing the output again yields the
+// same tree.
+func TestHTML5LibRoundTrip(t *testing.T) {
+	t.Parallel()
+	files, _ := testutil.CollectTestFiles(html5libTreeTestsDir, "*.dat")
+	if len(files) == 0 {
+		// The spec CI job sets JustGoHTML_RUN_HTML5LIB, where a missing
+		// submodule must not pass as a skipped test.
+		if os.Getenv("JustGoHTML_RUN_HTML5LIB") != "" {
+			t.Fatal("html5lib-tests not found - run 'git submodule update --init'")
+		}
+		t.Skip("html5lib-tests not found - run 'git submodule update --init'")
+	}
+	runRoundTripDir(t, html5libTreeTestsDir)
+}
+
+// TestJustHTMLRoundTrip runs the round-trip check over the JustHTML-specific
+// tree-construction tests.
+func TestJustHTMLRoundTrip(t *testing.T) {
+	t.Parallel()
+	if _, err := os.Stat(justHTMLTreeTestsDir); os.IsNotExist(err) {
+		t.Skip("JustHTML-tests not found")
+	}
+	runRoundTripDir(t, justHTMLTreeTestsDir)
+}
+
+func runRoundTripDir(t *testing.T, dir string) {
+	t.Helper()
+	files, err := testutil.CollectTestFiles(dir, "*.dat")
+	if err != nil {
+		t.Fatalf("Failed to collect test files: %v", err)
+	}
+	if len(files) == 0 {
+		t.Skip("No tree-construction test files found")
+	}
+
+	for _, file := range files {
+		name := filepath.Base(file)
+		if strings.Contains(file, "scripted") {
+			name = "scripted/" + name
+		}
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			tests, err := testutil.ParseTreeConstructionFile(file)
+			if err != nil {
+				t.Fatalf("Failed to parse test file: %v", err)
+			}
+			for i, test := range tests {
+				t.Run(strings.TrimSpace(truncateInput(test.Data, 40))+"#"+strconv.Itoa(i), func(t *testing.T) {
+					runRoundTripTest(t, test)
+				})
+			}
+		})
+	}
+}
+
+func runRoundTripTest(t *testing.T, test testutil.TreeConstructionTest) {
+	t.Helper()
+	if test.XMLCoercion {
+		t.Skip("XML coercion is lossy by design")
+	}
+	if reason := roundTripUnstable(test); reason != "" {
+		t.Skip(reason)
+	}
+
+	scripting := test.ScriptDirective == "script-on"
+	if test.FragmentContext != "" {
+		ctx := fragmentContext(test.FragmentContext)
+		nodes := parseFragmentNodes(test.Data, ctx, scripting)
+		if reason := unserializable(nodes...); reason != "" {
+			t.Skip(reason)
+		}
+		html := serializeFragment(nodes, ctx, scripting)
+		again := parseFragmentNodes(html, ctx, scripting)
+		want, got := testutil.SerializeHTML5LibNodes(nodes), testutil.SerializeHTML5LibNodes(again)
+		if got != want {
+			t.Errorf("round-trip mismatch\ninput: %q\nserialized: %q\n\nwant:\n%s\n\ngot:\n%s", test.Data, html, want, got)
+		}
+		return
+	}
+
+	var opts []JustGoHTML.Option
+	if test.IframeSrcdoc {
+		opts = append(opts, JustGoHTML.WithIframeSrcdoc())
+	}
+	if scripting {
+		opts = append(opts, JustGoHTML.WithScripting())
+	}
+	doc, err := JustGoHTML.Parse(test.Data, opts...)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if reason := unserializable(doc); reason != "" {
+		t.Skip(reason)
+	}
+	serializeOpts := serialize.DefaultOptions()
+	serializeOpts.Scripting = scripting
+	html := serialize.ToHTML(doc, serializeOpts)
+	again, err := JustGoHTML.Parse(html, opts...)
+	if err != nil {
+		t.Fatalf("reparse error: %v", err)
+	}
+	want, got := testutil.SerializeHTML5LibTree(doc), testutil.SerializeHTML5LibTree(again)
+	if got != want {
+		t.Errorf("round-trip mismatch\ninput: %q\nserialized: %q\n\nwant:\n%s\n\ngot:\n%s", test.Data, html, want, got)
+	}
+
+	pretty := serialize.ToHTML(doc, serialize.Options{Pretty: true, IndentSize: 2, LineWidth: 40, Scripting: scripting})
+	again, err = JustGoHTML.Parse(pretty, opts...)
+	if err != nil {
+		t.Fatalf("reparse error: %v", err)
+	}
+	if got, want := renderedText(again), renderedText(doc); got != want {
+		t.Errorf("pretty round-trip changed rendering\ninput: %q\npretty:\n%s\nwant: %q\ngot:  %q", test.Data, pretty, want, got)
+	}
+}
+
+// serializeFragment serializes nodes as the children of the context element,
+// which decides whether their text is escaped.
+func serializeFragment(nodes []dom.Node, context *dom.Element, scripting bool) string {
+	el := context.Clone(false).(*dom.Element)
+	for _, n := range nodes {
+		el.AppendChild(n.Clone(true))
+	}
+	opts := serialize.DefaultOptions()
+	opts.Scripting = scripting
+	html := serialize.ToHTML(el, opts)
+	html = strings.TrimPrefix(html, "<"+el.TagName+">")
+	return strings.TrimSuffix(html, "</"+el.TagName+">")
+}
+
+// parseFragmentNodes parses input in the given html5lib fragment context
+// ("div", "svg path", ...), with scripting enabled or not, and returns the
+// resulting nodes.
+func parseFragmentNodes(input string, context *dom.Element, scripting bool) []dom.Node {
+	namespace := "html"
+	switch context.Namespace {
+	case dom.NamespaceSVG:
+		namespace = "svg"
+	case dom.NamespaceMathML:
+		namespace = "mathml"
+	}
+	tok := tokenizer.New(input)
+	tb := treebuilder.NewFragment(tok, &treebuilder.FragmentContext{TagName: context.TagName, Namespace: namespace})
+	tok.SetScripting(scripting)
+	tb.SetScripting(scripting)
+	for {
+		tok.SetAllowCDATA(tb.AllowCDATA())
+		tt := tok.Next()
+		tb.ProcessToken(tt)
+		if tt.Type == tokenizer.EOF {
+			break
+		}
+	}
+	root := tb.Document().DocumentElement()
+	if root == nil {
+		return nil
+	}
+	if namespace == "html" {
+		return root.Children()
+	}
+	// Foreign contexts are represented by an element below the root.
+	for _, child := range root.Children() {
+		if el, ok := child.(*dom.Element); ok {
+			return el.Children()
+		}
+	}
+	return nil
+}
+
+// fragmentContext returns an element for an html5lib fragment context.
+func fragmentContext(context string) *dom.Element {
+	if ns, tag, ok := strings.Cut(context, " "); ok {
+		switch ns {
+		case "svg":
+			return dom.NewElementNS(tag, dom.NamespaceSVG)
+		case "math":
+			return dom.NewElementNS(tag, dom.NamespaceMathML)
+		}
+	}
+	return dom.NewElement(context)
+}
+
+func truncateInput(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// Reasons the trees of misnestedInputs cannot be written as markup. The
+// parser builds them from misnested tags, but when it reads the nested start
+// tag back it closes the open element first, or ignores the tag.
+const (
+	nestedNobr = "nobr in nobr: a nobr start tag closes the open nobr"
+	nestedP    = "p in p: a p start tag closes the open p"
+	nestedForm = "form in form: a form start tag is ignored while a form is open"
+	nestedA    = "a in a: an a start tag closes the open a"
+)
+
+// misnestedInputs maps the inputs whose trees nest elements in ways markup
+// cannot express to the nesting that makes them unstable. The adoption
+// agency algorithm and the foster parenting of table content produce them.
+var misnestedInputs = map[string]string{
+	"<!DOCTYPE html><body><b><nobr>1<table><nobr></b><i><nobr>2<nobr></i>3": nestedNobr,
+	"<p><table></p>": nestedP,
+	"<!doctype html><form><table></form><form></table></form>":                    nestedForm,
+	"<a><table><td><a><table></table><a></tr><a></table><b>X</b>C<a>Y":            nestedA,
+	"<a href=\"blah\">aba<table><a href=\"foo\">br<tr><td></td></tr>x</table>aoe": nestedA,
+	"<a><table><a></table><p><a><div><a>":                                         nestedA,
+	"<a><table><td><a><table></table><a></tr><a></table><a>":                      nestedA,
+	"<template><a><table><a>":                                                     nestedA,
+}
+
+func roundTripUnstable(test testutil.TreeConstructionTest) string {
+	if reason, ok := misnestedInputs[test.Data]; ok {
+		return "misnested tree cannot be expressed in markup: " + reason
+	}
+	return ""
+}
+
+// unserializable reports why the trees rooted at nodes cannot survive
+// serialization, or "" if they can.
+func unserializable(nodes ...dom.Node) string {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *dom.Element:
+			if n.Namespace == dom.NamespaceHTML && n.TagName == "plaintext" {
+				return "plaintext content cannot be closed"
+			}
+			if n.Namespace == dom.NamespaceHTML && n.TagName == "script" && !scriptEndsCleanly(n.Text()) {
+				return "script text leaves the tokenizer in a double-escaped state"
+			}
+			if n.TemplateContent != nil {
+				if reason := unserializable(n.TemplateContent.Children()...); reason != "" {
+					return reason
+				}
+			}
+		case *dom.Text:
+			if strings.ContainsRune(n.Data, '\r') {
+				return "carriage returns are normalized by the parser"
+			}
+		}
+		if reason := unserializable(n.Children()...); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// scriptEndsCleanly reports whether a </script> end tag following text is
+// recognized. It tracks the tokenizer's script data escape states; the end
+// tag is swallowed when text ends in the double-escaped state.
+func scriptEndsCleanly(text string) bool {
+	text = strings.ToLower(text)
+	tagFollows := func(i int, tag string) bool {
+		if !strings.HasPrefix(text[i:], tag) || i+len(tag) >= len(text) {
+			return false
+		}
+		switch text[i+len(tag)] {
+		case ' ', '\t', '\n', '\f', '/', '>':
+			return true
+		}
+		return false
+	}
+	const (
+		data = iota
+		escaped
+		doubleEscaped
+	)
+	state := data
+	for i := 0; i < len(text); i++ {
+		switch {
+		case state == data && strings.HasPrefix(text[i:], "<!--"):
+			state = escaped
+			i += 3
+		case state != data && strings.HasPrefix(text[i:], "-->"):
+			state = data
+			i += 2
+		case state == escaped && tagFollows(i, "<script"):
+			state = doubleEscaped
+		case state == doubleEscaped && tagFollows(i, "</script"):
+			state = escaped
+		}
+	}
+	return state != doubleEscaped
+}
@@ -2,12 +2,18 @@
 package serialize
 
 import (
-	"strconv"
 	"strings"
 
 	"github.com/MeKo-Christian/JustGoHTML/dom"
 )
 
+// Namespaces of attributes serialized with a prefix.
+const (
+	namespaceXLink = "http://www.w3.org/1999/xlink"
+	namespaceXML   = "http://www.w3.org/XML/1998/namespace"
+	namespaceXMLNS = "http://www.w3.org/2000/xmlns/"
+)
+
 // Options configures serialization behavior.
 type Options struct {
 	// Pretty enables pretty-printing with indentation.
@@ -15,6 +21,49 @@ type Options struct {
 
 	// IndentSize is the number of spaces per indentation level.
 	IndentSize int
+
+	// LineWidth is the column at which Pretty mode wraps inline content.
+	// Lines are only broken at existing whitespace, so words and tags longer
+	// than the width are kept intact. Zero disables wrapping.
+	LineWidth int
+
+	// QuoteChar is the quote character for attribute values, ' or ".
+	// The zero value uses double quotes.
+	QuoteChar rune
+
+	// MinimizeAttributeQuotes leaves attribute values unquoted where the
+	// syntax allows it, and uses single quotes when that avoids escaping
+	// double quotes. It has no effect when QuoteChar is '.
+	MinimizeAttributeQuotes bool
+
+	// UseTrailingSolidus writes void elements as <br />.
+	UseTrailingSolidus bool
+
+	// MinimizeBooleanAttributes omits empty attribute values, and the values
+	// of the boolean attributes of HTML elements (see IsBooleanAttribute)
+	// that equal the attribute name (disabled="disabled"). Other attributes
+	// keep their value, even if it is their name (alt="alt").
+	MinimizeBooleanAttributes bool
+
+	// OmitOptionalTags omits start and end tags the HTML syntax allows to
+	// be left out, such as </p>, </li> and <tbody>.
+	OmitOptionalTags bool
+
+	// StripWhitespace collapses runs of whitespace in text outside of
+	// preformatted and raw text elements.
+	StripWhitespace bool
+
+	// InjectMetaCharset adds a <meta charset> declaring Encoding to the head,
+	// or updates an existing charset declaration.
+	InjectMetaCharset bool
+
+	// Encoding is the encoding declared by InjectMetaCharset.
+	Encoding string
+
+	// Scripting writes the text of noscript elements without escaping, as
+	// the serialization algorithm does for trees parsed with scripting
+	// enabled, where noscript is a raw text element.
+	Scripting bool
 }
 
 // DefaultOptions returns the default serialization options.
@@ -25,36 +74,70 @@ func DefaultOptions() Options {
 	}
 }
 
+// markupOptions returns the markup writer configuration for opts.
+func (opts Options) markupOptions() *markupOptions {
+	return &markupOptions{
+		quoteChar:       opts.QuoteChar,
+		minimalQuotes:   opts.MinimizeAttributeQuotes,
+		trailingSolidus: opts.UseTrailingSolidus,
+		minimizeBoolean: opts.MinimizeBooleanAttributes,
+		stripWhitespace: opts.StripWhitespace,
+		omitOptional:    opts.OmitOptionalTags,
+		injectCharset:   opts.InjectMetaCharset,
+		encoding:        opts.Encoding,
+		scripting:       opts.Scripting,
+		specEscaping:    true,
+	}
+}
+
 // ToHTML serializes a node to HTML.
+//
+// Without Pretty, the node and its descendants are written following the
+// WHATWG "serializing HTML fragments" algorithm, so that parsing the output
+// reproduces the tree wherever the HTML syntax allows it: raw text content is
+// not escaped, template contents are included, namespaced attributes keep
+// their xml:, xmlns: and xlink: prefixes, and a newline is doubled at the
+// start of pre, textarea and listing. As an extension, doctypes keep their
+// public and system identifiers.
+//
+// With Pretty, the output is indented to show the structure, changing
+// whitespace only where it cannot affect rendering; see Options.LineWidth.
+// Only the attribute options apply in this mode. Otherwise the remaining
+// options shorten the output the same way they do for
+// SerializeTokensWithOptions.
 func ToHTML(node dom.Node, opts Options) string {
 	var sb strings.Builder
 	serializeNode(&sb, node, opts, 0)
 	return sb.String()
 }
 
-// ToMarkdown serializes a node to Markdown.
-func ToMarkdown(node dom.Node) string {
-	var sb strings.Builder
-	serializeMarkdown(&sb, node, 0, false)
-	return strings.TrimSpace(sb.String())
-}
-
 func serializeNode(sb *strings.Builder, node dom.Node, opts Options, depth int) {
 	serializeNodeWithInline(sb, node, opts, depth, false)
 }
 
 func serializeNodeWithInline(sb *strings.Builder, node dom.Node, opts Options, depth int, inline bool) {
+	if !opts.Pretty {
+		writeDOMMarkup(sb, node, opts)
+		return
+	}
 	switch n := node.(type) {
 	case *dom.Document:
 		serializeDocument(sb, n, opts, depth)
+	case *dom.DocumentFragment:
+		serializeTopLevel(sb, n.Children(), opts, depth)
 	case *dom.DocumentType:
 		serializeDoctype(sb, n)
 	case *dom.Element:
 		serializeElement(sb, n, opts, depth, inline)
 	case *dom.Text:
-		serializeText(sb, n, opts, depth)
+		// Text outside of an element is written as is.
+		writeMarkup(sb, appendDOMTokens(nil, n), opts.prettyMarkupOptions())
 	case *dom.Comment:
 		serializeComment(sb, n, opts, depth, inline)
+	case *dom.CDATASection:
+		serializeCDATASection(sb, n)
+	case *dom.ProcessingInstruction:
+		serializeProcessingInstruction(sb, n)
 	}
 }
 
@@ -65,134 +148,72 @@ func serializeDocument(sb *strings.Builder, doc *dom.Document, opts Options, dep
 			sb.WriteByte('\n')
 		}
 	}
-	for _, child := range doc.Children() {
-		serializeNode(sb, child, opts, depth)
-	}
+	serializeTopLevel(sb, doc.Children(), opts, depth)
 }
 
-func serializeDoctype(sb *strings.Builder, dt *dom.DocumentType) {
-	sb.WriteString("<!DOCTYPE ")
-	sb.WriteString(dt.Name)
-	if dt.PublicID != "" {
-		sb.WriteString(" PUBLIC \"")
-		sb.WriteString(dt.PublicID)
-		sb.WriteByte('"')
-		if dt.SystemID != "" {
-			sb.WriteString(" \"")
-			sb.WriteString(dt.SystemID)
-			sb.WriteByte('"')
+// serializeTopLevel serializes the children of a document or document
+// fragment, one per line.
+func serializeTopLevel(sb *strings.Builder, children []dom.Node, opts Options, depth int) {
+	for i, child := range children {
+		if opts.Pretty && i > 0 {
+			sb.WriteByte('\n')
 		}
-	} else if dt.SystemID != "" {
-		sb.WriteString(" SYSTEM \"")
-		sb.WriteString(dt.SystemID)
-		sb.WriteByte('"')
+		serializeNode(sb, child, opts, depth)
 	}
-	sb.WriteByte('>')
 }
 
-func serializeElement(sb *strings.Builder, elem *dom.Element, opts Options, depth int, inline bool) {
-	// Only add indentation for block elements on their own line, not inline elements
-	if opts.Pretty && depth > 0 && !inline {
-		sb.WriteString(strings.Repeat(" ", depth*opts.IndentSize))
-	}
-
-	sb.WriteByte('<')
-	sb.WriteString(elem.TagName)
-
-	for _, attr := range elem.Attributes.All() {
-		sb.WriteByte(' ')
-		sb.WriteString(attr.Name)
-		sb.WriteString("=\"")
-		sb.WriteString(escapeAttr(attr.Value))
-		sb.WriteByte('"')
-	}
-
-	if isVoidElement(elem.TagName) {
-		sb.WriteByte('>')
-		return
-	}
-
-	sb.WriteByte('>')
-
-	children := elem.Children()
+func serializeDoctype(sb *strings.Builder, dt *dom.DocumentType) {
+	writeDoctype(sb, dt.Name, dt.PublicID, dt.SystemID)
+}
 
-	if opts.Pretty {
-		serializeChildrenPretty(sb, children, opts, depth)
-	} else {
-		for _, child := range children {
-			serializeNode(sb, child, opts, depth+1)
-		}
+// serializedChildren returns the nodes serialized as the content of elem:
+// the template contents for HTML template elements, the children otherwise.
+func serializedChildren(elem *dom.Element) []dom.Node {
+	if elem.TemplateContent != nil && elem.Namespace == dom.NamespaceHTML {
+		return elem.TemplateContent.Children()
 	}
-
-	sb.WriteString("</")
-	sb.WriteString(elem.TagName)
-	sb.WriteByte('>')
+	return elem.Children()
 }
 
-// serializeChildrenPretty handles pretty-printing of element children.
-// It filters out whitespace-only text nodes and properly indents content.
-func serializeChildrenPretty(sb *strings.Builder, children []dom.Node, opts Options, depth int) {
-	// Filter to get significant children (skip whitespace-only text nodes)
-	significantChildren := make([]dom.Node, 0, len(children))
-	for _, child := range children {
-		if text, ok := child.(*dom.Text); ok {
-			if isWhitespaceOnly(text.Data) {
-				continue
-			}
-		}
-		significantChildren = append(significantChildren, child)
+// needsLeadingNewline reports whether an extra newline must follow the start
+// tag of elem. The parser drops a newline directly after <pre>, <textarea>
+// and <listing>, so content starting with one needs a second to survive.
+func needsLeadingNewline(elem *dom.Element, children []dom.Node) bool {
+	if elem.Namespace != dom.NamespaceHTML || len(children) == 0 {
+		return false
 	}
-
-	if len(significantChildren) == 0 {
-		return
+	switch elem.TagName {
+	case "pre", "textarea", "listing":
+		text, ok := children[0].(*dom.Text)
+		return ok && strings.HasPrefix(text.Data, "\n")
 	}
+	return false
+}
 
-	// Check if any child is a block element
-	hasBlock := false
-	for _, child := range significantChildren {
-		if elem, ok := child.(*dom.Element); ok {
-			if isBlockElement(elem.TagName) {
-				hasBlock = true
-				break
-			}
+// serializedAttrName returns the serialized name of attr. Attributes in the
+// XML, XMLNS and XLink namespaces are written with their conventional prefix.
+func serializedAttrName(attr dom.Attribute) string {
+	local := attr.Name
+	if attr.Namespace != "" {
+		if idx := strings.IndexByte(local, ':'); idx >= 0 {
+			local = local[idx+1:]
 		}
 	}
-
-	for _, child := range significantChildren {
-		if hasBlock {
-			sb.WriteByte('\n')
-			// Only increment depth for block content (indented on new lines)
-			serializeNodeWithInline(sb, child, opts, depth+1, false)
-		} else {
-			// Inline content: mark as inline so elements don't add indentation
-			serializeNodeWithInline(sb, child, opts, depth, true)
+	switch attr.Namespace {
+	case "":
+		return attr.Name
+	case namespaceXML:
+		return "xml:" + local
+	case namespaceXMLNS:
+		if local == "xmlns" {
+			return local
 		}
+		return "xmlns:" + local
+	case namespaceXLink:
+		return "xlink:" + local
+	default:
+		return attr.Name
 	}
-
-	if hasBlock {
-		sb.WriteByte('\n')
-		sb.WriteString(strings.Repeat(" ", depth*opts.IndentSize))
-	}
-}
-
-// serializeText serializes a text node.
-// In pretty mode, whitespace-only text nodes between block elements are skipped
-// since the pretty printer handles formatting.
-func serializeText(sb *strings.Builder, text *dom.Text, opts Options, _ int) {
-	data := text.Data
-
-	// In pretty mode, skip whitespace-only text nodes (they're just formatting noise)
-	if opts.Pretty && isWhitespaceOnly(data) {
-		return
-	}
-
-	// In pretty mode, collapse runs of whitespace but preserve leading/trailing
-	// single spaces for inline content like "text <b>bold</b> more"
-	if opts.Pretty {
-		data = collapseWhitespace(data)
-	}
-
-	sb.WriteString(escapeText(data))
 }
 
 // serializeComment serializes a comment node.
@@ -205,59 +226,42 @@ func serializeComment(sb *strings.Builder, comment *dom.Comment, opts Options, d
 	sb.WriteString("-->")
 }
 
-// isWhitespaceOnly returns true if the string contains only whitespace characters.
-func isWhitespaceOnly(s string) bool {
-	for _, r := range s {
-		if r != ' ' && r != '\t' && r != '\n' && r != '\r' && r != '\f' {
-			return false
-		}
+// serializeCDATASection serializes a CDATA section. The <![CDATA[ syntax is
+// only recognized in foreign content, so sections elsewhere are written as
+// escaped text to keep their content intact.
+func serializeCDATASection(sb *strings.Builder, cdata *dom.CDATASection) {
+	if parent, ok := cdata.Parent().(*dom.Element); !ok || parent.Namespace == dom.NamespaceHTML {
+		sb.WriteString(escapeText(cdata.Data))
+		return
 	}
-	return true
+	sb.WriteString("<![CDATA[")
+	// A literal "]]>" would end the section early; split it across two sections.
+	sb.WriteString(strings.ReplaceAll(cdata.Data, "]]>", "]]]]><![CDATA[>"))
+	sb.WriteString("]]>")
 }
 
-// collapseWhitespace collapses runs of whitespace into single spaces
-// but preserves a single leading/trailing space if present.
-// This is important for inline content like "text <b>bold</b> more".
-func collapseWhitespace(s string) string {
-	if len(s) == 0 {
-		return s
+// serializeProcessingInstruction serializes a processing instruction using
+// the HTML syntax "<?target data>".
+func serializeProcessingInstruction(sb *strings.Builder, pi *dom.ProcessingInstruction) {
+	sb.WriteString("<?")
+	sb.WriteString(pi.Target)
+	if pi.Data != "" {
+		sb.WriteByte(' ')
+		sb.WriteString(pi.Data)
 	}
+	sb.WriteByte('>')
+}
 
-	var sb strings.Builder
-	hasLeadingSpace := isWhitespaceChar(rune(s[0]))
-	hasTrailingSpace := isWhitespaceChar(rune(s[len(s)-1]))
-
-	inWhitespace := true // Start true to skip leading whitespace in loop
+// isWhitespaceOnly returns true if the string contains only whitespace characters.
+func isWhitespaceOnly(s string) bool {
 	for _, r := range s {
-		if isWhitespaceChar(r) {
-			if !inWhitespace {
-				sb.WriteByte(' ')
-				inWhitespace = true
-			}
-		} else {
-			sb.WriteRune(r)
-			inWhitespace = false
+		if r != ' ' && r != '\t' && r != '\n' && r != '\r' && r != '\f' {
+			return false
 		}
 	}
-
-	result := sb.String()
-	// Trim trailing space from collapsed content
-	if len(result) > 0 && result[len(result)-1] == ' ' {
-		result = result[:len(result)-1]
-	}
-
-	// Restore leading/trailing spaces if original had them
-	if hasLeadingSpace && len(result) > 0 {
-		result = " " + result
-	}
-	if hasTrailingSpace && len(result) > 0 {
-		result += " "
-	}
-
-	return result
+	return true
 }
 
-// isWhitespaceChar returns true if r is an HTML whitespace character.
 func isWhitespaceChar(r rune) bool {
 	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
 }
@@ -269,6 +273,8 @@ func escapeText(s string) string {
 		switch r {
 		case '&':
 			sb.WriteString("&amp;")
+		case '\u00a0':
+			sb.WriteString("&nbsp;")
 		case '<':
 			sb.WriteString("&lt;")
 		case '>':
@@ -280,22 +286,6 @@ func escapeText(s string) string {
 	return sb.String()
 }
 
-// escapeAttr escapes an attribute value.
-func escapeAttr(s string) string {
-	var sb strings.Builder
-	for _, r := range s {
-		switch r {
-		case '&':
-			sb.WriteString("&amp;")
-		case '"':
-			sb.WriteString("&quot;")
-		default:
-			sb.WriteRune(r)
-		}
-	}
-	return sb.String()
-}
-
 // isVoidElement returns true if the tag is a void element.
 func isVoidElement(tag string) bool {
 	switch tag {
@@ -306,180 +296,46 @@ func isVoidElement(tag string) bool {
 	return false
 }
 
-// isBlockElement returns true if the tag is typically block-level.
-func isBlockElement(tag string) bool {
-	switch tag {
-	case "address", "article", "aside", "blockquote", "body", "canvas", "dd", "div", //nolint:goconst
-		"dl", "dt", "fieldset", "figcaption", "figure", "footer", "form",
-		"h1", "h2", "h3", "h4", "h5", "h6", "head", "header", "hr", "html", "li", "main",
-		"nav", "noscript", "ol", "p", "pre", "section", "table", "tbody", "td", "tfoot",
-		"th", "thead", "title", "tr", "ul", "video":
-		return true
+// serializesAsVoid reports whether elem is written without content and end
+// tag. Besides the void elements this covers the obsolete basefont, bgsound,
+// frame and keygen elements.
+func serializesAsVoid(elem *dom.Element) bool {
+	if elem.Namespace != dom.NamespaceHTML {
+		return false
 	}
-	return false
-}
-
-// serializeMarkdown converts DOM nodes to Markdown format.
-func serializeMarkdown(sb *strings.Builder, node dom.Node, listDepth int, inList bool) {
-	switch n := node.(type) {
-	case *dom.Document:
-		for _, child := range n.Children() {
-			serializeMarkdown(sb, child, listDepth, inList)
-		}
-	case *dom.Element:
-		serializeElementMarkdown(sb, n, listDepth, inList)
-	case *dom.Text:
-		// Normalize whitespace - collapse multiple spaces/newlines into single spaces
-		text := collapseMarkdownWhitespace(n.Data)
-		if text != "" {
-			sb.WriteString(text)
-		}
-	case *dom.Comment:
-		// Comments are omitted in markdown
+	switch elem.TagName {
+	case "basefont", "bgsound", "frame", "keygen":
+		return true
 	}
+	return isVoidElement(elem.TagName)
 }
 
-// collapseMarkdownWhitespace collapses runs of whitespace including newlines.
-func collapseMarkdownWhitespace(s string) string {
-	if len(s) == 0 {
-		return s
-	}
-
-	var result strings.Builder
-	inWhitespace := true
-
-	for _, r := range s {
-		if isWhitespaceChar(r) {
-			if !inWhitespace {
-				result.WriteByte(' ')
-				inWhitespace = true
-			}
-		} else {
-			result.WriteRune(r)
-			inWhitespace = false
-		}
+// isRawTextParent reports whether text children of elem are serialized
+// without escaping.
+func isRawTextParent(elem *dom.Element) bool {
+	if elem.Namespace != dom.NamespaceHTML {
+		return false
 	}
-
-	return strings.TrimSpace(result.String())
-}
-
-// serializeElementMarkdown converts an element to Markdown.
-//
-//nolint:funlen // Markdown serialization requires many element type cases
-func serializeElementMarkdown(sb *strings.Builder, elem *dom.Element, listDepth int, inList bool) {
 	switch elem.TagName {
-	case "h1":
-		sb.WriteString("# ")
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		sb.WriteString("\n\n")
-	case "h2":
-		sb.WriteString("## ")
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		sb.WriteString("\n\n")
-	case "h3":
-		sb.WriteString("### ")
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		sb.WriteString("\n\n")
-	case "h4":
-		sb.WriteString("#### ")
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		sb.WriteString("\n\n")
-	case "h5":
-		sb.WriteString("##### ")
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		sb.WriteString("\n\n")
-	case "h6":
-		sb.WriteString("###### ")
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		sb.WriteString("\n\n")
-	case "p":
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		sb.WriteString("\n\n")
-	case "br":
-		sb.WriteString("  \n")
-	case "hr":
-		sb.WriteString("---\n\n")
-	case "strong", "b":
-		sb.WriteString(" **")
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		sb.WriteString("** ")
-	case "em", "i":
-		sb.WriteString(" *")
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		sb.WriteString("* ")
-	case "code":
-		sb.WriteString(" `")
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		sb.WriteString("` ")
-	case "pre":
-		sb.WriteString("```\n")
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		sb.WriteString("\n```\n\n")
-	case "a":
-		href, _ := elem.Attributes.Get("href")
-		sb.WriteString(" [")
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		sb.WriteString("](")
-		sb.WriteString(href)
-		sb.WriteString(") ")
-	case "img":
-		alt, _ := elem.Attributes.Get("alt")
-		src, _ := elem.Attributes.Get("src")
-		sb.WriteString("![")
-		sb.WriteString(alt)
-		sb.WriteString("](")
-		sb.WriteString(src)
-		sb.WriteString(")")
-	case "ul":
-		for _, child := range elem.Children() {
-			serializeMarkdown(sb, child, listDepth, true)
-		}
-		if listDepth == 0 {
-			sb.WriteString("\n")
-		}
-	case "ol":
-		index := 1
-		for _, child := range elem.Children() {
-			if li, ok := child.(*dom.Element); ok && li.TagName == "li" {
-				sb.WriteString(strings.Repeat("  ", listDepth))
-				sb.WriteString(strconv.Itoa(index))
-				sb.WriteString(". ")
-				serializeChildrenMarkdown(sb, li, listDepth+1, true)
-				sb.WriteString("\n")
-				index++
-			}
-		}
-		if listDepth == 0 {
-			sb.WriteString("\n")
-		}
-	case "li":
-		// List items are handled by parent ul/ol elements
-		sb.WriteString(strings.Repeat("  ", listDepth))
-		sb.WriteString("- ")
-		serializeChildrenMarkdown(sb, elem, listDepth+1, true)
-		sb.WriteString("\n")
-	case "blockquote":
-		sb.WriteString("> ")
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		sb.WriteString("\n\n")
-	case "table", "thead", "tbody", "tr", "th", "td":
-		// Basic table support - just extract text for now
-		serializeChildrenMarkdown(sb, elem, listDepth, false)
-		if elem.TagName == "tr" {
-			sb.WriteString("\n")
-		}
-	case "head", "title", "meta", "link", "script", "style":
-		// Skip head elements in markdown
-		return
-	default:
-		// For other elements, just serialize children
-		serializeChildrenMarkdown(sb, elem, listDepth, inList)
+	case "style", "script", "xmp", "iframe", "noembed", "noframes", "plaintext":
+		return true
 	}
+	return false
 }
 
-// serializeChildrenMarkdown serializes all children of an element to Markdown.
-func serializeChildrenMarkdown(sb *strings.Builder, elem *dom.Element, listDepth int, inList bool) {
-	for _, child := range elem.Children() {
-		serializeMarkdown(sb, child, listDepth, inList)
+// IsBlockElement reports whether the HTML element tag generates a
+// block-level box in the default style sheet, so whitespace around it is not
+// rendered.
+func IsBlockElement(tag string) bool {
+	switch tag {
+	case "address", "article", "aside", "blockquote", "body", "caption", "center", //nolint:goconst
+		"colgroup", "dd", "details", "dialog", "dir", "div", "dl", "dt", "fieldset",
+		"figcaption", "figure", "footer", "form", "frameset", "h1", "h2", "h3", "h4",
+		"h5", "h6", "head", "header", "hgroup", "hr", "html", "legend", "li", "listing",
+		"main", "menu", "nav", "ol", "optgroup", "option", "p", "plaintext", "pre",
+		"search", "section", "summary", "table", "tbody", "td", "tfoot", "th", "thead",
+		"tr", "ul", "xmp":
+		return true
 	}
+	return false
 }
@@ -1,6 +1,7 @@
 package serialize
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/MeKo-Christian/JustGoHTML/dom"
@@ -19,6 +20,21 @@ func TestToHTMLDocumentWithDoctypePretty(t *testing.T) {
 	}
 }
 
+func TestToHTMLDocumentFragment(t *testing.T) {
+	frag := dom.NewDocumentFragment()
+	p := dom.NewElement("p")
+	p.AppendChild(dom.NewText("a"))
+	frag.AppendChild(p)
+	frag.AppendChild(dom.NewText("b<"))
+
+	if out := ToHTML(frag, DefaultOptions()); out != "<p>a</p>b&lt;" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	if out := ToHTML(frag, Options{Pretty: true, IndentSize: 2}); out != "<p>a</p>\nb&lt;" {
+		t.Fatalf("unexpected pretty output: %q", out)
+	}
+}
+
 func TestToHTMLTextEscaping(t *testing.T) {
 	div := dom.NewElement("div")
 	div.AppendChild(dom.NewText("a<b&c"))
@@ -29,6 +45,20 @@ func TestToHTMLTextEscaping(t *testing.T) {
 	}
 }
 
+func TestToHTMLScriptingNoscript(t *testing.T) {
+	noscript := dom.NewElement("noscript")
+	noscript.AppendChild(dom.NewText("<p>a&b</p>"))
+
+	if out := ToHTML(noscript, DefaultOptions()); out != "<noscript>&lt;p&gt;a&amp;b&lt;/p&gt;</noscript>" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	for _, opts := range []Options{{Scripting: true}, {Scripting: true, Pretty: true, IndentSize: 2}} {
+		if out := ToHTML(noscript, opts); out != "<noscript><p>a&b</p></noscript>" {
+			t.Fatalf("unexpected output with %+v: %q", opts, out)
+		}
+	}
+}
+
 func TestToHTMLAttributeEscaping(t *testing.T) {
 	div := dom.NewElement("div")
 	div.SetAttr("data-val", `a&"b`)
@@ -89,13 +119,6 @@ func TestPrettyCommentInline(t *testing.T) {
 	}
 }
 
-func TestCollapseWhitespace(t *testing.T) {
-	got := collapseWhitespace("  a   b  ")
-	if got != " a b " {
-		t.Fatalf("unexpected collapsed whitespace: %q", got)
-	}
-}
-
 func TestIsWhitespaceOnly(t *testing.T) {
 	if !isWhitespaceOnly(" \n\t\r") {
 		t.Fatal("expected whitespace-only string to be true")
@@ -112,10 +135,282 @@ func TestIsVoidAndBlockElements(t *testing.T) {
 	if isVoidElement("div") {
 		t.Fatal("expected div to not be void element")
 	}
-	if !isBlockElement("div") {
+	if !IsBlockElement("div") {
 		t.Fatal("expected div to be block element")
 	}
-	if isBlockElement("span") {
+	if IsBlockElement("span") {
 		t.Fatal("expected span to not be block element")
 	}
 }
+
+func TestToHTMLCDATASection(t *testing.T) {
+	svg := dom.NewElementNS("svg", dom.NamespaceSVG)
+	svg.AppendChild(dom.NewCDATASection("a<b]]>c"))
+
+	out := ToHTML(svg, DefaultOptions())
+	if out != "<svg><![CDATA[a<b]]]]><![CDATA[>c]]></svg>" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+
+	// Outside foreign content the section cannot be represented and is
+	// written as text.
+	div := dom.NewElement("div")
+	div.AppendChild(dom.NewCDATASection("a<b"))
+	out = ToHTML(div, DefaultOptions())
+	if out != "<div>a&lt;b</div>" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
+
+func TestToHTMLProcessingInstruction(t *testing.T) {
+	svg := dom.NewElementNS("svg", dom.NamespaceSVG)
+	svg.AppendChild(dom.NewProcessingInstruction("xml-stylesheet", `href="a.css"`))
+	svg.AppendChild(dom.NewProcessingInstruction("empty", ""))
+
+	out := ToHTML(svg, DefaultOptions())
+	if out != `<svg><?xml-stylesheet href="a.css"><?empty></svg>` {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
+
+func TestToHTMLRawTextNotEscaped(t *testing.T) {
+	for _, tag := range []string{"script", "style", "xmp", "iframe", "noembed", "noframes", "plaintext"} {
+		el := dom.NewElement(tag)
+		el.AppendChild(dom.NewText("a<b>&amp;"))
+		out := ToHTML(el, DefaultOptions())
+		if want := "<" + tag + ">a<b>&amp;</" + tag + ">"; out != want {
+			t.Errorf("%s: got %q, want %q", tag, out, want)
+		}
+	}
+
+	// Only HTML raw text elements are special; SVG style content is escaped.
+	style := dom.NewElementNS("style", dom.NamespaceSVG)
+	style.AppendChild(dom.NewText("a<b"))
+	if out := ToHTML(style, DefaultOptions()); out != "<style>a&lt;b</style>" {
+		t.Errorf("svg style: got %q", out)
+	}
+}
+
+func TestToHTMLTemplateContent(t *testing.T) {
+	tmpl := dom.NewElement("template")
+	tmpl.TemplateContent = dom.NewDocumentFragment()
+	p := dom.NewElement("p")
+	p.AppendChild(dom.NewText("x"))
+	tmpl.TemplateContent.AppendChild(p)
+
+	if out := ToHTML(tmpl, DefaultOptions()); out != "<template><p>x</p></template>" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
+
+func TestToHTMLForeignAttributePrefixes(t *testing.T) {
+	use := dom.NewElementNS("use", dom.NamespaceSVG)
+	use.Attributes.SetNS("http://www.w3.org/1999/xlink", "href", "#a")
+	use.Attributes.SetNS("http://www.w3.org/XML/1998/namespace", "xml:lang", "en")
+	use.Attributes.SetNS("http://www.w3.org/2000/xmlns/", "xmlns", "http://www.w3.org/2000/svg")
+	use.Attributes.SetNS("http://www.w3.org/2000/xmlns/", "xlink", "http://www.w3.org/1999/xlink")
+
+	want := `<use xlink:href="#a" xml:lang="en" xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"></use>`
+	if out := ToHTML(use, DefaultOptions()); out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
+
+func TestToHTMLLeadingNewline(t *testing.T) {
+	for _, tag := range []string{"pre", "textarea", "listing"} {
+		el := dom.NewElement(tag)
+		el.AppendChild(dom.NewText("\nx"))
+		if out, want := ToHTML(el, DefaultOptions()), "<"+tag+">\n\nx</"+tag+">"; out != want {
+			t.Errorf("%s: got %q, want %q", tag, out, want)
+		}
+	}
+
+	div := dom.NewElement("div")
+	div.AppendChild(dom.NewText("\nx"))
+	if out := ToHTML(div, DefaultOptions()); out != "<div>\nx</div>" {
+		t.Errorf("div: got %q", out)
+	}
+}
+
+func TestToHTMLEscapesNbspAndAngleBracketsInAttributes(t *testing.T) {
+	p := dom.NewElement("p")
+	p.SetAttr("title", "a\u00a0<b>")
+	p.AppendChild(dom.NewText("c\u00a0d"))
+
+	if out := ToHTML(p, DefaultOptions()); out != `<p title="a&nbsp;&lt;b&gt;">c&nbsp;d</p>` {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
+
+func TestToHTMLObsoleteVoidElements(t *testing.T) {
+	for _, tag := range []string{"basefont", "bgsound", "frame", "keygen"} {
+		if out := ToHTML(dom.NewElement(tag), DefaultOptions()); out != "<"+tag+">" {
+			t.Errorf("%s: got %q", tag, out)
+		}
+	}
+	// Foreign elements always get an end tag.
+	if out := ToHTML(dom.NewElementNS("br", dom.NamespaceSVG), DefaultOptions()); out != "<br></br>" {
+		t.Errorf("svg br: got %q", out)
+	}
+}
+
+func TestToHTMLDoctypeQuoting(t *testing.T) {
+	var sb strings.Builder
+	serializeDoctype(&sb, dom.NewDocumentType("potato", "", `taco"`))
+	if got := sb.String(); got != `<!DOCTYPE potato SYSTEM 'taco"'>` {
+		t.Fatalf("unexpected output: %q", got)
+	}
+}
+
+func TestToHTMLMarkupOptions(t *testing.T) {
+	ul := dom.NewElement("ul")
+	for _, text := range []string{"a", "b"} {
+		li := dom.NewElement("li")
+		li.AppendChild(dom.NewText(text))
+		ul.AppendChild(li)
+	}
+	input := dom.NewElement("input")
+	input.SetAttr("disabled", "disabled")
+	input.SetAttr("value", `say "hi"`)
+	div := dom.NewElement("div")
+	div.AppendChild(ul)
+	div.AppendChild(input)
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "default",
+			opts: DefaultOptions(),
+			want: `<div><ul><li>a</li><li>b</li></ul><input disabled="disabled" value="say &quot;hi&quot;"></div>`,
+		},
+		{
+			name: "omit optional tags",
+			opts: Options{OmitOptionalTags: true},
+			want: `<div><ul><li>a<li>b</ul><input disabled="disabled" value="say &quot;hi&quot;"></div>`,
+		},
+		{
+			name: "minimize booleans and single quotes",
+			opts: Options{MinimizeBooleanAttributes: true, QuoteChar: '\''},
+			want: `<div><ul><li>a</li><li>b</li></ul><input disabled value='say "hi"'></div>`,
+		},
+		{
+			name: "minimize attribute quotes",
+			opts: Options{MinimizeAttributeQuotes: true},
+			want: `<div><ul><li>a</li><li>b</li></ul><input disabled=disabled value='say "hi"'></div>`,
+		},
+		{
+			name: "trailing solidus",
+			opts: Options{UseTrailingSolidus: true},
+			want: `<div><ul><li>a</li><li>b</li></ul><input disabled="disabled" value="say &quot;hi&quot;" /></div>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out := ToHTML(div, tt.opts); out != tt.want {
+				t.Errorf("got %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestToHTMLMinimizesOnlyBooleanAttributes(t *testing.T) {
+	elem := func(tag string, attrs ...string) *dom.Element {
+		e := dom.NewElement(tag)
+		for i := 0; i+1 < len(attrs); i += 2 {
+			e.SetAttr(attrs[i], attrs[i+1])
+		}
+		return e
+	}
+	svg := dom.NewElementNS("svg", dom.NamespaceSVG)
+	svg.SetAttr("disabled", "disabled")
+	tests := []struct {
+		elem *dom.Element
+		want string
+	}{
+		{elem("input", "checked", "checked", "alt", ""), `<input checked alt>`},
+		{elem("img", "alt", "alt"), `<img alt="alt">`},
+		{elem("a", "title", "title"), `<a title="title"></a>`},
+		{elem("input", "value", "value"), `<input value="value">`},
+		{elem("div", "disabled", "disabled"), `<div disabled="disabled"></div>`},
+		{svg, `<svg disabled="disabled"></svg>`},
+	}
+	opts := Options{MinimizeBooleanAttributes: true}
+	for _, tt := range tests {
+		if got := ToHTML(tt.elem, opts); got != tt.want {
+			t.Errorf("ToHTML = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestToHTMLStripWhitespace(t *testing.T) {
+	div := dom.NewElement("div")
+	div.AppendChild(dom.NewText("a  \n b"))
+	pre := dom.NewElement("pre")
+	pre.AppendChild(dom.NewText("x  y"))
+	div.AppendChild(pre)
+
+	if out := ToHTML(div, Options{StripWhitespace: true}); out != "<div>a b<pre>x  y</pre></div>" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
+
+func TestToHTMLInjectMetaCharset(t *testing.T) {
+	doc := dom.NewDocument()
+	html := dom.NewElement("html")
+	head := dom.NewElement("head")
+	title := dom.NewElement("title")
+	title.AppendChild(dom.NewText("t"))
+	head.AppendChild(title)
+	html.AppendChild(head)
+	html.AppendChild(dom.NewElement("body"))
+	doc.AppendChild(html)
+
+	opts := Options{InjectMetaCharset: true, Encoding: "UTF-8"}
+	want := `<html><head><meta charset="UTF-8"><title>t</title></head><body></body></html>`
+	if out := ToHTML(doc, opts); out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+
+	// An existing declaration is updated instead of adding a second one.
+	meta := dom.NewElement("meta")
+	meta.SetAttr("charset", "latin1")
+	head.InsertBefore(meta, title)
+	want = `<html><head><meta charset="UTF-8"><title>t</title></head><body></body></html>`
+	if out := ToHTML(doc, opts); out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+
+	opts.OmitOptionalTags = true
+	if out, want := ToHTML(doc, opts), `<meta charset="UTF-8"><title>t</title>`; out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
+
+func TestToHTMLOmitsBodyAndParagraphTags(t *testing.T) {
+	p := dom.NewElement("p")
+	p.SetAttr("class", "x")
+	p.AppendChild(dom.NewText("a"))
+	body := dom.NewElement("body")
+	body.AppendChild(p)
+
+	opts := Options{OmitOptionalTags: true, MinimizeBooleanAttributes: true}
+	// The body start tag has no attributes and is followed by an element, and
+	// the </p> ends the body, so both are omitted by the shared rules.
+	if out, want := ToHTML(body, opts), `<p class="x">a`; out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
+
+func TestToHTMLKeepsBodyStartTagBeforeHeadContent(t *testing.T) {
+	body := dom.NewElement("body")
+	body.AppendChild(dom.NewElement("script"))
+
+	// Without the body start tag the parser would put the script into the
+	// head.
+	if out, want := ToHTML(body, Options{OmitOptionalTags: true}), `<body><script></script>`; out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
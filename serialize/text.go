@@ -0,0 +1,340 @@
+package serialize
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// ToText renders a node as readable plain text, following the innerText
+// algorithm with the default style sheet:
+//
+//   - Block elements start and end lines, and paragraphs are separated by a
+//     blank line. <br> starts a new line.
+//   - Table cells in a row are separated by tabs, and each row is a line.
+//   - List items start with a bullet, or their number in ordered lists, and
+//     are indented by nesting level.
+//   - Elements that are not rendered are skipped: <head>, <script>, <style>,
+//     <template> and similar, elements with the hidden attribute or an
+//     inline display: none, closed <dialog> elements and the content of
+//     closed <details> elements apart from their summary.
+//   - Whitespace is collapsed as CSS does for the computed white-space
+//     value: <pre>, <textarea> and inline white-space styles preserve it.
+//
+// A node that is not rendered itself gives its text content, as innerText
+// does.
+func ToText(node dom.Node) string {
+	switch n := node.(type) {
+	case *dom.Text:
+		w := newTextWriter()
+		w.writeCollapsed(n.Data, false)
+		return w.sb.String()
+	case *dom.Element:
+		if isTextHidden(n) {
+			return n.Text()
+		}
+	}
+	w := newTextWriter()
+	w.node(node, whiteSpaceNormal)
+	return w.sb.String()
+}
+
+// whiteSpace is the handling of whitespace in text, after the CSS
+// white-space property.
+type whiteSpace int
+
+const (
+	// whiteSpaceNormal collapses all whitespace.
+	whiteSpaceNormal whiteSpace = iota
+	// whiteSpacePreLine collapses spaces and tabs but keeps line breaks.
+	whiteSpacePreLine
+	// whiteSpacePre preserves all whitespace.
+	whiteSpacePre
+)
+
+// textList tracks the numbering of an open list.
+type textList struct {
+	ordered  bool
+	reversed bool
+	next     int
+}
+
+// textWriter collects rendered text. Line breaks required by block
+// boundaries are held back until more text follows, so that they collapse
+// with each other and never appear at the start or end of the output.
+type textWriter struct {
+	sb        strings.Builder
+	breaks    int
+	space     bool
+	lineStart bool
+	lists     []*textList
+
+	// afterMarker is set between a list marker and the item's first text,
+	// where the line breaks of blocks inside the item are dropped.
+	afterMarker bool
+}
+
+func newTextWriter() *textWriter {
+	return &textWriter{lineStart: true}
+}
+
+// requireBreaks asks for at least n line breaks before the next text.
+func (w *textWriter) requireBreaks(n int) {
+	if n == 0 || w.afterMarker {
+		return
+	}
+	w.breaks = max(w.breaks, n)
+	w.space = false
+}
+
+// flush writes the required line breaks, counting the ones already written.
+func (w *textWriter) flush() {
+	if w.breaks > 0 && w.sb.Len() > 0 {
+		out := w.sb.String()
+		written := len(out) - len(strings.TrimRight(out, "\n"))
+		for range w.breaks - written {
+			w.sb.WriteByte('\n')
+		}
+		w.lineStart = true
+	}
+	w.breaks = 0
+	w.afterMarker = false
+}
+
+// writeCollapsed writes text whose whitespace runs collapse to a single
+// space, dropping spaces at the start and end of lines.
+func (w *textWriter) writeCollapsed(s string, keepLineBreaks bool) {
+	for _, r := range s {
+		switch {
+		case r == '\n' && keepLineBreaks:
+			w.writeSeparator("\n")
+		case isWhitespaceChar(r):
+			if !w.lineStart {
+				w.space = true
+			}
+		default:
+			w.flush()
+			if w.space && !w.lineStart {
+				w.sb.WriteByte(' ')
+			}
+			w.space = false
+			w.lineStart = false
+			w.sb.WriteRune(r)
+		}
+	}
+}
+
+// writePreserved writes text with its whitespace unchanged.
+func (w *textWriter) writePreserved(s string) {
+	if s == "" {
+		return
+	}
+	w.flush()
+	if w.space {
+		w.sb.WriteByte(' ')
+	}
+	w.sb.WriteString(s)
+	w.space = false
+	w.lineStart = strings.HasSuffix(s, "\n")
+}
+
+// writeSeparator writes a line break, tab or list marker, after which
+// collapsible spaces are dropped.
+func (w *textWriter) writeSeparator(s string) {
+	w.flush()
+	w.space = false
+	w.sb.WriteString(s)
+	w.lineStart = true
+}
+
+func (w *textWriter) node(n dom.Node, ws whiteSpace) {
+	switch n := n.(type) {
+	case *dom.Text:
+		switch ws {
+		case whiteSpacePre:
+			w.writePreserved(n.Data)
+		default:
+			w.writeCollapsed(n.Data, ws == whiteSpacePreLine)
+		}
+	case *dom.Element:
+		w.element(n, ws)
+	case *dom.Document, *dom.DocumentFragment:
+		for _, child := range n.Children() {
+			w.node(child, ws)
+		}
+	}
+}
+
+func (w *textWriter) element(elem *dom.Element, ws whiteSpace) {
+	if isTextHidden(elem) {
+		return
+	}
+	ws = elementWhiteSpace(elem, ws)
+	tag := ""
+	if elem.Namespace == dom.NamespaceHTML {
+		tag = elem.TagName
+	}
+
+	breaks := 0
+	switch {
+	case tag == "br":
+		w.writeSeparator("\n")
+		return
+	case tag == "p":
+		breaks = 2
+	case tag == "td" || tag == "th":
+	case IsBlockElement(tag):
+		breaks = 1
+	}
+	w.requireBreaks(breaks)
+
+	switch tag {
+	case "ul", "ol", "menu", "dir":
+		w.lists = append(w.lists, newTextList(elem))
+		defer func() { w.lists = w.lists[:len(w.lists)-1] }()
+	case "li":
+		if w.afterMarker {
+			// A nested list starts the outer item: put it on its own line.
+			w.afterMarker = false
+			w.requireBreaks(1)
+		}
+		w.writeSeparator(w.listMarker(elem))
+		w.afterMarker = true
+	}
+
+	children := elem.Children()
+	if tag == "details" && !elem.HasAttr("open") {
+		children = nil
+		for _, child := range elem.Children() {
+			if summary, ok := child.(*dom.Element); ok && summary.TagName == "summary" {
+				children = []dom.Node{summary}
+				break
+			}
+		}
+	}
+	for _, child := range children {
+		w.node(child, ws)
+	}
+
+	if tag == "li" {
+		w.afterMarker = false
+	}
+	if (tag == "td" || tag == "th") && nextCellSibling(elem) {
+		w.writeSeparator("\t")
+	}
+	w.requireBreaks(breaks)
+}
+
+func newTextList(elem *dom.Element) *textList {
+	list := &textList{ordered: elem.TagName == "ol", next: 1}
+	if !list.ordered {
+		return list
+	}
+	list.reversed = elem.HasAttr("reversed")
+	if list.reversed {
+		list.next = 0
+		for _, child := range elem.Children() {
+			if li, ok := child.(*dom.Element); ok && li.TagName == "li" {
+				list.next++
+			}
+		}
+	}
+	if start, err := strconv.Atoi(strings.TrimSpace(elem.Attr("start"))); err == nil {
+		list.next = start
+	}
+	return list
+}
+
+// listMarker returns the indentation and bullet or number of a list item.
+func (w *textWriter) listMarker(li *dom.Element) string {
+	if len(w.lists) == 0 {
+		return "• "
+	}
+	indent := strings.Repeat("  ", len(w.lists)-1)
+	list := w.lists[len(w.lists)-1]
+	if !list.ordered {
+		return indent + "• "
+	}
+	if value, err := strconv.Atoi(strings.TrimSpace(li.Attr("value"))); err == nil {
+		list.next = value
+	}
+	marker := indent + strconv.Itoa(list.next) + ". "
+	if list.reversed {
+		list.next--
+	} else {
+		list.next++
+	}
+	return marker
+}
+
+// nextCellSibling reports whether a table cell follows cell in its row.
+func nextCellSibling(cell *dom.Element) bool {
+	parent := cell.Parent()
+	if parent == nil {
+		return false
+	}
+	seen := false
+	for _, child := range parent.Children() {
+		if child == dom.Node(cell) {
+			seen = true
+			continue
+		}
+		if el, ok := child.(*dom.Element); ok && seen && (el.TagName == "td" || el.TagName == "th") {
+			return true
+		}
+	}
+	return false
+}
+
+// isTextHidden reports whether elem is not rendered.
+func isTextHidden(elem *dom.Element) bool {
+	switch elem.TagName {
+	case "script", "style":
+		return true
+	}
+	if elem.Namespace == dom.NamespaceHTML {
+		switch elem.TagName {
+		case "head", "template", "title", "noscript", "noframes", "noembed", "iframe",
+			"datalist", "param", "source", "track", "meta", "link", "base":
+			return true
+		case "dialog":
+			if !elem.HasAttr("open") {
+				return true
+			}
+		}
+	}
+	return elem.HasAttr("hidden") || styleProperty(elem, "display") == "none"
+}
+
+// elementWhiteSpace returns the white-space handling inside elem, given the
+// handling inherited from its parent.
+func elementWhiteSpace(elem *dom.Element, inherited whiteSpace) whiteSpace {
+	switch styleProperty(elem, "white-space") {
+	case "pre", "pre-wrap", "break-spaces":
+		return whiteSpacePre
+	case "pre-line":
+		return whiteSpacePreLine
+	case "normal", "nowrap":
+		return whiteSpaceNormal
+	}
+	if elem.Namespace == dom.NamespaceHTML && (isPreformattedElement(elem.TagName) || elem.TagName == "xmp" || elem.TagName == "plaintext") {
+		return whiteSpacePre
+	}
+	return inherited
+}
+
+// styleProperty returns the lowercased value of a property declared in the
+// inline style of elem, or "" if it is not declared.
+func styleProperty(elem *dom.Element, name string) string {
+	value := ""
+	for _, decl := range strings.Split(elem.Attr("style"), ";") {
+		prop, v, ok := strings.Cut(decl, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(prop), name) {
+			continue
+		}
+		v = strings.ToLower(strings.TrimSpace(v))
+		value = strings.TrimSpace(strings.TrimSuffix(v, "!important"))
+	}
+	return value
+}
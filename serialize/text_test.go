@@ -0,0 +1,77 @@
+package serialize_test
+
+import (
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+)
+
+func TestToText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "blocks and paragraphs",
+			input: "<h1>Head  line</h1><p>Para <b>one</b>\n two</p><div>a<div>b</div>c</div>",
+			want:  "Head line\n\nPara one two\n\na\nb\nc",
+		},
+		{
+			name:  "line breaks",
+			input: "<p>x <br> y<br><br>z</p>",
+			want:  "x\ny\n\nz",
+		},
+		{
+			name:  "tables",
+			input: "<table><caption>Cap</caption><tr><th>A<th>B</tr><tr><td> 1 <td></tr></table>after",
+			want:  "Cap\nA\tB\n1\t\nafter",
+		},
+		{
+			name:  "lists",
+			input: "<ul><li>one<li>two<ul><li>nested</ul></ul><ol start=3><li>a<li value=7>b<li>c</ol><ol reversed><li>x<li>y</ol>",
+			want:  "• one\n• two\n  • nested\n3. a\n7. b\n8. c\n2. x\n1. y",
+		},
+		{
+			name:  "hidden content",
+			input: "<head><title>T</title><style>p{}</style></head><p>a<script>s()</script><span hidden>h</span><span style=\"display: none\">n</span><template>t</template> b</p><dialog>d</dialog>",
+			want:  "a b",
+		},
+		{
+			name:  "details",
+			input: "<details><summary>Sum</summary>hidden</details><details open><summary>Open</summary>shown</details>",
+			want:  "Sum\nOpen\nshown",
+		},
+		{
+			name:  "preformatted",
+			input: "<pre>  a\n   b</pre><p>  q <span style=\"white-space: pre\">1  2</span> <textarea> t  x </textarea></p>",
+			want:  "  a\n   b\n\nq 1  2  t  x ",
+		},
+		{
+			name:  "pre-line",
+			input: "<p style=\"white-space: pre-line\">l1   x\n  l2</p>",
+			want:  "l1 x\nl2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, tt.input)
+			if got := serialize.ToText(doc); got != tt.want {
+				t.Errorf("ToText(%q)\ngot:  %q\nwant: %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToTextNodes(t *testing.T) {
+	doc := mustParse(t, "<p>before <a href=\"/x\">link\n <em>text</em></a></p><script>var  x;</script>")
+
+	if got, want := serialize.ToText(queryFirst(t, doc, "a")), "link text"; got != want {
+		t.Errorf("element: got %q, want %q", got, want)
+	}
+	// An element that is not rendered gives its text content.
+	if got, want := serialize.ToText(queryFirst(t, doc, "script")), "var  x;"; got != want {
+		t.Errorf("hidden element: got %q, want %q", got, want)
+	}
+}
@@ -168,3 +168,12 @@ func TestSerializeTokensTrailingSolidus(t *testing.T) {
 		t.Fatalf("unexpected output: %q", out)
 	}
 }
+
+func mustDecodeTokens(t *testing.T, tokens []json.RawMessage) []markupToken {
+	t.Helper()
+	decoded, err := decodeTokens(tokens)
+	if err != nil {
+		t.Fatalf("decode tokens: %v", err)
+	}
+	return decoded
+}
@@ -0,0 +1,177 @@
+package serialize
+
+import (
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// CollapseWhitespace removes whitespace below node that does not affect
+// rendering, modifying the tree in place. Runs of whitespace collapse to a
+// single space, and whitespace at the start and end of lines, next to block
+// boundaries and between head elements is removed. Preformatted, raw text
+// and foreign content is kept, as are title and noscript elements and
+// elements whose inline style mentions white-space.
+func CollapseWhitespace(node dom.Node) {
+	space := &spaceCollapser{lineStart: true}
+	space.walk(node)
+	space.lineBreak()
+}
+
+// spaceCollapser collapses whitespace in document order the way it is
+// rendered. It tracks the current line of inline content, so that spaces
+// collapsing across element boundaries and spaces at the start and end of
+// lines can be removed.
+type spaceCollapser struct {
+	// last is the last text node on the current line, or nil if other
+	// content followed it.
+	last *dom.Text
+
+	// lineStart is set while nothing has been written on the current line.
+	lineStart bool
+}
+
+func (s *spaceCollapser) walk(node dom.Node) {
+	parent, _ := node.(*dom.Element)
+	for _, child := range append([]dom.Node(nil), node.Children()...) {
+		switch c := child.(type) {
+		case *dom.Text:
+			s.text(node, c)
+		case *dom.Element:
+			s.element(parent, c)
+		case *dom.CDATASection, *dom.ProcessingInstruction:
+			s.content()
+		}
+	}
+}
+
+func (s *spaceCollapser) text(parent dom.Node, text *dom.Text) {
+	data := collapseTokenWhitespace(text.Data)
+	if s.lineStart || (s.last != nil && strings.HasSuffix(s.last.Data, " ")) {
+		data = strings.TrimPrefix(data, " ")
+	}
+	if data == "" {
+		parent.RemoveChild(text)
+		return
+	}
+	if data != text.Data {
+		text.SetData(data)
+	}
+	s.last = text
+	s.lineStart = false
+}
+
+func (s *spaceCollapser) element(parent, elem *dom.Element) {
+	block := isBlockBoundary(parent, elem)
+	switch {
+	case elem.TemplateContent != nil && elem.Namespace == dom.NamespaceHTML:
+		// Template contents are not rendered in place; they form a flow of
+		// their own.
+		inner := &spaceCollapser{lineStart: true}
+		inner.walk(elem.TemplateContent)
+		inner.lineBreak()
+	case isVerbatimElement(elem) || elem.TagName == "noscript" || elem.TagName == "title":
+		// The text of noscript may be markup, and the text of title is shown
+		// outside the page, so both are kept as well.
+		if block {
+			s.lineBreak()
+			return
+		}
+		s.content()
+	case elem.TagName == "br":
+		s.lineBreak()
+	case block:
+		s.lineBreak()
+		s.walk(elem)
+		s.lineBreak()
+	case isAtomicInline(elem.TagName):
+		// Atomic inlines render as opaque boxes: spaces around them are
+		// significant and their content is a flow of its own.
+		s.content()
+		inner := &spaceCollapser{}
+		inner.walk(elem)
+		s.content()
+	default:
+		s.walk(elem)
+	}
+}
+
+// content records content other than text on the current line.
+func (s *spaceCollapser) content() {
+	s.last = nil
+	s.lineStart = false
+}
+
+// lineBreak ends the current line, dropping the trailing space of its last
+// text.
+func (s *spaceCollapser) lineBreak() {
+	if s.last != nil {
+		data := strings.TrimSuffix(s.last.Data, " ")
+		if data == "" {
+			if parent := s.last.Parent(); parent != nil {
+				parent.RemoveChild(s.last)
+			}
+		} else if data != s.last.Data {
+			s.last.SetData(data)
+		}
+	}
+	s.last = nil
+	s.lineStart = true
+}
+
+// isAtomicInline reports whether elements with the given tag render as
+// inline boxes whose content does not take part in the surrounding line.
+func isAtomicInline(tag string) bool {
+	switch tag {
+	case "audio", "button", "canvas", "embed", "img", "input", "meter",
+		"object", "progress", "select", "video":
+		return true
+	}
+	return false
+}
+
+// IsBooleanAttribute reports whether name is a boolean attribute of the HTML
+// element tag, whose presence alone has an effect and whose value does not
+// matter.
+func IsBooleanAttribute(tag, name string) bool {
+	switch name {
+	case "autofocus", "inert", "itemscope":
+		return true
+	case "allowfullscreen":
+		return tag == "iframe"
+	case "async", "defer", "nomodule":
+		return tag == "script"
+	case "autoplay", "controls", "loop", "muted":
+		return tag == "audio" || tag == "video"
+	case "playsinline":
+		return tag == "video"
+	case "checked":
+		return tag == "input"
+	case "default":
+		return tag == "track"
+	case "disabled":
+		switch tag {
+		case "button", "fieldset", "input", "optgroup", "option", "select", "textarea", "link":
+			return true
+		}
+	case "formnovalidate":
+		return tag == "button" || tag == "input"
+	case "ismap":
+		return tag == "img"
+	case "multiple":
+		return tag == "input" || tag == "select"
+	case "novalidate":
+		return tag == "form"
+	case "open":
+		return tag == "details" || tag == "dialog"
+	case "readonly":
+		return tag == "input" || tag == "textarea"
+	case "required":
+		return tag == "input" || tag == "select" || tag == "textarea"
+	case "reversed":
+		return tag == "ol"
+	case "selected":
+		return tag == "option"
+	}
+	return false
+}
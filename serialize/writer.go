@@ -0,0 +1,138 @@
+package serialize
+
+import (
+	"bufio"
+	"io"
+	"slices"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/stream"
+)
+
+// WriteHTML writes the HTML serialization of node to w, as ToHTML would
+// return it.
+//
+// Without Pretty, the output is written while the tree is walked through a
+// small buffer, so memory use does not grow with the size of the output.
+// Pretty mode needs the layout of whole elements and builds the output in
+// memory before writing it.
+//
+// The first error returned by w stops the serialization and is returned.
+func WriteHTML(w io.Writer, node dom.Node, opts Options) error {
+	if opts.Pretty {
+		_, err := io.WriteString(w, ToHTML(node, opts))
+		return err
+	}
+	out := newStreamWriter(w)
+	mw := newMarkupWriter(out.buf, opts.markupOptions())
+	for tok := range domTokens(node) {
+		mw.push(tok)
+		if out.err != nil {
+			return out.err
+		}
+	}
+	mw.close()
+	return out.flush()
+}
+
+// WriteMarkdown writes the Markdown of node to w using the default options.
+func WriteMarkdown(w io.Writer, node dom.Node) error {
+	return WriteMarkdownWithOptions(w, node, DefaultMarkdownOptions())
+}
+
+// WriteMarkdownWithOptions writes the Markdown of node to w, as
+// ToMarkdownWithOptions would return it.
+//
+// Each top-level block is written as soon as it is rendered, so memory use
+// is bounded by the largest block rather than by the whole output. Link
+// references in LinkReferenced style are collected and written at the end.
+// The first error returned by w is returned.
+func WriteMarkdownWithOptions(w io.Writer, node dom.Node, opts MarkdownOptions) error {
+	out := newStreamWriter(w)
+	writeMarkdown(out.buf, node, opts)
+	return out.flush()
+}
+
+// WriteEvents writes the events of a stream.Stream channel back as HTML,
+// one token at a time. Text in script, style and the other raw text
+// elements is written unescaped, attributes are sorted by name, and the
+// options apply as they do for SerializeTokensWithOptions. Pretty has no
+// effect.
+//
+// After an error from w, the remaining events are drained so that the
+// producing goroutine can finish, and the error is returned.
+func WriteEvents(w io.Writer, events <-chan stream.Event, opts Options) error {
+	out := newStreamWriter(w)
+	mw := newMarkupWriter(out.buf, opts.markupOptions())
+	for ev := range events {
+		if out.err != nil {
+			continue
+		}
+		if tok, ok := eventToken(ev); ok {
+			mw.push(tok)
+		}
+	}
+	if out.err != nil {
+		return out.err
+	}
+	mw.close()
+	return out.flush()
+}
+
+// eventToken converts a stream event to a markup token.
+func eventToken(ev stream.Event) (markupToken, bool) {
+	switch ev.Type {
+	case stream.StartTagEvent:
+		names := make([]string, 0, len(ev.Attrs))
+		for name := range ev.Attrs {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		attrs := make([]tokenAttr, 0, len(names))
+		for _, name := range names {
+			attrs = append(attrs, tokenAttr{Name: name, Value: ev.Attrs[name]})
+		}
+		return markupToken{kind: startTagToken, name: ev.Name, attrs: attrs}, true
+	case stream.EndTagEvent:
+		return markupToken{kind: endTagToken, name: ev.Name}, true
+	case stream.TextEvent:
+		return markupToken{kind: textToken, data: ev.Data}, true
+	case stream.CommentEvent:
+		return markupToken{kind: commentToken, data: ev.Data}, true
+	case stream.DoctypeEvent:
+		return markupToken{kind: doctypeToken, name: ev.Name, publicID: ev.PublicID, systemID: ev.SystemID}, true
+	}
+	return markupToken{}, false
+}
+
+// streamWriter buffers output to an io.Writer and records the first write
+// error, so that serialization can stop as soon as the output fails.
+type streamWriter struct {
+	w   io.Writer
+	buf *bufio.Writer
+	err error
+}
+
+func newStreamWriter(w io.Writer) *streamWriter {
+	sw := &streamWriter{w: w}
+	sw.buf = bufio.NewWriter(sw)
+	return sw
+}
+
+// Write passes p to the underlying writer, failing once it has failed.
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	if sw.err != nil {
+		return 0, sw.err
+	}
+	n, err := sw.w.Write(p)
+	sw.err = err
+	return n, err
+}
+
+// flush writes the buffered output and returns the first error.
+func (sw *streamWriter) flush() error {
+	if err := sw.buf.Flush(); err != nil {
+		return err
+	}
+	return sw.err
+}
@@ -0,0 +1,140 @@
+package serialize_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+	"github.com/MeKo-Christian/JustGoHTML/stream"
+)
+
+const writerTestHTML = `<!DOCTYPE html><html><head><title>T</title></head><body>
+<h1>Title</h1><p>Some <a href="/x">linked</a> text &amp; more.</p>
+<ul><li>one</li><li>two</li></ul><pre>
+code</pre><table><tr><td>a</td><td>b</td></tr></table>
+<script>if (a < b) {}</script></body></html>`
+
+// failingWriter accepts n bytes and then fails.
+type failingWriter struct {
+	n int
+}
+
+var errWriteFailed = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		written := w.n
+		w.n = 0
+		return written, errWriteFailed
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestWriteHTML(t *testing.T) {
+	doc, err := JustGoHTML.Parse(writerTestHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []serialize.Options{
+		serialize.DefaultOptions(),
+		{OmitOptionalTags: true, MinimizeBooleanAttributes: true},
+		{InjectMetaCharset: true, Encoding: "utf-8"},
+		{Pretty: true, IndentSize: 2},
+	} {
+		var sb strings.Builder
+		if err := serialize.WriteHTML(&sb, doc, opts); err != nil {
+			t.Fatalf("WriteHTML(%+v) error: %v", opts, err)
+		}
+		if want := serialize.ToHTML(doc, opts); sb.String() != want {
+			t.Errorf("WriteHTML(%+v) = %q, want %q", opts, sb.String(), want)
+		}
+	}
+}
+
+func TestWriteHTMLError(t *testing.T) {
+	doc, err := JustGoHTML.Parse("<p>" + strings.Repeat("text ", 10000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &failingWriter{n: 100}
+	if err := serialize.WriteHTML(w, doc, serialize.DefaultOptions()); !errors.Is(err, errWriteFailed) {
+		t.Errorf("WriteHTML error = %v, want %v", err, errWriteFailed)
+	}
+	if err := serialize.WriteMarkdown(&failingWriter{n: 100}, doc); !errors.Is(err, errWriteFailed) {
+		t.Errorf("WriteMarkdown error = %v, want %v", err, errWriteFailed)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	doc, err := JustGoHTML.Parse(writerTestHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []serialize.MarkdownOptions{
+		serialize.DefaultMarkdownOptions(),
+		{LinkStyle: serialize.LinkReferenced, HeadingStyle: serialize.HeadingSetext},
+	} {
+		var sb strings.Builder
+		if err := serialize.WriteMarkdownWithOptions(&sb, doc, opts); err != nil {
+			t.Fatalf("WriteMarkdownWithOptions(%+v) error: %v", opts, err)
+		}
+		if want := serialize.ToMarkdownWithOptions(doc, opts); sb.String() != want {
+			t.Errorf("WriteMarkdownWithOptions(%+v) = %q, want %q", opts, sb.String(), want)
+		}
+	}
+}
+
+func TestWriteEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  serialize.Options
+		want  string
+	}{
+		{
+			name:  "round trip",
+			input: `<!DOCTYPE html><p class="a" id="b">x &amp; y<br><!-- c --></p>`,
+			want:  `<!DOCTYPE html><p class="a" id="b">x &amp; y<br><!-- c --></p>`,
+		},
+		{
+			name:  "sorted attributes",
+			input: `<a title="t" href="/x">link</a>`,
+			want:  `<a href="/x" title="t">link</a>`,
+		},
+		{
+			name:  "raw text",
+			input: `<script>if (a < b) {}</script>`,
+			want:  `<script>if (a < b) {}</script>`,
+		},
+		{
+			name:  "options",
+			input: `<ul><li>one</li><li>two</li></ul><input disabled="">`,
+			opts:  serialize.Options{OmitOptionalTags: true, MinimizeBooleanAttributes: true, UseTrailingSolidus: true},
+			want:  `<ul><li>one<li>two</ul><input disabled />`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := serialize.WriteEvents(&sb, stream.Stream(tt.input), tt.opts); err != nil {
+				t.Fatalf("WriteEvents error: %v", err)
+			}
+			if sb.String() != tt.want {
+				t.Errorf("WriteEvents = %q, want %q", sb.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteEventsError(t *testing.T) {
+	events := stream.Stream("<p>" + strings.Repeat("<b>text</b> ", 10000))
+	if err := serialize.WriteEvents(&failingWriter{n: 100}, events, serialize.DefaultOptions()); !errors.Is(err, errWriteFailed) {
+		t.Errorf("WriteEvents error = %v, want %v", err, errWriteFailed)
+	}
+	if _, ok := <-events; ok {
+		t.Error("WriteEvents did not drain the events after the error")
+	}
+}
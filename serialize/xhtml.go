@@ -0,0 +1,499 @@
+package serialize
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// ErrNotXMLSerializable is returned by ToXHTML when a node has no
+// well-formed XML serialization, for example because an element or
+// attribute name is not a valid XML name.
+var ErrNotXMLSerializable = errors.New("node is not XML-serializable")
+
+// ToXHTML serializes a node to XML following the "XML serialization"
+// algorithm of the DOM Parsing specification, producing polyglot markup for
+// HTML documents:
+//
+//   - Elements are written in their namespace: the first element of each
+//     namespace declares it as the default namespace, and xlink attributes
+//     declare the xlink prefix where needed. Attributes of HTML elements
+//     named xml:* or xlink:*, such as the xml:lang of polyglot documents,
+//     are written in the XML and XLink namespaces.
+//   - Empty elements are self-closing: <br />, <circle/>. Empty HTML
+//     elements that are not void keep their end tag, <p></p>.
+//   - Script and style content that contains markup characters is wrapped
+//     in a CDATA section behind comment guards, so that it has the same
+//     value for HTML and XML parsers. Content containing "]]>" cannot be
+//     written that way and gives an error.
+//   - Characters that XML does not allow are replaced as the tokenizer's XML
+//     coercion does: form feeds become spaces and other invalid characters
+//     U+FFFD. Comments containing "--" or ending in "-" are given spaces.
+//
+// Trees that cannot be represented in XML, because of element or attribute
+// names that are not XML names, conflicting namespace declarations,
+// duplicate attributes, invalid doctype identifiers or processing
+// instructions, or script content containing "]]>", give an error wrapping
+// ErrNotXMLSerializable.
+func ToXHTML(node dom.Node) (string, error) {
+	var sb strings.Builder
+	w := &xmlWriter{sb: &sb}
+	if err := w.node(node, newXMLScope()); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// WriteXHTML writes the XML serialization of node to w, as ToXHTML would
+// return it. Output may already have been written when an error is
+// returned.
+func WriteXHTML(w io.Writer, node dom.Node) error {
+	out := newStreamWriter(w)
+	xw := &xmlWriter{sb: out.buf}
+	if err := xw.node(node, newXMLScope()); err != nil {
+		return err
+	}
+	return out.flush()
+}
+
+// xmlScope holds the namespace declarations in scope of an element.
+type xmlScope struct {
+	defaultNS string
+	prefixes  map[string]string // prefix to namespace
+	owned     bool              // prefixes may be modified
+}
+
+func newXMLScope() xmlScope {
+	return xmlScope{prefixes: map[string]string{"xml": namespaceXML, "xmlns": namespaceXMLNS}}
+}
+
+// declare binds prefix to namespace, copying the map inherited from the
+// parent scope first.
+func (s *xmlScope) declare(prefix, namespace string) {
+	if !s.owned {
+		s.prefixes = maps.Clone(s.prefixes)
+		s.owned = true
+	}
+	s.prefixes[prefix] = namespace
+}
+
+// prefixFor returns the first prefix in alphabetical order that is bound
+// to namespace in the scope.
+func (s *xmlScope) prefixFor(namespace string) (string, bool) {
+	for _, prefix := range slices.Sorted(maps.Keys(s.prefixes)) {
+		if s.prefixes[prefix] == namespace {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+type xmlWriter struct {
+	sb markupSink
+}
+
+func (w *xmlWriter) node(n dom.Node, scope xmlScope) error {
+	switch n := n.(type) {
+	case *dom.Document:
+		if n.Doctype != nil {
+			if err := w.node(n.Doctype, scope); err != nil {
+				return err
+			}
+		}
+		return w.children(n.Children(), scope)
+	case *dom.DocumentFragment:
+		return w.children(n.Children(), scope)
+	case *dom.DocumentType:
+		return w.doctype(n)
+	case *dom.Element:
+		return w.element(n, scope)
+	case *dom.Text:
+		writeXMLEscaped(w.sb, n.Data, false)
+	case *dom.Comment:
+		w.sb.WriteString("<!--")
+		w.sb.WriteString(coerceXMLChars(coerceXMLComment(n.Data)))
+		w.sb.WriteString("-->")
+	case *dom.CDATASection:
+		w.sb.WriteString("<![CDATA[")
+		w.sb.WriteString(coerceXMLChars(strings.ReplaceAll(n.Data, "]]>", "]]]]><![CDATA[>")))
+		w.sb.WriteString("]]>")
+	case *dom.ProcessingInstruction:
+		if !isXMLNCName(n.Target) || strings.EqualFold(n.Target, "xml") {
+			return fmt.Errorf("%w: processing instruction target %q", ErrNotXMLSerializable, n.Target)
+		}
+		if strings.Contains(n.Data, "?>") {
+			return fmt.Errorf("%w: processing instruction data contains \"?>\"", ErrNotXMLSerializable)
+		}
+		w.sb.WriteString("<?")
+		w.sb.WriteString(n.Target)
+		if n.Data != "" {
+			w.sb.WriteByte(' ')
+			w.sb.WriteString(coerceXMLChars(n.Data))
+		}
+		w.sb.WriteString("?>")
+	}
+	return nil
+}
+
+func (w *xmlWriter) children(children []dom.Node, scope xmlScope) error {
+	for _, child := range children {
+		if err := w.node(child, scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *xmlWriter) doctype(dt *dom.DocumentType) error {
+	for _, r := range dt.PublicID {
+		if !isPubidChar(r) {
+			return fmt.Errorf("%w: doctype public identifier %q", ErrNotXMLSerializable, dt.PublicID)
+		}
+	}
+	if strings.ContainsRune(dt.SystemID, '"') && strings.ContainsRune(dt.SystemID, '\'') {
+		return fmt.Errorf("%w: doctype system identifier %q", ErrNotXMLSerializable, dt.SystemID)
+	}
+	w.sb.WriteString("<!DOCTYPE ")
+	w.sb.WriteString(dt.Name)
+	if dt.PublicID != "" {
+		w.sb.WriteString(` PUBLIC "`)
+		w.sb.WriteString(dt.PublicID)
+		w.sb.WriteByte('"')
+	} else if dt.SystemID != "" {
+		w.sb.WriteString(" SYSTEM")
+	}
+	if dt.SystemID != "" {
+		quote := `"`
+		if strings.ContainsRune(dt.SystemID, '"') {
+			quote = "'"
+		}
+		w.sb.WriteString(" " + quote + dt.SystemID + quote)
+	}
+	w.sb.WriteByte('>')
+	return nil
+}
+
+func (w *xmlWriter) element(elem *dom.Element, scope xmlScope) error {
+	if !isXMLNCName(elem.TagName) {
+		return fmt.Errorf("%w: element name %q", ErrNotXMLSerializable, elem.TagName)
+	}
+	attrs, err := xmlAttributes(elem, &scope)
+	if err != nil {
+		return err
+	}
+	// The children share the declarations and must copy them to add more.
+	scope.owned = false
+
+	w.sb.WriteByte('<')
+	w.sb.WriteString(elem.TagName)
+	for _, attr := range attrs {
+		w.sb.WriteByte(' ')
+		w.sb.WriteString(attr.Name)
+		w.sb.WriteString(`="`)
+		writeXMLEscaped(w.sb, attr.Value, true)
+		w.sb.WriteByte('"')
+	}
+
+	children := serializedChildren(elem)
+	html := elem.Namespace == dom.NamespaceHTML
+	if len(children) == 0 {
+		switch {
+		case !html:
+			w.sb.WriteString("/>")
+			return nil
+		case isVoidElement(elem.TagName):
+			w.sb.WriteString(" />")
+			return nil
+		}
+	}
+	w.sb.WriteByte('>')
+
+	if text, ok := rawTextContent(elem, children); ok {
+		if err := writeXMLScript(w.sb, elem.TagName, text); err != nil {
+			return err
+		}
+	} else if err := w.children(children, scope); err != nil {
+		return err
+	}
+
+	w.sb.WriteString("</")
+	w.sb.WriteString(elem.TagName)
+	w.sb.WriteByte('>')
+	return nil
+}
+
+// xmlAttributes returns the attributes of elem with their qualified names,
+// preceded by the namespace declarations the element needs, and records
+// the declarations in scope.
+func xmlAttributes(elem *dom.Element, scope *xmlScope) ([]tokenAttr, error) {
+	var decls, attrs []tokenAttr
+	if elem.Namespace != scope.defaultNS {
+		scope.defaultNS = elem.Namespace
+		decls = append(decls, tokenAttr{Name: "xmlns", Value: elem.Namespace})
+	}
+
+	all := elem.Attributes.All()
+
+	// Explicit prefix declarations come first, so that the prefixes of the
+	// attributes below can use them.
+	for _, attr := range all {
+		prefix, ok := xmlnsPrefix(attr)
+		if !ok || prefix == "" {
+			continue
+		}
+		if prefix == "xml" || prefix == "xmlns" || !isXMLNCName(prefix) {
+			return nil, fmt.Errorf("%w: namespace prefix %q", ErrNotXMLSerializable, prefix)
+		}
+		if scope.prefixes[prefix] != attr.Value {
+			scope.declare(prefix, attr.Value)
+		}
+		decls = append(decls, tokenAttr{Name: "xmlns:" + prefix, Value: attr.Value})
+	}
+
+	generated := 0
+	for _, attr := range all {
+		if prefix, ok := xmlnsPrefix(attr); ok {
+			// A default namespace declaration must agree with the namespace
+			// of the element, which has been declared above.
+			if prefix == "" && attr.Value != elem.Namespace {
+				return nil, fmt.Errorf("%w: xmlns=%q on element in namespace %q", ErrNotXMLSerializable, attr.Value, elem.Namespace)
+			}
+			continue
+		}
+
+		local, namespace := attr.Name, attr.Namespace
+		if namespace == "" && elem.Namespace == dom.NamespaceHTML {
+			// The HTML parser keeps xml:lang and xlink:href on HTML elements
+			// as attributes in no namespace; in polyglot markup they are in
+			// the XML and XLink namespaces.
+			switch prefix, _, _ := strings.Cut(local, ":"); prefix {
+			case "xml":
+				namespace = namespaceXML
+			case "xlink":
+				namespace = namespaceXLink
+			}
+		}
+		if namespace == "" {
+			if !isXMLNCName(local) {
+				return nil, fmt.Errorf("%w: attribute name %q", ErrNotXMLSerializable, attr.Name)
+			}
+			attrs = append(attrs, tokenAttr{Name: local, Value: attr.Value})
+			continue
+		}
+
+		prefix := ""
+		if i := strings.IndexByte(local, ':'); i >= 0 {
+			prefix, local = local[:i], local[i+1:]
+		}
+		switch namespace {
+		case namespaceXML:
+			prefix = "xml"
+		case namespaceXLink:
+			prefix = "xlink"
+		}
+		if !isXMLNCName(local) {
+			return nil, fmt.Errorf("%w: attribute name %q", ErrNotXMLSerializable, attr.Name)
+		}
+		if bound, ok := scope.prefixes[prefix]; prefix == "" || (ok && bound != namespace) {
+			// Use a prefix already bound to the namespace, or make one up.
+			if p, ok := scope.prefixFor(namespace); ok {
+				prefix = p
+			} else {
+				for {
+					generated++
+					prefix = "ns" + strconv.Itoa(generated)
+					if _, taken := scope.prefixes[prefix]; !taken {
+						break
+					}
+				}
+			}
+		}
+		if scope.prefixes[prefix] != namespace {
+			scope.declare(prefix, namespace)
+			decls = append(decls, tokenAttr{Name: "xmlns:" + prefix, Value: namespace})
+		}
+		attrs = append(attrs, tokenAttr{Name: prefix + ":" + local, Value: attr.Value})
+	}
+
+	attrs = append(decls, attrs...)
+	seen := make(map[string]bool, len(attrs))
+	for _, attr := range attrs {
+		if seen[attr.Name] {
+			return nil, fmt.Errorf("%w: duplicate attribute %q", ErrNotXMLSerializable, attr.Name)
+		}
+		seen[attr.Name] = true
+	}
+	return attrs, nil
+}
+
+// xmlnsPrefix reports whether attr is a namespace declaration, and returns
+// the declared prefix, "" for the default namespace. HTML elements carry
+// declarations as attributes in no namespace.
+func xmlnsPrefix(attr dom.Attribute) (string, bool) {
+	if attr.Namespace != "" && attr.Namespace != namespaceXMLNS {
+		return "", false
+	}
+	name := attr.Name
+	if attr.Namespace == namespaceXMLNS && name != "xmlns" && !strings.HasPrefix(name, "xmlns:") {
+		return name, true
+	}
+	if name == "xmlns" {
+		return "", true
+	}
+	prefix, ok := strings.CutPrefix(name, "xmlns:")
+	return prefix, ok
+}
+
+// rawTextContent returns the text of an HTML script or style element whose
+// children are all text.
+func rawTextContent(elem *dom.Element, children []dom.Node) (string, bool) {
+	if elem.Namespace != dom.NamespaceHTML || (elem.TagName != "script" && elem.TagName != "style") {
+		return "", false
+	}
+	var sb strings.Builder
+	for _, child := range children {
+		text, ok := child.(*dom.Text)
+		if !ok {
+			return "", false
+		}
+		sb.WriteString(text.Data)
+	}
+	return sb.String(), true
+}
+
+// writeXMLScript writes script or style content. Content without markup
+// characters is written as is; otherwise it goes into a CDATA section
+// whose delimiters are commented out for HTML parsers. Content containing
+// "]]>" would have to be split across two sections, which changes the text
+// HTML parsers see, so it is an error.
+func writeXMLScript(sb markupSink, tag, text string) error {
+	text = coerceXMLChars(text)
+	if strings.Contains(text, "]]>") {
+		return fmt.Errorf("%w: %s content contains \"]]>\"", ErrNotXMLSerializable, tag)
+	}
+	if !strings.ContainsAny(text, "<&") {
+		sb.WriteString(text)
+		return nil
+	}
+	if tag == "style" {
+		sb.WriteString("/*<![CDATA[*/")
+		sb.WriteString(text)
+		sb.WriteString("/*]]>*/")
+		return nil
+	}
+	sb.WriteString("//<![CDATA[\n")
+	sb.WriteString(text)
+	sb.WriteString("\n//]]>")
+	return nil
+}
+
+// writeXMLEscaped writes text or an attribute value, escaping markup
+// characters and replacing characters XML does not allow. In attribute
+// values, quotes and the whitespace characters that attribute value
+// normalization would turn into spaces are escaped as well.
+func writeXMLEscaped(sb markupSink, s string, attr bool) {
+	for _, r := range s {
+		switch {
+		case r == '&':
+			sb.WriteString("&amp;")
+		case r == '<':
+			sb.WriteString("&lt;")
+		case r == '>':
+			sb.WriteString("&gt;")
+		case attr && r == '"':
+			sb.WriteString("&quot;")
+		case attr && (r == '\t' || r == '\n' || r == '\r'):
+			sb.WriteString("&#" + strconv.Itoa(int(r)) + ";")
+		default:
+			sb.WriteRune(coerceXMLRune(r))
+		}
+	}
+}
+
+// coerceXMLChars replaces the characters of s that XML does not allow.
+func coerceXMLChars(s string) string {
+	return strings.Map(coerceXMLRune, s)
+}
+
+// coerceXMLRune replaces a form feed with a space, and characters outside
+// the XML Char production and noncharacters with U+FFFD.
+func coerceXMLRune(r rune) rune {
+	switch {
+	case r == '\f':
+		return ' '
+	case r == '\t' || r == '\n' || r == '\r':
+		return r
+	case r < 0x20, r >= 0xD800 && r <= 0xDFFF:
+		return unicode.ReplacementChar
+	case r >= 0xFDD0 && r <= 0xFDEF, r&0xFFFE == 0xFFFE:
+		return unicode.ReplacementChar
+	}
+	return r
+}
+
+// coerceXMLComment makes comment data valid in XML, where it may not
+// contain "--" or end with "-".
+func coerceXMLComment(data string) string {
+	for strings.Contains(data, "--") {
+		data = strings.ReplaceAll(data, "--", "- -")
+	}
+	if strings.HasSuffix(data, "-") {
+		data += " "
+	}
+	return data
+}
+
+// isXMLNCName reports whether s matches the NCName production of XML
+// Namespaces: an XML name without colons.
+func isXMLNCName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !isXMLNameStartChar(r) && (i == 0 || !isXMLNameChar(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isXMLNameStartChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		return true
+	case r >= 0xC0 && r <= 0xD6, r >= 0xD8 && r <= 0xF6, r >= 0xF8 && r <= 0x2FF,
+		r >= 0x370 && r <= 0x37D, r >= 0x37F && r <= 0x1FFF, r >= 0x200C && r <= 0x200D,
+		r >= 0x2070 && r <= 0x218F, r >= 0x2C00 && r <= 0x2FEF, r >= 0x3001 && r <= 0xD7FF,
+		r >= 0xF900 && r <= 0xFDCF, r >= 0xFDF0 && r <= 0xFFFD, r >= 0x10000 && r <= 0xEFFFF:
+		return true
+	}
+	return false
+}
+
+func isXMLNameChar(r rune) bool {
+	switch {
+	case r == '-', r == '.', r >= '0' && r <= '9', r == 0xB7:
+		return true
+	case r >= 0x300 && r <= 0x36F, r >= 0x203F && r <= 0x2040:
+		return true
+	}
+	return isXMLNameStartChar(r)
+}
+
+// isPubidChar reports whether r may appear in a public identifier.
+func isPubidChar(r rune) bool {
+	switch {
+	case r == ' ', r == '\r', r == '\n':
+		return true
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("-'()+,./:=?;!*#@$_%", r)
+}
@@ -0,0 +1,154 @@
+package serialize_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+)
+
+// checkWellFormed fails the test if s is not well-formed XML.
+func checkWellFormed(t *testing.T, s string) {
+	t.Helper()
+	dec := xml.NewDecoder(strings.NewReader(s))
+	dec.Strict = true
+	for {
+		_, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			t.Fatalf("output is not well-formed XML: %v\n%s", err, s)
+		}
+	}
+}
+
+func TestToXHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "document",
+			input: `<!DOCTYPE html><title>T</title><p class=a>x &amp; y<br>z<p></p>`,
+			want: `<!DOCTYPE html><html xmlns="http://www.w3.org/1999/xhtml"><head><title>T</title></head>` +
+				`<body><p class="a">x &amp; y<br />z</p><p></p></body></html>`,
+		},
+		{
+			name:  "foreign content",
+			input: `<svg viewBox="0 0 1 1"><a xlink:href="#x"><circle r=1></circle></a></svg><math><mi>x</mi></math>`,
+			want: `<html xmlns="http://www.w3.org/1999/xhtml"><head></head><body>` +
+				`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 1 1"><a xmlns:xlink="http://www.w3.org/1999/xlink" xlink:href="#x"><circle r="1"/></a></svg>` +
+				`<math xmlns="http://www.w3.org/1998/Math/MathML"><mi>x</mi></math></body></html>`,
+		},
+		{
+			name:  "existing declarations",
+			input: `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:og="http://ogp.me/ns#" lang=en><svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><use xlink:href="#a"/></svg>`,
+			want: `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:og="http://ogp.me/ns#" lang="en"><head></head><body>` +
+				`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><use xlink:href="#a"/></svg></body></html>`,
+		},
+		{
+			name:  "script",
+			input: `<script>if (a < b && c) {}</script><script>x()</script><style>a[title="<"] {}</style>`,
+			want: `<html xmlns="http://www.w3.org/1999/xhtml"><head><script>//<![CDATA[` + "\nif (a < b && c) {}\n" + `//]]></script>` +
+				`<script>x()</script><style>/*<![CDATA[*/a[title="<"] {}/*]]>*/</style></head><body></body></html>`,
+		},
+		{
+			name:  "invalid characters",
+			input: "<p title=\"a\nb\">x\fy﷐z<!-- a -- b- -->",
+			want: `<html xmlns="http://www.w3.org/1999/xhtml"><head></head><body>` +
+				`<p title="a&#10;b">x y` + "�" + `z<!-- a - - b- --></p></body></html>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serialize.ToXHTML(mustParse(t, tt.input))
+			if err != nil {
+				t.Fatalf("ToXHTML error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ToXHTML =\n%s\nwant\n%s", got, tt.want)
+			}
+			checkWellFormed(t, got)
+		})
+	}
+}
+
+func TestToXHTMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		node func() dom.Node
+	}{
+		{"element name", func() dom.Node {
+			return mustParse(t, `<p<q>x`)
+		}},
+		{"attribute name", func() dom.Node {
+			return mustParse(t, `<p "a"=b>x`)
+		}},
+		{"conflicting xmlns", func() dom.Node {
+			return mustParse(t, `<div xmlns="urn:x">x</div>`)
+		}},
+		{"CDATA end in script", func() dom.Node {
+			return mustParse(t, `<script>var s = "a]]>b";</script>`)
+		}},
+		{"CDATA end in style", func() dom.Node {
+			return mustParse(t, `<style>a[title="]]>"] {}</style>`)
+		}},
+		{"processing instruction", func() dom.Node {
+			frag := dom.NewDocumentFragment()
+			frag.AppendChild(dom.NewProcessingInstruction("xml", "version=\"1.0\""))
+			return frag
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := serialize.ToXHTML(tt.node()); !errors.Is(err, serialize.ErrNotXMLSerializable) {
+				t.Errorf("ToXHTML error = %v, want %v", err, serialize.ErrNotXMLSerializable)
+			}
+		})
+	}
+}
+
+func TestToXHTMLPolyglotRoundTrip(t *testing.T) {
+	const polyglot = `<!DOCTYPE html><html xmlns="http://www.w3.org/1999/xhtml" xml:lang="en" lang="en">` +
+		`<head><title>T</title></head><body><p xml:lang="de">x</p>` +
+		`<a xmlns:xlink="http://www.w3.org/1999/xlink" xlink:href="#x">y</a></body></html>`
+	got, err := serialize.ToXHTML(mustParse(t, polyglot))
+	if err != nil {
+		t.Fatalf("ToXHTML error: %v", err)
+	}
+	if got != polyglot {
+		t.Errorf("ToXHTML =\n%s\nwant\n%s", got, polyglot)
+	}
+	checkWellFormed(t, got)
+}
+
+func TestToXHTMLWellFormed(t *testing.T) {
+	for _, input := range prettyDocuments {
+		got, err := serialize.ToXHTML(mustParse(t, input))
+		if err != nil {
+			t.Fatalf("ToXHTML(%q) error: %v", input, err)
+		}
+		checkWellFormed(t, got)
+	}
+}
+
+func TestWriteXHTML(t *testing.T) {
+	doc := mustParse(t, writerTestHTML)
+	want, err := serialize.ToXHTML(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := serialize.WriteXHTML(&sb, doc); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != want {
+		t.Errorf("WriteXHTML = %q, want %q", sb.String(), want)
+	}
+}
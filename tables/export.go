@@ -0,0 +1,56 @@
+package tables
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+)
+
+// WriteCSV writes the grid of the table as CSV, one record per row, with
+// the text of spanning cells repeated in each slot they cover.
+func (t *Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(t.Strings()); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// jsonTable is the JSON form of a Table.
+type jsonTable struct {
+	Caption string      `json:"caption,omitempty"`
+	Rows    [][]string  `json:"rows"`
+	Cells   []*jsonCell `json:"cells"`
+}
+
+type jsonCell struct {
+	Row     int      `json:"row"`
+	Col     int      `json:"col"`
+	RowSpan int      `json:"rowSpan"`
+	ColSpan int      `json:"colSpan"`
+	Header  bool     `json:"header,omitempty"`
+	Text    string   `json:"text"`
+	Headers [][2]int `json:"headers,omitempty"`
+}
+
+// MarshalJSON encodes the table as an object with its caption, the text
+// grid as "rows", and the cells in tree order with their positions, spans
+// and the [row, col] anchors of their header cells.
+func (t *Table) MarshalJSON() ([]byte, error) {
+	out := jsonTable{Caption: t.Caption, Rows: t.Strings(), Cells: []*jsonCell{}}
+	for _, c := range t.Cells {
+		jc := &jsonCell{
+			Row:     c.Row,
+			Col:     c.Col,
+			RowSpan: c.RowSpan,
+			ColSpan: c.ColSpan,
+			Header:  c.Header,
+			Text:    c.Text,
+		}
+		for _, h := range c.Headers {
+			jc.Headers = append(jc.Headers, [2]int{h.Row, h.Col})
+		}
+		out.Cells = append(out.Cells, jc)
+	}
+	return json.Marshal(out)
+}
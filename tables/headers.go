@@ -0,0 +1,163 @@
+package tables
+
+import (
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// assignHeaders sets the header cells of the principal cell c, following
+// the "internal algorithm for scanning and assigning header cells" of the
+// HTML standard.
+func (t *Table) assignHeaders(c *Cell) {
+	var headers []*Cell
+	if ids := strings.Fields(c.Element.Attr("headers")); len(ids) > 0 {
+		for _, id := range ids {
+			for _, other := range t.Cells {
+				if other.Element.ID() == id {
+					headers = append(headers, other)
+					break
+				}
+			}
+		}
+	} else {
+		for y := c.Row; y < c.Row+c.RowSpan; y++ {
+			headers = t.scan(c, headers, c.Col, y, -1, 0)
+		}
+		for x := c.Col; x < c.Col+c.ColSpan; x++ {
+			headers = t.scan(c, headers, x, c.Row, 0, -1)
+		}
+		lastX, lastY := c.Col+c.ColSpan-1, c.Row+c.RowSpan-1
+		if c.rowGroup >= 0 {
+			for _, h := range t.Cells {
+				if h.Header && h.scope == "rowgroup" && h.rowGroup == c.rowGroup && h.Col <= lastX && h.Row <= lastY {
+					headers = append(headers, h)
+				}
+			}
+		}
+		if c.colGroup >= 0 {
+			for _, h := range t.Cells {
+				if h.Header && h.scope == "colgroup" && h.colGroup == c.colGroup && h.Col <= lastX && h.Row <= lastY {
+					headers = append(headers, h)
+				}
+			}
+		}
+	}
+
+	seen := map[*Cell]bool{c: true}
+	c.Headers = nil
+	for _, h := range headers {
+		if seen[h] || isEmpty(h) {
+			continue
+		}
+		seen[h] = true
+		c.Headers = append(c.Headers, h)
+	}
+}
+
+// scan walks from the slot (x, y) in the direction (dx, dy) and adds the
+// header cells it finds to headers. A header cell is skipped when a block
+// of header cells closer to the principal cell, separated from it by data
+// cells, already covers the same rows or columns, or when it is not a row
+// header (scanning a row) or column header (scanning a column).
+func (t *Table) scan(principal *Cell, headers []*Cell, x, y, dx, dy int) []*Cell {
+	var opaque, block []*Cell
+	inBlock := principal.Header
+	if inBlock {
+		block = append(block, principal)
+	}
+	for {
+		x += dx
+		y += dy
+		if x < 0 || y < 0 {
+			return headers
+		}
+		current := t.Cell(y, x)
+		if current == nil {
+			continue
+		}
+		if current.Header {
+			inBlock = true
+			block = append(block, current)
+			blocked := false
+			if dx == 0 {
+				for _, o := range opaque {
+					if o.Col == current.Col && o.ColSpan == current.ColSpan {
+						blocked = true
+					}
+				}
+				if !current.columnHeader {
+					blocked = true
+				}
+			} else {
+				for _, o := range opaque {
+					if o.Row == current.Row && o.RowSpan == current.RowSpan {
+						blocked = true
+					}
+				}
+				if !current.rowHeader {
+					blocked = true
+				}
+			}
+			if !blocked {
+				headers = append(headers, current)
+			}
+		} else if inBlock {
+			inBlock = false
+			opaque = append(opaque, block...)
+			block = nil
+		}
+	}
+}
+
+// classifyHeaders marks the column and row headers. A header cell is a
+// column header if its scope is col, or if it is auto and the rows it
+// covers hold no data cells. It is a row header if its scope is row, or if
+// it is auto, not a column header, and the columns it covers hold no data
+// cells.
+func (t *Table) classifyHeaders() {
+	width := 0
+	if len(t.Grid) > 0 {
+		width = len(t.Grid[0])
+	}
+	for _, h := range t.Cells {
+		if !h.Header {
+			continue
+		}
+		switch h.scope {
+		case "col":
+			h.columnHeader = true
+		case "row":
+			h.rowHeader = true
+		case "auto":
+			h.columnHeader = !t.hasDataCell(h.Row, h.Row+h.RowSpan, 0, width)
+			h.rowHeader = !h.columnHeader && !t.hasDataCell(0, len(t.Grid), h.Col, h.Col+h.ColSpan)
+		}
+	}
+}
+
+// hasDataCell reports whether any slot in rows [y0, y1) and columns
+// [x0, x1) is covered by a data cell.
+func (t *Table) hasDataCell(y0, y1, x0, x1 int) bool {
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			if c := t.Cell(y, x); c != nil && !c.Header {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isEmpty reports whether a cell has no content: no text and no elements.
+func isEmpty(c *Cell) bool {
+	if c.Text != "" {
+		return false
+	}
+	for _, child := range c.Element.Children() {
+		if _, ok := child.(*dom.Element); ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,387 @@
+// Package tables turns HTML tables into rectangular grids.
+//
+// FromElement runs the table processing model of the HTML standard over a
+// table element: it places every cell in the grid according to its rowspan
+// and colspan (including rowspan=0, which extends a cell to the end of its
+// row group), forms row and column groups, and assigns each cell its header
+// cells with the standard's algorithm, honoring the scope and headers
+// attributes.
+package tables
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/serialize"
+)
+
+// Limits of the table processing model.
+const (
+	maxColSpan = 1000
+	maxRowSpan = 65534
+)
+
+// Cell is a cell of a table.
+type Cell struct {
+	// Element is the td or th element of the cell.
+	Element *dom.Element
+
+	// Header reports whether the cell is a header cell (a th element).
+	Header bool
+
+	// Text is the text of the cell as a browser lays it out.
+	Text string
+
+	// Row and Col are the coordinates of the slot the cell is anchored at,
+	// its top-left slot.
+	Row, Col int
+
+	// RowSpan and ColSpan are the number of rows and columns the cell
+	// covers.
+	RowSpan, ColSpan int
+
+	// Headers are the header cells of the cell, from its headers attribute
+	// or from the cells above it and to its left.
+	Headers []*Cell
+
+	scope        string
+	columnHeader bool
+	rowHeader    bool
+	rowGroup     int // index into Table.rowGroups, or -1
+	colGroup     int // index into Table.colGroups, or -1
+}
+
+// span is a range of rows or columns, [start, end).
+type span struct{ start, end int }
+
+// Table is the grid of a table element.
+type Table struct {
+	// Element is the table element.
+	Element *dom.Element
+
+	// Caption is the text of the table's caption, if any.
+	Caption string
+
+	// Grid holds the cell covering each slot, indexed by row and then
+	// column. A cell spanning several slots appears in each of them. Slots
+	// that no cell covers are nil. All rows have the same length.
+	Grid [][]*Cell
+
+	// Cells lists the cells in tree order.
+	Cells []*Cell
+
+	rowGroups []span
+	colGroups []span
+}
+
+// Extract returns the tables of the HTML table elements below root,
+// including root itself, in tree order. Nested tables are returned as
+// tables of their own.
+func Extract(root dom.Node) []*Table {
+	var tables []*Table
+	dom.WalkElements(root, func(elem *dom.Element) bool {
+		if isHTML(elem, "table") {
+			tables = append(tables, FromElement(elem))
+		}
+		return true
+	})
+	return tables
+}
+
+// FromElement returns the table of a table element.
+func FromElement(table *dom.Element) *Table {
+	b := &builder{t: &Table{Element: table}, quirks: inQuirksMode(table)}
+	b.build()
+	t := b.t
+	for y, row := range t.Grid {
+		for len(row) < b.width {
+			row = append(row, nil)
+		}
+		t.Grid[y] = row
+	}
+	for len(t.Grid) < b.height {
+		t.Grid = append(t.Grid, make([]*Cell, b.width))
+	}
+	for _, c := range t.Cells {
+		c.rowGroup = t.groupOf(t.rowGroups, c.Row)
+		c.colGroup = t.groupOf(t.colGroups, c.Col)
+	}
+	t.classifyHeaders()
+	for _, c := range t.Cells {
+		t.assignHeaders(c)
+	}
+	return t
+}
+
+// Strings returns the text of each slot of the grid; a cell spanning
+// several slots is repeated in each of them, and empty slots are "".
+func (t *Table) Strings() [][]string {
+	out := make([][]string, len(t.Grid))
+	for y, row := range t.Grid {
+		out[y] = make([]string, len(row))
+		for x, c := range row {
+			if c != nil {
+				out[y][x] = c.Text
+			}
+		}
+	}
+	return out
+}
+
+// Cell returns the cell covering the slot at row y and column x, or nil.
+func (t *Table) Cell(y, x int) *Cell {
+	if y < 0 || y >= len(t.Grid) || x < 0 || x >= len(t.Grid[y]) {
+		return nil
+	}
+	return t.Grid[y][x]
+}
+
+func (t *Table) groupOf(groups []span, i int) int {
+	for g, s := range groups {
+		if s.start <= i && i < s.end {
+			return g
+		}
+	}
+	return -1
+}
+
+// builder runs the table processing model.
+type builder struct {
+	t             *Table
+	quirks        bool
+	width, height int
+	yCurrent      int
+	downwardCells []*Cell
+	captionSeen   bool
+}
+
+func (b *builder) build() {
+	var pendingFooters []*dom.Element
+	inColumns := true
+	for _, child := range dom.ChildElements(b.t.Element) {
+		if child.Namespace != dom.NamespaceHTML {
+			continue
+		}
+		switch child.TagName {
+		case "caption":
+			if !b.captionSeen {
+				b.captionSeen = true
+				b.t.Caption = strings.TrimSpace(serialize.ToText(child))
+			}
+		case "colgroup":
+			if inColumns {
+				b.columnGroup(child)
+			}
+		case "tr":
+			inColumns = false
+			b.row(child)
+		case "thead", "tbody", "tfoot":
+			inColumns = false
+			b.endRowGroup()
+			if child.TagName == "tfoot" {
+				pendingFooters = append(pendingFooters, child)
+				continue
+			}
+			b.rowGroup(child)
+		}
+	}
+	for _, tfoot := range pendingFooters {
+		b.rowGroup(tfoot)
+	}
+}
+
+// columnGroup processes a colgroup element, which widens the table by the
+// spans of its col children, or by its own span if it has none.
+func (b *builder) columnGroup(colgroup *dom.Element) {
+	start := b.width
+	cols := 0
+	for _, col := range dom.ChildElements(colgroup) {
+		if isHTML(col, "col") {
+			b.width += spanAttr(col, "span", 1, maxColSpan)
+			cols++
+		}
+	}
+	if cols == 0 {
+		b.width += spanAttr(colgroup, "span", 1, maxColSpan)
+	}
+	b.t.colGroups = append(b.t.colGroups, span{start, b.width})
+}
+
+// rowGroup processes a thead, tbody or tfoot element.
+func (b *builder) rowGroup(group *dom.Element) {
+	start := b.height
+	for _, tr := range dom.ChildElements(group) {
+		if isHTML(tr, "tr") {
+			b.row(tr)
+		}
+	}
+	if b.height > start {
+		b.t.rowGroups = append(b.t.rowGroups, span{start, b.height})
+	}
+	b.endRowGroup()
+}
+
+// endRowGroup extends the downward-growing cells to the end of the group.
+func (b *builder) endRowGroup() {
+	for b.yCurrent < b.height {
+		b.growDownward()
+		b.yCurrent++
+	}
+	b.downwardCells = nil
+}
+
+// row processes a tr element.
+func (b *builder) row(tr *dom.Element) {
+	if b.height == b.yCurrent {
+		b.height++
+	}
+	x := 0
+	b.growDownward()
+	for _, elem := range dom.ChildElements(tr) {
+		if !isHTML(elem, "td") && !isHTML(elem, "th") {
+			continue
+		}
+		for x < b.width && b.slot(b.yCurrent, x) != nil {
+			x++
+		}
+		if x == b.width {
+			b.width++
+		}
+		colspan := spanAttr(elem, "colspan", 1, maxColSpan)
+		rowspan := 1
+		if value, ok := elem.Attributes.Get("rowspan"); ok {
+			if n, ok := parseNonNegative(value); ok {
+				rowspan = min(n, maxRowSpan)
+			}
+		}
+		// rowspan=0 extends the cell to the end of its row group, except in
+		// quirks mode, where it is treated as 1.
+		growsDownward := false
+		if rowspan == 0 {
+			growsDownward = !b.quirks
+			rowspan = 1
+		}
+		b.width = max(b.width, x+colspan)
+		b.height = max(b.height, b.yCurrent+rowspan)
+		c := &Cell{
+			Element: elem,
+			Header:  elem.TagName == "th",
+			Text:    strings.TrimSpace(serialize.ToText(elem)),
+			Row:     b.yCurrent,
+			Col:     x,
+			RowSpan: rowspan,
+			ColSpan: colspan,
+			scope:   scope(elem),
+		}
+		b.t.Cells = append(b.t.Cells, c)
+		for y := b.yCurrent; y < b.yCurrent+rowspan; y++ {
+			for cx := x; cx < x+colspan; cx++ {
+				b.cover(y, cx, c)
+			}
+		}
+		if growsDownward {
+			b.downwardCells = append(b.downwardCells, c)
+		}
+		x += colspan
+	}
+	b.yCurrent++
+}
+
+// growDownward extends the downward-growing cells to the current row.
+func (b *builder) growDownward() {
+	for _, c := range b.downwardCells {
+		if b.yCurrent < c.Row+c.RowSpan {
+			continue
+		}
+		c.RowSpan = b.yCurrent - c.Row + 1
+		for x := c.Col; x < c.Col+c.ColSpan; x++ {
+			b.cover(b.yCurrent, x, c)
+		}
+	}
+}
+
+func (b *builder) slot(y, x int) *Cell {
+	if y >= len(b.t.Grid) || x >= len(b.t.Grid[y]) {
+		return nil
+	}
+	return b.t.Grid[y][x]
+}
+
+// cover makes c cover the slot at (y, x). Slots already covered by another
+// cell, a table model error, keep that cell.
+func (b *builder) cover(y, x int, c *Cell) {
+	for len(b.t.Grid) <= y {
+		b.t.Grid = append(b.t.Grid, nil)
+	}
+	row := b.t.Grid[y]
+	for len(row) <= x {
+		row = append(row, nil)
+	}
+	if row[x] == nil {
+		row[x] = c
+	}
+	b.t.Grid[y] = row
+}
+
+// spanAttr parses a colspan or span attribute: missing, invalid and zero
+// values give def, and large values are clamped to limit.
+func spanAttr(elem *dom.Element, name string, def, limit int) int {
+	value, ok := elem.Attributes.Get(name)
+	if !ok {
+		return def
+	}
+	n, ok := parseNonNegative(value)
+	if !ok || n == 0 {
+		return def
+	}
+	return min(n, limit)
+}
+
+// parseNonNegative parses the leading digits of s, after leading
+// whitespace and an optional "+", as the HTML rules for non-negative
+// integers do.
+func parseNonNegative(s string) (int, bool) {
+	s = strings.TrimLeft(s, " \t\n\f\r")
+	s = strings.TrimPrefix(s, "+")
+	end := 0
+	for end < len(s) && '0' <= s[end] && s[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[:end])
+	if err != nil {
+		// Too many digits: larger than any limit.
+		return maxRowSpan, true
+	}
+	return n, true
+}
+
+// scope returns the state of the scope attribute of a th element: "row",
+// "col", "rowgroup", "colgroup" or "auto".
+func scope(elem *dom.Element) string {
+	if elem.TagName != "th" {
+		return ""
+	}
+	s := strings.ToLower(strings.TrimSpace(elem.Attr("scope")))
+	switch s {
+	case "row", "col", "rowgroup", "colgroup":
+		return s
+	}
+	return "auto"
+}
+
+func inQuirksMode(n dom.Node) bool {
+	for ; n != nil; n = n.Parent() {
+		if doc, ok := n.(*dom.Document); ok {
+			return doc.QuirksMode == dom.Quirks
+		}
+	}
+	return false
+}
+
+func isHTML(e *dom.Element, tag string) bool {
+	return e.Namespace == dom.NamespaceHTML && e.TagName == tag
+}
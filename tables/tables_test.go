@@ -0,0 +1,218 @@
+package tables_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/tables"
+)
+
+func mustParse(t *testing.T, html string) *dom.Document {
+	t.Helper()
+	doc, err := JustGoHTML.Parse(html)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", html, err)
+	}
+	return doc
+}
+
+func onlyTable(t *testing.T, html string) *tables.Table {
+	t.Helper()
+	ts := tables.Extract(mustParse(t, "<!DOCTYPE html>"+html))
+	if len(ts) == 0 {
+		t.Fatalf("no table in %q", html)
+	}
+	return ts[0]
+}
+
+// gridString renders the grid with one line per row and "|" between slots.
+func gridString(tbl *tables.Table) string {
+	var lines []string
+	for _, row := range tbl.Strings() {
+		lines = append(lines, strings.Join(row, "|"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func headerTexts(c *tables.Cell) []string {
+	var out []string
+	for _, h := range c.Headers {
+		out = append(out, h.Text)
+	}
+	return out
+}
+
+func TestGrid(t *testing.T) {
+	tests := []struct {
+		name, html, want string
+	}{
+		{
+			"plain",
+			`<table><tr><td>a<td>b<tr><td>c<td>d</table>`,
+			"a|b\nc|d",
+		},
+		{
+			"colspan and rowspan",
+			`<table><tr><td rowspan=2>a<td colspan=2>b<tr><td>c<td>d<tr><td>e</table>`,
+			"a|b|b\na|c|d\ne||",
+		},
+		{
+			"ragged rows",
+			`<table><tr><td>a<tr><td>b<td>c<td>d</table>`,
+			"a||\nb|c|d",
+		},
+		{
+			"rowspan zero ends with the row group",
+			`<table><tbody><tr><td rowspan=0>a<td>b<tr><td>c<tr><td>d</tbody><tbody><tr><td>e<td>f</tbody></table>`,
+			"a|b\na|c\na|d\ne|f",
+		},
+		{
+			"footer moves to the end",
+			`<table><tfoot><tr><td>foot</tfoot><tbody><tr><td>body</tbody><thead><tr><td>head</thead></table>`,
+			"body\nhead\nfoot",
+		},
+		{
+			"overlapping cells keep the first",
+			`<table><tr><td>a<td rowspan=2>b<tr><td colspan=2>c<td>d</table>`,
+			"a|b|\nc|b|d",
+		},
+		{
+			"invalid and clamped spans",
+			`<table><tr><td colspan=0>a<td colspan="+2x">b<td rowspan=x>c</table>`,
+			"a|b|b|c",
+		},
+		{
+			"colgroup widens the table",
+			`<table><colgroup span=2></colgroup><colgroup><col><col span=2></colgroup><tr><td>a</table>`,
+			"a||||",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gridString(onlyTable(t, tt.html)); got != tt.want {
+				t.Errorf("grid =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRowSpanZeroInQuirksMode(t *testing.T) {
+	ts := tables.Extract(mustParse(t, `<table><tr><td rowspan=0>a<td>b<tr><td>c</table>`))
+	if got, want := gridString(ts[0]), "a|b\nc|"; got != want {
+		t.Errorf("grid =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCells(t *testing.T) {
+	tbl := onlyTable(t, `<table><caption> Sales <b>2024</b></caption><tr><th>x<td colspan=2 rowspan=3>big</table>`)
+	if tbl.Caption != "Sales 2024" {
+		t.Errorf("Caption = %q", tbl.Caption)
+	}
+	if len(tbl.Cells) != 2 {
+		t.Fatalf("got %d cells", len(tbl.Cells))
+	}
+	big := tbl.Cells[1]
+	if big.Row != 0 || big.Col != 1 || big.RowSpan != 3 || big.ColSpan != 2 || big.Header {
+		t.Errorf("big cell = %+v", big)
+	}
+	if tbl.Cell(2, 2) != big || tbl.Cell(2, 0) != nil || tbl.Cell(5, 0) != nil {
+		t.Errorf("Cell lookups are wrong")
+	}
+	if !tbl.Cells[0].Header {
+		t.Errorf("th is not a header cell")
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	tbl := onlyTable(t, `<table>
+<thead><tr><th><th>Q1<th>Q2</thead>
+<tbody>
+<tr><th>North<td>1<td>2
+<tr><th>South<td>3<td>4
+</tbody></table>`)
+	cases := map[[2]int][]string{
+		{1, 1}: {"North", "Q1"},
+		{2, 2}: {"South", "Q2"},
+		{1, 0}: nil,
+		{0, 1}: nil,
+	}
+	for pos, want := range cases {
+		if got := headerTexts(tbl.Cell(pos[0], pos[1])); !slices.Equal(got, want) {
+			t.Errorf("headers of %v = %q, want %q", pos, got, want)
+		}
+	}
+}
+
+func TestHeadersScopeAndAttribute(t *testing.T) {
+	tbl := onlyTable(t, `<table>
+<tr><th id=h1 scope=col>Name<th id=h2 scope=col>Value<th id=h3>Unused
+<tr><th scope=row>a<td>1<td headers="h1 h3 h1 missing">explicit
+<tbody><tr><th scope=rowgroup>Group<td>x<td>y<tr><td>z<td>w<td>v</tbody>
+</table>`)
+	if got, want := headerTexts(tbl.Cell(1, 1)), []string{"a", "Value"}; !slices.Equal(got, want) {
+		t.Errorf("scoped headers = %q, want %q", got, want)
+	}
+	if got, want := headerTexts(tbl.Cell(1, 2)), []string{"Name", "Unused"}; !slices.Equal(got, want) {
+		t.Errorf("headers attribute = %q, want %q", got, want)
+	}
+	if got := headerTexts(tbl.Cell(3, 2)); !slices.Contains(got, "Group") {
+		t.Errorf("row group header missing from %q", got)
+	}
+}
+
+func TestHeadersBlockedByOpaqueHeaders(t *testing.T) {
+	// The second header row is separated from the data by a data row, so
+	// only the nearest header block applies.
+	tbl := onlyTable(t, `<table>
+<tr><th>Far
+<tr><td>data
+<tr><th>Near
+<tr><td>x
+</table>`)
+	if got, want := headerTexts(tbl.Cell(3, 0)), []string{"Near"}; !slices.Equal(got, want) {
+		t.Errorf("headers = %q, want %q", got, want)
+	}
+}
+
+func TestExtractNested(t *testing.T) {
+	ts := tables.Extract(mustParse(t, `<table><tr><td>outer<table><tr><td>inner</table></table><div><table><tr><td>second</table></div>`))
+	var got []string
+	for _, tbl := range ts {
+		got = append(got, fmt.Sprint(tbl.Strings()))
+	}
+	want := []string{"[[outer\ninner]]", "[[inner]]", "[[second]]"}
+	if !slices.Equal(got, want) {
+		t.Errorf("tables = %q, want %q", got, want)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	tbl := onlyTable(t, `<table><tr><th colspan=2>Name, full<tr><td>"A"<td>b</table>`)
+	var buf bytes.Buffer
+	if err := tbl.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "\"Name, full\",\"Name, full\"\n\"\"\"A\"\"\",b\n"; got != want {
+		t.Errorf("CSV = %q, want %q", got, want)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	tbl := onlyTable(t, `<table><caption>c</caption><tr><th>h<tr><td>d</table>`)
+	data, err := json.Marshal(tbl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"caption":"c","rows":[["h"],["d"]],"cells":[` +
+		`{"row":0,"col":0,"rowSpan":1,"colSpan":1,"header":true,"text":"h"},` +
+		`{"row":1,"col":0,"rowSpan":1,"colSpan":1,"text":"d","headers":[[0,0]]}]}`
+	if string(data) != want {
+		t.Errorf("JSON =\n%s\nwant\n%s", data, want)
+	}
+}
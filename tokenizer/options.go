@@ -11,6 +11,14 @@ type Options struct {
 	// - Some non-XML characters become U+FFFD
 	// - Comments replace "--" with "- -"
 	XMLCoercion bool
+
+	// PreserveCDATA emits the content of CDATA sections in foreign content as
+	// CDATA tokens instead of Character tokens, keeping section boundaries.
+	PreserveCDATA bool
+
+	// Scripting tokenizes the content of noscript elements as raw text, as a
+	// browser with scripting enabled does.
+	Scripting bool
 }
 
 func defaultOptions() Options {
@@ -144,8 +144,15 @@ type Tokenizer struct {
 	line   int
 	column int
 
+	// prevLine and prevColumn are the position before the last character
+	// getChar returned, which reconsuming it restores.
+	prevLine   int
+	prevColumn int
+
 	// Current tag token being built.
 	currentTagKind        TokenKind
+	currentTagLine        int
+	currentTagColumn      int
 	currentTagName        []rune
 	currentTagAttrs       []Attr
 	currentTagAttrIndex   map[string]struct{}
@@ -227,6 +234,7 @@ func (t *Tokenizer) reset(input string) {
 	t.ignoreLF = false
 	t.line = 1
 	t.column = 0
+	t.prevLine, t.prevColumn = 1, 0
 	t.textMode = t.state
 
 	t.currentTagKind = StartTag
@@ -278,6 +286,16 @@ func (t *Tokenizer) SetXMLCoercion(enabled bool) {
 	t.opts.XMLCoercion = enabled
 }
 
+// SetPreserveCDATA enables/disables emitting CDATA sections as CDATA tokens.
+func (t *Tokenizer) SetPreserveCDATA(enabled bool) {
+	t.opts.PreserveCDATA = enabled
+}
+
+// SetScripting enables/disables tokenizing noscript content as raw text.
+func (t *Tokenizer) SetScripting(enabled bool) {
+	t.opts.Scripting = enabled
+}
+
 // SetAllowCDATA toggles CDATA section parsing for foreign content.
 func (t *Tokenizer) SetAllowCDATA(enabled bool) {
 	t.allowCDATA = enabled
@@ -354,6 +372,7 @@ func (t *Tokenizer) getChar() (rune, bool) {
 			return 0, false
 		}
 		t.pos--
+		t.line, t.column = t.prevLine, t.prevColumn
 	}
 
 	for {
@@ -396,6 +415,7 @@ func (t *Tokenizer) peek(offset int) (rune, bool) {
 }
 
 func (t *Tokenizer) advance(c rune) {
+	t.prevLine, t.prevColumn = t.line, t.column
 	if c == '\n' {
 		t.line++
 		t.column = 0
@@ -469,9 +489,23 @@ func (t *Tokenizer) flushText() {
 	// Update hint for next text node (use previous size as estimate).
 	t.textBufferHint = textLen
 
+	if t.textMode == CDATASectionState && t.opts.PreserveCDATA {
+		t.emit(Token{Type: CDATA, Data: data})
+		return
+	}
 	t.emit(Token{Type: Character, Data: data})
 }
 
+// flushCDATA ends a CDATA section. With PreserveCDATA, empty sections still
+// produce a CDATA token so that their boundaries survive.
+func (t *Tokenizer) flushCDATA() {
+	if t.opts.PreserveCDATA && t.textBuffer.Len() == 0 {
+		t.emit(Token{Type: CDATA})
+		return
+	}
+	t.flushText()
+}
+
 func (t *Tokenizer) finishAttribute() {
 	if len(t.currentAttrName) == 0 {
 		return
@@ -525,6 +559,8 @@ func (t *Tokenizer) emitCurrentTag() bool {
 		Name:        name,
 		Attrs:       attrs,
 		SelfClosing: t.currentTagSelfClosing,
+		Line:        t.currentTagLine,
+		Column:      t.currentTagColumn,
 	}
 
 	// Tokenizer-side state switching for rawtext/rcdata elements.
@@ -554,6 +590,13 @@ func (t *Tokenizer) emitCurrentTag() bool {
 			t.textMode = PLAINTEXTState
 			t.rawtextTagName = name
 			switchedTextMode = true
+		case "noscript":
+			if t.opts.Scripting {
+				t.state = RAWTEXTState
+				t.textMode = RAWTEXTState
+				t.rawtextTagName = name
+				switchedTextMode = true
+			}
 		}
 	}
 
@@ -662,6 +705,13 @@ func (t *Tokenizer) stateData() {
 
 func (t *Tokenizer) startTag(kind TokenKind, first rune) {
 	t.currentTagKind = kind
+	// The first letter of the name was just consumed; the "<" is before it,
+	// or before the "/" of an end tag.
+	t.currentTagLine = t.line
+	t.currentTagColumn = max(1, t.column-1)
+	if kind == EndTag {
+		t.currentTagColumn = max(1, t.column-2)
+	}
 	t.currentTagName = t.currentTagName[:0]
 	t.currentTagAttrs = t.currentTagAttrs[:0]
 	// Return old map to pool; map is allocated on demand.
@@ -1778,7 +1828,8 @@ func (t *Tokenizer) stateCDATASection() {
 	c, ok := t.getChar()
 	if !ok {
 		t.emitError("eof-in-cdata")
-		t.emitEOF()
+		t.flushCDATA()
+		t.emit(Token{Type: EOF})
 		return
 	}
 	if c == ']' {
@@ -1808,7 +1859,7 @@ func (t *Tokenizer) stateCDATASectionBracket() {
 func (t *Tokenizer) stateCDATASectionEnd() {
 	c, ok := t.getChar()
 	if ok && c == '>' {
-		t.flushText()
+		t.flushCDATA()
 		t.state = DataState
 		return
 	}
@@ -1903,6 +1954,7 @@ func (t *Tokenizer) stateRCDATAEndTagName() {
 			if ok && (c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f') {
 				t.flushText()
 				t.currentTagKind = EndTag
+				t.currentTagLine, t.currentTagColumn = 0, 0
 				t.currentTagName = []rune(tagName)
 				t.currentTagAttrs = t.currentTagAttrs[:0]
 				putAttrMap(t.currentTagAttrIndex)
@@ -1913,6 +1965,7 @@ func (t *Tokenizer) stateRCDATAEndTagName() {
 			if ok && c == '/' {
 				t.flushText()
 				t.currentTagKind = EndTag
+				t.currentTagLine, t.currentTagColumn = 0, 0
 				t.currentTagName = []rune(tagName)
 				t.currentTagAttrs = t.currentTagAttrs[:0]
 				putAttrMap(t.currentTagAttrIndex)
@@ -2038,6 +2091,7 @@ func (t *Tokenizer) stateRAWTEXTEndTagName() {
 			if ok && (c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f') {
 				t.flushText()
 				t.currentTagKind = EndTag
+				t.currentTagLine, t.currentTagColumn = 0, 0
 				t.currentTagName = []rune(tagName)
 				t.currentTagAttrs = t.currentTagAttrs[:0]
 				putAttrMap(t.currentTagAttrIndex)
@@ -2048,6 +2102,7 @@ func (t *Tokenizer) stateRAWTEXTEndTagName() {
 			if ok && c == '/' {
 				t.flushText()
 				t.currentTagKind = EndTag
+				t.currentTagLine, t.currentTagColumn = 0, 0
 				t.currentTagName = []rune(tagName)
 				t.currentTagAttrs = t.currentTagAttrs[:0]
 				putAttrMap(t.currentTagAttrIndex)
@@ -2216,6 +2271,7 @@ func (t *Tokenizer) stateScriptDataEscapedEndTagName() {
 			if ok && (c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f') {
 				t.flushText()
 				t.currentTagKind = EndTag
+				t.currentTagLine, t.currentTagColumn = 0, 0
 				t.currentTagName = []rune(tagName)
 				t.currentTagAttrs = t.currentTagAttrs[:0]
 				putAttrMap(t.currentTagAttrIndex)
@@ -2226,6 +2282,7 @@ func (t *Tokenizer) stateScriptDataEscapedEndTagName() {
 			if ok && c == '/' {
 				t.flushText()
 				t.currentTagKind = EndTag
+				t.currentTagLine, t.currentTagColumn = 0, 0
 				t.currentTagName = []rune(tagName)
 				t.currentTagAttrs = t.currentTagAttrs[:0]
 				putAttrMap(t.currentTagAttrIndex)
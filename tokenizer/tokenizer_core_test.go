@@ -61,6 +61,43 @@ func TestTokenizer_XMLCoercion(t *testing.T) {
 	}
 }
 
+func TestTokenizer_PreserveCDATA(t *testing.T) {
+	tok := New("a<![CDATA[x<y]]><![CDATA[]]>b")
+	tok.SetAllowCDATA(true)
+	tok.SetPreserveCDATA(true)
+	var tokens []Token
+	for {
+		tt := tok.Next()
+		if tt.Type == EOF {
+			break
+		}
+		tokens = append(tokens, tt)
+	}
+	want := []Token{
+		{Type: Character, Data: "a"},
+		{Type: CDATA, Data: "x<y"},
+		{Type: CDATA, Data: ""},
+		{Type: Character, Data: "b"},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("tokens = %#v, want %d tokens", tokens, len(want))
+	}
+	for i := range want {
+		if tokens[i].Type != want[i].Type || tokens[i].Data != want[i].Data {
+			t.Errorf("token %d = %v(%q), want %v(%q)", i, tokens[i].Type, tokens[i].Data, want[i].Type, want[i].Data)
+		}
+	}
+}
+
+func TestTokenizer_CDATAWithoutPreserve(t *testing.T) {
+	tok := New("<![CDATA[x]]>")
+	tok.SetAllowCDATA(true)
+	tt := tok.Next()
+	if tt.Type != Character || tt.Data != "x" {
+		t.Fatalf("token = %v(%q), want Character(x)", tt.Type, tt.Data)
+	}
+}
+
 func TestTokenizer_NullInAttrNameAndValue(t *testing.T) {
 	tokens := collectTokens("<div a\u0000b='b\u0000c'>", DataState)
 	if len(tokens) != 1 || tokens[0].Type != StartTag {
@@ -100,3 +137,26 @@ func TestTokenizer_SwitchToRCDATAForTitle(t *testing.T) {
 		t.Fatalf("data = %q, want entity-decoded text", datas[1])
 	}
 }
+
+func TestTokenizer_TagPositions(t *testing.T) {
+	tokens := collectTokens("<p a=1 b=x>t</p>\r\n<br/><title>a</title>", DataState)
+	type pos struct {
+		name         string
+		line, column int
+	}
+	var got []pos
+	for _, tok := range tokens {
+		if tok.Type == StartTag || tok.Type == EndTag {
+			got = append(got, pos{tok.Name, tok.Line, tok.Column})
+		}
+	}
+	want := []pos{{"p", 1, 1}, {"p", 1, 13}, {"br", 2, 1}, {"title", 2, 6}, {"title", 0, 0}}
+	if len(got) != len(want) {
+		t.Fatalf("tag positions = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("tag %d position = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -30,6 +30,11 @@ const (
 
 	// EOF indicates end of input.
 	EOF
+
+	// CDATA represents the content of a CDATA section in foreign content. It
+	// is only produced when Options.PreserveCDATA is set; otherwise CDATA
+	// sections are emitted as Character tokens.
+	CDATA
 )
 
 // String returns the name of the token kind.
@@ -42,6 +47,7 @@ func (t TokenKind) String() string {
 		"Comment",
 		"Character",
 		"EOF",
+		"CDATA",
 	}
 	if t >= 0 && int(t) < len(names) {
 		return names[t]
@@ -83,7 +89,7 @@ type Token struct {
 	// Name is the tag name for StartTag/EndTag, or DOCTYPE name.
 	Name string
 
-	// Data is the content for Comment or Character tokens.
+	// Data is the content for Comment, Character or CDATA tokens.
 	Data string
 
 	// Attrs holds attributes for StartTag tokens.
@@ -106,6 +112,10 @@ type Token struct {
 
 	// CommentEOF indicates a bogus comment ended at EOF.
 	CommentEOF bool
+
+	// Line and Column are the 1-based position of the "<" that starts a
+	// StartTag or EndTag token, or 0 where it is not known.
+	Line, Column int
 }
 
 // Attr represents an HTML attribute.
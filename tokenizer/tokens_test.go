@@ -14,6 +14,7 @@ func TestTokenKindString(t *testing.T) {
 		{Comment, "Comment"},
 		{Character, "Character"},
 		{EOF, "EOF"},
+		{CDATA, "CDATA"},
 		{TokenKind(-1), "Unknown"},
 		{TokenKind(123), "Unknown"},
 	}
@@ -220,10 +220,16 @@ func (tb *TreeBuilder) insertFosterNode(node dom.Node) {
 }
 
 func newFormattingElement(entry formattingEntry) *dom.Element {
+	var el *dom.Element
 	if entry.node != nil && entry.node.Namespace != dom.NamespaceHTML {
-		return dom.NewElementNS(entry.node.TagName, entry.node.Namespace)
+		el = dom.NewElementNS(entry.node.TagName, entry.node.Namespace)
+	} else {
+		el = dom.NewElement(entry.name)
 	}
-	return dom.NewElement(entry.name)
+	if entry.node != nil {
+		el.SetPosition(entry.node.Position())
+	}
+	return el
 }
 
 func (tb *TreeBuilder) indexOfOpenElement(target *dom.Element) (int, bool) {
@@ -50,7 +50,20 @@ type TreeBuilder struct {
 
 	ignoreLeadingLF bool
 
+	// tagName, tagLine and tagColumn describe the start tag being
+	// processed, which the element created for it records.
+	tagName            string
+	tagLine, tagColumn int
+
 	iframeSrcdoc bool
+
+	// scripting parses noscript content as raw text, as a browser with
+	// scripting enabled does.
+	scripting bool
+
+	// processingInstructions turns "<?...>" bogus comments in foreign content
+	// into ProcessingInstruction nodes.
+	processingInstructions bool
 }
 
 // New creates a new tree builder for full document parsing.
@@ -160,6 +173,25 @@ func (tb *TreeBuilder) SetIframeSrcdoc(enabled bool) {
 	tb.iframeSrcdoc = enabled
 }
 
+// SetScripting toggles the scripting flag, which makes noscript a raw text
+// element. The tokenizer must have scripting enabled as well.
+func (tb *TreeBuilder) SetScripting(enabled bool) {
+	tb.scripting = enabled
+	ctx := tb.fragmentContext
+	if enabled && ctx != nil && (ctx.Namespace == "" || ctx.Namespace == "html") &&
+		strings.EqualFold(ctx.TagName, "noscript") {
+		tb.tokenizer.SetLastStartTag("noscript")
+		tb.tokenizer.SetState(tokenizer.RAWTEXTState)
+	}
+}
+
+// SetProcessingInstructions toggles creating ProcessingInstruction nodes for
+// "<?target data?>" markup in foreign content, which otherwise becomes a
+// comment as the HTML standard requires.
+func (tb *TreeBuilder) SetProcessingInstructions(enabled bool) {
+	tb.processingInstructions = enabled
+}
+
 // Document returns the constructed document.
 func (tb *TreeBuilder) Document() *dom.Document {
 	return tb.document
@@ -195,8 +227,26 @@ func (tb *TreeBuilder) FragmentNodes() []*dom.Element {
 	return out
 }
 
+// FragmentChildNodes returns all of the fragment's top-level nodes, including
+// text and comments.
+func (tb *TreeBuilder) FragmentChildNodes() []dom.Node {
+	root := tb.fragmentElement
+	if root == nil {
+		root = tb.fragmentRoot
+	}
+	if root == nil {
+		return nil
+	}
+	return append([]dom.Node(nil), root.Children()...)
+}
+
 // ProcessToken consumes a tokenizer token and updates the DOM tree.
 func (tb *TreeBuilder) ProcessToken(tok tokenizer.Token) {
+	if tok.Type == tokenizer.StartTag {
+		tb.tagName, tb.tagLine, tb.tagColumn = tok.Name, tok.Line, tok.Column
+	} else {
+		tb.tagName = ""
+	}
 	// The full HTML5 algorithm is implemented incrementally; keep the current
 	// behavior non-panicking and deterministic.
 	for {
@@ -209,6 +259,11 @@ func (tb *TreeBuilder) ProcessToken(tok tokenizer.Token) {
 				return
 			}
 		}
+		// CDATA tokens are only meaningful in foreign content; anywhere else
+		// their content is ordinary character data.
+		if tok.Type == tokenizer.CDATA && (tb.forceHTMLMode || !tb.shouldUseForeignContent(tok)) {
+			tok.Type = tokenizer.Character
+		}
 		// Check if we should use foreign content rules.
 		// forceHTMLMode bypasses this check when reprocessing a token that
 		// triggered breakout from foreign content.
@@ -364,11 +419,41 @@ func (tb *TreeBuilder) insertElement(name string, attrs []tokenizer.Attr) *dom.E
 		}
 		el.SetAttr(a.Name, a.Value)
 	}
+	tb.setPosition(el)
 	tb.insertNode(el, nil)
+	tb.associateWithForm(el)
 	tb.openElements = append(tb.openElements, el)
 	return el
 }
 
+// setPosition records the source position of the start tag being processed
+// on the element created for it. Elements the parser implies, such as a
+// tbody for a tr start tag, have no position.
+func (tb *TreeBuilder) setPosition(el *dom.Element) {
+	if tb.tagName != "" && strings.EqualFold(el.TagName, tb.tagName) {
+		el.SetPosition(tb.tagLine, tb.tagColumn)
+	}
+}
+
+// associateWithForm associates a form-associated element with the form
+// element pointer, as "create an element for a token" does, so that
+// controls the tree construction moves out of their form still belong to it.
+func (tb *TreeBuilder) associateWithForm(el *dom.Element) {
+	if tb.formElement == nil || tb.elementInStack("template") {
+		return
+	}
+	switch el.TagName {
+	case "button", "fieldset", "input", "object", "output", "select", "textarea":
+		if el.HasAttr("form") {
+			return
+		}
+	case "img":
+	default:
+		return
+	}
+	el.SetFormOwner(tb.formElement)
+}
+
 func (tb *TreeBuilder) insertElementUnderHTML(name string, attrs []tokenizer.Attr) *dom.Element {
 	el := dom.NewElement(name)
 	if el.TagName == "template" && el.Namespace == dom.NamespaceHTML && el.TemplateContent == nil {
@@ -382,6 +467,8 @@ func (tb *TreeBuilder) insertElementUnderHTML(name string, attrs []tokenizer.Att
 		el.SetAttr(a.Name, a.Value)
 	}
 
+	tb.setPosition(el)
+
 	var parent dom.Node
 	if docEl := tb.document.DocumentElement(); docEl != nil {
 		parent = docEl
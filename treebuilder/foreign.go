@@ -70,7 +70,20 @@ func (tb *TreeBuilder) processForeignContent(tok tokenizer.Token) bool {
 		}
 		tb.insertText(data)
 		return false
+	case tokenizer.CDATA:
+		data := strings.ReplaceAll(tok.Data, "\x00", string('\uFFFD'))
+		if !isAllWhitespaceIgnoringNull(tok.Data) {
+			tb.framesetOK = false
+		}
+		tb.insertNode(dom.NewCDATASection(data), nil)
+		return false
 	case tokenizer.Comment:
+		if tb.processingInstructions {
+			if pi := processingInstructionFromComment(tok.Data); pi != nil {
+				tb.insertNode(pi, nil)
+				return false
+			}
+		}
 		// html5lib tree-construction expects <![CDATA[...]]> in foreign content to
 		// produce character data, not a comment node.
 		if strings.HasPrefix(tok.Data, "[CDATA[") {
@@ -102,7 +115,7 @@ func (tb *TreeBuilder) processForeignContent(tok tokenizer.Token) bool {
 			adjustedName = adjustSVGTagName(tok.Name)
 		}
 		switch nameLower {
-		case "title", "textarea", "script", "style", "xmp", "iframe", "noembed", "noframes", "plaintext":
+		case "title", "textarea", "script", "style", "xmp", "iframe", "noembed", "noframes", "noscript", "plaintext":
 			if namespace != dom.NamespaceHTML {
 				tb.tokenizer.SetLastStartTag("")
 				tb.tokenizer.SetState(tokenizer.DataState)
@@ -216,6 +229,25 @@ func (tb *TreeBuilder) isMathMLTextIntegrationPoint(node *dom.Element) bool {
 	return constants.MathMLTextIntegrationPoints[ip]
 }
 
+// processingInstructionFromComment converts the data of a bogus comment
+// produced by "<?target data?>" markup into a ProcessingInstruction. It
+// returns nil if the comment does not start with "?" followed by a target.
+func processingInstructionFromComment(data string) *dom.ProcessingInstruction {
+	if !strings.HasPrefix(data, "?") {
+		return nil
+	}
+	body := strings.TrimSuffix(data[1:], "?")
+	end := strings.IndexAny(body, "\t\n\f\r ")
+	if end < 0 {
+		end = len(body)
+	}
+	target := body[:end]
+	if target == "" {
+		return nil
+	}
+	return dom.NewProcessingInstruction(target, strings.TrimLeft(body[end:], "\t\n\f\r "))
+}
+
 func foreignBreakoutFont(attrs []tokenizer.Attr) bool {
 	for _, a := range attrs {
 		if a.Namespace != "" {
@@ -286,6 +318,7 @@ func (tb *TreeBuilder) insertForeignElement(name, namespace string, attrs []dom.
 	for _, a := range attrs {
 		el.Attributes.SetNS(a.Namespace, a.Name, a.Value)
 	}
+	tb.setPosition(el)
 	tb.insertNode(el, nil)
 	if !selfClosing {
 		tb.openElements = append(tb.openElements, el)
@@ -59,3 +59,47 @@ func TestForeignContent_HTMLIntegrationPoint_ForeignObject(t *testing.T) {
 		t.Fatalf("tree mismatch\ngot:\n%s\n\nwant:\n%s", got, want)
 	}
 }
+
+func TestForeignContent_XMLNodes(t *testing.T) {
+	input := `<svg>a<![CDATA[b<c]]>d<?xml-stylesheet href="s.css"?><![CDATA[]]></svg><?php echo 1 ?><p><![CDATA[x]]>`
+
+	doc, err := JustGoHTML.Parse(input, JustGoHTML.WithXMLNodes())
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	got := testutil.SerializeHTML5LibTree(doc)
+	want := `| <html>
+|   <head>
+|   <body>
+|     <svg svg>
+|       "a"
+|       <![CDATA[b<c]]>
+|       "d"
+|       <?xml-stylesheet href="s.css">
+|       <![CDATA[]]>
+|     <!-- ?php echo 1 ? -->
+|     <p>
+|       <!-- [CDATA[x]] -->`
+	if got != want {
+		t.Fatalf("tree mismatch\ngot:\n%s\n\nwant:\n%s", got, want)
+	}
+
+	// Without the option the same input follows the standard.
+	doc, err = JustGoHTML.Parse(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	got = testutil.SerializeHTML5LibTree(doc)
+	want = `| <html>
+|   <head>
+|   <body>
+|     <svg svg>
+|       "ab<cd"
+|       <!-- ?xml-stylesheet href="s.css"? -->
+|     <!-- ?php echo 1 ? -->
+|     <p>
+|       <!-- [CDATA[x]] -->`
+	if got != want {
+		t.Fatalf("default tree mismatch\ngot:\n%s\n\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package treebuilder_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+func TestTreeBuilder_FormAssociation(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string // names of the controls of the first form
+	}{
+		{"descendants", `<form><input name=a><p><select name=b></select></p></form><input name=c>`, []string{"a", "b"}},
+		{"form in table", `<table><form><tr><td><input name=a></td></tr><input type=hidden name=b></table>`, []string{"a", "b"}},
+		{"form closed by ancestor", `<div><form><input name=a></div><input name=b><textarea name=c></textarea>`, []string{"a", "b", "c"}},
+		{"end tag resets pointer", `<div><form></form></div><input name=a>`, nil},
+		{"form attribute wins", `<form id=f1><input name=a form=f2></form><form id=f2><input name=b></form>`, nil},
+		{"template", `<div><form></div><template><input name=a></template>`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := JustGoHTML.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			forms := doc.Forms()
+			if len(forms) == 0 {
+				t.Fatal("no forms")
+			}
+			var got []string
+			for _, el := range forms[0].Elements() {
+				got = append(got, el.Attr("name"))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("controls = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTreeBuilder_FormAssociationImg(t *testing.T) {
+	doc, err := JustGoHTML.Parse(`<div><form></div><img src=x.png>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	img, _ := doc.QueryFirst("img")
+	form, _ := doc.QueryFirst("form")
+	if img.Form() != form {
+		t.Errorf("img form owner = %v, want the form", img.Form())
+	}
+	if _, ok := img.Parent().(*dom.Element); !ok || img.Parent() == dom.Node(form) {
+		t.Errorf("img should be outside the form")
+	}
+}
+
+func TestTreeBuilder_FormAssociationReset(t *testing.T) {
+	doc, err := JustGoHTML.Parse(`<div><form id=f></div><p><input name=a></p>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	form, _ := doc.QueryFirst("form")
+	p, _ := doc.QueryFirst("p")
+	input, _ := doc.QueryFirst("input")
+	if input.Form() != form {
+		t.Fatalf("input form owner = %v, want the form", input.Form())
+	}
+
+	// Moving an ancestor of the input into another form resets its owner.
+	other := dom.NewElement("form")
+	doc.Body().AppendChild(other)
+	other.AppendChild(p)
+	if input.Form() != other {
+		t.Errorf("moved input form owner = %v, want the other form", input.Form())
+	}
+	other.RemoveChild(p)
+	if input.Form() != nil {
+		t.Errorf("removed input form owner = %v, want nil", input.Form())
+	}
+}
@@ -144,6 +144,9 @@ func (tb *TreeBuilder) reconstructActiveFormattingElements() {
 	for index < len(tb.activeFormatting) {
 		entry := tb.activeFormatting[index]
 		el := tb.insertElement(entry.name, cloneTokenAttrs(entry.attrs))
+		if entry.node != nil {
+			el.SetPosition(entry.node.Position())
+		}
 		tb.activeFormatting[index].node = el
 		index++
 	}
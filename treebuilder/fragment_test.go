@@ -32,3 +32,29 @@ func TestNewFragment_ContextElementNamespace(t *testing.T) {
 		t.Fatalf("mathml context element tag = %q, want %q", tbMath.fragmentElement.TagName, "mi")
 	}
 }
+
+func TestFragmentChildNodes(t *testing.T) {
+	tok := tokenizer.New("text<b>bold</b><!--c-->")
+	tb := NewFragment(tok, &FragmentContext{TagName: "div", Namespace: "html"})
+	for {
+		tt := tok.Next()
+		tb.ProcessToken(tt)
+		if tt.Type == tokenizer.EOF {
+			break
+		}
+	}
+
+	nodes := tb.FragmentChildNodes()
+	if len(nodes) != 3 {
+		t.Fatalf("got %d nodes, want 3", len(nodes))
+	}
+	if text, ok := nodes[0].(*dom.Text); !ok || text.Data != "text" {
+		t.Errorf("nodes[0] = %#v, want text %q", nodes[0], "text")
+	}
+	if _, ok := nodes[2].(*dom.Comment); !ok {
+		t.Errorf("nodes[2] = %#v, want a comment", nodes[2])
+	}
+	if got := len(tb.FragmentNodes()); got != 1 {
+		t.Errorf("FragmentNodes returned %d elements, want 1", got)
+	}
+}
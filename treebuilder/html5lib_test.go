@@ -130,19 +130,19 @@ func runTreeConstructionTestFile(t *testing.T, path string) {
 func runSingleTreeConstructionTest(test testutil.TreeConstructionTest) (string, string, string, error) {
 	var got, want string
 	var err error
-	// Skip script tests for now.
-	if test.ScriptDirective == "script-on" {
-		return "", "", "script-on tests not yet supported", nil
-	}
+	scripting := test.ScriptDirective == "script-on"
 
 	want = strings.TrimRight(test.Document, "\n")
 
 	if test.FragmentContext != "" {
-		got, err = parseHTML5LibFragment(test.Data, test.FragmentContext, test.XMLCoercion)
+		got, err = parseHTML5LibFragment(test.Data, test.FragmentContext, test.XMLCoercion, scripting)
 		return got, want, "", err
 	}
 
 	opts := []JustGoHTML.Option{}
+	if scripting {
+		opts = append(opts, JustGoHTML.WithScripting())
+	}
 	if test.IframeSrcdoc {
 		opts = append(opts, JustGoHTML.WithIframeSrcdoc())
 	}
@@ -156,7 +156,7 @@ func runSingleTreeConstructionTest(test testutil.TreeConstructionTest) (string,
 	return testutil.SerializeHTML5LibTree(doc), want, "", nil
 }
 
-func parseHTML5LibFragment(input string, ctx string, xmlCoercion bool) (string, error) {
+func parseHTML5LibFragment(input string, ctx string, xmlCoercion, scripting bool) (string, error) {
 	fc, err := parseFragmentContext(ctx)
 	if err != nil {
 		return "", err
@@ -165,6 +165,8 @@ func parseHTML5LibFragment(input string, ctx string, xmlCoercion bool) (string,
 	tok := tokenizer.New(input)
 	tok.SetXMLCoercion(xmlCoercion)
 	tb := treebuilder.NewFragment(tok, fc)
+	tok.SetScripting(scripting)
+	tb.SetScripting(scripting)
 
 	for {
 		tok.SetAllowCDATA(tb.AllowCDATA())
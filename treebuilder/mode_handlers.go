@@ -203,6 +203,13 @@ func (tb *TreeBuilder) processInHead(tok tokenizer.Token) bool {
 			return false
 		case "noscript":
 			tb.insertElement(tok.Name, tok.Attrs)
+			if tb.scripting {
+				tb.originalMode = tb.mode
+				tb.mode = Text
+				tb.tokenizer.SetLastStartTag(tok.Name)
+				tb.tokenizer.SetState(tokenizer.RAWTEXTState)
+				return false
+			}
 			tb.mode = InHeadNoscript
 			return false
 		case tagBase, tagBasefont, tagBgsound, tagLink, tagMeta:
@@ -759,6 +766,17 @@ func (tb *TreeBuilder) processInBody(tok tokenizer.Token) bool {
 			tb.pushFormattingMarker()
 			tb.framesetOK = false
 			return false
+		case "noscript":
+			// With scripting enabled, noscript follows the generic raw text
+			// element parsing algorithm; otherwise it is an ordinary element.
+			if tb.scripting {
+				tb.insertElement(tok.Name, tok.Attrs)
+				tb.originalMode = tb.mode
+				tb.mode = Text
+				tb.tokenizer.SetLastStartTag(tok.Name)
+				tb.tokenizer.SetState(tokenizer.RAWTEXTState)
+				return false
+			}
 		}
 
 		if constants.FormattingElements[tok.Name] {
@@ -0,0 +1,39 @@
+package treebuilder_test
+
+import (
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+)
+
+func TestTreeBuilder_ElementPositions(t *testing.T) {
+	doc, err := JustGoHTML.Parse("<!DOCTYPE html><p id=a>x<b id=b>y\n<p id=c>z</b><table><tr id=d><td><svg id=e></svg></table>")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	tests := []struct {
+		selector     string
+		line, column int
+	}{
+		{"#a", 1, 16},
+		{"#b", 1, 25},
+		{"#c", 2, 1},
+		// The b element reconstructed in the second paragraph keeps the
+		// position of its start tag.
+		{"#c > b", 1, 25},
+		{"#d", 2, 21},
+		{"#e", 2, 34},
+		// Implied elements have no position.
+		{"html", 0, 0},
+		{"tbody", 0, 0},
+	}
+	for _, tt := range tests {
+		el, err := doc.QueryFirst(tt.selector)
+		if err != nil || el == nil {
+			t.Fatalf("QueryFirst(%q) = %v, %v", tt.selector, el, err)
+		}
+		if line, column := el.Position(); line != tt.line || column != tt.column {
+			t.Errorf("%s position = %d:%d, want %d:%d", tt.selector, line, column, tt.line, tt.column)
+		}
+	}
+}
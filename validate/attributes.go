@@ -0,0 +1,468 @@
+package validate
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// obsoleteAttributes lists the obsolete attributes of each element. Most
+// are presentational and replaced by CSS.
+var obsoleteAttributes = func() map[string]map[string]bool {
+	m := map[string]map[string]bool{
+		"a":        set("charset", "coords", "methods", "name", "rev", "shape", "urn"),
+		"area":     set("hreflang", "nohref", "type"),
+		"body":     set("alink", "background", "bgcolor", "link", "marginbottom", "marginheight", "marginleft", "marginright", "margintop", "marginwidth", "text", "vlink"),
+		"br":       set("clear"),
+		"caption":  set("align"),
+		"col":      set("align", "char", "charoff", "valign", "width"),
+		"colgroup": set("align", "char", "charoff", "valign", "width"),
+		"div":      set("align"),
+		"dl":       set("compact"),
+		"embed":    set("align", "hspace", "name", "vspace"),
+		"head":     set("profile"),
+		"hr":       set("align", "color", "noshade", "size", "width"),
+		"html":     set("manifest", "version"),
+		"iframe":   set("align", "allowtransparency", "datasrc", "frameborder", "framespacing", "hspace", "longdesc", "marginheight", "marginwidth", "scrolling", "vspace"),
+		"img":      set("align", "border", "datasrc", "hspace", "longdesc", "lowsrc", "name", "vspace"),
+		"input":    set("align", "datasrc", "hspace", "ismap", "usemap", "vspace"),
+		"legend":   set("align"),
+		"li":       set("type"),
+		"link":     set("charset", "methods", "rev", "target", "urn"),
+		"menu":     set("compact"),
+		"meta":     set("scheme"),
+		"object":   set("align", "archive", "border", "classid", "codebase", "codetype", "declare", "hspace", "standby", "typemustmatch", "vspace"),
+		"ol":       set("compact"),
+		"p":        set("align"),
+		"param":    set("type", "valuetype"),
+		"pre":      set("width"),
+		"script":   set("charset", "event", "for", "language"),
+		"table":    set("align", "bgcolor", "border", "cellpadding", "cellspacing", "datapagesize", "frame", "rules", "summary", "width"),
+		"td":       set("abbr", "align", "axis", "bgcolor", "char", "charoff", "height", "nowrap", "scope", "valign", "width"),
+		"th":       set("align", "axis", "bgcolor", "char", "charoff", "height", "nowrap", "valign", "width"),
+		"tr":       set("align", "bgcolor", "char", "charoff", "valign"),
+		"ul":       set("compact", "type"),
+	}
+	for _, tag := range []string{"h1", "h2", "h3", "h4", "h5", "h6"} {
+		m[tag] = set("align")
+	}
+	for _, tag := range []string{"tbody", "thead", "tfoot"} {
+		m[tag] = set("align", "char", "charoff", "valign")
+	}
+	return m
+}()
+
+// booleanAttributes are the boolean attributes, whose value must be empty
+// or the attribute's name.
+var booleanAttributes = set(
+	"allowfullscreen", "async", "autofocus", "autoplay", "checked",
+	"controls", "default", "defer", "disabled", "formnovalidate", "inert",
+	"ismap", "itemscope", "loop", "multiple", "muted", "nomodule",
+	"novalidate", "open", "playsinline", "readonly", "required", "reversed",
+	"selected", "shadowrootclonable", "shadowrootdelegatesfocus",
+	"shadowrootserializable",
+)
+
+// attributeCheck checks the value of an attribute on some elements, or on
+// all elements when tags is nil.
+type attributeCheck struct {
+	tags  map[string]bool
+	check func(string) string
+}
+
+// attributeChecks lists the value checks of each attribute. The srcset
+// attribute and the value, min, max and step attributes of input elements
+// depend on other attributes and are checked separately.
+var attributeChecks = map[string][]attributeCheck{
+	// Global attributes.
+	"contenteditable": {{nil, enum("", "true", "false", "plaintext-only")}},
+	"dir":             {{nil, enum("ltr", "rtl", "auto")}},
+	"draggable":       {{nil, enum("true", "false")}},
+	"enterkeyhint":    {{nil, enum("enter", "done", "go", "next", "previous", "search", "send")}},
+	"hidden":          {{nil, enum("", "hidden", "until-found")}},
+	"inputmode":       {{nil, enum("none", "text", "tel", "url", "email", "numeric", "decimal", "search")}},
+	"lang":            {{nil, checkLang}},
+	"spellcheck":      {{nil, enum("", "true", "false")}},
+	"tabindex":        {{nil, checkInteger}},
+	"translate":       {{nil, enum("", "yes", "no")}},
+
+	// URLs.
+	"action":     {{set("form"), checkNonEmptyURL}},
+	"cite":       {{set("blockquote", "del", "ins", "q"), checkURL}},
+	"data":       {{set("object"), checkNonEmptyURL}},
+	"formaction": {{set("button", "input"), checkNonEmptyURL}},
+	"href": {
+		{set("a", "area", "base"), checkURL},
+		{set("link"), checkNonEmptyURL},
+	},
+	"ping":   {{set("a", "area"), checkURLList}},
+	"poster": {{set("video"), checkNonEmptyURL}},
+	"src":    {{set("audio", "embed", "iframe", "img", "input", "script", "source", "track", "video"), checkNonEmptyURL}},
+	"usemap": {{set("img"), checkHashName}},
+
+	// Numbers.
+	"colspan":   {{set("td", "th"), checkBoundedInteger(1, 1000)}},
+	"cols":      {{set("textarea"), checkPositiveInteger}},
+	"height":    {{set("canvas", "embed", "iframe", "img", "input", "object", "source", "video"), checkNonNegativeInteger}},
+	"high":      {{set("meter"), checkFloat}},
+	"low":       {{set("meter"), checkFloat}},
+	"max":       {{set("meter", "progress"), checkFloat}},
+	"maxlength": {{set("input", "textarea"), checkNonNegativeInteger}},
+	"min":       {{set("meter"), checkFloat}},
+	"minlength": {{set("input", "textarea"), checkNonNegativeInteger}},
+	"optimum":   {{set("meter"), checkFloat}},
+	"rows":      {{set("textarea"), checkPositiveInteger}},
+	"rowspan":   {{set("td", "th"), checkBoundedInteger(0, 65534)}},
+	"size":      {{set("input", "select"), checkPositiveInteger}},
+	"span":      {{set("col", "colgroup"), checkBoundedInteger(1, 1000)}},
+	"start":     {{set("ol"), checkInteger}},
+	"value": {
+		{set("li"), checkInteger},
+		{set("meter", "progress"), checkFloat},
+	},
+	"width": {{set("canvas", "embed", "iframe", "img", "input", "object", "source", "video"), checkNonNegativeInteger}},
+
+	// Dates and times.
+	"datetime": {
+		{set("del", "ins"), checkDateWithOptionalTime},
+		{set("time"), checkTimeDatetime},
+	},
+
+	// Enumerated attributes.
+	"autocomplete":   {{set("form"), enum("on", "off")}},
+	"charset":        {{set("meta"), enum("utf-8")}},
+	"crossorigin":    {{set("audio", "img", "link", "script", "video"), enum("", "anonymous", "use-credentials")}},
+	"decoding":       {{set("img"), enum("sync", "async", "auto")}},
+	"enctype":        {{set("form"), enum("application/x-www-form-urlencoded", "multipart/form-data", "text/plain")}},
+	"fetchpriority":  {{set("img", "link", "script"), enum("high", "low", "auto")}},
+	"formenctype":    {{set("button", "input"), enum("application/x-www-form-urlencoded", "multipart/form-data", "text/plain")}},
+	"formmethod":     {{set("button", "input"), enum("get", "post", "dialog")}},
+	"kind":           {{set("track"), enum("subtitles", "captions", "descriptions", "chapters", "metadata")}},
+	"loading":        {{set("iframe", "img"), enum("lazy", "eager")}},
+	"method":         {{set("form"), enum("get", "post", "dialog")}},
+	"preload":        {{set("audio", "video"), enum("", "none", "metadata", "auto")}},
+	"referrerpolicy": {{set("a", "area", "iframe", "img", "link", "script"), enum("", "no-referrer", "no-referrer-when-downgrade", "same-origin", "origin", "strict-origin", "origin-when-cross-origin", "strict-origin-when-cross-origin", "unsafe-url")}},
+	"scope":          {{set("th"), enum("row", "col", "rowgroup", "colgroup")}},
+	"shape":          {{set("area"), enum("circle", "default", "poly", "rect")}},
+	"type": {
+		{set("button"), enum("submit", "reset", "button")},
+		{set("input"), enum(inputTypes...)},
+		{set("ol"), exactEnum("1", "a", "A", "i", "I")},
+	},
+	"wrap": {{set("textarea"), enum("soft", "hard")}},
+}
+
+// inputTypes are the states of the type attribute of input elements.
+var inputTypes = []string{
+	"hidden", "text", "search", "tel", "url", "email", "password", "date",
+	"month", "week", "time", "datetime-local", "number", "range", "color",
+	"checkbox", "radio", "file", "submit", "image", "reset", "button",
+}
+
+// inputValueChecks lists the checks of the value, min and max attributes
+// of input elements by type.
+var inputValueChecks = map[string]func(string) string{
+	"color":          checkColor,
+	"date":           checkDate,
+	"datetime-local": checkLocalDateTime,
+	"month":          checkMonth,
+	"number":         checkFloat,
+	"range":          checkFloat,
+	"time":           checkTime,
+	"url":            checkAbsoluteURL,
+	"week":           checkWeek,
+}
+
+// enum returns a checker for an enumerated attribute with the given ASCII
+// case-insensitive keywords.
+func enum(keywords ...string) func(string) string {
+	return func(s string) string {
+		for _, k := range keywords {
+			if strings.EqualFold(s, k) {
+				return ""
+			}
+		}
+		return "expected " + quoteList(keywords)
+	}
+}
+
+// exactEnum is like enum but compares keywords case-sensitively.
+func exactEnum(keywords ...string) func(string) string {
+	return func(s string) string {
+		for _, k := range keywords {
+			if s == k {
+				return ""
+			}
+		}
+		return "expected " + quoteList(keywords)
+	}
+}
+
+func quoteList(keywords []string) string {
+	quoted := make([]string, len(keywords))
+	for i, k := range keywords {
+		quoted[i] = strconv.Quote(k)
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return "one of " + strings.Join(quoted, ", ")
+}
+
+// checkAttributes reports obsolete attributes and attribute values that do
+// not match their microsyntax.
+func (v *validator) checkAttributes(e *dom.Element) {
+	for _, attr := range e.Attributes.All() {
+		if attr.Namespace != "" {
+			continue
+		}
+		if obsoleteAttributes[e.TagName][attr.Name] {
+			v.report(e, CodeObsoleteAttribute, "the %s attribute on the %s element is obsolete", attr.Name, e.TagName)
+			continue
+		}
+		if booleanAttributes[attr.Name] && attr.Value != "" && !strings.EqualFold(attr.Value, attr.Name) {
+			v.invalidValue(e, attr.Name, attr.Value, "boolean attribute must be empty or "+strconv.Quote(attr.Name))
+			continue
+		}
+		for _, c := range attributeChecks[attr.Name] {
+			if c.tags != nil && !c.tags[e.TagName] {
+				continue
+			}
+			if msg := c.check(attr.Value); msg != "" {
+				v.invalidValue(e, attr.Name, attr.Value, msg)
+			}
+		}
+	}
+	switch e.TagName {
+	case "img", "source":
+		v.checkSrcset(e)
+	case "input":
+		v.checkInputValues(e)
+	}
+}
+
+// checkSrcset checks the srcset and sizes attributes of img and source
+// elements: width descriptors require a sizes attribute, and sizes requires
+// width descriptors.
+func (v *validator) checkSrcset(e *dom.Element) {
+	srcset, ok := e.Attributes.Get("srcset")
+	if !ok {
+		return
+	}
+	info, msg := checkSrcset(srcset)
+	switch {
+	case msg != "":
+		v.invalidValue(e, "srcset", srcset, msg)
+	case info.widths && !e.HasAttr("sizes"):
+		v.report(e, CodeMissingAttribute, "srcset with width descriptors requires a sizes attribute on the %s element", e.TagName)
+	case !info.widths && e.HasAttr("sizes"):
+		v.invalidValue(e, "sizes", e.Attr("sizes"), "sizes requires width descriptors in srcset")
+	}
+}
+
+// checkInputValues checks the value, min, max and step attributes of input
+// elements against the microsyntax of their type.
+func (v *validator) checkInputValues(e *dom.Element) {
+	check := inputValueChecks[inputType(e)]
+	if check == nil {
+		return
+	}
+	for _, name := range []string{"value", "min", "max"} {
+		if value := e.Attr(name); value != "" {
+			if msg := check(value); msg != "" {
+				v.invalidValue(e, name, value, msg)
+			}
+		}
+	}
+	if step, ok := e.Attributes.Get("step"); ok {
+		if msg := checkStep(step); msg != "" {
+			v.invalidValue(e, "step", step, msg)
+		}
+	}
+}
+
+func (v *validator) invalidValue(e *dom.Element, name, value, msg string) {
+	v.report(e, CodeInvalidAttributeValue, "bad value %q for attribute %s on element %s: %s", value, name, e.TagName, msg)
+}
+
+// checkRequired reports missing required attributes.
+func (v *validator) checkRequired(e *dom.Element) {
+	require := func(names ...string) {
+		for _, name := range names {
+			if e.HasAttr(name) {
+				return
+			}
+		}
+		what := "the " + names[0] + " attribute"
+		if len(names) > 1 {
+			what = "one of the attributes " + strings.Join(names, ", ")
+		}
+		v.report(e, CodeMissingAttribute, "%s element must have %s", e.TagName, what)
+	}
+	switch e.TagName {
+	case "area":
+		if e.HasAttr("href") {
+			require("alt")
+		}
+	case "base":
+		require("href", "target")
+	case "bdo":
+		require("dir")
+	case "html":
+		if !e.HasAttr("lang") && !e.HasAttr("xml:lang") {
+			v.warn(e, CodeMissingLang, "html element should have a lang attribute declaring the language of the document")
+		}
+	case "img":
+		require("src")
+		if !e.HasAttr("alt") && !inCaptionedFigure(e) {
+			require("alt")
+		}
+	case "input":
+		if inputType(e) == "image" {
+			require("alt")
+		}
+	case "link":
+		require("href", "imagesrcset")
+		require("rel", "itemprop")
+	case "map":
+		require("name")
+	case "meta":
+		require("name", "http-equiv", "charset", "itemprop", "property")
+		if e.HasAttr("name") || e.HasAttr("http-equiv") || e.HasAttr("itemprop") {
+			require("content")
+		}
+	case "meter":
+		require("value")
+	case "object":
+		require("data", "type")
+	case "optgroup":
+		require("label")
+	case "source":
+		if parentElementIs(e, "picture") {
+			require("srcset")
+		} else {
+			require("src")
+		}
+	case "track":
+		require("src")
+	}
+}
+
+// checkIDs reports empty IDs, IDs with whitespace and duplicate IDs, and
+// records the IDs of the document for checkReferences. The content of
+// templates is a separate tree and is skipped.
+func (v *validator) checkIDs() {
+	v.ids = map[string]*dom.Element{}
+	var walk func(n dom.Node)
+	walk = func(n dom.Node) {
+		for _, child := range n.Children() {
+			e, ok := child.(*dom.Element)
+			if !ok {
+				continue
+			}
+			if id, ok := e.Attributes.Get("id"); ok {
+				switch first := v.ids[id]; {
+				case id == "":
+					v.invalidValue(e, "id", id, "an ID must not be empty")
+				case strings.ContainsAny(id, asciiWhitespace):
+					v.invalidValue(e, "id", id, "an ID must not contain whitespace")
+				case first != nil:
+					if line, column := first.Position(); line > 0 {
+						v.report(e, CodeDuplicateID, "duplicate ID %q, first used at %d:%d", id, line, column)
+					} else {
+						v.report(e, CodeDuplicateID, "duplicate ID %q", id)
+					}
+				default:
+					v.ids[id] = e
+				}
+			}
+			walk(e)
+		}
+	}
+	walk(v.doc)
+}
+
+// idReferences are the attributes that refer to one (for, list) or more
+// (the others) IDs of the document.
+var idReferences = []string{
+	"aria-activedescendant", "aria-controls", "aria-describedby",
+	"aria-details", "aria-errormessage", "aria-flowto", "aria-labelledby",
+	"aria-owns", "for", "headers", "list",
+}
+
+// checkReferences reports attributes that refer to IDs that are not in the
+// document, or to elements of the wrong kind.
+func (v *validator) checkReferences(e *dom.Element) {
+	if inTemplate(e) {
+		return
+	}
+	for _, name := range idReferences {
+		value, ok := e.Attributes.Get(name)
+		if !ok {
+			continue
+		}
+		var want func(*dom.Element) bool
+		switch {
+		case name == "for" && e.TagName == "label":
+			want = isLabelable
+		case name == "for" && e.TagName == "output":
+			want = func(*dom.Element) bool { return true }
+		case name == "list" && e.TagName == "input":
+			want = func(t *dom.Element) bool { return t.TagName == "datalist" }
+		case name == "headers" && in(e, "td", "th"):
+			want = func(t *dom.Element) bool { return t.TagName == "th" }
+		case strings.HasPrefix(name, "aria-"):
+			want = func(*dom.Element) bool { return true }
+		default:
+			continue
+		}
+		for _, id := range strings.Fields(value) {
+			switch target := v.ids[id]; {
+			case target == nil:
+				v.report(e, CodeInvalidIDReference, "the %s attribute on the %s element refers to ID %q, which is not in the document", name, e.TagName, id)
+			case !want(target):
+				v.report(e, CodeInvalidIDReference, "the %s attribute on the %s element refers to a %s element", name, e.TagName, target.TagName)
+			}
+		}
+	}
+}
+
+// inputType returns the state of an input element's type attribute.
+func inputType(e *dom.Element) string {
+	t := strings.ToLower(strings.Trim(e.Attr("type"), asciiWhitespace))
+	for _, known := range inputTypes {
+		if t == known {
+			return t
+		}
+	}
+	return "text"
+}
+
+// isLabelable reports whether a label element can be associated with e.
+func isLabelable(e *dom.Element) bool {
+	switch e.TagName {
+	case "button", "meter", "output", "progress", "select", "textarea":
+		return true
+	case "input":
+		return inputType(e) != "hidden"
+	}
+	return isCustom(e)
+}
+
+// inCaptionedFigure reports whether an img is in a figure with a
+// figcaption, which lets it omit the alt attribute.
+func inCaptionedFigure(img *dom.Element) bool {
+	parent := img.ParentElement()
+	return parent != nil && parent.TagName == "figure" && firstChild(parent, "figcaption") != nil
+}
+
+// inTemplate reports whether e is in the content of a template element.
+func inTemplate(e *dom.Element) bool {
+	var n dom.Node = e
+	for n.Parent() != nil {
+		n = n.Parent()
+	}
+	_, ok := n.(*dom.Document)
+	return !ok
+}
@@ -0,0 +1,376 @@
+package validate
+
+import (
+	"strings"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+)
+
+// phrasing are the elements that are phrasing content. link and meta are
+// phrasing content only in some cases, see isPhrasing.
+var phrasing = set(
+	"a", "abbr", "area", "audio", "b", "bdi", "bdo", "br", "button", "canvas",
+	"cite", "code", "data", "datalist", "del", "dfn", "em", "embed", "i",
+	"iframe", "img", "input", "ins", "kbd", "label", "map", "mark", "math",
+	"meter", "noscript", "object", "output", "picture", "progress", "q",
+	"ruby", "s", "samp", "script", "select", "slot", "small", "span",
+	"strong", "sub", "sup", "svg", "template", "textarea", "time", "u", "var",
+	"video", "wbr",
+)
+
+// flowOnly are the elements that are flow content but not phrasing
+// content.
+var flowOnly = set(
+	"address", "article", "aside", "blockquote", "details", "dialog", "div",
+	"dl", "fieldset", "figure", "footer", "form", "h1", "h2", "h3", "h4",
+	"h5", "h6", "header", "hgroup", "hr", "main", "menu", "nav", "ol", "p",
+	"pre", "search", "section", "table", "ul",
+)
+
+// otherElements are the remaining elements of the HTML standard, which are
+// only allowed in specific parents.
+var otherElements = set(
+	"base", "body", "caption", "col", "colgroup", "dd", "dt", "figcaption",
+	"head", "html", "legend", "li", "link", "meta", "optgroup", "option",
+	"param", "rp", "rt", "source", "style", "summary", "tbody", "td",
+	"tfoot", "th", "thead", "title", "tr", "track",
+)
+
+// obsoleteElements are the obsolete elements of the HTML standard.
+var obsoleteElements = set(
+	"acronym", "applet", "basefont", "bgsound", "big", "blink", "center",
+	"dir", "font", "frame", "frameset", "image", "isindex", "keygen",
+	"listing", "marquee", "menuitem", "multicol", "nextid", "nobr",
+	"noembed", "noframes", "plaintext", "rb", "rtc", "spacer", "strike",
+	"tt", "xmp",
+)
+
+// transparent are the elements whose content model is that of their
+// parent.
+var transparent = set("a", "audio", "canvas", "del", "ins", "map", "noscript", "object", "slot", "video")
+
+// phrasingParents are the elements whose content model is phrasing content.
+var phrasingParents = set(
+	"abbr", "b", "bdi", "bdo", "button", "cite", "code", "data", "dfn", "em",
+	"h1", "h2", "h3", "h4", "h5", "h6", "i", "kbd", "label", "legend",
+	"mark", "meter", "output", "p", "pre", "progress", "q", "rt", "s", "samp",
+	"small", "span", "strong", "sub", "summary", "sup", "time", "u", "var",
+)
+
+// childLists are the elements whose children are limited to a list of
+// elements, besides script-supporting elements. Text other than whitespace
+// is not allowed in them.
+var childLists = map[string]map[string]bool{
+	"colgroup": set("col"),
+	"dl":       set("dt", "dd", "div"),
+	"head":     set("base", "link", "meta", "noscript", "style", "title"),
+	"hgroup":   set("h1", "h2", "h3", "h4", "h5", "h6", "p"),
+	"html":     set("head", "body"),
+	"menu":     set("li"),
+	"ol":       set("li"),
+	"optgroup": set("option"),
+	"picture":  set("source", "img"),
+	"select":   set("option", "optgroup", "hr"),
+	"table":    set("caption", "colgroup", "thead", "tbody", "tfoot", "tr"),
+	"tbody":    set("tr"),
+	"tfoot":    set("tr"),
+	"thead":    set("tr"),
+	"tr":       set("td", "th"),
+	"ul":       set("li"),
+}
+
+// textOnly are the elements whose content is text, which the parser
+// guarantees for source documents.
+var textOnly = set("iframe", "noembed", "noframes", "option", "script", "style", "textarea", "title", "xmp")
+
+// descendantRules lists, for elements that restrict their descendants, the
+// descendants they do not allow.
+var descendantRules = map[string]func(*dom.Element) bool{
+	"a": func(e *dom.Element) bool { return isInteractive(e) || e.TagName == "a" || e.HasAttr("tabindex") },
+	"address": func(e *dom.Element) bool {
+		return isHeading(e) || isSectioning(e) || in(e, "header", "footer", "address")
+	},
+	"button":   func(e *dom.Element) bool { return isInteractive(e) || e.TagName == "a" || e.HasAttr("tabindex") },
+	"caption":  func(e *dom.Element) bool { return e.TagName == "table" },
+	"dfn":      func(e *dom.Element) bool { return e.TagName == "dfn" },
+	"dt":       func(e *dom.Element) bool { return isHeading(e) || isSectioning(e) || in(e, "header", "footer") },
+	"footer":   func(e *dom.Element) bool { return in(e, "header", "footer") },
+	"form":     func(e *dom.Element) bool { return e.TagName == "form" },
+	"header":   func(e *dom.Element) bool { return in(e, "header", "footer") },
+	"label":    func(e *dom.Element) bool { return e.TagName == "label" },
+	"meter":    func(e *dom.Element) bool { return e.TagName == "meter" },
+	"progress": func(e *dom.Element) bool { return e.TagName == "progress" },
+	"th":       func(e *dom.Element) bool { return isHeading(e) || isSectioning(e) || in(e, "header", "footer") },
+}
+
+// checkElementKind reports obsolete and unknown elements.
+func (v *validator) checkElementKind(e *dom.Element) {
+	switch {
+	case obsoleteElements[e.TagName]:
+		v.report(e, CodeObsoleteElement, "the %s element is obsolete", e.TagName)
+	case !isKnown(e):
+		v.report(e, CodeUnknownElement, "unknown element %s", e.TagName)
+	}
+}
+
+// checkContext reports e if the content model of its parent does not allow
+// it. The content model of transparent parents is that of their own parent.
+func (v *validator) checkContext(e *dom.Element) {
+	parent := modelParent(e)
+	if parent == nil || parent.Namespace != dom.NamespaceHTML || isCustom(e) || isCustom(parent) ||
+		!isKnown(e) || !isKnown(parent) || obsoleteElements[e.TagName] || obsoleteElements[parent.TagName] {
+		return
+	}
+	if textOnly[parent.TagName] || isScriptSupporting(e) {
+		return
+	}
+	allowed := true
+	switch {
+	case childLists[parent.TagName] != nil:
+		allowed = childLists[parent.TagName][e.TagName]
+	case phrasingParents[parent.TagName]:
+		allowed = isPhrasing(e) || (isHeading(e) && (parent.TagName == "legend" || parent.TagName == "summary"))
+	case parent.TagName == "datalist":
+		allowed = e.TagName == "option" || isPhrasing(e)
+	case parent.TagName == "ruby":
+		allowed = isPhrasing(e) || e.TagName == "rt" || e.TagName == "rp"
+	case parent.TagName == "details":
+		allowed = isFlow(e) || (e.TagName == "summary" && e == firstElementChild(parent))
+	case parent.TagName == "fieldset":
+		allowed = isFlow(e) || (e.TagName == "legend" && e == firstElementChild(parent))
+	case parent.TagName == "figure":
+		allowed = isFlow(e) || e.TagName == "figcaption"
+	case parent.TagName == "audio" || parent.TagName == "video":
+		allowed = isFlow(e) || e.TagName == "source" || e.TagName == "track"
+	case parent.TagName == "object":
+		allowed = isFlow(e) || e.TagName == "param"
+	case parent.TagName == "div" && parentElementIs(parent, "dl"):
+		allowed = e.TagName == "dt" || e.TagName == "dd"
+	case parent.TagName == "template" || parent.TagName == "slot":
+	default:
+		allowed = isFlow(e)
+	}
+	if !allowed {
+		v.report(e, CodeDisallowedChild, "%s element not allowed as child of %s element", e.TagName, parent.TagName)
+	}
+}
+
+// checkChildren reports text in elements that do not allow it, and time
+// elements without a datetime attribute whose text is not a date or time.
+func (v *validator) checkChildren(e *dom.Element) {
+	if childLists[e.TagName] != nil || (e.TagName == "div" && parentElementIs(e, "dl")) {
+		for _, child := range e.Children() {
+			if text, ok := child.(*dom.Text); ok && strings.Trim(text.Data, " \t\n\f\r") != "" {
+				v.report(e, CodeDisallowedText, "text not allowed in %s element", e.TagName)
+				break
+			}
+		}
+	}
+	if e.TagName == "time" && !e.HasAttr("datetime") {
+		if msg := checkTimeDatetime(e.Text()); msg != "" {
+			v.report(e, CodeInvalidElementContent, "time element without a datetime attribute must contain a valid date or time: %s", msg)
+		}
+	}
+}
+
+// checkDescendant reports e if an ancestor does not allow it as a
+// descendant, and main elements that are not hierarchically correct.
+func (v *validator) checkDescendant(e *dom.Element) {
+	for anc := e.ParentElement(); anc != nil; anc = anc.ParentElement() {
+		if anc.Namespace != dom.NamespaceHTML {
+			continue
+		}
+		if rule := descendantRules[anc.TagName]; rule != nil && rule(e) {
+			v.report(e, CodeDisallowedDescendant, "%s element not allowed as descendant of %s element", describe(e), anc.TagName)
+		}
+		if e.TagName == "main" && !in(anc, "html", "body", "div", "form") && !isCustom(anc) {
+			v.report(e, CodeDisallowedDescendant, "main element not allowed as descendant of %s element", anc.TagName)
+		}
+	}
+}
+
+// checkHead reports a missing or repeated title, repeated base and charset
+// declarations, and more than one visible main element.
+func (v *validator) checkHead() {
+	var titles, bases, charsets, mains []*dom.Element
+	walkElements(v.doc, func(e *dom.Element) {
+		if e.Namespace != dom.NamespaceHTML {
+			return
+		}
+		switch {
+		case e.TagName == "title" && parentElementIs(e, "head"):
+			titles = append(titles, e)
+		case e.TagName == "base":
+			bases = append(bases, e)
+		case e.TagName == "meta" && e.HasAttr("charset"),
+			e.TagName == "meta" && strings.EqualFold(e.Attr("http-equiv"), "content-type"):
+			charsets = append(charsets, e)
+		case e.TagName == "main" && !e.HasAttr("hidden"):
+			mains = append(mains, e)
+		}
+	})
+	if head := v.doc.Head(); head != nil && len(titles) == 0 {
+		v.report(head, CodeMissingTitle, "head element must contain a title element")
+	}
+	for _, list := range [][]*dom.Element{titles, bases, charsets, mains} {
+		for _, e := range list[min(1, len(list)):] {
+			what := e.TagName + " element"
+			if e.TagName == "meta" {
+				what = "character encoding declaration"
+			} else if e.TagName == "main" {
+				what = "visible main element"
+			}
+			v.report(e, CodeDuplicateElement, "a document must not have more than one %s", what)
+		}
+	}
+}
+
+// modelParent returns the element whose content model applies to the
+// children of e's parent: the parent, or for transparent parents the
+// nearest ancestor that is not transparent. Media sources and tracks and
+// object parameters belong to their parent.
+func modelParent(e *dom.Element) *dom.Element {
+	parent := e.ParentElement()
+	if parent != nil && (in(e, "source", "track") && in(parent, "audio", "video") ||
+		e.TagName == "param" && parent.TagName == "object") {
+		return parent
+	}
+	for parent != nil && parent.Namespace == dom.NamespaceHTML && transparent[parent.TagName] {
+		next := parent.ParentElement()
+		if next == nil {
+			break
+		}
+		parent = next
+	}
+	return parent
+}
+
+// isKnown reports whether e is an element of the HTML standard or a custom
+// element. Other elements are reported by checkElementKind alone.
+func isKnown(e *dom.Element) bool {
+	return phrasing[e.TagName] || flowOnly[e.TagName] || otherElements[e.TagName] ||
+		obsoleteElements[e.TagName] || isCustom(e)
+}
+
+// isPhrasing reports whether e is phrasing content.
+func isPhrasing(e *dom.Element) bool {
+	switch e.TagName {
+	case "link":
+		return e.HasAttr("itemprop") || isBodyOK(e)
+	case "meta":
+		return e.HasAttr("itemprop")
+	case "area":
+		return e.Ancestor("map") != nil
+	}
+	return phrasing[e.TagName] || isCustom(e)
+}
+
+// isFlow reports whether e is flow content.
+func isFlow(e *dom.Element) bool {
+	return isPhrasing(e) || flowOnly[e.TagName]
+}
+
+// isBodyOK reports whether a link element's rel keywords are all allowed in
+// the body.
+func isBodyOK(link *dom.Element) bool {
+	rels := strings.Fields(strings.ToLower(link.Attr("rel")))
+	if len(rels) == 0 {
+		return false
+	}
+	for _, rel := range rels {
+		switch rel {
+		case "dns-prefetch", "modulepreload", "pingback", "preconnect",
+			"prefetch", "preload", "stylesheet":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isInteractive reports whether e is interactive content.
+func isInteractive(e *dom.Element) bool {
+	switch e.TagName {
+	case "button", "details", "embed", "iframe", "label", "select", "textarea":
+		return true
+	case "a":
+		return e.HasAttr("href")
+	case "audio", "video":
+		return e.HasAttr("controls")
+	case "img":
+		return e.HasAttr("usemap")
+	case "input":
+		return !strings.EqualFold(strings.TrimSpace(e.Attr("type")), "hidden")
+	}
+	return false
+}
+
+func isHeading(e *dom.Element) bool {
+	return in(e, "h1", "h2", "h3", "h4", "h5", "h6", "hgroup")
+}
+
+func isSectioning(e *dom.Element) bool {
+	return in(e, "article", "aside", "nav", "section")
+}
+
+func isScriptSupporting(e *dom.Element) bool {
+	return e.TagName == "script" || e.TagName == "template"
+}
+
+// isCustom reports whether e is an autonomous custom element, whose name
+// contains a hyphen.
+func isCustom(e *dom.Element) bool {
+	return strings.Contains(e.TagName, "-")
+}
+
+// describe names e for messages, noting what makes it interactive when
+// that is not its name alone.
+func describe(e *dom.Element) string {
+	switch {
+	case e.TagName == "a" && e.HasAttr("href"):
+		return "a (with href)"
+	case e.HasAttr("tabindex") && !isInteractive(e):
+		return e.TagName + " (with tabindex)"
+	}
+	return e.TagName
+}
+
+func in(e *dom.Element, tags ...string) bool {
+	for _, tag := range tags {
+		if e.TagName == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func set(items ...string) map[string]bool {
+	m := make(map[string]bool, len(items))
+	for _, item := range items {
+		m[item] = true
+	}
+	return m
+}
+
+func parentElementIs(e *dom.Element, tag string) bool {
+	parent := e.ParentElement()
+	return parent != nil && parent.TagName == tag && parent.Namespace == dom.NamespaceHTML
+}
+
+func firstElementChild(e *dom.Element) *dom.Element {
+	for _, child := range e.Children() {
+		if elem, ok := child.(*dom.Element); ok {
+			return elem
+		}
+	}
+	return nil
+}
+
+func firstChild(e *dom.Element, tag string) *dom.Element {
+	for _, child := range e.Children() {
+		if elem, ok := child.(*dom.Element); ok && elem.TagName == tag {
+			return elem
+		}
+	}
+	return nil
+}
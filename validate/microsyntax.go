@@ -0,0 +1,502 @@
+package validate
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The microsyntax checkers return "" for a valid value and a short
+// description of the problem otherwise.
+
+const asciiWhitespace = " \t\n\f\r"
+
+var (
+	integerPattern = regexp.MustCompile(`^-?[0-9]+$`)
+	floatPattern   = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+	langPattern    = regexp.MustCompile(`^(?:[A-Za-z]{2,8}|[xXiI])(?:-[A-Za-z0-9]{1,8})*$`)
+	colorPattern   = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+	durationISO    = regexp.MustCompile(`^P(?:[0-9]+D)?(?:T(?:[0-9]+H)?(?:[0-9]+M)?(?:[0-9]+(?:\.[0-9]{1,3})?S)?)?$`)
+	durationParts  = regexp.MustCompile(`^(?:[ \t\n\f\r]*[0-9]+(?:\.[0-9]{1,3})?[ \t\n\f\r]*[wWdDhHmMsS])+[ \t\n\f\r]*$`)
+)
+
+func checkInteger(s string) string {
+	if !integerPattern.MatchString(s) {
+		return "expected an integer"
+	}
+	return ""
+}
+
+func checkNonNegativeInteger(s string) string {
+	if !integerPattern.MatchString(s) || strings.HasPrefix(s, "-") {
+		return "expected a non-negative integer"
+	}
+	return ""
+}
+
+func checkPositiveInteger(s string) string {
+	if msg := checkNonNegativeInteger(s); msg != "" {
+		return "expected a positive integer"
+	}
+	if strings.Trim(s, "0") == "" {
+		return "expected a positive integer"
+	}
+	return ""
+}
+
+// checkBoundedInteger returns a checker for positive integers up to limit.
+func checkBoundedInteger(lowest, limit int) func(string) string {
+	return func(s string) string {
+		n, err := strconv.Atoi(s)
+		if checkNonNegativeInteger(s) != "" || err != nil || n < lowest || n > limit {
+			return "expected an integer from " + strconv.Itoa(lowest) + " to " + strconv.Itoa(limit)
+		}
+		return ""
+	}
+}
+
+func checkFloat(s string) string {
+	if !floatPattern.MatchString(s) {
+		return "expected a number"
+	}
+	return ""
+}
+
+func checkStep(s string) string {
+	if strings.EqualFold(s, "any") {
+		return ""
+	}
+	if f, err := strconv.ParseFloat(s, 64); checkFloat(s) != "" || err != nil || f <= 0 {
+		return `expected a positive number or "any"`
+	}
+	return ""
+}
+
+func checkColor(s string) string {
+	if !colorPattern.MatchString(s) {
+		return `expected a color of the form "#rrggbb"`
+	}
+	return ""
+}
+
+// checkLang checks a language tag for well-formedness, or the empty string
+// for an unknown language.
+func checkLang(s string) string {
+	if s != "" && !langPattern.MatchString(s) {
+		return "expected a BCP 47 language tag"
+	}
+	return ""
+}
+
+// checkURL checks a valid URL potentially surrounded by spaces.
+func checkURL(s string) string {
+	s = strings.Trim(s, asciiWhitespace)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c <= ' ' || c == 0x7F:
+			return "illegal whitespace or control character in URL"
+		case strings.IndexByte("\"<>\\^`{|}", c) >= 0:
+			return "illegal character " + strconv.QuoteRune(rune(c)) + " in URL"
+		case c == '%':
+			if i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+				return `"%" in URL not followed by two hexadecimal digits`
+			}
+		}
+	}
+	if _, err := url.Parse(s); err != nil {
+		return "invalid URL"
+	}
+	return ""
+}
+
+// checkNonEmptyURL checks a valid non-empty URL potentially surrounded by
+// spaces.
+func checkNonEmptyURL(s string) string {
+	if strings.Trim(s, asciiWhitespace) == "" {
+		return "URL must not be empty"
+	}
+	return checkURL(s)
+}
+
+// checkAbsoluteURL checks an absolute URL, the value of url inputs.
+func checkAbsoluteURL(s string) string {
+	if s == "" {
+		return ""
+	}
+	if msg := checkURL(s); msg != "" {
+		return msg
+	}
+	if u, _ := url.Parse(strings.Trim(s, asciiWhitespace)); !u.IsAbs() {
+		return "expected an absolute URL"
+	}
+	return ""
+}
+
+// checkURLList checks a space-separated list of URLs, such as ping.
+func checkURLList(s string) string {
+	for _, u := range strings.Fields(s) {
+		if msg := checkNonEmptyURL(u); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// checkHashName checks a reference to a map element, such as usemap.
+func checkHashName(s string) string {
+	if len(s) < 2 || s[0] != '#' {
+		return `expected "#" followed by a map name`
+	}
+	return ""
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// srcsetInfo describes a parsed srcset attribute.
+type srcsetInfo struct {
+	widths bool // some candidate has a width descriptor
+}
+
+// checkSrcset checks an image candidate list: each candidate is a URL
+// followed by at most one width ("100w") or pixel density ("2x")
+// descriptor, candidates do not mix widths and densities, and no two
+// candidates have the same descriptor.
+func checkSrcset(s string) (srcsetInfo, string) {
+	var info srcsetInfo
+	densities := map[float64]bool{}
+	widths := map[int]bool{}
+	hasDensity := false
+	candidates := splitCandidates(s)
+	if len(candidates) == 0 {
+		return info, "srcset must contain at least one image candidate"
+	}
+	for _, c := range candidates {
+		if c.url == "" {
+			return info, "empty image candidate"
+		}
+		if msg := checkNonEmptyURL(c.url); msg != "" {
+			return info, msg
+		}
+		switch len(c.descriptors) {
+		case 0:
+			if densities[1] {
+				return info, "two image candidates with pixel density 1x"
+			}
+			densities[1] = true
+			hasDensity = true
+			continue
+		case 1:
+		default:
+			return info, "image candidate " + strconv.Quote(c.url) + " has more than one descriptor"
+		}
+		d := c.descriptors[0]
+		switch {
+		case strings.HasSuffix(d, "w"):
+			n, err := strconv.Atoi(d[:len(d)-1])
+			if checkPositiveInteger(d[:len(d)-1]) != "" || err != nil {
+				return info, "invalid width descriptor " + strconv.Quote(d)
+			}
+			if widths[n] {
+				return info, "two image candidates with width " + d
+			}
+			widths[n] = true
+			info.widths = true
+		case strings.HasSuffix(d, "x"):
+			f, err := strconv.ParseFloat(d[:len(d)-1], 64)
+			if checkFloat(d[:len(d)-1]) != "" || err != nil || f <= 0 {
+				return info, "invalid pixel density descriptor " + strconv.Quote(d)
+			}
+			if densities[f] {
+				return info, "two image candidates with pixel density " + d
+			}
+			densities[f] = true
+			hasDensity = true
+		default:
+			return info, "invalid descriptor " + strconv.Quote(d)
+		}
+	}
+	if info.widths && hasDensity {
+		return info, "width and pixel density descriptors must not be mixed"
+	}
+	return info, ""
+}
+
+type candidate struct {
+	url         string
+	descriptors []string
+}
+
+// splitCandidates splits a srcset value into image candidates, following
+// the "parse a srcset attribute" algorithm of the HTML standard. Empty
+// candidates between commas are returned with an empty URL.
+func splitCandidates(s string) []candidate {
+	var out []candidate
+	pos := 0
+	skip := func(chars string) int {
+		start := pos
+		for pos < len(s) && strings.IndexByte(chars, s[pos]) >= 0 {
+			pos++
+		}
+		return pos - start
+	}
+	skip(asciiWhitespace)
+	for pos < len(s) {
+		if s[pos] == ',' {
+			// A comma without a URL before it.
+			out = append(out, candidate{})
+			pos++
+			skip(asciiWhitespace)
+			continue
+		}
+		start := pos
+		for pos < len(s) && strings.IndexByte(asciiWhitespace, s[pos]) < 0 {
+			pos++
+		}
+		c := candidate{url: s[start:pos]}
+		if strings.HasSuffix(c.url, ",") {
+			c.url = strings.TrimRight(c.url, ",")
+			out = append(out, c)
+			skip(asciiWhitespace)
+			continue
+		}
+		skip(asciiWhitespace)
+		var desc strings.Builder
+		inParens := false
+		for ; pos < len(s); pos++ {
+			ch := s[pos]
+			if !inParens && ch == ',' {
+				pos++
+				break
+			}
+			if !inParens && strings.IndexByte(asciiWhitespace, ch) >= 0 {
+				if desc.Len() > 0 {
+					c.descriptors = append(c.descriptors, desc.String())
+					desc.Reset()
+				}
+				continue
+			}
+			switch ch {
+			case '(':
+				inParens = true
+			case ')':
+				inParens = false
+			}
+			desc.WriteByte(ch)
+		}
+		if desc.Len() > 0 {
+			c.descriptors = append(c.descriptors, desc.String())
+		}
+		out = append(out, c)
+		skip(asciiWhitespace)
+	}
+	return out
+}
+
+// Dates and times.
+
+func checkDate(s string) string {
+	if !validDate(s) {
+		return "expected a date of the form YYYY-MM-DD"
+	}
+	return ""
+}
+
+func checkMonth(s string) string {
+	if _, _, ok := parseMonth(s); !ok {
+		return "expected a month of the form YYYY-MM"
+	}
+	return ""
+}
+
+func checkWeek(s string) string {
+	year, rest, ok := parseYear(s)
+	if !ok || len(rest) != 4 || rest[0] != '-' || rest[1] != 'W' {
+		return "expected a week of the form YYYY-Www"
+	}
+	week, err := strconv.Atoi(rest[2:])
+	if err != nil || week < 1 || week > weeksInYear(year) || !isDigits(rest[2:]) {
+		return "expected a week of the form YYYY-Www"
+	}
+	return ""
+}
+
+func checkTime(s string) string {
+	if !validTime(s) {
+		return "expected a time of the form HH:MM, HH:MM:SS or HH:MM:SS.sss"
+	}
+	return ""
+}
+
+func checkLocalDateTime(s string) string {
+	if !validLocalDateTime(s) {
+		return "expected a date and time of the form YYYY-MM-DDTHH:MM"
+	}
+	return ""
+}
+
+// checkDateWithOptionalTime checks the datetime attribute of del and ins: a
+// date, or a date and time with a time-zone offset.
+func checkDateWithOptionalTime(s string) string {
+	if validDate(s) || validGlobalDateTime(s) {
+		return ""
+	}
+	return "expected a date, or a date and time with a time-zone offset"
+}
+
+// checkTimeDatetime checks the datetime value of a time element, which
+// allows any of the date and time microsyntaxes and durations.
+func checkTimeDatetime(s string) string {
+	s = strings.Trim(s, asciiWhitespace)
+	if _, _, ok := parseMonth(s); ok {
+		return ""
+	}
+	if validDate(s) || validYearlessDate(s) || validTime(s) || validLocalDateTime(s) ||
+		validTimeZone(s) || validGlobalDateTime(s) || checkWeek(s) == "" ||
+		durationISO.MatchString(s) && s != "P" && !strings.HasSuffix(s, "T") ||
+		durationParts.MatchString(s) {
+		return ""
+	}
+	if year, rest, ok := parseYear(s); ok && rest == "" && year > 0 {
+		return ""
+	}
+	return "expected a date, time, duration or time-zone offset"
+}
+
+// parseYear parses four or more digits at the start of s.
+func parseYear(s string) (year int, rest string, ok bool) {
+	n := 0
+	for n < len(s) && '0' <= s[n] && s[n] <= '9' {
+		n++
+	}
+	if n < 4 {
+		return 0, s, false
+	}
+	year, err := strconv.Atoi(s[:n])
+	if err != nil || year == 0 {
+		return 0, s, false
+	}
+	return year, s[n:], true
+}
+
+func parseMonth(s string) (year, month int, ok bool) {
+	year, rest, ok := parseYear(s)
+	if !ok || len(rest) != 3 || rest[0] != '-' || !isDigits(rest[1:]) {
+		return 0, 0, false
+	}
+	month, _ = strconv.Atoi(rest[1:])
+	return year, month, 1 <= month && month <= 12
+}
+
+func validDate(s string) bool {
+	if len(s) < 10 {
+		return false
+	}
+	year, month, ok := parseMonth(s[:len(s)-3])
+	if !ok || s[len(s)-3] != '-' || !isDigits(s[len(s)-2:]) {
+		return false
+	}
+	day, _ := strconv.Atoi(s[len(s)-2:])
+	return 1 <= day && day <= daysIn(year, month)
+}
+
+func validYearlessDate(s string) bool {
+	s = strings.TrimPrefix(s, "--")
+	if len(s) != 5 || s[2] != '-' || !isDigits(s[:2]) || !isDigits(s[3:]) {
+		return false
+	}
+	month, _ := strconv.Atoi(s[:2])
+	day, _ := strconv.Atoi(s[3:])
+	// February 29 is allowed: the year is unknown.
+	return 1 <= month && month <= 12 && 1 <= day && day <= daysIn(2000, month)
+}
+
+func validTime(s string) bool {
+	if len(s) < 5 || s[2] != ':' || !isDigits(s[:2]) || !isDigits(s[3:5]) {
+		return false
+	}
+	hour, _ := strconv.Atoi(s[:2])
+	minute, _ := strconv.Atoi(s[3:5])
+	if hour > 23 || minute > 59 {
+		return false
+	}
+	rest := s[5:]
+	if rest == "" {
+		return true
+	}
+	if len(rest) < 3 || rest[0] != ':' || !isDigits(rest[1:3]) {
+		return false
+	}
+	if second, _ := strconv.Atoi(rest[1:3]); second > 59 {
+		return false
+	}
+	rest = rest[3:]
+	if rest == "" {
+		return true
+	}
+	return rest[0] == '.' && len(rest) >= 2 && len(rest) <= 4 && isDigits(rest[1:])
+}
+
+func validLocalDateTime(s string) bool {
+	i := strings.IndexAny(s, "T ")
+	return i > 0 && validDate(s[:i]) && validTime(s[i+1:])
+}
+
+func validTimeZone(s string) bool {
+	if s == "Z" {
+		return true
+	}
+	if len(s) < 5 || (s[0] != '+' && s[0] != '-') {
+		return false
+	}
+	hm := strings.Replace(s[1:], ":", "", 1)
+	if len(hm) != 4 || !isDigits(hm) || (len(s) == 6 && s[3] != ':') {
+		return false
+	}
+	hour, _ := strconv.Atoi(hm[:2])
+	minute, _ := strconv.Atoi(hm[2:])
+	return hour <= 23 && minute <= 59
+}
+
+func validGlobalDateTime(s string) bool {
+	i := strings.IndexAny(s, "T ")
+	if i <= 0 || !validDate(s[:i]) {
+		return false
+	}
+	rest := s[i+1:]
+	if strings.HasSuffix(rest, "Z") {
+		return validTime(rest[:len(rest)-1])
+	}
+	j := strings.LastIndexAny(rest, "+-")
+	return j > 0 && validTime(rest[:j]) && validTimeZone(rest[j:])
+}
+
+func daysIn(year, month int) int {
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// weeksInYear returns 53 for years whose first day is a Thursday, or a
+// Wednesday in leap years, and 52 otherwise.
+func weeksInYear(year int) int {
+	first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	if first == time.Thursday || (first == time.Wednesday && daysIn(year, 2) == 29) {
+		return 53
+	}
+	return 52
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
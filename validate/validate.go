@@ -0,0 +1,182 @@
+// Package validate checks HTML documents against the authoring conformance
+// requirements of the HTML standard, beyond the parse errors the tokenizer
+// reports: content models (such as block content in a p element or
+// interactive content in a link), required attributes, duplicate IDs and
+// broken ID references, obsolete elements and attributes, and the
+// microsyntaxes of attribute values (numbers, dates and times, URLs,
+// srcset, language tags and enumerated values).
+//
+// Errors carry kebab-case codes in the style of the parse error codes of the
+// HTML standard, and the position of the element they concern.
+package validate
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	htmlerrors "github.com/MeKo-Christian/JustGoHTML/errors"
+	"github.com/MeKo-Christian/JustGoHTML/tokenizer"
+	"github.com/MeKo-Christian/JustGoHTML/treebuilder"
+)
+
+// Error codes of conformance errors.
+const (
+	CodeMissingDoctype        = "missing-doctype"
+	CodeObsoleteDoctype       = "obsolete-doctype"
+	CodeUnknownElement        = "unknown-element"
+	CodeDisallowedChild       = "element-not-allowed-as-child"
+	CodeDisallowedDescendant  = "element-not-allowed-as-descendant"
+	CodeDisallowedText        = "text-not-allowed"
+	CodeDuplicateElement      = "duplicate-element"
+	CodeMissingTitle          = "missing-title"
+	CodeMissingAttribute      = "missing-required-attribute"
+	CodeMissingLang           = "missing-lang"
+	CodeObsoleteElement       = "obsolete-element"
+	CodeObsoleteAttribute     = "obsolete-attribute"
+	CodeInvalidAttributeValue = "invalid-attribute-value"
+	CodeDuplicateID           = "duplicate-id"
+	CodeInvalidIDReference    = "invalid-id-reference"
+	CodeInvalidElementContent = "invalid-element-content"
+)
+
+// Error is a conformance error. Parse errors, which HTML also reports, have
+// the codes of the errors package and no Element.
+type Error struct {
+	// Code identifies the kind of error, such as "duplicate-id".
+	Code string
+
+	// Message is a human-readable description of the error.
+	Message string
+
+	// Line and Column are the 1-based position of the start tag of the
+	// element the error concerns, or of the parse error. They are 0 for
+	// errors that concern the whole document or an element without a
+	// known position.
+	Line, Column int
+
+	// Element is the element the error concerns, or nil.
+	Element *dom.Element
+
+	// Warning marks advice rather than a conformance error, such as a
+	// missing lang attribute on the html element.
+	Warning bool
+}
+
+// Error implements the error interface, in the format of
+// errors.ParseError.
+func (e *Error) Error() string {
+	prefix := ""
+	if e.Warning {
+		prefix = "warning: "
+	}
+	if e.Line > 0 && e.Column > 0 {
+		return fmt.Sprintf("%s%s at %d:%d: %s", prefix, e.Code, e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%s%s: %s", prefix, e.Code, e.Message)
+}
+
+// HTML parses html as a document and returns its parse errors and the
+// conformance errors of the resulting tree, ordered by position.
+func HTML(html string) []*Error {
+	tok := tokenizer.New(html)
+	tb := treebuilder.New(tok)
+	for {
+		tok.SetAllowCDATA(tb.AllowCDATA())
+		tt := tok.Next()
+		tb.ProcessToken(tt)
+		if tt.Type == tokenizer.EOF {
+			break
+		}
+	}
+	var errs []*Error
+	for _, e := range tok.Errors() {
+		errs = append(errs, &Error{
+			Code:    e.Code,
+			Message: htmlerrors.Message(e.Code),
+			Line:    e.Line,
+			Column:  e.Column,
+		})
+	}
+	errs = append(errs, Document(tb.Document())...)
+	slices.SortStableFunc(errs, func(a, b *Error) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+	return errs
+}
+
+// Document returns the conformance errors of doc in tree order, with errors
+// about the document as a whole first. It returns nil for a conforming
+// document.
+func Document(doc *dom.Document) []*Error {
+	v := &validator{doc: doc}
+	v.checkDoctype()
+	v.checkHead()
+	v.checkIDs()
+	walkElements(doc, v.checkElement)
+	return v.errs
+}
+
+// validator collects the errors of a document.
+type validator struct {
+	doc  *dom.Document
+	errs []*Error
+
+	// ids maps the IDs of the document to the first element that has
+	// them, see checkIDs.
+	ids map[string]*dom.Element
+}
+
+// report adds an error about e, which may be nil.
+func (v *validator) report(e *dom.Element, code, format string, args ...any) {
+	err := &Error{Code: code, Message: fmt.Sprintf(format, args...), Element: e}
+	if e != nil {
+		err.Line, err.Column = e.Position()
+	}
+	v.errs = append(v.errs, err)
+}
+
+// warn is like report but adds a warning.
+func (v *validator) warn(e *dom.Element, code, format string, args ...any) {
+	v.report(e, code, format, args...)
+	v.errs[len(v.errs)-1].Warning = true
+}
+
+// checkElement runs the checks of a single element.
+func (v *validator) checkElement(e *dom.Element) {
+	if e.Namespace != dom.NamespaceHTML {
+		return
+	}
+	v.checkElementKind(e)
+	v.checkContext(e)
+	v.checkChildren(e)
+	v.checkDescendant(e)
+	v.checkAttributes(e)
+	v.checkRequired(e)
+	v.checkReferences(e)
+}
+
+// checkDoctype reports a missing DOCTYPE, and DOCTYPEs other than
+// <!DOCTYPE html> and its legacy-compat form.
+func (v *validator) checkDoctype() {
+	dt := v.doc.Doctype
+	switch {
+	case dt == nil:
+		v.report(nil, CodeMissingDoctype, "missing DOCTYPE before the html element")
+	case dt.Name != "html" || dt.PublicID != "" || (dt.SystemID != "" && dt.SystemID != "about:legacy-compat"):
+		v.report(nil, CodeObsoleteDoctype, "obsolete DOCTYPE; use <!DOCTYPE html>")
+	}
+}
+
+// walkElements calls fn for the elements below n in tree order, including
+// the content of template elements, which dom.WalkElements skips.
+func walkElements(n dom.Node, fn func(*dom.Element)) {
+	dom.WalkElements(n, func(e *dom.Element) bool {
+		fn(e)
+		if e.TemplateContent != nil {
+			walkElements(e.TemplateContent, fn)
+		}
+		return true
+	})
+}
@@ -0,0 +1,202 @@
+package validate_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/JustGoHTML"
+	"github.com/MeKo-Christian/JustGoHTML/dom"
+	"github.com/MeKo-Christian/JustGoHTML/validate"
+)
+
+// prefix is a conforming start of a document. Tests put their markup on the
+// second line.
+const prefix = "<!DOCTYPE html><html lang=en><title>t</title>\n"
+
+func mustParse(t *testing.T, html string) *dom.Document {
+	t.Helper()
+	doc, err := JustGoHTML.Parse(html)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", html, err)
+	}
+	return doc
+}
+
+// summarize returns the code and position of each error.
+func summarize(errs []*validate.Error) []string {
+	var out []string
+	for _, e := range errs {
+		out = append(out, fmt.Sprintf("%s %d:%d", e.Code, e.Line, e.Column))
+	}
+	return out
+}
+
+func TestConforming(t *testing.T) {
+	tests := []string{
+		`<p>Text with <a href="/x">a <em>link</em></a>.</p>`,
+		`<a href=/><div>block link</div></a>`,
+		`<ul><li>a<li>b</ul><dl><div><dt>t<dd>d</div></dl>`,
+		`<figure><img src=a.png><figcaption>Caption</figcaption></figure>`,
+		`<img src=a.png alt="" srcset="a.png 1x, b.png 2x">`,
+		`<img src=a.png alt="" srcset="a.png 100w, b.png 200w" sizes="50vw">`,
+		`<picture><source srcset=a.webp type=image/webp><img src=a.png alt=x></picture>`,
+		`<video src=v.mp4 controls><track src=c.vtt kind=captions></video>`,
+		`<table><caption>c</caption><tr><th id=h>a<td headers=h colspan=2>b</table>`,
+		`<label for=i>Name</label><input id=i>`,
+		`<input type=date value=2024-02-29 min=2024-01-01><input type=week value=2020-W53>`,
+		`<input type=number step=any value=-1.5e3><input type=color value=#00ff00>`,
+		`<input list=l><datalist id=l><option value=a></datalist>`,
+		`<time datetime="2024-05-01T10:00Z">May</time><time>10:30</time><time>PT2H</time>`,
+		`<del datetime=2024-05-01>x</del><ol type=A start=3><li value=5>x</ol>`,
+		`<p hidden=until-found dir=auto tabindex=-1 lang=de-CH>x</p>`,
+		`<details><summary>More</summary><p>x</details>`,
+		`<my-widget><div>x</div></my-widget>`,
+		`<template><li>x</li><p id=t></p></template><p id=t></p>`,
+		`<main><p>x</main><button disabled>b</button><meta itemprop=a content=b>`,
+	}
+	for _, html := range tests {
+		if errs := validate.HTML(prefix + html); len(errs) != 0 {
+			t.Errorf("HTML(%q) = %v, want no errors", html, summarize(errs))
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		html string
+		want []string
+	}{
+		// Content models.
+		{`<a href=/><button>b</button></a>`, []string{"element-not-allowed-as-descendant 2:11"}},
+		{`<a href=/><span><input></span></a>`, []string{"element-not-allowed-as-descendant 2:17"}},
+		{`<button><span tabindex=0>x</span></button>`, []string{"element-not-allowed-as-descendant 2:9"}},
+		{`<span><div>x</div></span>`, []string{"element-not-allowed-as-child 2:7"}},
+		{`<span><a><div>x</div></a></span>`, []string{"element-not-allowed-as-child 2:10"}},
+		{`<ul><p>x</p></ul>`, []string{"element-not-allowed-as-child 2:5"}},
+		{`<ul>text<li>x</ul>`, []string{"text-not-allowed 2:1"}},
+		{`<label><label>x</label></label>`, []string{"element-not-allowed-as-descendant 2:8"}},
+		{`<header><footer>x</footer></header>`, []string{"element-not-allowed-as-descendant 2:9"}},
+		{`<section><main>x</main></section>`, []string{"element-not-allowed-as-descendant 2:10"}},
+		{`<details><p>x</p><summary>s</summary></details>`, []string{"element-not-allowed-as-child 2:18"}},
+
+		// Elements.
+		{`<center>x</center>`, []string{"obsolete-element 2:1"}},
+		{`<blah>x</blah>`, []string{"unknown-element 2:1"}},
+		{`<main>a</main><main>b</main>`, []string{"duplicate-element 2:15"}},
+		{`<time>soon</time>`, []string{"invalid-element-content 2:1"}},
+
+		// Required attributes.
+		{`<img src=a.png>`, []string{"missing-required-attribute 2:1"}},
+		{`<img alt=x>`, []string{"missing-required-attribute 2:1"}},
+		{`<input type=image src=a.png>`, []string{"missing-required-attribute 2:1"}},
+		{`<bdo>x</bdo>`, []string{"missing-required-attribute 2:1"}},
+		{`<meter>x</meter>`, []string{"missing-required-attribute 2:1"}},
+		{`<img src=a.png alt="" srcset="a.png 100w">`, []string{"missing-required-attribute 2:1"}},
+
+		// IDs.
+		{`<p id=a>x</p>` + "\n" + `<span id=a>y</span>`, []string{"duplicate-id 3:1"}},
+		{`<p id="">x</p><p id="a b">y</p>`, []string{"invalid-attribute-value 2:1", "invalid-attribute-value 2:15"}},
+		{`<label for=missing>x</label>`, []string{"invalid-id-reference 2:1"}},
+		{`<label for=d>x</label><div id=d></div>`, []string{"invalid-id-reference 2:1"}},
+		{`<input list=d><div id=d></div>`, []string{"invalid-id-reference 2:1"}},
+		{`<div aria-labelledby="a b" id=a></div>`, []string{"invalid-id-reference 2:1"}},
+
+		// Obsolete attributes.
+		{`<table border=1><tr><td align=left>x</table>`, []string{"obsolete-attribute 2:1", "obsolete-attribute 2:21"}},
+		{`<br clear=all><a name=x>y</a>`, []string{"obsolete-attribute 2:1", "obsolete-attribute 2:15"}},
+
+		// Microsyntaxes.
+		{`<p tabindex=one dir=up>x</p>`, []string{"invalid-attribute-value 2:1", "invalid-attribute-value 2:1"}},
+		{`<p lang="en us">x</p>`, []string{"invalid-attribute-value 2:1"}},
+		{`<input disabled=no>`, []string{"invalid-attribute-value 2:1"}},
+		{`<input type=date value=2023-02-29>`, []string{"invalid-attribute-value 2:1"}},
+		{`<input type=week value=2024-W53>`, []string{"invalid-attribute-value 2:1"}},
+		{`<input type=time value=24:00>`, []string{"invalid-attribute-value 2:1"}},
+		{`<input type=number value=1,5 step=0>`, []string{"invalid-attribute-value 2:1", "invalid-attribute-value 2:1"}},
+		{`<input type=color value=red>`, []string{"invalid-attribute-value 2:1"}},
+		{`<input type=url value=/relative>`, []string{"invalid-attribute-value 2:1"}},
+		{`<input type=fancy>`, []string{"invalid-attribute-value 2:1"}},
+		{`<a href="http://x/a b">x</a>`, []string{"invalid-attribute-value 2:1"}},
+		{`<a href="/%zz">x</a>`, []string{"invalid-attribute-value 2:1"}},
+		{`<img src="" alt="">`, []string{"invalid-attribute-value 2:1"}},
+		{`<img src=a.png alt="" srcset="a.png 1x, b.png 1x">`, []string{"invalid-attribute-value 2:1"}},
+		{`<img src=a.png alt="" srcset="a.png 100w, b.png 2x" sizes=10vw>`, []string{"invalid-attribute-value 2:1"}},
+		{`<img src=a.png alt="" srcset="a.png 2q">`, []string{"invalid-attribute-value 2:1"}},
+		{`<table><tr><td colspan=0 rowspan=x>x</table>`, []string{"invalid-attribute-value 2:12", "invalid-attribute-value 2:12"}},
+		{`<ol type=x><li value=1.5>a</ol>`, []string{"invalid-attribute-value 2:1", "invalid-attribute-value 2:12"}},
+		{`<form method=put></form>`, []string{"invalid-attribute-value 2:1"}},
+		{`<time datetime=yesterday>x</time>`, []string{"invalid-attribute-value 2:1"}},
+		{`<ins datetime="2024-05-01T10:00">x</ins>`, []string{"invalid-attribute-value 2:1"}},
+		{`<meter value=high>x</meter>`, []string{"invalid-attribute-value 2:1"}},
+	}
+	for _, tt := range tests {
+		got := summarize(validate.HTML(prefix + tt.html))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("HTML(%q) = %v, want %v", tt.html, got, tt.want)
+		}
+	}
+}
+
+func TestDocumentLevel(t *testing.T) {
+	tests := []struct {
+		html string
+		want []string
+	}{
+		{`<html lang=en><title>t</title>`, []string{"missing-doctype 0:0"}},
+		{`<!DOCTYPE html PUBLIC "-//W3C//DTD HTML 4.01//EN"><html lang=en><title>t</title>`, []string{"obsolete-doctype 0:0"}},
+		{`<!DOCTYPE html SYSTEM "about:legacy-compat"><html lang=en><title>t</title>`, nil},
+		{`<!DOCTYPE html><html lang=en><p>x`, []string{"missing-title 0:0"}},
+		{`<!DOCTYPE html><html lang=en><title>a</title><title>b</title>`, []string{"duplicate-element 1:46"}},
+		{`<!DOCTYPE html><html lang=en><meta charset=utf-8><meta charset=utf-8><title>t</title>`, []string{"duplicate-element 1:50"}},
+		{`<!DOCTYPE html><html lang=en><meta charset=latin1><title>t</title>`, []string{"invalid-attribute-value 1:30"}},
+		{`<!DOCTYPE html><html lang=en><meta name=x><title>t</title>`, []string{"missing-required-attribute 1:30"}},
+	}
+	for _, tt := range tests {
+		got := summarize(validate.HTML(tt.html))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("HTML(%q) = %v, want %v", tt.html, got, tt.want)
+		}
+	}
+}
+
+func TestMissingLang(t *testing.T) {
+	errs := validate.HTML("<!DOCTYPE html><html><title>t</title>")
+	if len(errs) != 1 || errs[0].Code != validate.CodeMissingLang || !errs[0].Warning {
+		t.Fatalf("HTML() = %v, want a missing-lang warning", errs)
+	}
+	if got, want := errs[0].Error(), "warning: missing-lang at 1:16: html element should have a lang attribute declaring the language of the document"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	errs := validate.HTML(prefix + "<p class=a class=b>x</p>")
+	if len(errs) != 1 || errs[0].Code != "duplicate-attribute" || errs[0].Element != nil {
+		t.Fatalf("HTML() = %v, want a duplicate-attribute parse error", errs)
+	}
+	if errs[0].Line != 2 || errs[0].Message == "" {
+		t.Errorf("parse error = %+v, want line 2 and a message", errs[0])
+	}
+}
+
+func TestDocument(t *testing.T) {
+	doc := mustParse(t, prefix+`<p><img id=i src=a.png></p>`)
+	errs := validate.Document(doc)
+	if len(errs) != 1 || errs[0].Element != doc.GetElementByID("i") {
+		t.Fatalf("Document() = %v, want one error about the img element", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "img element must have the alt attribute") {
+		t.Errorf("Error() = %q", errs[0].Error())
+	}
+
+	// Elements created through the DOM have no position.
+	p := dom.NewElement("p")
+	p.AppendChild(dom.NewElement("div"))
+	doc.Body().AppendChild(p)
+	errs = validate.Document(doc)
+	if got := summarize(errs); !slices.Equal(got, []string{"missing-required-attribute 2:4", "element-not-allowed-as-child 0:0"}) {
+		t.Errorf("Document() after DOM changes = %v", got)
+	}
+}